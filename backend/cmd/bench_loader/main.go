@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/models"
+)
+
+// Compara a hidratação de uma página de resultados no padrão antigo (queries por grupo e por
+// name) com o carregador em lote do repository. O banco é um driver falso que gera linhas
+// determinísticas para cada tabela e conta as queries executadas.
+
+const (
+	namesPerGroup        = 3
+	imagesPerGroup       = 2
+	applicationsPerGroup = 4
+	stocksPerName        = 2
+)
+
+// queryCount conta as queries recebidas pelo driver falso
+var queryCount int64
+
+// fakeTable descreve como o driver falso gera as linhas de uma tabela
+type fakeTable struct {
+	model  interface{}
+	key    string // coluna filtrada pelos IDs recebidos como argumento
+	perKey int    // linhas por ID; 0 = a própria linha do ID
+	extra  []string
+}
+
+var fakeTables = map[string]fakeTable{
+	"part_group":           {model: &models.PartGroup{}, key: "id"},
+	"part_name":            {model: &models.PartName{}, key: "group_id", perKey: namesPerGroup},
+	"brand":                {model: &models.Brand{}, key: "id"},
+	"part_image":           {model: &models.PartImage{}, key: "group_id", perKey: imagesPerGroup},
+	"application":          {model: &models.Application{}, key: "group_id", perKey: applicationsPerGroup, extra: []string{"group_id"}},
+	"stock":                {model: &models.Stock{}, key: "part_name_id", perKey: stocksPerName},
+	"company":              {model: &models.Company{}, key: "id"},
+	"product_type":         {model: &models.ProductType{}, key: "id"},
+	"subfamily":            {model: &models.Subfamily{}, key: "id"},
+	"family":               {model: &models.Family{}, key: "id"},
+	"part_group_dimension": {model: &models.PartGroupDimension{}, key: "id"},
+}
+
+var tablePattern = regexp.MustCompile(`FROM\s+"?partexplorer"?\."?(\w+)"?`)
+
+var schemaCache sync.Map
+
+// derivedID gera um UUID estável a partir de um nome e de outro UUID
+func derivedID(name string, id string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name+"|"+id)).String()
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("transactions not supported") }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	atomic.AddInt64(&queryCount, 1)
+
+	match := tablePattern.FindStringSubmatch(query)
+	if match == nil {
+		return nil, fmt.Errorf("unexpected query: %s", query)
+	}
+	table, ok := fakeTables[match[1]]
+	if !ok {
+		return nil, fmt.Errorf("unexpected table %s", match[1])
+	}
+
+	parsed, err := schema.Parse(table.model, &schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+	var fields []*schema.Field
+	for _, field := range parsed.Fields {
+		if field.DBName != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	rows := &fakeRows{columns: append([]string{}, table.extra...)}
+	for _, field := range fields {
+		rows.columns = append(rows.columns, field.DBName)
+	}
+
+	for _, arg := range args {
+		key, ok := arg.Value.(string)
+		if _, err := uuid.Parse(key); !ok || err != nil {
+			continue
+		}
+
+		ids := []string{key}
+		if table.perKey > 0 {
+			ids = ids[:0]
+			for i := 0; i < table.perKey; i++ {
+				ids = append(ids, derivedID(fmt.Sprintf("%s%d", match[1], i), key))
+			}
+		}
+
+		for _, id := range ids {
+			row := make([]driver.Value, 0, len(rows.columns))
+			for _, column := range table.extra {
+				if column == table.key {
+					row = append(row, key)
+				}
+			}
+			for _, field := range fields {
+				row = append(row, fakeValue(field, id, key, table.key))
+			}
+			rows.values = append(rows.values, row)
+		}
+	}
+
+	return rows, nil
+}
+
+// fakeValue gera o valor de uma coluna; chaves estrangeiras são derivadas do ID da linha
+func fakeValue(field *schema.Field, id, key, keyColumn string) driver.Value {
+	switch {
+	case field.DBName == "id":
+		return id
+	case field.DBName == keyColumn:
+		return key
+	}
+
+	kind := field.FieldType
+	if kind.Kind() == reflect.Ptr {
+		kind = kind.Elem()
+	}
+	switch {
+	case kind == reflect.TypeOf(uuid.UUID{}):
+		return derivedID(field.DBName, id)
+	case kind == reflect.TypeOf(time.Time{}):
+		return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	switch kind.Kind() {
+	case reflect.String:
+		return field.DBName + " " + id[:8]
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return int64(1)
+	case reflect.Float32, reflect.Float64:
+		return float64(1)
+	case reflect.Bool:
+		return false
+	}
+	return nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
+
+// legacyLoadPage reproduz a hidratação anterior ao carregador em lote: para cada ID, o
+// part_group, os names, as imagens, as aplicações e o product_type, e os estoques de cada name
+func legacyLoadPage(db *gorm.DB, ids []string) []models.SearchResult {
+	results := make([]models.SearchResult, 0, len(ids))
+	for _, id := range ids {
+		var pg models.PartGroup
+		if err := db.Where("id = ?", id).First(&pg).Error; err != nil {
+			continue
+		}
+
+		var names []models.PartName
+		db.Where("group_id = ?", pg.ID).Find(&names)
+
+		var images []models.PartImage
+		db.Where("group_id = ?", pg.ID).Find(&images)
+
+		var applications []models.Application
+		db.Joins("JOIN partexplorer.part_group_application pga ON partexplorer.application.id = pga.application_id").
+			Where("pga.group_id = ?", pg.ID).
+			Find(&applications)
+
+		if pg.ProductTypeID != nil {
+			var productType models.ProductType
+			db.Preload("Subfamily.Family").First(&productType, "id = ?", *pg.ProductTypeID)
+			pg.ProductType = &productType
+		}
+
+		var allStocks []models.Stock
+		for _, pn := range names {
+			var stocks []models.Stock
+			db.Model(&models.Stock{}).
+				Preload("Company").
+				Where("part_name_id = ?", pn.ID).
+				Find(&stocks)
+			allStocks = append(allStocks, stocks...)
+		}
+
+		results = append(results, models.SearchResult{
+			ID:           id,
+			PartGroup:    pg,
+			Names:        names,
+			Images:       images,
+			Applications: applications,
+			Stocks:       allStocks,
+		})
+	}
+	return results
+}
+
+// countQueries executa fn e retorna quantas queries foram feitas
+func countQueries(fn func()) int64 {
+	before := atomic.LoadInt64(&queryCount)
+	fn()
+	return atomic.LoadInt64(&queryCount) - before
+}
+
+// pageIDs gera IDs de part_group para uma página
+func pageIDs(size int) []string {
+	ids := make([]string, size)
+	for i := range ids {
+		ids[i] = derivedID("page", fmt.Sprint(i))
+	}
+	return ids
+}
+
+func main() {
+	testing.Init()
+	fmt.Println("🧪 Comparando queries por página: N+1 x carregador em lote...")
+
+	sql.Register("partexplorer_fake", fakeDriver{})
+	sqlDB, err := sql.Open("partexplorer_fake", "")
+	if err != nil {
+		fmt.Printf("❌ Erro ao abrir banco falso: %v\n", err)
+		os.Exit(1)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		fmt.Printf("❌ Erro ao abrir gorm: %v\n", err)
+		os.Exit(1)
+	}
+	repo := database.NewPartRepository(db)
+
+	fmt.Println("\n=== TESTE 1: Resultados completos ===")
+	ids := pageIDs(16)
+	results, err := repo.GetPartsByIDs(ids)
+	check.That(err == nil, "GetPartsByIDs sem erro (err=%v)", err)
+	check.That(len(results) == len(ids), "%d resultados para %d IDs", len(results), len(ids))
+	inOrder := true
+	complete := true
+	for i, result := range results {
+		inOrder = inOrder && i < len(ids) && result.ID == ids[i]
+		pt := result.PartGroup.ProductType
+		complete = complete &&
+			len(result.Names) == namesPerGroup &&
+			result.Names[0].Brand != nil &&
+			len(result.Images) == imagesPerGroup &&
+			len(result.Applications) == applicationsPerGroup &&
+			len(result.Stocks) == namesPerGroup*stocksPerName &&
+			result.Stocks[0].Company != nil &&
+			pt != nil && pt.Subfamily.Family.ID != uuid.Nil &&
+			result.Dimension != nil
+	}
+	check.That(inOrder, "Ordem dos IDs preservada")
+	check.That(complete, "Names (com marca), imagens, aplicações, estoques (com empresa), product_type e dimensão carregados")
+
+	fmt.Println("\n=== TESTE 2: Queries por página ===")
+	fmt.Printf("%-10s %-12s %-12s\n", "página", "N+1", "em lote")
+	var batchedCounts []int64
+	for _, size := range []int{1, 16, 48} {
+		ids := pageIDs(size)
+		legacy := countQueries(func() { legacyLoadPage(db, ids) })
+		batched := countQueries(func() { repo.GetPartsByIDs(ids) })
+		batchedCounts = append(batchedCounts, batched)
+		fmt.Printf("%-10d %-12d %-12d\n", size, legacy, batched)
+		if size > 1 {
+			check.That(batched < legacy, "Página de %d: %d queries em lote contra %d", size, batched, legacy)
+		}
+	}
+	check.That(batchedCounts[1] == batchedCounts[2], "Queries em lote não crescem com a página (%v)", batchedCounts)
+
+	fmt.Println("\n=== BENCHMARK: página de 16 resultados ===")
+	for _, bench := range []struct {
+		name string
+		fn   func()
+	}{
+		{"N+1", func() { legacyLoadPage(db, ids) }},
+		{"em lote", func() { repo.GetPartsByIDs(ids) }},
+	} {
+		result := testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			queries := countQueries(func() {
+				for i := 0; i < b.N; i++ {
+					bench.fn()
+				}
+			})
+			b.ReportMetric(float64(queries)/float64(b.N), "queries/op")
+		})
+		fmt.Printf("%-10s %s %s\n", bench.name, result.String(), result.MemString())
+	}
+
+	check.Finish()
+}
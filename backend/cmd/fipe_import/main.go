@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/fipe"
+)
+
+// Importa as tabelas mensais oficiais da FIPE (CSV ou JSON) para fipe_vehicle e fipe_price.
+//
+//	go run ./cmd/fipe_import -month 2025-01 tabela-fipe-2025-01.csv
+//	go run ./cmd/fipe_import -dry-run fipe/*.json
+//
+// Cada arquivo é importado em uma transação; importar de novo um mês substitui os preços dele.
+
+func main() {
+	format := flag.String("format", "", "formato dos arquivos (csv ou json); padrão: pela extensão")
+	month := flag.String("month", "", "mês de referência (AAAA-MM) das linhas sem a coluna de mês")
+	dryRun := flag.Bool("dry-run", false, "só lê e valida os arquivos, sem gravar")
+	maxErrors := flag.Int("max-errors", 20, "quantas linhas rejeitadas listar por arquivo")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Uso: %s [opções] arquivo...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var reference time.Time
+	if *month != "" {
+		parsed, err := fipe.ParseReferenceMonth(*month)
+		if err != nil {
+			log.Fatalf("❌ [FIPE] Mês inválido: %v", err)
+		}
+		reference = parsed
+	}
+
+	var repo database.FipeRepository
+	if !*dryRun {
+		if err := godotenv.Load(); err != nil {
+			log.Println("No .env file found, using environment variables")
+		}
+		if err := database.InitDatabase(); err != nil {
+			log.Fatalf("❌ [FIPE] Erro ao conectar ao banco: %v", err)
+		}
+		// Sem o log de cada INSERT dos lotes
+		db := database.GetDB().Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Warn)})
+		repo = database.NewFipeRepository(db)
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		if err := importFile(repo, path, *format, reference, *maxErrors); err != nil {
+			log.Printf("❌ [FIPE] %s: %v", path, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// importFile lê um arquivo e grava as linhas válidas; as rejeitadas são listadas e não impedem
+// a importação das demais
+func importFile(repo database.FipeRepository, path, format string, month time.Time, maxErrors int) error {
+	if format == "" {
+		format = fipe.FormatFromPath(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	result, err := fipe.Read(file, strings.ToLower(format), month)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("📄 [FIPE] %s: %d linha(s) válida(s), %d rejeitada(s)", path, len(result.Records), len(result.Errors))
+	for i, rowErr := range result.Errors {
+		if i == maxErrors {
+			log.Printf("   ... mais %d linha(s) rejeitada(s)", len(result.Errors)-maxErrors)
+			break
+		}
+		log.Printf("   ⚠️ %v", rowErr)
+	}
+
+	if repo == nil || len(result.Records) == 0 {
+		return nil
+	}
+
+	imported, err := repo.Import(result.Records)
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ [FIPE] %s: %d código(s), %d preço(s) importado(s) (meses %s)",
+		path, imported.Vehicles, imported.Prices, strings.Join(imported.Months, ", "))
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/nfe"
+)
+
+// Importa os XML de NF-e de compra e venda e lança os itens no estoque das empresas
+// cadastradas com o CNPJ do emitente ou do destinatário.
+//
+//	go run ./cmd/nfe_import -actor joao notas/2025-01/
+//	go run ./cmd/nfe_import -dry-run 35250111222333000181550010000012341000012345-nfe.xml
+//
+// Notas já importadas são ignoradas; os itens não casados ficam em /api/v1/nfe/queue.
+
+func main() {
+	actor := flag.String("actor", os.Getenv("USER"), "responsável pelas movimentações de estoque")
+	dryRun := flag.Bool("dry-run", false, "só lê e valida os XML, sem gravar")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Uso: %s [opções] arquivo.xml|diretório...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	paths, err := xmlFiles(flag.Args())
+	if err != nil {
+		log.Fatalf("❌ [NFE] %v", err)
+	}
+
+	var repo database.NFeRepository
+	if !*dryRun {
+		if err := godotenv.Load(); err != nil {
+			log.Println("No .env file found, using environment variables")
+		}
+		if err := database.InitDatabase(); err != nil {
+			log.Fatalf("❌ [NFE] Erro ao conectar ao banco: %v", err)
+		}
+		repo = database.NewNFeRepository(database.GetDB())
+	}
+
+	var imported, skipped, failed, pending int
+	for _, path := range paths {
+		posted, queued, err := importFile(repo, path, *actor)
+		switch {
+		case errors.Is(err, database.ErrNFeAlreadyImported):
+			skipped++
+		case err != nil:
+			log.Printf("❌ [NFE] %s: %v", path, err)
+			failed++
+		default:
+			imported++
+			pending += queued
+			if repo != nil {
+				log.Printf("✅ [NFE] %s: %d item(ns) lançado(s), %d na fila de conciliação", path, posted, queued)
+			}
+		}
+	}
+
+	log.Printf("📊 [NFE] %d nota(s) importada(s), %d já importada(s), %d com erro, %d item(ns) na fila de conciliação",
+		imported, skipped, failed, pending)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// importFile lê o XML e, fora do dry-run, importa a nota
+func importFile(repo database.NFeRepository, path, actor string) (int, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	invoice, err := nfe.Parse(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	if repo == nil {
+		log.Printf("📄 [NFE] %s: nota %s, %d item(ns)", path, invoice.Number, len(invoice.Items))
+		return 0, 0, nil
+	}
+
+	record, err := repo.Ingest(invoice, actor)
+	if err != nil {
+		return 0, 0, err
+	}
+	return record.Posted, record.Pending, nil
+}
+
+// xmlFiles expande os diretórios nos arquivos .xml que eles contêm
+func xmlFiles(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".xml") {
+				paths = append(paths, path)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
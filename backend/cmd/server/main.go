@@ -11,9 +11,12 @@ import (
 	"partexplorer/backend/internal/database"
 	"partexplorer/backend/internal/elasticsearch"
 	"partexplorer/backend/internal/handlers"
+	"partexplorer/backend/internal/manufacturer"
 	"partexplorer/backend/internal/metrics"
 	"partexplorer/backend/internal/middleware"
 	"partexplorer/backend/internal/routes"
+	"partexplorer/backend/internal/stockfeed"
+	"partexplorer/backend/internal/vehicledata"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -48,11 +51,50 @@ func main() {
 	// Criar repositórios
 	repo := database.NewPartRepository(database.GetDB())
 	companyRepo := database.NewCompanyRepository(database.GetDB())
+	stockRepo := database.NewStockRepository(database.GetDB())
 	carRepo := database.NewCarRepository(database.GetDB())
+	carErrorRepo := database.NewCarErrorRepository(database.GetDB())
+	vehicleModelRepo := database.NewVehicleModelRepository(database.GetDB())
+	manufacturerAliasRepo := database.NewManufacturerAliasRepository(database.GetDB())
+	fipeRepo := database.NewFipeRepository(database.GetDB())
+	stockImportRepo := database.NewStockImportRepository(database.GetDB())
+	nfeRepo := database.NewNFeRepository(database.GetDB())
+
+	// Dicionário de fabricantes usado pela busca por placa e pela indexação
+	if database.GetDB() != nil {
+		if dict, err := manufacturerAliasRepo.Dictionary(); err != nil {
+			log.Printf("Warning: Failed to load manufacturer aliases, using built-in list: %v", err)
+		} else if dict.Len() > 0 {
+			manufacturer.SetDefault(dict)
+		}
+	}
 
 	// Criar handlers
 	handler := api.NewHandler(repo)
 
+	// Indexação incremental a partir do search_outbox
+	if database.GetDB() != nil && elasticsearch.GetClient() != nil {
+		changeIndexer := elasticsearch.NewChangeIndexer(database.NewOutboxRepository(database.GetDB()), repo, elasticsearch.NewIndexerService())
+		changeIndexer.Start()
+
+		// Índice do autocomplete, reconstruído com a popularidade das buscas
+		suggestionIndexer := elasticsearch.NewSuggestionIndexer(database.NewSuggestionRepository(database.GetDB()))
+		suggestionIndexer.Start()
+	}
+
+	// Atualização em background dos dados vencidos das placas consultadas recentemente e
+	// novas tentativas das consultas que falharam
+	if database.GetDB() != nil {
+		vehicledata.NewRefresher(carRepo).Start()
+		vehicledata.NewErrorRetryWorker(carErrorRepo, carRepo).Start()
+	}
+
+	// Importações de arquivos de estoque, processadas em lotes e retomadas após reinício
+	stockImports := stockfeed.NewRunner(stockImportRepo)
+	if database.GetDB() != nil {
+		stockImports.Start()
+	}
+
 	// Inicializar router
 	r := gin.Default()
 
@@ -66,7 +108,8 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, If-Match")
+		c.Header("Access-Control-Expose-Headers", "X-Cache, X-Search-Engine, ETag")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -123,9 +166,11 @@ func main() {
 		// Search endpoints
 		apiGroup.GET("/search", handler.SearchParts)
 		apiGroup.GET("/search/brand", handler.SearchPartsByBrand)
+		apiGroup.GET("/search/fitment", handler.SearchFitment)
 		apiGroup.GET("/search/sql", handler.SearchPartsSQL)
 		apiGroup.GET("/search/advanced", handler.AdvancedSearch)
 		apiGroup.GET("/suggest", handler.GetSuggestions)
+		apiGroup.GET("/autocomplete", handler.Autocomplete)
 
 		// Estatísticas
 		apiGroup.GET("/stats", handler.GetStats)
@@ -135,6 +180,9 @@ func main() {
 
 		// Elasticsearch endpoints
 		apiGroup.POST("/index", handler.IndexAllParts)
+		apiGroup.GET("/index/jobs", handler.ListIndexJobs)
+		apiGroup.GET("/index/jobs/:id", handler.GetIndexJob)
+		apiGroup.POST("/index/jobs/:id/cancel", handler.CancelIndexJob)
 		apiGroup.GET("/index/stats", handler.GetIndexStats)
 
 		// Cache endpoints
@@ -145,6 +193,7 @@ func main() {
 		apiGroup.GET("/parts", handler.SearchParts)
 		apiGroup.GET("/parts/:id", handler.GetPartByID)
 		apiGroup.GET("/parts/sku/:sku", handler.GetPartBySKU)
+		apiGroup.GET("/crossref/:code", handler.GetCrossReference)
 		apiGroup.GET("/debug/parts/:id", handler.DebugPartGroup)
 		apiGroup.GET("/debug/sql/parts/:id", handler.DebugPartGroupSQL)
 		apiGroup.GET("/debug/names/:id", handler.DebugPartNames)
@@ -162,25 +211,57 @@ func main() {
 		apiGroup.GET("/families", handler.GetFamilies)
 
 		// Stock endpoints
-		// routes.SetupStockRoutes(apiGroup, stockRepo) // This line was removed as per the edit hint
+		routes.SetupStockRoutes(apiGroup, stockRepo)
+		routes.SetupStockImportRoutes(apiGroup, stockImportRepo, stockImports)
+
+		// NF-e de compra e venda lançadas no estoque, com a fila de conciliação dos itens
+		routes.SetupNFeRoutes(apiGroup, nfeRepo)
 
 		// Company endpoints
 		apiGroup.GET("/companies", handler.GetAllCompanies)
 		apiGroup.GET("/cities", handler.GetCities)
 		apiGroup.GET("/ceps", handler.GetCEPs)
 		routes.SetupCompanyRoutes(apiGroup, companyRepo)
+
+		// Mapeamento dos modelos dos provedores de placa para o catálogo (fila de revisão)
+		routes.SetupVehicleModelRoutes(apiGroup, vehicleModelRepo)
+
+		// Apelidos de fabricantes ("VW", "GM", "CHEV") usados nas buscas por aplicação
+		routes.SetupManufacturerAliasRoutes(apiGroup, manufacturerAliasRepo)
+
+		// Tabela FIPE importada (cmd/fipe_import) e histórico de preços por código
+		routes.SetupFipeRoutes(apiGroup, fipeRepo, carRepo)
 	}
 
 	// Car endpoints - configurar separadamente
-	carHandler := handlers.NewCarHandler(carRepo)
+	// Consultas de placa rodam em jobs em background, compartilhados por placa
+	plateLookups := vehicledata.NewLookupManager(carRepo)
+	carHandler := handlers.NewCarHandler(carRepo, plateLookups)
 	r.GET("/api/v1/cars/health", carHandler.HealthCheck)
 	r.GET("/api/v1/cars/test", carHandler.TestEndpoint)
 	r.GET("/api/v1/cars/search/:plate", carHandler.SearchCarByPlate)
 	r.GET("/api/v1/cars/cache/:plate", carHandler.GetCarByPlate)
+	r.GET("/api/v1/cars/vin/:vin", carHandler.DecodeVIN)
+	r.POST("/api/v1/cars/lookup", carHandler.StartLookup)
+	r.GET("/api/v1/cars/lookup/:id", carHandler.GetLookup)
+	r.GET("/api/v1/cars/lookup/:id/events", carHandler.StreamLookup)
+
+	// Revisão das consultas de placa que falharam (car_error)
+	carErrorHandler := handlers.NewCarErrorHandler(carErrorRepo, carRepo)
+	r.GET("/api/v1/cars/errors", carErrorHandler.ListErrors)
+	r.GET("/api/v1/cars/errors/summary", carErrorHandler.GetSummary)
+	r.GET("/api/v1/cars/errors/:plate", carErrorHandler.GetError)
+	r.POST("/api/v1/cars/errors/:plate/retry", carErrorHandler.RetryError)
+	r.DELETE("/api/v1/cars/errors/:plate", carErrorHandler.DiscardError)
+
+	// Curva de preço FIPE do veículo da placa
+	fipeHandler := handlers.NewFipeHandler(fipeRepo, carRepo)
+	r.GET("/api/v1/cars/fipe/:plate", fipeHandler.GetCarPriceCurve)
 
 	// Plate search endpoint
-	plateSearchHandler := handlers.NewPlateSearchHandler(repo, carRepo)
+	plateSearchHandler := handlers.NewPlateSearchHandler(repo, plateLookups, vehicleModelRepo)
 	r.GET("/api/v1/plate-search/:plate", plateSearchHandler.SearchByPlate)
+	r.GET("/api/v1/vin-search/:vin", plateSearchHandler.SearchByVIN)
 
 	// GeoIP endpoints
 	r.GET("/api/geoip/location", handlers.GetUserLocation)
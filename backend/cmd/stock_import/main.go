@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/stockfeed"
+)
+
+// Importa o arquivo de estoque completo de uma empresa (CSV, XLSX ou JSON lines), pelo mesmo
+// job usado em /api/v1/stock-imports, e mostra o relatório de erros por linha.
+//
+//	go run ./cmd/stock_import -company <id> -actor joao estoque.xlsx
+//	go run ./cmd/stock_import -company <id> -actor joao -mode replace estoque.csv
+//	go run ./cmd/stock_import -resume <job id>
+//
+// O processamento é feito em lotes; uma importação interrompida continua com -resume.
+
+func main() {
+	company := flag.String("company", "", "ID da empresa dona do estoque")
+	mode := flag.String("mode", models.StockImportMerge, "merge (só os itens do arquivo) ou replace (zera os itens fora do arquivo)")
+	format := flag.String("format", "", "formato do arquivo (csv, xlsx ou jsonl); padrão: pela extensão")
+	actor := flag.String("actor", os.Getenv("USER"), "responsável pelas movimentações de estoque")
+	resume := flag.String("resume", "", "retoma a importação com este ID em vez de ler um arquivo")
+	dryRun := flag.Bool("dry-run", false, "só lê e valida o arquivo, sem gravar")
+	maxErrors := flag.Int("max-errors", 20, "quantas linhas com erro listar")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Uso: %s [opções] arquivo\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if (*resume == "") == (flag.NArg() != 1) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var job *models.StockImportJob
+	var lines []models.StockImportLine
+	if *resume == "" {
+		path := flag.Arg(0)
+		if *company == "" {
+			if !*dryRun {
+				log.Fatalf("❌ [STOCK-IMPORT] Informe a empresa com -company")
+			}
+			// Na validação sem banco a empresa não é usada
+			*company = uuid.Nil.String()
+		}
+		if *format == "" {
+			*format = stockfeed.FormatFromPath(path)
+		}
+		var err error
+		if job, lines, err = readFile(path, strings.ToLower(*format), *company, *mode, *actor); err != nil {
+			log.Fatalf("❌ [STOCK-IMPORT] %s: %v", path, err)
+		}
+		log.Printf("📄 [STOCK-IMPORT] %s: %d linha(s), %d rejeitada(s) na leitura", path, job.TotalLines, job.Errors)
+		if *dryRun {
+			printErrors(lines, *maxErrors)
+			return
+		}
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+	if err := database.InitDatabase(); err != nil {
+		log.Fatalf("❌ [STOCK-IMPORT] Erro ao conectar ao banco: %v", err)
+	}
+	// Sem o log de cada INSERT dos lotes
+	db := database.GetDB().Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Warn)})
+	repo := database.NewStockImportRepository(db)
+
+	id := *resume
+	if job != nil {
+		if err := repo.Create(job, lines); err != nil {
+			log.Fatalf("❌ [STOCK-IMPORT] Erro ao criar a importação: %v", err)
+		}
+		id = job.ID.String()
+		log.Printf("🆔 [STOCK-IMPORT] Importação %s criada (modo %s)", id, job.Mode)
+	} else if _, err := repo.Resume(id); err != nil {
+		log.Fatalf("❌ [STOCK-IMPORT] Erro ao retomar a importação %s: %v", id, err)
+	}
+
+	job, err := stockfeed.NewRunner(repo).Run(id)
+	if err != nil {
+		log.Fatalf("❌ [STOCK-IMPORT] Importação %s interrompida: %v (retome com -resume %s)", id, err, id)
+	}
+	if job.Message != "" {
+		log.Printf("⚠️ [STOCK-IMPORT] %s", job.Message)
+	}
+
+	report, err := repo.ListLines(id, models.StockImportLineError, 1, *maxErrors)
+	if err != nil {
+		log.Fatalf("❌ [STOCK-IMPORT] Erro ao buscar o relatório de erros: %v", err)
+	}
+	printErrors(report.Lines, *maxErrors)
+	if report.Total > int64(len(report.Lines)) {
+		log.Printf("   ... mais %d linha(s) com erro", report.Total-int64(len(report.Lines)))
+	}
+}
+
+// readFile lê o arquivo e monta o job com as linhas
+func readFile(path, format, company, mode, actor string) (*models.StockImportJob, []models.StockImportLine, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	lines, err := stockfeed.Read(file, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stockfeed.NewJob(company, mode, format, path, actor, lines)
+}
+
+// printErrors lista até max linhas com erro
+func printErrors(lines []models.StockImportLine, max int) {
+	shown := 0
+	for _, line := range lines {
+		if line.Status != models.StockImportLineError {
+			continue
+		}
+		if shown == max {
+			log.Printf("   ... mais linhas com erro")
+			return
+		}
+		log.Printf("   ⚠️ linha %d (sku %q, ean %q): %s", line.Line, line.SKU, line.EAN, line.Error)
+		shown++
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/handlers"
+	"partexplorer/backend/internal/metrics"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/vehicledata"
+)
+
+// failingProvider sempre falha com o erro informado
+type failingProvider struct {
+	name string
+	err  error
+}
+
+func (p failingProvider) Name() string { return p.name }
+func (p failingProvider) Lookup(context.Context, string) (*models.CarInfo, error) {
+	return nil, p.err
+}
+
+// pgError imita o erro do driver do PostgreSQL
+type pgError struct{ code string }
+
+func (e pgError) Error() string    { return "ERROR: pg error (SQLSTATE " + e.code + ")" }
+func (e pgError) SQLState() string { return e.code }
+
+// fakeQueue é a car_error em memória
+type fakeQueue struct {
+	database.CarErrorRepository
+	entries map[string]*models.CarError
+}
+
+func (q *fakeQueue) Due(limit int) ([]string, error) {
+	var plates []string
+	for plate, entry := range q.entries {
+		if entry.Status == models.CarErrorPending && (entry.NextRetryAt == nil || !entry.NextRetryAt.After(time.Now())) {
+			plates = append(plates, plate)
+		}
+	}
+	return plates, nil
+}
+
+func (q *fakeQueue) Counts() ([]models.CarErrorCount, error) {
+	totals := map[[2]string]int64{}
+	for _, entry := range q.entries {
+		totals[[2]string{entry.Status, entry.Reason}]++
+	}
+	var counts []models.CarErrorCount
+	for key, count := range totals {
+		counts = append(counts, models.CarErrorCount{Status: key[0], Reason: key[1], Count: count})
+	}
+	return counts, nil
+}
+
+func (q *fakeQueue) Get(plate string) (*models.CarError, error) {
+	entry, ok := q.entries[plate]
+	if !ok {
+		return nil, database.ErrCarErrorNotFound
+	}
+	return entry, nil
+}
+
+func (q *fakeQueue) Discard(plate string) (*models.CarError, error) {
+	entry, err := q.Get(plate)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status == models.CarErrorDiscarded || entry.Status == models.CarErrorResolved {
+		return entry, database.ErrCarErrorClosed
+	}
+	entry.Status = models.CarErrorDiscarded
+	return entry, nil
+}
+
+// fakeCars refaz as placas: as de working resolvem, as demais falham de novo
+type fakeCars struct {
+	database.CarRepository
+	queue   *fakeQueue
+	working map[string]bool
+}
+
+func (r *fakeCars) RetryCarError(plate string) (*models.CarError, error) {
+	entry, err := r.queue.Get(plate)
+	if err != nil {
+		return nil, err
+	}
+	entry.Attempts++
+	if r.working[plate] {
+		entry.Status = models.CarErrorResolved
+		return entry, nil
+	}
+	next := time.Now().Add(time.Hour)
+	entry.NextRetryAt = &next
+	return entry, nil
+}
+
+func main() {
+	fmt.Println("🧪 Testando a fila de novas tentativas da car_error...")
+
+	fmt.Println("\n=== TESTE 1: Classificação das falhas ===")
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("%w: status 403", vehicledata.ErrBlocked), models.CarErrorReasonBlocked},
+		{fmt.Errorf("%w: invalid character", vehicledata.ErrParse), models.CarErrorReasonParse},
+		{fmt.Errorf("save: %w", pgError{"23505"}), models.CarErrorReasonConstraint},
+		{vehicledata.ErrCircuitOpen, models.CarErrorReasonProvider},
+		{context.DeadlineExceeded, models.CarErrorReasonProvider},
+	} {
+		got := database.ClassifyCarError(tc.err)
+		check.That(got == tc.want, "%v -> %s", tc.err, got)
+	}
+
+	chain := vehicledata.NewChain([]vehicledata.PlateProvider{
+		failingProvider{"keplaca", fmt.Errorf("%w: status 429", vehicledata.ErrBlocked)},
+		failingProvider{"json", errors.New("unexpected status 500")},
+	}, nil)
+	_, err := chain.Lookup(context.Background(), "ABC1234")
+	check.That(errors.Is(err, vehicledata.ErrBlocked) && database.ClassifyCarError(err) == models.CarErrorReasonBlocked,
+		"Falha de todos os provedores mantém o bloqueio para a classificação: %v", err)
+
+	fmt.Println("\n=== TESTE 2: Dados guardados ===")
+	saved := &models.CarError{Data: map[string]interface{}{"placa": "ABC1234", "marca": "FIAT"}}
+	providerOnly := &models.CarError{Data: map[string]interface{}{"placa": "ABC1234"}}
+	check.That(saved.HasCarData() && !providerOnly.HasCarData(), "Falha ao gravar guarda o veículo; falha de provedor só a placa")
+
+	fmt.Println("\n=== TESTE 3: Worker ===")
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	queue := &fakeQueue{entries: map[string]*models.CarError{
+		"ABC1234": {LicensePlate: "ABC1234", Status: models.CarErrorPending, Reason: models.CarErrorReasonConstraint, NextRetryAt: &past},
+		"BRA2E19": {LicensePlate: "BRA2E19", Status: models.CarErrorPending, Reason: models.CarErrorReasonBlocked, NextRetryAt: &past},
+		"XYZ9876": {LicensePlate: "XYZ9876", Status: models.CarErrorPending, Reason: models.CarErrorReasonBlocked, NextRetryAt: &future},
+		"DEF5678": {LicensePlate: "DEF5678", Status: models.CarErrorExhausted, Reason: models.CarErrorReasonParse},
+	}}
+	cars := &fakeCars{queue: queue, working: map[string]bool{"ABC1234": true}}
+	worker := vehicledata.NewErrorRetryWorker(queue, cars)
+
+	resolved, err := worker.RetryBatch()
+	check.That(err == nil && resolved == 1, "Lote resolve 1 de 2 vencidas (%d)", resolved)
+	check.That(queue.entries["XYZ9876"].Attempts == 0 && queue.entries["DEF5678"].Attempts == 0, "Agendadas para depois e esgotadas ficam de fora")
+	check.That(queue.entries["BRA2E19"].NextRetryAt.After(time.Now()), "Falha de novo é reagendada")
+	resolved, _ = worker.RetryBatch()
+	check.That(resolved == 0 && queue.entries["BRA2E19"].Attempts == 1, "Nada vencido no lote seguinte")
+
+	check.That(worker.ExportCounts() == nil, "Contagens exportadas")
+	gauge := func(status, reason string) float64 {
+		return testutil.ToFloat64(metrics.CarErrorEntries.WithLabelValues(status, reason))
+	}
+	check.That(gauge(models.CarErrorPending, models.CarErrorReasonBlocked) == 2 && gauge(models.CarErrorResolved, models.CarErrorReasonConstraint) == 1,
+		"Métrica por status e motivo (pendentes bloqueadas %.0f, resolvidas %.0f)",
+		gauge(models.CarErrorPending, models.CarErrorReasonBlocked), gauge(models.CarErrorResolved, models.CarErrorReasonConstraint))
+
+	fmt.Println("\n=== TESTE 4: Endpoints ===")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := handlers.NewCarErrorHandler(queue, cars)
+	router.GET("/api/v1/cars/errors/summary", handler.GetSummary)
+	router.GET("/api/v1/cars/errors/:plate", handler.GetError)
+	router.POST("/api/v1/cars/errors/:plate/retry", handler.RetryError)
+	router.DELETE("/api/v1/cars/errors/:plate", handler.DiscardError)
+
+	w := request(router, http.MethodGet, "/api/v1/cars/errors/summary")
+	var summary struct {
+		Counts []models.CarErrorCount `json:"counts"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &summary)
+	check.That(w.Code == http.StatusOK && len(summary.Counts) == 3, "Resumo -> %d %+v", w.Code, summary.Counts)
+
+	w = request(router, http.MethodGet, "/api/v1/cars/errors/DEF5678")
+	check.That(w.Code == http.StatusOK, "Consulta -> %d", w.Code)
+	w = request(router, http.MethodGet, "/api/v1/cars/errors/AAA0000")
+	check.That(w.Code == http.StatusNotFound, "Placa sem erro -> %d", w.Code)
+
+	cars.working["DEF5678"] = true
+	w = request(router, http.MethodPost, "/api/v1/cars/errors/DEF5678/retry")
+	check.That(w.Code == http.StatusOK && queue.entries["DEF5678"].Status == models.CarErrorResolved, "Nova tentativa manual de esgotada -> %d %s", w.Code, queue.entries["DEF5678"].Status)
+
+	w = request(router, http.MethodDelete, "/api/v1/cars/errors/BRA2E19")
+	check.That(w.Code == http.StatusOK && queue.entries["BRA2E19"].Status == models.CarErrorDiscarded, "Descarte -> %d", w.Code)
+	w = request(router, http.MethodDelete, "/api/v1/cars/errors/BRA2E19")
+	check.That(w.Code == http.StatusConflict, "Descartar de novo -> %d", w.Code)
+
+	check.Finish()
+}
+
+func request(r http.Handler, method, url string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
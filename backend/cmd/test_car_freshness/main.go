@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/vehicledata"
+)
+
+// fakeSource simula o cache: plates são as placas vencidas e broken as que os provedores
+// não conseguem atualizar
+type fakeSource struct {
+	plates    []string
+	broken    map[string]bool
+	refreshed []string
+	since     time.Time
+}
+
+func (s *fakeSource) StalePlates(queriedSince time.Time, limit int) ([]string, error) {
+	s.since = queriedSince
+	if len(s.plates) > limit {
+		return s.plates[:limit], nil
+	}
+	return s.plates, nil
+}
+
+func (s *fakeSource) RefreshCarByPlate(plate string) (*models.CarInfo, error) {
+	s.refreshed = append(s.refreshed, plate)
+	if s.broken[plate] {
+		return nil, errors.New("provider unavailable")
+	}
+	// Atualizada, deixa de estar vencida
+	for i, stale := range s.plates {
+		if stale == plate {
+			s.plates = append(s.plates[:i], s.plates[i+1:]...)
+			break
+		}
+	}
+	return &models.CarInfo{Placa: plate}, nil
+}
+
+func main() {
+	fmt.Println("🧪 Testando a validade do cache de placas e o refresher...")
+
+	fmt.Println("\n=== TESTE 1: Política padrão ===")
+	for _, env := range []string{"CAR_TTL_IDENTITY", "CAR_TTL_REGISTRATION", "CAR_TTL_FIPE", "CAR_REFRESH_INTERVAL", "CAR_REFRESH_RECENT", "CAR_REFRESH_BATCH"} {
+		os.Unsetenv(env)
+	}
+	policy := vehicledata.DefaultFreshnessPolicy()
+	check.That(policy.MinTTL() == 24*time.Hour, "Menor prazo é o da FIPE (%s)", policy.MinTTL())
+
+	now := time.Now()
+	fresh := policy.Evaluate(now.Add(-time.Hour), now)
+	check.That(!fresh.Stale && fresh.AgeSeconds == 3600, "Dados de 1h estão válidos (%+v)", fresh)
+
+	fipe := policy.Evaluate(now.Add(-48*time.Hour), now)
+	check.That(fipe.Stale && len(fipe.StaleFields) == 1 && fipe.StaleFields[0] == vehicledata.FieldGroupFipe,
+		"Dados de 2 dias: só a FIPE venceu %v", fipe.StaleFields)
+	check.That(!vehicledata.IdentityStale(fipe), "FIPE vencida não bloqueia a consulta")
+
+	old := policy.Evaluate(now.Add(-5*365*24*time.Hour), now)
+	check.That(len(old.StaleFields) == 2 && !vehicledata.IdentityStale(old),
+		"Dados de 5 anos: registro e FIPE vencidos, identificação nunca vence %v", old.StaleFields)
+
+	fmt.Println("\n=== TESTE 2: Prazos configurados ===")
+	os.Setenv("CAR_TTL_IDENTITY", "8760h")
+	os.Setenv("CAR_TTL_FIPE", "0")
+	os.Setenv("CAR_TTL_REGISTRATION", "invalido")
+	policy = vehicledata.DefaultFreshnessPolicy()
+	check.That(policy.TTL[vehicledata.FieldGroupFipe] == 0, "CAR_TTL_FIPE=0 desativa o vencimento da FIPE")
+	check.That(policy.TTL[vehicledata.FieldGroupRegistration] == 720*time.Hour, "Valor inválido mantém o padrão (%s)", policy.TTL[vehicledata.FieldGroupRegistration])
+	old = policy.Evaluate(now.Add(-2*8760*time.Hour), now)
+	check.That(vehicledata.IdentityStale(old), "Identificação com prazo definido vence %v", old.StaleFields)
+
+	os.Setenv("CAR_TTL_IDENTITY", "0")
+	os.Setenv("CAR_TTL_REGISTRATION", "0")
+	policy = vehicledata.DefaultFreshnessPolicy()
+	check.That(policy.MinTTL() == 0 && !policy.Evaluate(now.Add(-8760*time.Hour), now).Stale, "Sem prazos, nada vence")
+
+	fmt.Println("\n=== TESTE 3: Refresher ===")
+	os.Setenv("CAR_REFRESH_BATCH", "2")
+	os.Setenv("CAR_REFRESH_RECENT", "24h")
+	source := &fakeSource{
+		plates: []string{"ABC1234", "BRA2E19", "XYZ9876"},
+		broken: map[string]bool{"ABC1234": true},
+	}
+	refresher := vehicledata.NewRefresher(source)
+
+	refreshed, err := refresher.RefreshBatch()
+	check.That(err == nil && refreshed == 1 && len(source.refreshed) == 2, "Lote de 2: 1 atualizada, 1 falha (%v)", source.refreshed)
+	check.That(time.Since(source.since) >= 24*time.Hour && time.Since(source.since) < 25*time.Hour, "Só as placas consultadas nas últimas 24h")
+
+	source.refreshed = nil
+	refreshed, _ = refresher.RefreshBatch()
+	check.That(refreshed == 1 && len(source.refreshed) == 1 && source.refreshed[0] == "XYZ9876",
+		"Placa com falha recente espera; a próxima é atualizada (%v)", source.refreshed)
+
+	source.refreshed = nil
+	refreshed, _ = refresher.RefreshBatch()
+	check.That(refreshed == 0 && len(source.refreshed) == 0, "Nada a fazer enquanto a falha é recente")
+
+	check.Finish()
+}
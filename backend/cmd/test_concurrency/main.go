@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/routes"
+)
+
+// fakeStocks confere a versão e aplica as variações como o repositório, sem banco
+type fakeStocks struct {
+	database.StockRepository
+	stocks map[string]*models.Stock
+}
+
+func (f *fakeStocks) GetStockByID(id string) (*models.Stock, error) {
+	stock, ok := f.stocks[id]
+	if !ok {
+		return nil, database.ErrStockNotFound
+	}
+	return stock, nil
+}
+
+func (f *fakeStocks) UpdateStock(id string, version int, updates map[string]interface{}, count *models.StockMovementRequest) (*models.Stock, []models.StockMovement, error) {
+	stock, ok := f.stocks[id]
+	if !ok {
+		return nil, nil, database.ErrStockNotFound
+	}
+	if version != stock.Version {
+		return stock, nil, database.ErrVersionConflict
+	}
+	if price, ok := updates["price"].(float64); ok {
+		stock.Price = &price
+	}
+	stock.Version++
+	return stock, nil, nil
+}
+
+func (f *fakeStocks) AdjustQuantity(id string, req models.StockMovementRequest) (*models.Stock, *models.StockMovement, error) {
+	stock, ok := f.stocks[id]
+	if !ok {
+		return nil, nil, database.ErrStockNotFound
+	}
+	delta, err := database.StockMovementDelta(req, *stock.Quantity)
+	if err != nil {
+		return nil, nil, err
+	}
+	if *stock.Quantity+delta < 0 {
+		return nil, nil, database.ErrInsufficientStock
+	}
+	after := *stock.Quantity + delta
+	stock.Quantity = &after
+	stock.Version++
+	return stock, &models.StockMovement{StockID: stock.ID, Type: req.Type, Quantity: delta, QuantityAfter: after, Actor: req.Actor}, nil
+}
+
+// fakeCompanies guarda as empresas em memória com a versão
+type fakeCompanies struct {
+	database.CompanyRepository
+	companies map[string]*models.Company
+}
+
+func (f *fakeCompanies) GetCompanyByID(id string) (*models.Company, error) {
+	company, ok := f.companies[id]
+	if !ok {
+		return nil, database.ErrCompanyNotFound
+	}
+	return company, nil
+}
+
+func (f *fakeCompanies) UpdateCompany(id string, version int, updates map[string]interface{}) (*models.Company, error) {
+	company, ok := f.companies[id]
+	if !ok {
+		return nil, database.ErrCompanyNotFound
+	}
+	if version != company.Version {
+		return company, database.ErrVersionConflict
+	}
+	if name, ok := updates["name"].(string); ok {
+		company.Name = name
+	}
+	company.Version++
+	return company, nil
+}
+
+func main() {
+	fmt.Println("🧪 Testando o controle de concorrência de estoque e empresa...")
+
+	quantity, price := 10, 19.9
+	stock := &models.Stock{ID: uuid.New(), PartNameID: uuid.New(), Quantity: &quantity, Price: &price, Version: 3}
+	stocks := &fakeStocks{stocks: map[string]*models.Stock{stock.ID.String(): stock}}
+	company := &models.Company{ID: uuid.New(), Name: "Auto Peças Central", Version: 1}
+	companies := &fakeCompanies{companies: map[string]*models.Company{company.ID.String(): company}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupStockRoutes(router.Group("/api/v1"), stocks)
+	routes.SetupCompanyRoutes(router.Group("/api/v1"), companies)
+	stockURL := "/api/v1/stocks/" + stock.ID.String()
+	companyURL := "/api/v1/companies/" + company.ID.String()
+
+	fmt.Println("\n=== TESTE 1: ETag e If-Match no estoque ===")
+	w := request(router, http.MethodGet, stockURL, "", nil)
+	etag := w.Header().Get("ETag")
+	check.That(w.Code == http.StatusOK && etag == `"3"`, "GET devolve a versão no ETag: %s", etag)
+
+	w = request(router, http.MethodPut, stockURL, `{"price": 21.5}`, map[string]string{"If-Match": etag})
+	check.That(w.Code == http.StatusOK && w.Header().Get("ETag") == `"4"` && *stock.Price == 21.5,
+		"Atualização na versão do ETag -> %d, novo ETag %s", w.Code, w.Header().Get("ETag"))
+
+	// Segundo cliente ainda com o ETag antigo: não sobrescreve e recebe o estado atual
+	w = request(router, http.MethodPut, stockURL, `{"price": 18}`, map[string]string{"If-Match": etag})
+	var conflict struct {
+		Version int          `json:"version"`
+		Current models.Stock `json:"current"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &conflict)
+	check.That(w.Code == http.StatusConflict && *stock.Price == 21.5 && conflict.Version == 4 && *conflict.Current.Price == 21.5 &&
+		w.Header().Get("ETag") == `"4"`, "ETag antigo -> %d com o estado atual (preço %v, versão %d)", w.Code, *stock.Price, conflict.Version)
+
+	w = request(router, http.MethodPut, stockURL, `{"price": 18}`, map[string]string{"If-Match": `W/"4"`})
+	check.That(w.Code == http.StatusOK && *stock.Price == 18, "ETag fraco aceito -> %d", w.Code)
+	w = request(router, http.MethodPut, stockURL, `{"price": 17}`, map[string]string{"If-Match": "*"})
+	check.That(w.Code == http.StatusPreconditionRequired && *stock.Price == 18 && stock.Version == 5, "If-Match * não informa a versão -> %d", w.Code)
+	w = request(router, http.MethodPut, stockURL, `{"price": 17}`, nil)
+	check.That(w.Code == http.StatusPreconditionRequired && *stock.Price == 18 && stock.Version == 5, "Sem If-Match não sobrescreve -> %d", w.Code)
+	w = request(router, http.MethodPut, stockURL, `{"price": 16}`, map[string]string{"If-Match": `"abc"`})
+	check.That(w.Code == http.StatusBadRequest && *stock.Price == 18, "If-Match inválido -> %d", w.Code)
+
+	fmt.Println("\n=== TESTE 2: Incremento e decremento atômicos ===")
+	w = request(router, http.MethodPost, stockURL+"/increment", `{"amount": 5}`, map[string]string{"X-User": "joao"})
+	var changed struct {
+		Quantity int                  `json:"quantity"`
+		Version  int                  `json:"version"`
+		Movement models.StockMovement `json:"movement"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &changed)
+	check.That(w.Code == http.StatusOK && changed.Quantity == 15 && changed.Movement.Type == models.StockMovementReceipt &&
+		changed.Movement.Actor == "joao" && w.Header().Get("ETag") == fmt.Sprintf(`"%d"`, changed.Version),
+		"Incremento entra como receipt -> %d saldo %d", w.Code, changed.Quantity)
+
+	w = request(router, http.MethodPost, stockURL+"/decrement", `{"amount": 4, "actor": "maria"}`, map[string]string{"If-Match": `"1"`})
+	json.Unmarshal(w.Body.Bytes(), &changed)
+	check.That(w.Code == http.StatusOK && changed.Quantity == 11 && changed.Movement.Type == models.StockMovementSale,
+		"Decremento entra como sale e ignora o If-Match -> %d saldo %d", w.Code, changed.Quantity)
+
+	w = request(router, http.MethodPost, stockURL+"/decrement", `{"amount": 3, "type": "adjustment", "reason": "avaria", "actor": "maria"}`, nil)
+	json.Unmarshal(w.Body.Bytes(), &changed)
+	check.That(w.Code == http.StatusOK && changed.Quantity == 8 && changed.Movement.Quantity == -3, "Decremento como ajuste com motivo -> %d", w.Code)
+
+	w = request(router, http.MethodPost, stockURL+"/decrement", `{"amount": 50, "actor": "maria"}`, nil)
+	check.That(w.Code == http.StatusConflict && *stock.Quantity == 8, "Decremento acima do saldo -> %d", w.Code)
+	w = request(router, http.MethodPost, stockURL+"/increment", `{"amount": 2, "type": "sale", "actor": "maria"}`, nil)
+	check.That(w.Code == http.StatusBadRequest, "Incremento do tipo sale -> %d", w.Code)
+	w = request(router, http.MethodPost, stockURL+"/increment", `{"amount": 0, "actor": "maria"}`, nil)
+	check.That(w.Code == http.StatusBadRequest, "Quantidade zero -> %d", w.Code)
+	w = request(router, http.MethodPost, stockURL+"/increment", `{"amount": 2}`, nil)
+	check.That(w.Code == http.StatusBadRequest, "Sem responsável -> %d", w.Code)
+	w = request(router, http.MethodPost, "/api/v1/stocks/"+uuid.NewString()+"/increment", `{"amount": 2, "actor": "maria"}`, nil)
+	check.That(w.Code == http.StatusNotFound, "Estoque inexistente -> %d", w.Code)
+
+	fmt.Println("\n=== TESTE 3: ETag e If-Match na empresa ===")
+	w = request(router, http.MethodGet, companyURL, "", nil)
+	etag = w.Header().Get("ETag")
+	check.That(w.Code == http.StatusOK && etag == `"1"` && strings.Contains(w.Body.String(), `"version":1`), "GET da empresa com ETag %s", etag)
+
+	w = request(router, http.MethodPut, companyURL, `{"name": "Auto Peças Central Ltda"}`, map[string]string{"If-Match": etag})
+	check.That(w.Code == http.StatusOK && w.Header().Get("ETag") == `"2"`, "Atualização na versão do ETag -> %d", w.Code)
+
+	w = request(router, http.MethodPut, companyURL, `{"name": "Central Autopeças"}`, map[string]string{"If-Match": etag})
+	var companyConflict struct {
+		Current models.Company `json:"current"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &companyConflict)
+	check.That(w.Code == http.StatusConflict && company.Name == "Auto Peças Central Ltda" && companyConflict.Current.Name == company.Name,
+		"Edição concorrente não sobrescreve -> %d com o nome atual %q", w.Code, companyConflict.Current.Name)
+
+	w = request(router, http.MethodPut, companyURL, `{"name": "Central Autopeças"}`, nil)
+	check.That(w.Code == http.StatusPreconditionRequired && company.Name == "Auto Peças Central Ltda", "Empresa sem If-Match -> %d", w.Code)
+
+	w = request(router, http.MethodPut, "/api/v1/companies/"+uuid.NewString(), `{"name": "X"}`, map[string]string{"If-Match": `"1"`})
+	check.That(w.Code == http.StatusNotFound, "Empresa inexistente -> %d", w.Code)
+
+	check.Finish()
+}
+
+func request(r http.Handler, method, url, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
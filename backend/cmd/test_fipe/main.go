@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/fipe"
+	"partexplorer/backend/internal/handlers"
+	"partexplorer/backend/internal/models"
+)
+
+// fakeFipe guarda as linhas importadas em memória
+type fakeFipe struct {
+	database.FipeRepository
+	records []fipe.Record
+}
+
+func (f *fakeFipe) GetVehicle(value string) (*models.FipeVehicle, error) {
+	code, err := fipe.NormalizeCode(value)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range f.records {
+		if record.Code == code {
+			return &models.FipeVehicle{Code: code, Brand: record.Brand, Model: record.Model, Fuel: record.Fuel}, nil
+		}
+	}
+	return nil, database.ErrFipeNotFound
+}
+
+func (f *fakeFipe) PriceCurve(value string, modelYear int, from, to time.Time) (*models.FipePriceCurve, error) {
+	vehicle, err := f.GetVehicle(value)
+	if err != nil {
+		return nil, err
+	}
+	curve := &models.FipePriceCurve{Code: vehicle.Code, ModelYear: modelYear, Vehicle: vehicle}
+	for _, record := range f.records {
+		if record.Code != vehicle.Code || record.ModelYear != modelYear ||
+			(!from.IsZero() && record.ReferenceMonth.Before(from)) || (!to.IsZero() && record.ReferenceMonth.After(to)) {
+			continue
+		}
+		curve.Prices = append(curve.Prices, models.FipePricePoint{
+			ReferenceMonth: record.ReferenceMonth.Format("2006-01"),
+			Price:          record.Price,
+			Source:         models.FipePriceSourceTable,
+		})
+	}
+	return curve, nil
+}
+
+// fakeCars é o cache de placas em memória
+type fakeCars struct {
+	database.CarRepository
+	cars map[string]*models.Car
+}
+
+func (r *fakeCars) GetCarByPlate(plate string) (*models.Car, error) {
+	car, ok := r.cars[plate]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return car, nil
+}
+
+const csvTable = "\ufeffCódigo Fipe;Marca;Modelo;Ano Modelo;Combustível;Valor;Mês Referência\n" +
+	"005340-6;VW - VolksWagen;Gol 1.0 Flex 12V 5p;2019;Gasolina;R$ 41.234,00;janeiro de 2025\n" +
+	"5340-6;VW - VolksWagen;Gol 1.0 Flex 12V 5p;2019;Gasolina;R$ 40.980,00;fevereiro de 2025\n" +
+	"005340-6;VW - VolksWagen;Gol 1.0 Flex 12V 5p;Zero KM;Gasolina;R$ 75.000,00;fevereiro de 2025\n" +
+	"ABC;VW - VolksWagen;Gol;2019;Gasolina;R$ 1,00;janeiro de 2025\n" +
+	"005340-6;VW - VolksWagen;Gol;2019;Gasolina;consulte;janeiro de 2025\n"
+
+const jsonTable = `[
+	{"Valor": "R$ 10.000,00", "Marca": "Acura", "Modelo": "Integra GS 1.8", "AnoModelo": 1992, "Combustivel": "Gasolina", "CodigoFipe": "038001-1", "MesReferencia": "maio de 2021 ", "TipoVeiculo": 1},
+	{"Valor": "R$ 10.500,00", "Marca": "Acura", "Modelo": "Integra GS 1.8", "AnoModelo": 1992, "Combustivel": "Gasolina", "CodigoFipe": "038001-1", "MesReferencia": "junho de 2021"}
+]`
+
+func main() {
+	fmt.Println("🧪 Testando a importação da tabela FIPE e o histórico de preços...")
+
+	fmt.Println("\n=== TESTE 1: Código, preço e mês ===")
+	for _, tc := range []struct{ in, want string }{
+		{"005340-6", "005340-6"}, {"5340-6", "005340-6"}, {"0053406", "005340-6"}, {"005.340-6", "005340-6"},
+	} {
+		got, err := fipe.NormalizeCode(tc.in)
+		check.That(err == nil && got == tc.want, "Código %q -> %q", tc.in, got)
+	}
+	for _, in := range []string{"", "12345678", "ABC123-4"} {
+		_, err := fipe.NormalizeCode(in)
+		check.That(errors.Is(err, fipe.ErrInvalidCode), "Código inválido %q rejeitado", in)
+	}
+
+	for _, tc := range []struct {
+		in   string
+		want float64
+	}{
+		{"R$ 35.000,00", 35000}, {"R$35.000,50", 35000.5}, {"35000.00", 35000}, {"35.000", 35000}, {"1.234.567", 1234567}, {"950", 950},
+	} {
+		got, err := fipe.ParsePrice(tc.in)
+		check.That(err == nil && got == tc.want, "Preço %q -> %.2f", tc.in, got)
+	}
+	_, err := fipe.ParsePrice("consulte")
+	check.That(errors.Is(err, fipe.ErrInvalidPrice), "Preço sem número rejeitado")
+
+	january := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for _, in := range []string{"janeiro de 2025", "Janeiro/2025", "2025-01", "01/2025", "2025-01-01"} {
+		got, err := fipe.ParseReferenceMonth(in)
+		check.That(err == nil && got.Equal(january), "Mês %q -> %s", in, got.Format("2006-01"))
+	}
+	_, err = fipe.ParseReferenceMonth("13/2025")
+	check.That(errors.Is(err, fipe.ErrInvalidMonth), "Mês inválido rejeitado")
+
+	fmt.Println("\n=== TESTE 2: Tabela em CSV ===")
+	result, err := fipe.Read(strings.NewReader(csvTable), fipe.FormatFromPath("tabela.csv"), time.Time{})
+	check.That(err == nil && len(result.Records) == 3 && len(result.Errors) == 2,
+		"Ponto e vírgula, cabeçalho com acentos: %d válidas, %d rejeitadas", len(result.Records), len(result.Errors))
+	if err == nil && len(result.Records) == 3 {
+		first := result.Records[0]
+		check.That(first.Code == "005340-6" && first.Brand == "VW - VolksWagen" && first.ModelYear == 2019 && first.Price == 41234 && first.ReferenceMonth.Equal(january),
+			"Primeira linha %+v", first)
+		check.That(result.Records[1].Code == "005340-6", "Código sem zeros à esquerda normalizado")
+		check.That(result.Records[2].ModelYear == fipe.ZeroKm, "Zero KM vira ano-modelo %d", fipe.ZeroKm)
+		check.That(result.Errors[0].Line == 5 && errors.Is(result.Errors[0], fipe.ErrInvalidCode), "Rejeição com a linha: %v", result.Errors[0])
+		check.That(errors.Is(result.Errors[1], fipe.ErrInvalidPrice), "Preço inválido: %v", result.Errors[1])
+	}
+
+	commas := "codigo_fipe,marca,modelo,ano_modelo,combustivel,valor\n004412-0,GM - Chevrolet,ONIX 1.0,2019,Flex,\"55.100,00\"\n"
+	result, err = fipe.Read(strings.NewReader(commas), fipe.FormatCSV, time.Time{})
+	check.That(err == nil && len(result.Records) == 0 && len(result.Errors) == 1 && errors.Is(result.Errors[0], fipe.ErrInvalidMonth),
+		"Sem coluna de mês e sem -month a linha é rejeitada")
+	result, err = fipe.Read(strings.NewReader(commas), fipe.FormatCSV, january)
+	check.That(err == nil && len(result.Records) == 1 && result.Records[0].Price == 55100 && result.Records[0].ReferenceMonth.Equal(january),
+		"Vírgula como separador e mês informado: %+v", result.Records)
+
+	fmt.Println("\n=== TESTE 3: Tabela em JSON ===")
+	result, err = fipe.Read(strings.NewReader(jsonTable), fipe.FormatFromPath("fipe.json"), time.Time{})
+	check.That(err == nil && len(result.Records) == 2 && len(result.Errors) == 0, "Lista no formato da API da FIPE: %d linhas (%v)", len(result.Records), err)
+	if len(result.Records) == 2 {
+		check.That(result.Records[0].ModelYear == 1992 && result.Records[1].ReferenceMonth.Month() == time.June, "Ano numérico e mês lidos")
+	}
+	lines := `{"codigo_fipe": "038001-1", "ano_modelo": "1992 Gasolina", "valor": 9800, "mes_referencia": "2021-04"}` + "\n" +
+		`{"codigo_fipe": "038001-1", "ano_modelo": "1992", "valor": "R$ 9.900,00", "mes_referencia": "2021-05"}`
+	result, err = fipe.Read(strings.NewReader(lines), fipe.FormatFromPath("fipe.jsonl"), time.Time{})
+	check.That(err == nil && len(result.Records) == 2 && result.Records[0].Price == 9800 && result.Records[0].ModelYear == 1992,
+		"Um objeto por linha: %+v", result.Records)
+	_, err = fipe.Read(strings.NewReader(lines), "xlsx", time.Time{})
+	check.That(errors.Is(err, fipe.ErrUnknownFormat), "Formato desconhecido rejeitado")
+
+	fmt.Println("\n=== TESTE 4: Carro ligado ao código FIPE ===")
+	car := (&models.CarInfo{Placa: "ABC1234", AnoModelo: "2019", CodigoFipe: "5340-6", ValorFipe: "R$ 41.234,00"}).ToCar()
+	check.That(car.FipeCode == "005340-6" && car.FipeValue == 41234, "Código normalizado e valor lido: %s %.2f", car.FipeCode, car.FipeValue)
+
+	fmt.Println("\n=== TESTE 5: Endpoints ===")
+	table, _ := fipe.Read(strings.NewReader(csvTable), fipe.FormatCSV, time.Time{})
+	repo := &fakeFipe{records: table.Records}
+	cars := &fakeCars{cars: map[string]*models.Car{
+		"ABC1234": car,
+		"XYZ9876": {LicensePlate: "XYZ9876"},
+	}}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := handlers.NewFipeHandler(repo, cars)
+	router.GET("/api/v1/fipe/:code", handler.GetVehicle)
+	router.GET("/api/v1/fipe/:code/prices", handler.GetPriceCurve)
+	router.GET("/api/v1/cars/fipe/:plate", handler.GetCarPriceCurve)
+
+	w := request(router, "/api/v1/fipe/5340-6")
+	check.That(w.Code == http.StatusOK && strings.Contains(w.Body.String(), "Gol 1.0"), "Código -> %d", w.Code)
+	w = request(router, "/api/v1/fipe/999999-9")
+	check.That(w.Code == http.StatusNotFound, "Código fora da tabela -> %d", w.Code)
+	w = request(router, "/api/v1/fipe/ABC/prices?model_year=2019")
+	check.That(w.Code == http.StatusBadRequest, "Código inválido -> %d", w.Code)
+	w = request(router, "/api/v1/fipe/005340-6/prices")
+	check.That(w.Code == http.StatusBadRequest, "Sem model_year -> %d", w.Code)
+	w = request(router, "/api/v1/fipe/005340-6/prices?model_year=2019&from=fevereiro")
+	check.That(w.Code == http.StatusBadRequest, "Mês inválido -> %d", w.Code)
+
+	var curve models.FipePriceCurve
+	w = request(router, "/api/v1/cars/fipe/ABC1234")
+	json.Unmarshal(w.Body.Bytes(), &curve)
+	check.That(w.Code == http.StatusOK && curve.LicensePlate == "ABC1234" && len(curve.Prices) == 2 &&
+		curve.Prices[0].ReferenceMonth == "2025-01" && curve.Prices[1].Price == 40980,
+		"Curva da placa -> %d %+v", w.Code, curve.Prices)
+	w = request(router, "/api/v1/cars/fipe/ABC1234?from=2025-02")
+	curve = models.FipePriceCurve{}
+	json.Unmarshal(w.Body.Bytes(), &curve)
+	check.That(w.Code == http.StatusOK && len(curve.Prices) == 1, "Curva a partir de fevereiro -> %d meses", len(curve.Prices))
+	w = request(router, "/api/v1/cars/fipe/XYZ9876")
+	check.That(w.Code == http.StatusNotFound, "Placa sem código FIPE -> %d", w.Code)
+	w = request(router, "/api/v1/cars/fipe/AAA0000")
+	check.That(w.Code == http.StatusNotFound, "Placa fora do cache -> %d", w.Code)
+
+	check.Finish()
+}
+
+func request(r http.Handler, url string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
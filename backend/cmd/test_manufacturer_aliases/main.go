@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/manufacturer"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/routes"
+	"partexplorer/backend/internal/vehiclemodel"
+)
+
+// fakeAliasRepository guarda os apelidos em memória com as mesmas regras do repositório
+type fakeAliasRepository struct {
+	database.ManufacturerAliasRepository
+	aliases      map[string]string
+	applications map[string]int64
+}
+
+func (r *fakeAliasRepository) List(manufacturerName string) ([]models.ManufacturerAlias, error) {
+	var list []models.ManufacturerAlias
+	for alias, canonical := range r.aliases {
+		if manufacturerName == "" || canonical == manufacturer.Normalize(manufacturerName) {
+			list = append(list, models.ManufacturerAlias{Alias: alias, Manufacturer: canonical})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Alias < list[j].Alias })
+	return list, nil
+}
+
+func (r *fakeAliasRepository) Get(alias string) (*models.ManufacturerAlias, error) {
+	canonical, ok := r.aliases[manufacturer.Normalize(alias)]
+	if !ok {
+		return nil, database.ErrManufacturerAliasNotFound
+	}
+	return &models.ManufacturerAlias{Alias: manufacturer.Normalize(alias), Manufacturer: canonical}, nil
+}
+
+func (r *fakeAliasRepository) Create(alias, manufacturerName string) (*models.ManufacturerAlias, error) {
+	alias, manufacturerName = manufacturer.Normalize(alias), manufacturer.Normalize(manufacturerName)
+	if _, ok := r.aliases[alias]; ok {
+		return nil, database.ErrManufacturerAliasExists
+	}
+	if target, ok := r.aliases[manufacturerName]; ok && target != manufacturerName {
+		return nil, database.ErrManufacturerAliasChain
+	}
+	r.aliases[manufacturerName] = manufacturerName
+	r.aliases[alias] = manufacturerName
+	return &models.ManufacturerAlias{Alias: alias, Manufacturer: manufacturerName}, nil
+}
+
+func (r *fakeAliasRepository) Delete(alias string) error {
+	alias = manufacturer.Normalize(alias)
+	canonical, ok := r.aliases[alias]
+	if !ok {
+		return database.ErrManufacturerAliasNotFound
+	}
+	if canonical == alias {
+		for other, target := range r.aliases {
+			if target == alias && other != alias {
+				return database.ErrManufacturerAliasInUse
+			}
+		}
+	}
+	delete(r.aliases, alias)
+	return nil
+}
+
+func (r *fakeAliasRepository) Dictionary() (*manufacturer.Dictionary, error) {
+	return manufacturer.NewDictionary(r.aliases), nil
+}
+
+func (r *fakeAliasRepository) Missing() ([]models.MissingManufacturerAlias, error) {
+	var missing []models.MissingManufacturerAlias
+	for name, count := range r.applications {
+		if _, ok := r.aliases[manufacturer.Normalize(name)]; !ok {
+			missing = append(missing, models.MissingManufacturerAlias{Manufacturer: name, Applications: count})
+		}
+	}
+	return missing, nil
+}
+
+func main() {
+	fmt.Println("🧪 Testando o dicionário de apelidos de fabricantes...")
+
+	fmt.Println("\n=== TESTE 1: Dicionário embutido ===")
+	dict := manufacturer.Default()
+	for _, tc := range []struct{ name, want string }{
+		{"VW", "VOLKSWAGEN"},
+		{"volkswagen", "VOLKSWAGEN"},
+		{"GM", "CHEVROLET"},
+		{"Chev", "CHEVROLET"},
+		{"  mercedes  ", "MERCEDES-BENZ"},
+		{"Citroën", "CITROEN"},
+		{"Caoa  Chery", "CHERY"},
+	} {
+		got := dict.Canonical(tc.name)
+		check.That(got == tc.want, "%q -> %s", tc.name, got)
+	}
+	_, ok := dict.Lookup("LIFAN")
+	check.That(!ok && dict.Canonical("lifan") == "LIFAN", "Fabricante desconhecido só é normalizado")
+
+	fmt.Println("\n=== TESTE 2: Dicionário carregado do banco ===")
+	repo := &fakeAliasRepository{
+		aliases:      map[string]string{"VOLKSWAGEN": "VOLKSWAGEN", "VW": "VOLKSWAGEN", "CHEVROLET": "CHEVROLET", "GM": "CHEVROLET"},
+		applications: map[string]int64{"VW": 10, "CHEV": 3, "Lifan": 2},
+	}
+	loaded, _ := repo.Dictionary()
+	manufacturer.SetDefault(loaded)
+	check.That(manufacturer.Default().Len() == 4, "SetDefault troca o dicionário em uso (%d apelidos)", manufacturer.Default().Len())
+	source := vehiclemodel.ParseSource("CHEV", "ONIX 1.0")
+	check.That(source.Manufacturer == "CHEV", "Sem o apelido CHEV a placa fica com a marca crua (%s)", source.Manufacturer)
+
+	fmt.Println("\n=== TESTE 3: Endpoints ===")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupManufacturerAliasRoutes(router.Group("/api/v1"), repo)
+
+	w := request(router, http.MethodGet, "/api/v1/manufacturer-aliases/missing", nil)
+	var report struct {
+		Manufacturers []models.MissingManufacturerAlias `json:"manufacturers"`
+		Total         int                               `json:"total"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &report)
+	check.That(w.Code == http.StatusOK && report.Total == 2, "Relatório de fabricantes sem apelido -> %d %+v", w.Code, report.Manufacturers)
+
+	w = request(router, http.MethodPost, "/api/v1/manufacturer-aliases/", map[string]string{"alias": "chev", "manufacturer": "Chevrolet"})
+	check.That(w.Code == http.StatusCreated, "Criação de CHEV -> %d", w.Code)
+	source = vehiclemodel.ParseSource("CHEV", "ONIX 1.0")
+	check.That(source.Manufacturer == "CHEVROLET", "A busca por placa usa o apelido novo sem reiniciar (%s)", source.Manufacturer)
+
+	w = request(router, http.MethodPost, "/api/v1/manufacturer-aliases/", map[string]string{"alias": "CHEV", "manufacturer": "CHEVROLET"})
+	check.That(w.Code == http.StatusConflict, "Apelido duplicado -> %d", w.Code)
+	w = request(router, http.MethodPost, "/api/v1/manufacturer-aliases/", map[string]string{"alias": "VOLKS", "manufacturer": "VW"})
+	check.That(w.Code == http.StatusConflict, "Apelido de outro apelido -> %d", w.Code)
+	w = request(router, http.MethodPost, "/api/v1/manufacturer-aliases/", map[string]string{"manufacturer": "LIFAN"})
+	check.That(w.Code == http.StatusBadRequest, "Sem apelido -> %d", w.Code)
+
+	w = request(router, http.MethodGet, "/api/v1/manufacturer-aliases/?manufacturer=chevrolet", nil)
+	var list struct {
+		Aliases []models.ManufacturerAlias `json:"aliases"`
+		Total   int                        `json:"total"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	check.That(w.Code == http.StatusOK && list.Total == 3, "Apelidos da CHEVROLET -> %d %+v", w.Code, list.Aliases)
+
+	w = request(router, http.MethodGet, "/api/v1/manufacturer-aliases/"+url.PathEscape("vw"), nil)
+	check.That(w.Code == http.StatusOK && bytes.Contains(w.Body.Bytes(), []byte(`"VOLKSWAGEN"`)), "Consulta de VW -> %d %s", w.Code, w.Body.String())
+	w = request(router, http.MethodDelete, "/api/v1/manufacturer-aliases/CHEVROLET", nil)
+	check.That(w.Code == http.StatusConflict, "Canônico com apelidos não pode ser removido -> %d", w.Code)
+	w = request(router, http.MethodDelete, "/api/v1/manufacturer-aliases/CHEV", nil)
+	check.That(w.Code == http.StatusOK, "Remoção de CHEV -> %d", w.Code)
+	check.That(vehiclemodel.ParseSource("CHEV", "ONIX").Manufacturer == "CHEV", "Remoção recarrega o dicionário")
+	w = request(router, http.MethodGet, "/api/v1/manufacturer-aliases/CHEV", nil)
+	check.That(w.Code == http.StatusNotFound, "Apelido removido -> %d", w.Code)
+
+	check.Finish()
+}
+
+func request(r http.Handler, method, url string, body interface{}) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&payload).Encode(body)
+	}
+	req := httptest.NewRequest(method, url, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
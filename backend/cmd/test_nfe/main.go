@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/nfe"
+	"partexplorer/backend/internal/routes"
+)
+
+//go:embed fixtures/*.xml
+var fixtures embed.FS
+
+// fakeNFe guarda as notas em memória e casa os itens pelo EAN ou pelo código, sem banco
+type fakeNFe struct {
+	database.NFeRepository
+	companies map[string]uuid.UUID // CNPJ -> empresa
+	catalog   map[string]uuid.UUID // EAN ou SKU -> part_name
+	invoices  map[string]*models.NFeInvoice
+	items     map[int64]*models.NFeItem
+	nextID    int64
+	// concurrent simula a importação concorrente que gravou a chave de acesso primeiro
+	concurrent bool
+}
+
+func (f *fakeNFe) Ingest(invoice *nfe.Invoice, actor string) (*models.NFeInvoice, error) {
+	if f.concurrent {
+		// O índice único recusa a nota; o repositório busca a que já foi gravada
+		err := fmt.Errorf("failed to create NF-e: %w", &pgconn.PgError{Code: "23505", ConstraintName: "idx_nfe_invoice_access_key"})
+		return database.NFeIngestConflict(nil, err, func() (*models.NFeInvoice, error) {
+			for _, record := range f.invoices {
+				if record.AccessKey == invoice.AccessKey {
+					return record, nil
+				}
+			}
+			return nil, database.ErrNFeNotFound
+		})
+	}
+	for _, record := range f.invoices {
+		if record.AccessKey == invoice.AccessKey {
+			return record, database.ErrNFeAlreadyImported
+		}
+	}
+
+	record := &models.NFeInvoice{ID: uuid.New(), AccessKey: invoice.AccessKey, Number: invoice.Number, Type: invoice.Type, Actor: actor}
+	sides := map[string]string{nfe.SideIssuer: invoice.Issuer.Document, nfe.SideRecipient: invoice.Recipient.Document}
+	found := false
+	for side, document := range sides {
+		companyID, ok := f.companies[document]
+		if !ok {
+			continue
+		}
+		found = true
+		for _, line := range invoice.Items {
+			f.nextID++
+			item := &models.NFeItem{ID: f.nextID, InvoiceID: record.ID, ItemNumber: line.Number, CompanyID: companyID,
+				MovementType: nfe.MovementType(invoice.Type, side), Code: line.Code, EAN: line.EAN, Quantity: line.Quantity, Status: models.NFeItemPosted}
+			partID, matched := f.catalog[line.EAN]
+			if !matched {
+				partID, matched = f.catalog[line.Code]
+			}
+			_, whole := nfe.WholeQuantity(line.Quantity)
+			switch {
+			case !matched:
+				item.Status, item.Reason = models.NFeItemPending, models.NFeReasonNotFound
+			case !whole:
+				item.Status, item.Reason = models.NFeItemPending, models.NFeReasonFractional
+			default:
+				item.PartNameID = &partID
+			}
+			if item.Status == models.NFeItemPosted {
+				record.Posted++
+			} else {
+				record.Pending++
+			}
+			f.items[item.ID] = item
+			record.Items = append(record.Items, *item)
+		}
+	}
+	if !found {
+		return nil, database.ErrNFeCompanyNotFound
+	}
+	f.invoices[record.ID.String()] = record
+	return record, nil
+}
+
+func (f *fakeNFe) ListQueue(companyID, reason string, page, pageSize int) (*models.NFeItemListResponse, error) {
+	response := &models.NFeItemListResponse{Items: []models.NFeItem{}, Page: page, PageSize: pageSize}
+	for id := int64(1); id <= f.nextID; id++ {
+		item := f.items[id]
+		if item.Status == models.NFeItemPending && (companyID == "" || item.CompanyID.String() == companyID) && (reason == "" || item.Reason == reason) {
+			response.Items = append(response.Items, *item)
+		}
+	}
+	response.Total = int64(len(response.Items))
+	return response, nil
+}
+
+func (f *fakeNFe) ResolveItem(id int64, req models.NFeResolveRequest) (*models.NFeItem, error) {
+	item, ok := f.items[id]
+	if !ok {
+		return nil, database.ErrNFeItemNotFound
+	}
+	if item.Status != models.NFeItemPending {
+		return nil, database.ErrNFeItemClosed
+	}
+	partID, err := uuid.Parse(req.PartNameID)
+	if err != nil || req.Quantity == nil || *req.Quantity <= 0 {
+		return nil, database.ErrInvalidStockMovement
+	}
+	item.Status, item.Reason, item.PartNameID, item.ResolvedBy = models.NFeItemPosted, "", &partID, req.Actor
+	return item, nil
+}
+
+func (f *fakeNFe) DiscardItem(id int64, actor string) (*models.NFeItem, error) {
+	item, ok := f.items[id]
+	if !ok {
+		return nil, database.ErrNFeItemNotFound
+	}
+	if item.Status != models.NFeItemPending {
+		return nil, database.ErrNFeItemClosed
+	}
+	item.Status, item.ResolvedBy = models.NFeItemDiscarded, actor
+	return item, nil
+}
+
+func main() {
+	fmt.Println("🧪 Testando a importação de NF-e...")
+
+	fmt.Println("\n=== TESTE 1: NF-e de venda 4.00 (nfeProc) ===")
+	sale, err := nfe.Parse(bytes.NewReader(fixture("venda_4_00.xml")))
+	check.That(err == nil, "Nota com protocolo lida: %v", err)
+	if err == nil {
+		check.That(sale.AccessKey == "35250111222333000181550010000012341123456786" && sale.Number == "1234" && sale.Series == "1" && sale.Status == "100",
+			"Chave, número, série e cStat: %s %s/%s %s", sale.AccessKey, sale.Number, sale.Series, sale.Status)
+		check.That(sale.Type == nfe.TypeOutbound && sale.IssuedAt.Format("2006-01-02 15:04") == "2025-01-15 10:30",
+			"Nota de saída com dhEmi: %s", sale.IssuedAt)
+		check.That(sale.Issuer.Document == "11222333000181" && sale.Recipient.Document == "12345678909" && sale.Recipient.Name == "JOSE DA SILVA",
+			"Emitente CNPJ e destinatário CPF: %+v / %+v", sale.Issuer, sale.Recipient)
+		check.That(len(sale.Items) == 3, "Três itens: %d", len(sale.Items))
+		if len(sale.Items) == 3 {
+			first := sale.Items[0]
+			check.That(first.Number == 1 && first.Code == "KL-1234" && first.EAN == "7891234567895" && first.Quantity == 2 && first.UnitPrice == 39.9,
+				"Item 1: cProd, cEAN, qCom e vUnCom: %+v", first)
+			check.That(sale.Items[1].Code == "FX 99" && sale.Items[1].EAN == "", "Item 2: \"SEM GTIN\" vira EAN vazio")
+			check.That(sale.Items[2].Unit == "M" && sale.Items[2].Quantity == 2.5, "Item 3: quantidade fracionária em metros: %v %s", sale.Items[2].Quantity, sale.Items[2].Unit)
+		}
+	}
+
+	fmt.Println("\n=== TESTE 2: NF-e de compra 3.10 (NFe sem protocolo) ===")
+	purchase, err := nfe.Parse(bytes.NewReader(fixture("compra_3_10.xml")))
+	check.That(err == nil, "Nota sem protocolo lida: %v", err)
+	if err == nil {
+		check.That(purchase.Status == "" && purchase.Number == "98765" && purchase.Series == "2" && purchase.IssuedAt.Format("2006-01-02") == "2024-12-10",
+			"Sem cStat e data pelo dEmi: %s", purchase.IssuedAt)
+		check.That(purchase.Issuer.Document == "33445566000186" && purchase.Recipient.Document == "11222333000181",
+			"CNPJ formatado do emitente só com dígitos: %s", purchase.Issuer.Document)
+		check.That(len(purchase.Items) == 1 && purchase.Items[0].EAN == "7891234567895" && purchase.Items[0].Code == "000451",
+			"cEAN vazio usa o cEANTrib: %+v", purchase.Items)
+	}
+
+	fmt.Println("\n=== TESTE 3: Notas rejeitadas ===")
+	_, err = nfe.Parse(bytes.NewReader(fixture("denegada.xml")))
+	check.That(errors.Is(err, nfe.ErrNotAuthorized), "Uso denegado (cStat 110): %v", err)
+	_, err = nfe.Parse(bytes.NewReader(fixture("sem_itens.xml")))
+	check.That(errors.Is(err, nfe.ErrInvalidNFe), "Nota sem itens: %v", err)
+	_, err = nfe.Parse(strings.NewReader("<nota><numero>1</numero></nota>"))
+	check.That(errors.Is(err, nfe.ErrInvalidNFe), "XML que não é NF-e: %v", err)
+	_, err = nfe.Parse(strings.NewReader("não é xml"))
+	check.That(errors.Is(err, nfe.ErrInvalidNFe), "Conteúdo que não é XML: %v", err)
+	broken := strings.Replace(string(fixture("venda_4_00.xml")), "NFe3525", "NFe25", 1)
+	_, err = nfe.Parse(strings.NewReader(broken))
+	check.That(errors.Is(err, nfe.ErrInvalidNFe), "Chave de acesso com menos de 44 dígitos: %v", err)
+
+	fmt.Println("\n=== TESTE 4: Tipo de movimentação, quantidades e CNPJ ===")
+	for _, tc := range []struct {
+		invoiceType int
+		side, want  string
+	}{
+		{nfe.TypeOutbound, nfe.SideIssuer, models.StockMovementSale},
+		{nfe.TypeOutbound, nfe.SideRecipient, models.StockMovementReceipt},
+		{nfe.TypeInbound, nfe.SideIssuer, models.StockMovementReceipt},
+		{nfe.TypeInbound, nfe.SideRecipient, models.StockMovementSale},
+	} {
+		got := nfe.MovementType(tc.invoiceType, tc.side)
+		check.That(got == tc.want, "tpNF %d, %s -> %s", tc.invoiceType, tc.side, got)
+	}
+	for quantity, want := range map[float64]int{10: 10, 1: 1, 2.5: 0, 0: 0, -3: 0} {
+		got, ok := nfe.WholeQuantity(quantity)
+		check.That(got == want && ok == (want > 0), "Quantidade %v -> %d (%v)", quantity, got, ok)
+	}
+	for cnpj, want := range map[string]bool{"11222333000181": true, "33445566000186": true, "11222333000182": false, "00000000000000": false, "1122233300018": false} {
+		check.That(nfe.ValidCNPJ(cnpj) == want, "CNPJ %s válido: %v", cnpj, want)
+	}
+	check.That(nfe.NormalizeDocument("11.222.333/0001-81") == "11222333000181", "CNPJ formatado normalizado")
+
+	fmt.Println("\n=== TESTE 5: Endpoints ===")
+	shop, distributor := uuid.New(), uuid.New()
+	filter := uuid.New()
+	repo := &fakeNFe{
+		companies: map[string]uuid.UUID{"11222333000181": shop, "33445566000186": distributor},
+		catalog:   map[string]uuid.UUID{"7891234567895": filter, "CABO-10": uuid.New()},
+		invoices:  map[string]*models.NFeInvoice{},
+		items:     map[int64]*models.NFeItem{},
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupNFeRoutes(router.Group("/api/v1"), repo)
+
+	w := post(router, "/api/v1/nfe/", "application/xml", fixture("venda_4_00.xml"), "maria")
+	var record models.NFeInvoice
+	json.Unmarshal(w.Body.Bytes(), &record)
+	check.That(w.Code == http.StatusCreated && record.Actor == "maria" && record.Posted == 1 && record.Pending == 2,
+		"Venda importada com responsável do X-User: 1 lançado, 2 na fila (sem cadastro e fracionário) -> %d %+v", w.Code, record)
+	if len(record.Items) == 3 {
+		check.That(record.Items[0].MovementType == models.StockMovementSale && record.Items[0].CompanyID == shop, "Venda baixa o estoque do emitente")
+	}
+
+	w = post(router, "/api/v1/nfe/", "application/xml", fixture("venda_4_00.xml"), "maria")
+	check.That(w.Code == http.StatusConflict && strings.Contains(w.Body.String(), record.ID.String()), "Mesma nota de novo -> %d com o invoice_id", w.Code)
+	repo.concurrent = true
+	w = post(router, "/api/v1/nfe/", "application/xml", fixture("venda_4_00.xml"), "maria")
+	check.That(w.Code == http.StatusConflict && strings.Contains(w.Body.String(), record.ID.String()),
+		"Importação concorrente da mesma nota (índice único) -> %d com o invoice_id da nota gravada", w.Code)
+	repo.concurrent = false
+
+	body, contentType := multipartXML("compra_3_10.xml", fixture("compra_3_10.xml"), "joao")
+	w = post(router, "/api/v1/nfe/", contentType, body, "")
+	json.Unmarshal(w.Body.Bytes(), &record)
+	check.That(w.Code == http.StatusCreated && record.Actor == "joao" && len(record.Items) == 2 && record.Posted == 2,
+		"Compra entre duas empresas cadastradas por multipart: um item para cada -> %d %+v", w.Code, record)
+	for _, item := range record.Items {
+		want := models.StockMovementReceipt
+		if item.CompanyID == distributor {
+			want = models.StockMovementSale
+		}
+		check.That(item.MovementType == want, "Empresa %s: %s", item.CompanyID, item.MovementType)
+	}
+
+	w = post(router, "/api/v1/nfe/", "application/xml", fixture("denegada.xml"), "maria")
+	check.That(w.Code == http.StatusBadRequest, "Nota denegada -> %d", w.Code)
+	w = post(router, "/api/v1/nfe/", "application/xml", []byte("<nota/>"), "maria")
+	check.That(w.Code == http.StatusBadRequest, "XML inválido -> %d", w.Code)
+	unknown := strings.ReplaceAll(string(fixture("compra_3_10.xml")), "11222333000181", "11444777000161")
+	unknown = strings.ReplaceAll(unknown, "33.445.566/0001-86", "11444777000161")
+	unknown = strings.Replace(unknown, "NFe4124", "NFe4125", 1)
+	w = post(router, "/api/v1/nfe/", "application/xml", []byte(unknown), "maria")
+	check.That(w.Code == http.StatusNotFound, "Nenhuma empresa cadastrada com os CNPJ da nota -> %d", w.Code)
+
+	w = request(router, http.MethodGet, "/api/v1/nfe/queue?company_id="+shop.String(), "")
+	var queue models.NFeItemListResponse
+	json.Unmarshal(w.Body.Bytes(), &queue)
+	check.That(w.Code == http.StatusOK && queue.Total == 2, "Fila de conciliação da loja -> %d (%d item(ns))", w.Code, queue.Total)
+	w = request(router, http.MethodGet, "/api/v1/nfe/queue?reason="+models.NFeReasonFractional, "")
+	json.Unmarshal(w.Body.Bytes(), &queue)
+	check.That(queue.Total == 1 && queue.Items[0].Code == "CABO-10", "Filtro por motivo: %d item(ns)", queue.Total)
+
+	if queue.Total == 1 {
+		itemURL := fmt.Sprintf("/api/v1/nfe/queue/%d", queue.Items[0].ID)
+		w = request(router, http.MethodPost, itemURL+"/resolve", fmt.Sprintf(`{"part_name_id": %q, "quantity": 3}`, uuid.NewString()))
+		var item models.NFeItem
+		json.Unmarshal(w.Body.Bytes(), &item)
+		check.That(w.Code == http.StatusOK && item.Status == models.NFeItemPosted && item.ResolvedBy == "ana", "Item fracionário lançado na revisão -> %d %+v", w.Code, item)
+		w = request(router, http.MethodPost, itemURL+"/discard", "")
+		check.That(w.Code == http.StatusConflict, "Item já resolvido não sai da fila de novo -> %d", w.Code)
+	}
+	w = request(router, http.MethodPost, "/api/v1/nfe/queue/2/resolve", `{"quantity": 1}`)
+	check.That(w.Code == http.StatusBadRequest, "Revisão sem peça -> %d", w.Code)
+	w = request(router, http.MethodPost, "/api/v1/nfe/queue/2/discard", "")
+	check.That(w.Code == http.StatusOK && repo.items[2].Status == models.NFeItemDiscarded, "Item descartado -> %d", w.Code)
+	w = request(router, http.MethodPost, "/api/v1/nfe/queue/999/discard", "")
+	check.That(w.Code == http.StatusNotFound, "Item inexistente -> %d", w.Code)
+	w = request(router, http.MethodPost, "/api/v1/nfe/queue/abc/resolve", "")
+	check.That(w.Code == http.StatusBadRequest, "ID de item inválido -> %d", w.Code)
+
+	check.Finish()
+}
+
+func fixture(name string) []byte {
+	data, err := fixtures.ReadFile("fixtures/" + name)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func multipartXML(fileName string, content []byte, actor string) ([]byte, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", fileName)
+	part.Write(content)
+	writer.WriteField("actor", actor)
+	writer.Close()
+	return body.Bytes(), writer.FormDataContentType()
+}
+
+func post(r http.Handler, url, contentType string, body []byte, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func request(r http.Handler, method, url, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", "ana")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
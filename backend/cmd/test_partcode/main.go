@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/partcode"
+)
+
+func main() {
+	fmt.Println("🧪 Testando normalização de SKU/EAN...")
+
+	fmt.Println("\n=== TESTE 1: SKUs equivalentes ===")
+	for _, sku := range []string{"KL-1234", "KL1234", "kl 1234", " kl.1234 "} {
+		check.That(partcode.Normalize(sku) == "KL1234", "%q -> %s", sku, partcode.Normalize(sku))
+	}
+
+	fmt.Println("\n=== TESTE 2: EAN com e sem zero à esquerda ===")
+	check.That(partcode.NormalizeGTIN("7891234567895") == partcode.NormalizeGTIN("07891234567895"), "EAN-13 e GTIN-14 iguais: %s", partcode.NormalizeGTIN("7891234567895"))
+	check.That(partcode.NormalizeGTIN("789-1234-567895") == "07891234567895", "Separadores ignorados")
+	check.That(partcode.NormalizeByType("Descrição", "desc") == "", "Descrições não têm código normalizado")
+
+	fmt.Println("\n=== TESTE 3: Dígito verificador ===")
+	for code, format := range map[string]string{
+		"7891234567895":  "EAN-13",
+		"07891234567895": "GTIN-14",
+		"036000291452":   "UPC-A",
+		"96385074":       "EAN-8",
+	} {
+		got, err := partcode.ValidateGTIN(code)
+		check.That(err == nil && got == format, "%s válido (%s, err=%v)", code, got, err)
+	}
+	_, err := partcode.ValidateGTIN("7891234567890")
+	check.That(err != nil, "Dígito inválido rejeitado: %v", err)
+	_, err = partcode.ValidateGTIN("KL1234")
+	check.That(err != nil, "Código não numérico rejeitado: %v", err)
+
+	fmt.Println("\n=== TESTE 4: Candidatos de busca ===")
+	check.That(fmt.Sprint(partcode.Candidates("kl-1234")) == "[KL1234]", "SKU: %v", partcode.Candidates("kl-1234"))
+	check.That(fmt.Sprint(partcode.Candidates("7891234567895")) == "[7891234567895 07891234567895]", "EAN: %v", partcode.Candidates("7891234567895"))
+	check.That(len(partcode.Candidates(" - ")) == 0, "Código vazio sem candidatos")
+
+	check.Finish()
+}
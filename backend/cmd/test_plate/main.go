@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/handlers"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/plate"
+	"partexplorer/backend/internal/vehicledata"
+)
+
+// slowResolver demora para responder, para que pedidos simultâneos se encontrem no mesmo job
+type slowResolver struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *slowResolver) SearchCarByPlate(value string) (*models.CarInfo, error) {
+	r.mu.Lock()
+	r.calls = append(r.calls, value)
+	r.mu.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	return &models.CarInfo{Placa: value, Marca: "VW", Modelo: "GOL", Ano: "2012"}, nil
+}
+
+func main() {
+	fmt.Println("🧪 Testando placas no padrão antigo e Mercosul...")
+
+	fmt.Println("\n=== TESTE 1: Validação e normalização ===")
+	for input, want := range map[string]plate.Plate{
+		"ABC1234":   {Canonical: "ABC1234", Format: plate.FormatLegacy},
+		"abc-1234":  {Canonical: "ABC1234", Format: plate.FormatLegacy},
+		" abc 1234": {Canonical: "ABC1234", Format: plate.FormatLegacy},
+		"ABC1C34":   {Canonical: "ABC1C34", Format: plate.FormatMercosul},
+		"abc1c34":   {Canonical: "ABC1C34", Format: plate.FormatMercosul},
+		"BRA2E19":   {Canonical: "BRA2E19", Format: plate.FormatMercosul},
+	} {
+		got, err := plate.Parse(input)
+		check.That(err == nil && got == want, "%q -> %s (%s)", input, got.Canonical, got.Format)
+	}
+	for _, input := range []string{"", "ABC123", "ABC12345", "AB12345", "1BC1234", "ABC1D2E", "ABCD123", "ÁBC1234"} {
+		_, err := plate.Parse(input)
+		check.That(err == plate.ErrInvalid, "%q rejeitada", input)
+	}
+	check.That(plate.Valid("ABC-1234") && !plate.Valid("ABC-12"), "Valid")
+
+	fmt.Println("\n=== TESTE 2: Conversão antigo <-> Mercosul ===")
+	for legacy, mercosul := range map[string]string{
+		"ABC1234": "ABC1C34",
+		"XYZ9087": "XYZ9A87",
+		"KLM5990": "KLM5J90",
+	} {
+		parsedLegacy, _ := plate.Parse(legacy)
+		parsedMercosul, _ := plate.Parse(mercosul)
+		check.That(parsedLegacy.Alternate() == mercosul, "%s -> %s", legacy, parsedLegacy.Alternate())
+		check.That(parsedMercosul.Alternate() == legacy, "%s -> %s", mercosul, parsedMercosul.Alternate())
+		check.That(parsedLegacy.Key() == parsedMercosul.Key(), "Mesma chave para %s e %s (%s)", legacy, mercosul, parsedLegacy.Key())
+	}
+	noLegacy, _ := plate.Parse("RIO2K18")
+	check.That(noLegacy.Alternate() == "" && len(noLegacy.Forms()) == 1, "Mercosul com 5ª letra K não tem forma antiga")
+	parsed, _ := plate.Parse("abc1234")
+	check.That(fmt.Sprint(parsed.Forms()) == "[ABC1234 ABC1C34]", "Formas para o cache: %v", parsed.Forms())
+	check.That(parsed.String() == "ABC-1234", "Exibição antiga: %s", parsed)
+	parsed, _ = plate.Parse("abc1c34")
+	check.That(parsed.String() == "ABC1C34", "Exibição Mercosul: %s", parsed)
+
+	fmt.Println("\n=== TESTE 3: Jobs compartilhados entre as duas formas ===")
+	resolver := &slowResolver{}
+	manager := vehicledata.NewLookupManager(resolver)
+	first, _ := manager.Submit("ABC-1234")
+	second, coalesced := manager.Submit("abc1c34")
+	check.That(coalesced && first.ID == second.ID, "ABC-1234 e ABC1C34 usam o mesmo job")
+	other, coalesced := manager.Submit("ABC1D34")
+	check.That(!coalesced && other.ID != first.ID, "ABC1D34 (outra placa) tem seu próprio job")
+	for _, id := range []string{first.ID, other.ID} {
+		manager.Wait(context.Background(), id)
+	}
+	check.That(len(resolver.calls) == 2, "2 consultas para 3 pedidos: %v", resolver.calls)
+
+	fmt.Println("\n=== TESTE 4: Validação nos endpoints ===")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	carHandler := handlers.NewCarHandler(nil, manager)
+	r.GET("/api/v1/cars/search/:plate", carHandler.SearchCarByPlate)
+	r.POST("/api/v1/cars/lookup", carHandler.StartLookup)
+	r.GET("/api/v1/plate-search/:plate", handlers.NewPlateSearchHandler(nil, manager, nil).SearchByPlate)
+
+	for _, path := range []string{"/api/v1/cars/search/ABC12X4", "/api/v1/plate-search/1234ABC"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		check.That(w.Code == http.StatusBadRequest && strings.Contains(w.Body.String(), "Mercosul"), "%s -> %d", path, w.Code)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/cars/lookup", strings.NewReader(`{"plate": "abc-1c34"}`)))
+	check.That(w.Code == http.StatusAccepted && strings.Contains(w.Body.String(), `"plate":"ABC1C34"`), "Mercosul aceito no lookup -> %d", w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/cars/search/abc1c34", nil))
+	check.That(w.Code == http.StatusOK && strings.Contains(w.Body.String(), `"alternate_plate":"ABC1234"`), "Resposta informa a forma antiga -> %d", w.Code)
+
+	check.Finish()
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/handlers"
+	"partexplorer/backend/internal/metrics"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/vehicledata"
+)
+
+// fakeProvider responde conforme o modo atual e conta as chamadas
+type fakeProvider struct {
+	name  string
+	calls int64
+
+	mu    sync.Mutex
+	mode  string // "ok", "fail", "not_found" ou "slow"
+	fails int    // com mode "ok", quantas falhas antes de responder
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) setMode(mode string, fails int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mode = mode
+	p.fails = fails
+}
+
+func (p *fakeProvider) Lookup(ctx context.Context, plate string) (*models.CarInfo, error) {
+	atomic.AddInt64(&p.calls, 1)
+
+	p.mu.Lock()
+	mode := p.mode
+	if mode == "ok" && p.fails > 0 {
+		p.fails--
+		mode = "fail"
+	}
+	p.mu.Unlock()
+
+	switch mode {
+	case "fail":
+		return nil, errors.New("blocked with status 403")
+	case "not_found":
+		return nil, vehicledata.ErrNotFound
+	case "slow":
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	return &models.CarInfo{Placa: plate, Marca: "FIAT", Modelo: "UNO", Ano: "2010"}, nil
+}
+
+func (p *fakeProvider) resetCalls() int64 {
+	return atomic.SwapInt64(&p.calls, 0)
+}
+
+// state retorna o estado do breaker do provedor protegido
+func state(guarded *vehicledata.GuardedProvider) string {
+	return guarded.Status().State
+}
+
+// gauge lê o valor do gauge de estado do circuito do provedor
+func gauge(name string) float64 {
+	return testutil.ToFloat64(metrics.PlateProviderCircuitState.WithLabelValues(name))
+}
+
+func main() {
+	fmt.Println("🧪 Testando circuit breaker, limite de taxa e retentativas dos provedores de placa...")
+	ctx := context.Background()
+
+	cfg := vehicledata.GuardConfig{
+		FailureThreshold: 3,
+		OpenTimeout:      150 * time.Millisecond,
+		Retries:          0,
+	}
+
+	fmt.Println("\n=== TESTE 1: Fechado -> aberto ===")
+	fake := &fakeProvider{name: "fake", mode: "fail"}
+	guarded := vehicledata.NewGuardedProvider(fake, cfg)
+	check.That(state(guarded) == vehicledata.CircuitClosed && gauge("fake") == 0, "Começa fechado (gauge=%v)", gauge("fake"))
+
+	for i := 0; i < 2; i++ {
+		guarded.Lookup(ctx, "ABC1234")
+	}
+	check.That(state(guarded) == vehicledata.CircuitClosed, "Ainda fechado após 2 falhas (%d seguidas)", guarded.Status().ConsecutiveFailures)
+	guarded.Lookup(ctx, "ABC1234")
+	check.That(state(guarded) == vehicledata.CircuitOpen && gauge("fake") == 2, "Aberto após 3 falhas seguidas (gauge=%v)", gauge("fake"))
+	check.That(guarded.Status().OpenUntil != nil, "Status informa até quando fica aberto")
+
+	fake.resetCalls()
+	start := time.Now()
+	_, err := guarded.Lookup(ctx, "ABC1234")
+	check.That(errors.Is(err, vehicledata.ErrCircuitOpen) && time.Since(start) < 10*time.Millisecond, "Aberto rejeita na hora (err=%v)", err)
+	check.That(fake.resetCalls() == 0, "Provedor não é chamado com o circuito aberto")
+
+	fmt.Println("\n=== TESTE 2: Aberto -> meio-aberto -> aberto ===")
+	time.Sleep(cfg.OpenTimeout)
+
+	// A tentativa do meio-aberto é lenta para que uma segunda consulta chegue durante ela
+	slowFail := &fakeProvider{name: "fake-probe", mode: "fail"}
+	probeGuard := vehicledata.NewGuardedProvider(slowFail, cfg)
+	for i := 0; i < 3; i++ {
+		probeGuard.Lookup(ctx, "ABC1234")
+	}
+	check.That(state(probeGuard) == vehicledata.CircuitOpen, "Segundo provedor aberto")
+	time.Sleep(cfg.OpenTimeout)
+	slowFail.setMode("slow", 0)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var probeErr error
+	go func() {
+		defer wg.Done()
+		probeCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, probeErr = probeGuard.Lookup(probeCtx, "ABC1234")
+	}()
+	time.Sleep(10 * time.Millisecond)
+	check.That(state(probeGuard) == vehicledata.CircuitHalfOpen && gauge("fake-probe") == 1, "Meio-aberto durante a tentativa (gauge=%v)", gauge("fake-probe"))
+	_, err = probeGuard.Lookup(ctx, "ABC1234")
+	check.That(errors.Is(err, vehicledata.ErrCircuitOpen), "Só uma tentativa passa no meio-aberto (err=%v)", err)
+	wg.Wait()
+	check.That(errors.Is(probeErr, context.DeadlineExceeded), "Tentativa estourou o timeout (err=%v)", probeErr)
+	check.That(state(probeGuard) == vehicledata.CircuitOpen && gauge("fake-probe") == 2, "Timeout no meio-aberto reabre o circuito")
+
+	_, err = guarded.Lookup(ctx, "ABC1234")
+	check.That(err != nil && !errors.Is(err, vehicledata.ErrCircuitOpen) && state(guarded) == vehicledata.CircuitOpen,
+		"Falha no meio-aberto reabre o circuito (err=%v)", err)
+
+	fmt.Println("\n=== TESTE 3: Meio-aberto -> fechado ===")
+	time.Sleep(cfg.OpenTimeout)
+	fake.setMode("ok", 0)
+	info, err := guarded.Lookup(ctx, "ABC1234")
+	check.That(err == nil && info.Marca == "FIAT", "Tentativa do meio-aberto respondeu (err=%v)", err)
+	check.That(state(guarded) == vehicledata.CircuitClosed && gauge("fake") == 0, "Circuito fechado de novo (gauge=%v)", gauge("fake"))
+	check.That(guarded.Status().ConsecutiveFailures == 0, "Falhas zeradas")
+
+	fake.setMode("not_found", 0)
+	for i := 0; i < 5; i++ {
+		guarded.Lookup(ctx, "ABC1234")
+	}
+	check.That(state(guarded) == vehicledata.CircuitClosed, "Placa não encontrada não conta como falha")
+
+	fmt.Println("\n=== TESTE 4: Retentativas com intervalo aleatório ===")
+	retrying := &fakeProvider{name: "fake-retry", mode: "ok", fails: 2}
+	retryGuard := vehicledata.NewGuardedProvider(retrying, vehicledata.GuardConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      time.Minute,
+		Retries:          2,
+		RetryBackoff:     20 * time.Millisecond,
+	})
+	start = time.Now()
+	info, err = retryGuard.Lookup(ctx, "ABC1234")
+	check.That(err == nil && info != nil, "Respondeu na terceira tentativa (err=%v)", err)
+	check.That(retrying.resetCalls() == 3, "3 chamadas ao provedor")
+	check.That(time.Since(start) < 20*time.Millisecond+40*time.Millisecond+50*time.Millisecond, "Intervalos limitados por 20ms e 40ms (%v)", time.Since(start))
+
+	retrying.setMode("not_found", 0)
+	retryGuard.Lookup(ctx, "ABC1234")
+	check.That(retrying.resetCalls() == 1, "ErrNotFound não é repetido")
+
+	retrying.setMode("fail", 0)
+	_, err = retryGuard.Lookup(ctx, "ABC1234")
+	check.That(err != nil && retrying.resetCalls() == 3, "Falha persistente: 1 tentativa + 2 retentativas (err=%v)", err)
+
+	fmt.Println("\n=== TESTE 5: Limite de taxa ===")
+	limited := &fakeProvider{name: "fake-rate", mode: "ok"}
+	limitGuard := vehicledata.NewGuardedProvider(limited, vehicledata.GuardConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      time.Minute,
+		RateLimit:        20,
+		RateBurst:        2,
+	})
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		limitGuard.Lookup(ctx, "ABC1234")
+	}
+	elapsed := time.Since(start)
+	check.That(elapsed >= 90*time.Millisecond, "Rajada de 2 e depois 20/s: 4 consultas em %v", elapsed)
+	shortCtx, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	_, err = limitGuard.Lookup(shortCtx, "ABC1234")
+	cancel()
+	check.That(errors.Is(err, context.DeadlineExceeded), "Sem token antes do timeout: %v", err)
+	check.That(state(limitGuard) == vehicledata.CircuitClosed, "Espera pelo limite não conta como falha")
+
+	fmt.Println("\n=== TESTE 6: Cadeia e health ===")
+	open := &fakeProvider{name: "fake-open", mode: "fail"}
+	openGuard := vehicledata.NewGuardedProvider(open, vehicledata.GuardConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+	openGuard.Lookup(ctx, "ABC1234")
+	backup := &fakeProvider{name: "fake-backup", mode: "ok"}
+	chain := vehicledata.NewChain([]vehicledata.PlateProvider{openGuard, vehicledata.NewGuardedProvider(backup, cfg)}, nil)
+	start = time.Now()
+	info, err = chain.Lookup(ctx, "ABC1234")
+	check.That(err == nil && info.Provedor == "fake-backup" && time.Since(start) < 50*time.Millisecond, "Provedor aberto é pulado sem esperar (err=%v)", err)
+	statuses := chain.Status()
+	check.That(len(statuses) == 2 && statuses[0].State == vehicledata.CircuitOpen && statuses[1].State == vehicledata.CircuitClosed,
+		"Status da cadeia: %s=%s, %s=%s", statuses[0].Name, statuses[0].State, statuses[1].Name, statuses[1].State)
+
+	fixtures := filepath.Join(os.TempDir(), "partexplorer_plates.json")
+	os.WriteFile(fixtures, []byte(`{"ABC1234": {"marca": "FIAT", "modelo": "UNO", "ano": "2010"}}`), 0o644)
+	defer os.Remove(fixtures)
+	os.Setenv("PLATE_PROVIDERS", "fixture")
+	os.Setenv("PLATE_FIXTURE_FILE", fixtures)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/v1/cars/health", handlers.NewCarHandler(database.NewCarRepository(nil), nil).HealthCheck)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/cars/health", nil))
+	var health struct {
+		Status    string                       `json:"status"`
+		Providers []vehicledata.ProviderStatus `json:"providers"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &health)
+	check.That(w.Code == http.StatusOK && health.Status == "ok", "Health -> %d %s", w.Code, health.Status)
+	check.That(len(health.Providers) == 1 && health.Providers[0].Name == "fixture" && health.Providers[0].State == vehicledata.CircuitClosed && health.Providers[0].Timeout == "30s",
+		"Health expõe os provedores: %+v", health.Providers)
+
+	check.Finish()
+}
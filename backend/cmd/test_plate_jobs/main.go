@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/handlers"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/vehicledata"
+)
+
+// fakeResolver simula o CarRepository: demora delay, conta as chamadas e a concorrência máxima
+type fakeResolver struct {
+	delay time.Duration
+
+	calls      int64
+	running    int64
+	maxRunning int64
+
+	mu        sync.Mutex
+	callsFor  map[string]int
+	responses map[string]func() (*models.CarInfo, error)
+}
+
+func newFakeResolver(delay time.Duration) *fakeResolver {
+	return &fakeResolver{
+		delay:     delay,
+		callsFor:  make(map[string]int),
+		responses: make(map[string]func() (*models.CarInfo, error)),
+	}
+}
+
+func (r *fakeResolver) SearchCarByPlate(plate string) (*models.CarInfo, error) {
+	atomic.AddInt64(&r.calls, 1)
+	current := atomic.AddInt64(&r.running, 1)
+	defer atomic.AddInt64(&r.running, -1)
+	for {
+		max := atomic.LoadInt64(&r.maxRunning)
+		if current <= max || atomic.CompareAndSwapInt64(&r.maxRunning, max, current) {
+			break
+		}
+	}
+
+	r.mu.Lock()
+	r.callsFor[plate]++
+	response := r.responses[plate]
+	r.mu.Unlock()
+
+	time.Sleep(r.delay)
+	if response != nil {
+		return response()
+	}
+	return &models.CarInfo{Placa: plate, Marca: "RENAULT", Modelo: "CLIO", Ano: "2006", Provedor: "fake"}, nil
+}
+
+func main() {
+	fmt.Println("🧪 Testando jobs de consulta de placa...")
+	os.Setenv("PLATE_LOOKUP_WORKERS", "2")
+
+	fmt.Println("\n=== TESTE 1: Pedidos simultâneos da mesma placa ===")
+	resolver := newFakeResolver(200 * time.Millisecond)
+	manager := vehicledata.NewLookupManager(resolver)
+
+	var wg sync.WaitGroup
+	ids := make([]string, 10)
+	coalescedCount := int64(0)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			job, coalesced := manager.Submit("abc-1234")
+			ids[i] = job.ID
+			if coalesced {
+				atomic.AddInt64(&coalescedCount, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	sameJob := true
+	for _, id := range ids {
+		sameJob = sameJob && id == ids[0]
+	}
+	check.That(sameJob, "10 pedidos compartilham o job %s", ids[0])
+	check.That(coalescedCount == 9, "9 pedidos reaproveitaram o job em andamento (%d)", coalescedCount)
+
+	job, err := manager.Wait(context.Background(), ids[0])
+	check.That(err == nil && job.Status == vehicledata.LookupStatusCompleted, "Job concluído (status=%s, err=%v)", job.Status, err)
+	check.That(job.CarInfo != nil && job.CarInfo.Marca == "RENAULT", "Resultado disponível no job")
+	check.That(job.Requests == 10, "Job registra os 10 pedidos (%d)", job.Requests)
+	check.That(resolver.callsFor["ABC1234"] == 1, "Placa consultada uma única vez (%d)", resolver.callsFor["ABC1234"])
+
+	next, coalesced := manager.Submit("ABC1234")
+	check.That(!coalesced && next.ID != ids[0], "Depois de terminado, um novo pedido cria outro job")
+	manager.Wait(context.Background(), next.ID)
+
+	fmt.Println("\n=== TESTE 2: Limite de workers ===")
+	resolver = newFakeResolver(100 * time.Millisecond)
+	manager = vehicledata.NewLookupManager(resolver)
+	var jobs []string
+	for i := 0; i < 6; i++ {
+		job, _ := manager.Submit(fmt.Sprintf("AAA%04d", i))
+		jobs = append(jobs, job.ID)
+	}
+	queued := 0
+	time.Sleep(20 * time.Millisecond)
+	for _, id := range jobs {
+		if job, _ := manager.Get(id); job.Status == vehicledata.LookupStatusQueued {
+			queued++
+		}
+	}
+	check.That(queued == 4, "Com 2 workers, 4 de 6 jobs aguardam na fila (%d)", queued)
+	for _, id := range jobs {
+		manager.Wait(context.Background(), id)
+	}
+	check.That(resolver.maxRunning == 2, "No máximo 2 consultas simultâneas (%d)", resolver.maxRunning)
+	check.That(resolver.calls == 6, "6 placas diferentes, 6 consultas (%d)", resolver.calls)
+	check.That(len(manager.List()) == 6, "List retorna os 6 jobs")
+
+	fmt.Println("\n=== TESTE 3: Espera com timeout e status finais ===")
+	resolver = newFakeResolver(300 * time.Millisecond)
+	resolver.responses["NFD0000"] = func() (*models.CarInfo, error) {
+		return nil, fmt.Errorf("não foi possível obter dados do veículo: %w", vehicledata.ErrNotFound)
+	}
+	resolver.responses["ERR0000"] = func() (*models.CarInfo, error) {
+		return nil, errors.New("banco de dados não conectado")
+	}
+	resolver.responses["PNC0000"] = func() (*models.CarInfo, error) {
+		panic("falha inesperada")
+	}
+	manager = vehicledata.NewLookupManager(resolver)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	job, err = manager.Lookup(ctx, "ABC1234")
+	cancel()
+	check.That(errors.Is(err, context.DeadlineExceeded) && !job.Finished(), "Timeout retorna o job ainda em andamento (status=%s)", job.Status)
+	job, err = manager.Wait(context.Background(), job.ID)
+	check.That(err == nil && job.Status == vehicledata.LookupStatusCompleted, "O job continua e termina depois do timeout")
+
+	for plate, status := range map[string]string{
+		"NFD0000": vehicledata.LookupStatusNotFound,
+		"ERR0000": vehicledata.LookupStatusFailed,
+		"PNC0000": vehicledata.LookupStatusFailed,
+	} {
+		job, err := manager.Lookup(context.Background(), plate)
+		check.That(err == nil && job.Status == status, "%s -> %s (%s)", plate, job.Status, job.Message)
+	}
+	_, err = manager.Get("inexistente")
+	check.That(errors.Is(err, vehicledata.ErrLookupJobNotFound), "Job inexistente: %v", err)
+
+	fmt.Println("\n=== TESTE 4: Endpoints ===")
+	gin.SetMode(gin.TestMode)
+	resolver = newFakeResolver(300 * time.Millisecond)
+	manager = vehicledata.NewLookupManager(resolver)
+	carHandler := handlers.NewCarHandler(nil, manager)
+	r := gin.New()
+	r.GET("/api/v1/cars/search/:plate", carHandler.SearchCarByPlate)
+	r.POST("/api/v1/cars/lookup", carHandler.StartLookup)
+	r.GET("/api/v1/cars/lookup/:id", carHandler.GetLookup)
+	r.GET("/api/v1/cars/lookup/:id/events", carHandler.StreamLookup)
+
+	var started struct {
+		Job       vehicledata.LookupJob `json:"job"`
+		Coalesced bool                  `json:"coalesced"`
+	}
+	w := serve(r, http.MethodPost, "/api/v1/cars/lookup", `{"plate": "xyz-9a87"}`)
+	json.Unmarshal(w.Body.Bytes(), &started)
+	check.That(w.Code == http.StatusAccepted && started.Job.ID != "" && started.Job.Plate == "XYZ9A87", "POST /cars/lookup -> %d, job %s", w.Code, started.Job.ID)
+
+	w = serve(r, http.MethodPost, "/api/v1/cars/lookup", `{"plate": "XYZ1"}`)
+	check.That(w.Code == http.StatusBadRequest, "Placa inválida -> %d", w.Code)
+
+	w = serve(r, http.MethodGet, "/api/v1/cars/search/XYZ9A87?wait=10ms", "")
+	var pending struct {
+		Job vehicledata.LookupJob `json:"job"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &pending)
+	check.That(w.Code == http.StatusAccepted && pending.Job.ID == started.Job.ID, "Busca síncrona com espera curta -> %d, mesmo job", w.Code)
+
+	w = serve(r, http.MethodGet, "/api/v1/cars/lookup/"+started.Job.ID+"/events", "")
+	events := w.Body.String()
+	check.That(strings.Contains(events, "event:status") && strings.Contains(events, "event:done"), "SSE envia status e done")
+	check.That(strings.Contains(events, `"status":"completed"`), "Evento done traz o resultado")
+
+	w = serve(r, http.MethodGet, "/api/v1/cars/lookup/"+started.Job.ID, "")
+	check.That(w.Code == http.StatusOK && strings.Contains(w.Body.String(), `"marca":"RENAULT"`), "GET /cars/lookup/:id -> %d com o veículo", w.Code)
+
+	w = serve(r, http.MethodGet, "/api/v1/cars/search/XYZ9A87", "")
+	check.That(w.Code == http.StatusOK && strings.Contains(w.Body.String(), `"provedor":"fake"`), "Busca síncrona aguarda o job -> %d", w.Code)
+
+	w = serve(r, http.MethodGet, "/api/v1/cars/lookup/inexistente", "")
+	check.That(w.Code == http.StatusNotFound, "Job inexistente -> %d", w.Code)
+	check.That(resolver.callsFor["XYZ9A87"] == 2, "Duas consultas ao resolver: o job inicial e a busca síncrona depois dele (%d)", resolver.callsFor["XYZ9A87"])
+
+	check.Finish()
+}
+
+// serve executa uma requisição no router
+func serve(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/vehicledata"
+)
+
+// slowProvider demora mais que o timeout configurado
+type slowProvider struct{}
+
+func (slowProvider) Name() string { return "slow" }
+
+func (slowProvider) Lookup(ctx context.Context, plate string) (*models.CarInfo, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(5 * time.Second):
+		return &models.CarInfo{Marca: "LENTO", Modelo: "LENTO", Ano: "2000"}, nil
+	}
+}
+
+// fixturePath localiza plates.json ao lado deste arquivo
+func fixturePath() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "plates.json")
+}
+
+func main() {
+	fmt.Println("🧪 Testando provedores de placa...")
+	ctx := context.Background()
+
+	fmt.Println("\n=== TESTE 1: Provedor de fixtures ===")
+	fixture, err := vehicledata.LoadFixtureProvider(fixturePath())
+	check.That(err == nil, "Fixtures carregadas (err=%v)", err)
+	if err != nil {
+		os.Exit(1)
+	}
+	info, err := fixture.Lookup(ctx, "abc-1234")
+	check.That(err == nil && info.Marca == "RENAULT" && info.Placa == "ABC1234", "abc-1234 -> %+v (err=%v)", info, err)
+	_, err = fixture.Lookup(ctx, "AAA0000")
+	check.That(errors.Is(err, vehicledata.ErrNotFound), "Placa desconhecida retorna ErrNotFound (err=%v)", err)
+
+	fmt.Println("\n=== TESTE 2: Provedor JSON ===")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer segredo" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/veiculos/XYZ9A87":
+			fmt.Fprint(w, `{"data": {"brand": "CHEVROLET", "model": "ONIX 1.0", "year": 2019, "imported": false, "fipe": {"code": "004412-0"}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	jsonProvider := vehicledata.NewJSONProvider("json", server.URL+"/veiculos/{plate}", "segredo", map[string]string{
+		"marca":       "data.brand",
+		"modelo":      "data.model",
+		"ano":         "data.year",
+		"importado":   "data.imported",
+		"codigo_fipe": "data.fipe.code",
+	})
+	info, err = jsonProvider.Lookup(ctx, "xyz-9a87")
+	check.That(err == nil, "Consulta JSON sem erro (err=%v)", err)
+	if info != nil {
+		check.That(info.Marca == "CHEVROLET" && info.Modelo == "ONIX 1.0", "Marca e modelo mapeados: %s %s", info.Marca, info.Modelo)
+		check.That(info.Ano == "2019" && info.Importado == "NÃO" && info.CodigoFipe == "004412-0",
+			"Número, booleano e caminho aninhado: ano=%s importado=%s fipe=%s", info.Ano, info.Importado, info.CodigoFipe)
+	}
+	_, err = jsonProvider.Lookup(ctx, "AAA0000")
+	check.That(errors.Is(err, vehicledata.ErrNotFound), "404 retorna ErrNotFound (err=%v)", err)
+	_, err = vehicledata.NewJSONProvider("json", server.URL+"/veiculos/{plate}", "errado", nil).Lookup(ctx, "XYZ9A87")
+	check.That(err != nil && !errors.Is(err, vehicledata.ErrNotFound), "Erro de autenticação não é ErrNotFound (err=%v)", err)
+
+	fmt.Println("\n=== TESTE 3: Ordem e mescla da cadeia ===")
+	partial := vehicledata.NewFixtureProvider(map[string]models.CarInfo{
+		"XYZ9A87": {Marca: "GM", Modelo: "ONIX"},
+	})
+	chain := vehicledata.NewChain([]vehicledata.PlateProvider{partial, jsonProvider}, nil)
+	check.That(strings.Join(chain.Providers(), ",") == "fixture,json", "Ordem: %v", chain.Providers())
+	info, err = chain.Lookup(ctx, "XYZ9A87")
+	check.That(err == nil, "Cadeia respondeu (err=%v)", err)
+	if info != nil {
+		check.That(info.Provedor == "fixture", "Provedor registrado: %s", info.Provedor)
+		check.That(info.Marca == "GM" && info.Modelo == "ONIX", "Campos do primeiro provedor mantidos: %s %s", info.Marca, info.Modelo)
+		check.That(info.Ano == "2019" && info.CodigoFipe == "004412-0", "Campos faltantes completados pelo segundo: ano=%s fipe=%s", info.Ano, info.CodigoFipe)
+	}
+
+	info, err = vehicledata.NewChain([]vehicledata.PlateProvider{jsonProvider, fixture}, nil).Lookup(ctx, "ABC1234")
+	check.That(err == nil && info.Provedor == "fixture" && info.Marca == "RENAULT", "Segundo provedor responde quando o primeiro não conhece a placa (err=%v)", err)
+
+	_, err = chain.Lookup(ctx, "AAA0000")
+	check.That(errors.Is(err, vehicledata.ErrNotFound), "Ninguém conhece a placa: ErrNotFound (err=%v)", err)
+
+	fmt.Println("\n=== TESTE 4: Timeout por provedor ===")
+	chain = vehicledata.NewChain([]vehicledata.PlateProvider{slowProvider{}, fixture}, map[string]time.Duration{
+		"slow": 100 * time.Millisecond,
+	})
+	start := time.Now()
+	info, err = chain.Lookup(ctx, "ABC1234")
+	elapsed := time.Since(start)
+	check.That(err == nil && info.Provedor == "fixture", "Provedor lento ignorado após o timeout (err=%v)", err)
+	check.That(elapsed < time.Second, "Consulta terminou em %v", elapsed)
+
+	_, err = vehicledata.NewChain([]vehicledata.PlateProvider{slowProvider{}}, map[string]time.Duration{
+		"slow": 50 * time.Millisecond,
+	}).Lookup(ctx, "ABC1234")
+	check.That(err != nil && !errors.Is(err, vehicledata.ErrNotFound), "Timeout não é ErrNotFound (err=%v)", err)
+
+	fmt.Println("\n=== TESTE 5: Configuração pelo ambiente ===")
+	os.Setenv("PLATE_FIXTURE_FILE", fixturePath())
+	os.Setenv("PLATE_PROVIDERS", "fixture, keplaca")
+	os.Setenv("PLATE_PROVIDER_TIMEOUT_FIXTURE", "2s")
+	chain, err = vehicledata.NewChainFromEnv()
+	check.That(err == nil && strings.Join(chain.Providers(), ",") == "fixture,keplaca", "PLATE_PROVIDERS respeitado (err=%v)", err)
+
+	os.Setenv("PLATE_PROVIDERS", "fixture,inexistente")
+	_, err = vehicledata.NewChainFromEnv()
+	check.That(err != nil && strings.Contains(err.Error(), "inexistente"), "Provedor desconhecido rejeitado: %v", err)
+
+	os.Setenv("PLATE_PROVIDERS", "json")
+	os.Unsetenv("PLATE_JSON_URL")
+	_, err = vehicledata.NewChainFromEnv()
+	check.That(err != nil, "Provedor JSON sem URL rejeitado: %v", err)
+
+	check.Finish()
+}
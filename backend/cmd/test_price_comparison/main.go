@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/routes"
+)
+
+// fakePrices filtra, ordena e resume as ofertas em memória com as regras do repositório
+type fakePrices struct {
+	database.StockRepository
+	stocks  []models.Stock
+	history []models.StockPriceHistory
+	filters []models.PriceFilter
+}
+
+func (f *fakePrices) offers(filter models.PriceFilter) []models.Stock {
+	f.filters = append(f.filters, filter)
+	var offers []models.Stock
+	for _, stock := range f.stocks {
+		if stock.Price == nil || *stock.Price <= 0 || stock.Obsolete {
+			continue
+		}
+		if filter.State != "" && !strings.EqualFold(*stock.Company.State, filter.State) {
+			continue
+		}
+		if filter.InStock && (stock.Quantity == nil || *stock.Quantity <= 0) {
+			continue
+		}
+		offers = append(offers, stock)
+	}
+	return offers
+}
+
+func (f *fakePrices) RankOffers(partNameID string, filter models.PriceFilter, page, pageSize int) (*models.StockOfferListResponse, error) {
+	if _, err := uuid.Parse(partNameID); err != nil {
+		return nil, database.ErrInvalidPriceQuery
+	}
+	stocks := f.offers(filter)
+	sort.SliceStable(stocks, func(i, j int) bool { return *stocks[i].Price < *stocks[j].Price })
+
+	response := &models.StockOfferListResponse{PartNameID: partNameID, Offers: []models.StockOffer{}, Total: int64(len(stocks)), Page: page, PageSize: pageSize}
+	for i, stock := range stocks {
+		response.Offers = append(response.Offers, models.StockOffer{
+			Rank: i + 1,
+			StockResponse: models.StockResponse{
+				ID: stock.ID.String(), Price: stock.Price, Quantity: stock.Quantity,
+				CompanyName: stock.Company.Name, CompanyPhone: stock.Company.Phone, CompanyEmail: stock.Company.Email,
+			},
+			CompanyCity:  stock.Company.City,
+			CompanyState: stock.Company.State,
+		})
+	}
+	return response, nil
+}
+
+func (f *fakePrices) GetGroupPriceStats(groupID string, filter models.PriceFilter) (*models.PartGroupPriceStats, error) {
+	if _, err := uuid.Parse(groupID); err != nil {
+		return nil, database.ErrInvalidPriceQuery
+	}
+	stats := &models.PartGroupPriceStats{GroupID: groupID, ByState: []models.PriceStats{}, ByCity: []models.PriceStats{}}
+	byState := map[string][]float64{}
+	var all []float64
+	for _, stock := range f.offers(filter) {
+		byState[*stock.Company.State] = append(byState[*stock.Company.State], *stock.Price)
+		all = append(all, *stock.Price)
+	}
+	for state, prices := range byState {
+		stats.ByState = append(stats.ByState, summary(state, prices))
+	}
+	if len(all) > 0 {
+		overall := summary("", all)
+		stats.Overall = &overall
+	}
+	return stats, nil
+}
+
+func (f *fakePrices) ListPriceHistory(id string, page, pageSize int) (*models.StockPriceHistoryListResponse, error) {
+	response := &models.StockPriceHistoryListResponse{Prices: []models.StockPriceHistory{}, Page: page, PageSize: pageSize}
+	for i := len(f.history) - 1; i >= 0; i-- {
+		if f.history[i].StockID.String() == id {
+			response.Prices = append(response.Prices, f.history[i])
+		}
+	}
+	response.Total = int64(len(response.Prices))
+	return response, nil
+}
+
+// summary calcula a mediana como o percentile_cont(0.5) do Postgres
+func summary(state string, prices []float64) models.PriceStats {
+	sort.Float64s(prices)
+	median := prices[len(prices)/2]
+	if len(prices)%2 == 0 {
+		median = (prices[len(prices)/2-1] + prices[len(prices)/2]) / 2
+	}
+	return models.PriceStats{State: state, Offers: int64(len(prices)), Min: prices[0], Median: median, Max: prices[len(prices)-1]}
+}
+
+func main() {
+	fmt.Println("🧪 Testando a comparação de preços entre empresas...")
+
+	partNameID := uuid.New()
+	company := func(name, city, state, phone string) *models.Company {
+		email := strings.ToLower(strings.ReplaceAll(name, " ", "")) + "@exemplo.com.br"
+		return &models.Company{ID: uuid.New(), Name: name, City: &city, State: &state, Phone: &phone, Email: &email}
+	}
+	stock := func(c *models.Company, price float64, quantity int, obsolete bool) models.Stock {
+		return models.Stock{ID: uuid.New(), PartNameID: partNameID, CompanyID: c.ID, Company: c, Price: &price, Quantity: &quantity, Obsolete: obsolete}
+	}
+	central := company("Auto Peças Central", "São Paulo", "SP", "(11) 3333-4444")
+	campinas := company("Campinas Autopeças", "Campinas", "SP", "(19) 3232-1010")
+	sul := company("Distribuidora Sul", "Curitiba", "PR", "(41) 3030-2020")
+	repo := &fakePrices{stocks: []models.Stock{
+		stock(central, 45.9, 3, false),
+		stock(campinas, 39.9, 0, false),
+		stock(sul, 52, 10, false),
+		stock(sul, 12, 5, true), // obsoleto não entra
+	}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupStockRoutes(router.Group("/api/v1"), repo)
+
+	fmt.Println("\n=== TESTE 1: Ranking de ofertas ===")
+	w := request(router, "/api/v1/stocks/part/"+partNameID.String()+"/offers")
+	var ranking models.StockOfferListResponse
+	json.Unmarshal(w.Body.Bytes(), &ranking)
+	check.That(w.Code == http.StatusOK && ranking.Total == 3, "Ofertas com preço e não obsoletas -> %d (%d)", w.Code, ranking.Total)
+	if len(ranking.Offers) == 3 {
+		first := ranking.Offers[0]
+		check.That(first.Rank == 1 && *first.Price == 39.9 && ranking.Offers[2].Rank == 3 && *ranking.Offers[2].Price == 52,
+			"Menor preço primeiro: %v, %v, %v", *first.Price, *ranking.Offers[1].Price, *ranking.Offers[2].Price)
+		check.That(first.CompanyName == "Campinas Autopeças" && *first.CompanyPhone == "(19) 3232-1010" && *first.CompanyEmail != "" && *first.CompanyCity == "Campinas",
+			"Oferta traz os dados de contato da empresa")
+	}
+	check.That(strings.Contains(w.Body.String(), `"rank":1,"id":`) && strings.Contains(w.Body.String(), `"company_phone"`),
+		"Campos do StockResponse no mesmo nível do rank")
+
+	w = request(router, "/api/v1/stocks/part/"+partNameID.String()+"/offers?state=sp&in_stock=true")
+	json.Unmarshal(w.Body.Bytes(), &ranking)
+	filter := repo.filters[len(repo.filters)-1]
+	check.That(filter.State == "sp" && filter.InStock && ranking.Total == 1 && ranking.Offers[0].CompanyName == "Auto Peças Central",
+		"Filtro por estado e só com estoque: %+v -> %d oferta(s)", filter, ranking.Total)
+
+	w = request(router, "/api/v1/stocks/part/abc/offers")
+	check.That(w.Code == http.StatusBadRequest, "SKU inválido -> %d", w.Code)
+
+	fmt.Println("\n=== TESTE 2: Estatísticas do grupo ===")
+	w = request(router, "/api/v1/stocks/group/"+uuid.NewString()+"/price-stats")
+	var stats models.PartGroupPriceStats
+	json.Unmarshal(w.Body.Bytes(), &stats)
+	check.That(w.Code == http.StatusOK && stats.Overall != nil && stats.Overall.Offers == 3 && stats.Overall.Min == 39.9 &&
+		stats.Overall.Median == 45.9 && stats.Overall.Max == 52, "Total geral: %+v", stats.Overall)
+	for _, state := range stats.ByState {
+		if state.State == "SP" {
+			check.That(state.Offers == 2 && state.Median == 42.9, "Mediana de SP com duas ofertas é a média: %+v", state)
+		}
+	}
+	w = request(router, "/api/v1/stocks/group/"+uuid.NewString()+"/price-stats?state=AM")
+	json.Unmarshal(w.Body.Bytes(), &stats)
+	check.That(w.Code == http.StatusOK && stats.Overall == nil && len(stats.ByState) == 0, "Sem ofertas na região: total nulo")
+	w = request(router, "/api/v1/stocks/group/x/price-stats")
+	check.That(w.Code == http.StatusBadRequest, "Grupo inválido -> %d", w.Code)
+
+	fmt.Println("\n=== TESTE 3: Histórico de preços ===")
+	first := repo.stocks[0]
+	old, current := 49.9, *first.Price
+	repo.history = []models.StockPriceHistory{
+		{ID: 1, StockID: first.ID, Price: &old, ChangedAt: time.Now().Add(-48 * time.Hour)},
+		{ID: 2, StockID: first.ID, Price: &current, PreviousPrice: &old, ChangedAt: time.Now()},
+	}
+	w = request(router, "/api/v1/stocks/"+first.ID.String()+"/prices")
+	var history models.StockPriceHistoryListResponse
+	json.Unmarshal(w.Body.Bytes(), &history)
+	check.That(w.Code == http.StatusOK && history.Total == 2 && *history.Prices[0].Price == current && *history.Prices[0].PreviousPrice == old,
+		"Fotos de preço, a mais recente primeiro -> %d", w.Code)
+
+	check.Finish()
+}
+
+func request(r http.Handler, url string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/elasticsearch"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/search"
+	"partexplorer/backend/internal/synonyms"
+
+	"github.com/olivere/elastic/v7"
+)
+
+const (
+	groupA = "11111111-1111-1111-1111-111111111111"
+	groupB = "22222222-2222-2222-2222-222222222222"
+)
+
+// fakeRepo implementa apenas os métodos de PartRepository usados pelo orquestrador
+type fakeRepo struct {
+	database.PartRepository
+	sqlCalls int
+}
+
+func (r *fakeRepo) SearchPartsFaceted(query string, filters models.SearchFilters, page, pageSize int) (*models.SearchResponse, error) {
+	r.sqlCalls++
+	return &models.SearchResponse{
+		Results:  []models.SearchResult{{ID: groupB, Score: 1.0}},
+		Total:    1,
+		Page:     page,
+		PageSize: pageSize,
+		Query:    query,
+	}, nil
+}
+
+func (r *fakeRepo) SearchPartsByFitment(query models.FitmentQuery, page, pageSize int) (*models.SearchResponse, error) {
+	r.sqlCalls++
+	return &models.SearchResponse{
+		Results:  []models.SearchResult{{ID: groupB, Score: 2.0}},
+		Total:    1,
+		Page:     page,
+		PageSize: pageSize,
+		Facets:   map[string][]models.FacetValue{"family": {{Value: "FILTROS", Count: 1}}},
+	}, nil
+}
+
+// CanonicalManufacturer simula partexplorer.f_canonical_manufacturer com um único apelido
+func (r *fakeRepo) CanonicalManufacturer(name string) (string, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "VW" {
+		return "VOLKSWAGEN", nil
+	}
+	return name, nil
+}
+
+func (r *fakeRepo) GetPartsByIDs(ids []string) ([]models.SearchResult, error) {
+	results := make([]models.SearchResult, len(ids))
+	for i, id := range ids {
+		results[i] = models.SearchResult{ID: id}
+	}
+	return results, nil
+}
+
+// fakeSuggestions implementa o fallback SQL do autocomplete
+type fakeSuggestions struct {
+	database.SuggestionRepository
+	calls int
+}
+
+func (r *fakeSuggestions) Suggest(prefix string, types []string, state string, limit int) ([]models.Suggestion, error) {
+	r.calls++
+	return []models.Suggestion{{Text: "BOSCH", Type: models.SuggestionBrand, Score: 12}}, nil
+}
+
+// suggestionRepo é o fallback SQL do autocomplete compartilhado pelos testes
+var suggestionRepo = &fakeSuggestions{}
+
+// lastSearchBody guarda o corpo da última busca recebida pelo stub
+var lastSearchBody string
+
+// stubES simula as rotas do Elasticsearch usadas pelo SearchService
+func stubES(clusterStatus string, searchStatus int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/_cluster/health":
+			fmt.Fprintf(w, `{"cluster_name":"stub","status":%q}`, clusterStatus)
+		case strings.HasPrefix(r.URL.Path, "/"+elasticsearch.SuggestAlias+"/") && strings.HasSuffix(r.URL.Path, "/_search"):
+			body, _ := io.ReadAll(r.Body)
+			lastSearchBody = string(body)
+			if searchStatus != http.StatusOK {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error":{"type":"index_not_found_exception","reason":"no such index"},"status":404}`)
+				return
+			}
+			fmt.Fprint(w, `{
+				"took": 1,
+				"hits": {"total": {"value": 0, "relation": "eq"}, "hits": []},
+				"suggest": {
+					"autocomplete": [{
+						"text": "fil", "offset": 0, "length": 3,
+						"options": [
+							{"text": "FILTRO DE OLEO", "_index": "partexplorer_suggest", "_id": "1", "_score": 120, "_source": {"text": "FILTRO DE OLEO", "type": "product_type"}},
+							{"text": "FIL-100", "_index": "partexplorer_suggest", "_id": "2", "_score": 15, "_source": {"text": "FIL-100", "type": "sku"}}
+						]
+					}]
+				}
+			}`)
+		case strings.HasSuffix(r.URL.Path, "/_search"):
+			body, _ := io.ReadAll(r.Body)
+			lastSearchBody = string(body)
+			// 404 simula apenas a ausência do índice de sugestões
+			if searchStatus != http.StatusOK && searchStatus != http.StatusNotFound {
+				w.WriteHeader(searchStatus)
+				fmt.Fprint(w, `{"error":{"type":"stub_failure","reason":"forced"},"status":500}`)
+				return
+			}
+			fmt.Fprintf(w, `{
+				"took": 1,
+				"hits": {
+					"total": {"value": 2, "relation": "eq"},
+					"max_score": 7.5,
+					"hits": [
+						{"_index":"partexplorer","_id":%q,"_score":7.5,"_source":{"id":%q,"names":["FILTRO DE OLEO"]}},
+						{"_index":"partexplorer","_id":%q,"_score":3.25,"_source":{"id":%q,"names":["FILTRO DE AR"]}}
+					]
+				},
+				"aggregations": {
+					"family": {"buckets": [{"key": "FILTROS", "doc_count": 2}]},
+					"subfamily": {"buckets": [{"key": "FILTRO DE OLEO", "doc_count": 1}, {"key": "FILTRO DE AR", "doc_count": 1}]},
+					"brand": {"doc_count": 2, "values": {"buckets": [{"key": "BOSCH", "doc_count": 2}, {"key": "MAHLE", "doc_count": 1}]}}
+				}
+			}`, groupA, groupA, groupB, groupB)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newOrchestrator(url string, repo *fakeRepo) *search.Orchestrator {
+	client, err := elastic.NewClient(
+		elastic.SetURL(url),
+		elastic.SetSniff(false),
+		elastic.SetHealthcheck(false),
+	)
+	if err != nil {
+		fmt.Printf("❌ Erro ao criar cliente: %v\n", err)
+		os.Exit(1)
+	}
+	return search.NewOrchestrator(repo, suggestionRepo, elasticsearch.NewSearchServiceWithClient(client))
+}
+
+func main() {
+	fmt.Println("🔎 TESTANDO ORQUESTRADOR DE BUSCA (stub Elasticsearch)")
+
+	// Teste 1: cluster saudável - resultados e scores vêm do Elasticsearch
+	fmt.Println("\n=== TESTE 1: Cluster saudável ===")
+	server := stubES("green", http.StatusOK)
+	repo := &fakeRepo{}
+	response, engine, err := newOrchestrator(server.URL, repo).SearchParts("filtro", nil, 1, 10)
+	server.Close()
+	check.That(err == nil, "Busca sem erro (err=%v)", err)
+	check.That(engine == search.EngineElasticsearch, "Engine = %s", engine)
+	check.That(repo.sqlCalls == 0, "SQL não foi chamado (%d chamadas)", repo.sqlCalls)
+	if response != nil && len(response.Results) == 2 {
+		check.That(response.Results[0].ID == groupA && response.Results[0].Score == 7.5, "Primeiro resultado com score real: %.2f", response.Results[0].Score)
+		check.That(response.Results[1].ID == groupB && response.Results[1].Score == 3.25, "Ordem de relevância preservada: %.2f", response.Results[1].Score)
+		check.That(response.Total == 2, "Total = %d", response.Total)
+	} else {
+		check.That(false, "Esperava 2 resultados")
+	}
+
+	// Teste 2: cluster red - fallback para PostgreSQL
+	fmt.Println("\n=== TESTE 2: Cluster red ===")
+	server = stubES("red", http.StatusOK)
+	repo = &fakeRepo{}
+	_, engine, err = newOrchestrator(server.URL, repo).SearchParts("filtro", nil, 1, 10)
+	server.Close()
+	check.That(err == nil, "Busca sem erro (err=%v)", err)
+	check.That(engine == search.EnginePostgres, "Engine = %s", engine)
+	check.That(repo.sqlCalls == 1, "SQL chamado uma vez (%d chamadas)", repo.sqlCalls)
+
+	// Teste 3: busca falha no Elasticsearch - fallback para PostgreSQL
+	fmt.Println("\n=== TESTE 3: Erro na busca do Elasticsearch ===")
+	server = stubES("yellow", http.StatusInternalServerError)
+	repo = &fakeRepo{}
+	_, engine, err = newOrchestrator(server.URL, repo).SearchParts("filtro", nil, 1, 10)
+	server.Close()
+	check.That(err == nil, "Busca sem erro (err=%v)", err)
+	check.That(engine == search.EnginePostgres, "Engine = %s", engine)
+
+	// Teste 4: Elasticsearch fora do ar
+	fmt.Println("\n=== TESTE 4: Elasticsearch fora do ar ===")
+	server = stubES("green", http.StatusOK)
+	closedURL := server.URL
+	server.Close()
+	repo = &fakeRepo{}
+	_, engine, err = newOrchestrator(closedURL, repo).SearchParts("filtro", nil, 1, 10)
+	check.That(err == nil, "Busca sem erro (err=%v)", err)
+	check.That(engine == search.EnginePostgres, "Engine = %s", engine)
+
+	// Teste 5: busca por aplicação com facets
+	fmt.Println("\n=== TESTE 5: Fitment com cluster saudável ===")
+	server = stubES("green", http.StatusOK)
+	repo = &fakeRepo{}
+	fitment := models.FitmentQuery{Manufacturer: "VW", Model: "GOL", Year: 2010, Fuel: "FLEX"}
+	response, engine, err = newOrchestrator(server.URL, repo).SearchFitment(fitment, 1, 10)
+	server.Close()
+	check.That(err == nil, "Busca sem erro (err=%v)", err)
+	check.That(engine == search.EngineElasticsearch, "Engine = %s", engine)
+	check.That(strings.Contains(lastSearchBody, `"VOLKSWAGEN"`) && !strings.Contains(lastSearchBody, `"VW"`), "Fabricante convertido pelo dicionário do banco")
+	if response != nil {
+		check.That(len(response.Results) == 2 && response.Results[0].Score == 7.5, "Resultados ordenados por especificidade")
+		check.That(len(response.Facets["family"]) == 1 && response.Facets["family"][0].Count == 2, "Facet family = %v", response.Facets["family"])
+		check.That(len(response.Facets["subfamily"]) == 2, "Facet subfamily = %v", response.Facets["subfamily"])
+	}
+
+	// Teste 6: busca por aplicação com fallback
+	fmt.Println("\n=== TESTE 6: Fitment com cluster red ===")
+	server = stubES("red", http.StatusOK)
+	repo = &fakeRepo{}
+	response, engine, err = newOrchestrator(server.URL, repo).SearchFitment(fitment, 1, 10)
+	server.Close()
+	check.That(err == nil, "Busca sem erro (err=%v)", err)
+	check.That(engine == search.EnginePostgres, "Engine = %s", engine)
+	check.That(repo.sqlCalls == 1 && response != nil && len(response.Facets["family"]) == 1, "Fallback SQL com facets")
+
+	// Teste 7: facets selecionados
+	fmt.Println("\n=== TESTE 7: Facets selecionados ===")
+	server = stubES("green", http.StatusOK)
+	repo = &fakeRepo{}
+	filters := models.SearchFilters{models.FacetBrand: {"BOSCH"}}
+	response, engine, err = newOrchestrator(server.URL, repo).SearchParts("filtro", filters, 1, 10)
+	server.Close()
+	check.That(err == nil && engine == search.EngineElasticsearch, "Busca no Elasticsearch (engine=%s, err=%v)", engine, err)
+	check.That(strings.Contains(lastSearchBody, `"post_filter"`) && strings.Contains(lastSearchBody, `"brands.keyword":["BOSCH"]`), "Facet selecionado enviado como post_filter")
+	if response != nil && len(response.Facets[models.FacetBrand]) == 2 {
+		brands := response.Facets[models.FacetBrand]
+		check.That(brands[0].Value == "BOSCH" && brands[0].Selected, "BOSCH marcado como selecionado")
+		check.That(brands[1].Value == "MAHLE" && !brands[1].Selected && brands[1].Count == 1, "MAHLE disponível para seleção múltipla")
+	} else {
+		check.That(false, "Esperava 2 valores no facet brand")
+	}
+
+	// Localização do modo "Onde encontrar" e facets de localização não se misturam
+	params, _ := url.ParseQuery("searchMode=find&state=SP&city=Campinas&brand=BOSCH&brand=MAHLE&facet_state=PR&facet_city=Curitiba")
+	filters = models.ParseSearchFilters(params)
+	check.That(len(filters[models.FacetBrand]) == 2 && fmt.Sprint(filters[models.FacetState]) == "[PR]" && fmt.Sprint(filters[models.FacetCity]) == "[Curitiba]",
+		"Facets de localização em facet_state/facet_city: %v", filters)
+	params, _ = url.ParseQuery("state=SP&city=Campinas")
+	check.That(models.ParseSearchFilters(params).IsEmpty(), "state e city sozinhos não filtram os facets")
+
+	// Teste 8: busca tolerante a erros de digitação e sinônimos
+	fmt.Println("\n=== TESTE 8: Fuzzy e sinônimos ===")
+	server = stubES("green", http.StatusOK)
+	repo = &fakeRepo{}
+	_, engine, err = newOrchestrator(server.URL, repo).SearchParts("pastilah freio", nil, 1, 10)
+	server.Close()
+	check.That(err == nil && engine == search.EngineElasticsearch, "Busca no Elasticsearch (engine=%s, err=%v)", engine, err)
+	check.That(strings.Contains(lastSearchBody, `"fuzziness":"AUTO"`), "Query fuzzy enviada")
+	check.That(strings.Contains(lastSearchBody, `"codes"`), "Códigos casam por termo exato")
+	check.That(synonyms.Normalize("AMORTECEDÔR") == "amortecedor", "Normalização remove acentos: %s", synonyms.Normalize("AMORTECEDÔR"))
+	variants := synonyms.Default().Expand("Pastilha")
+	check.That(len(variants) > 1 && variants[0] == "pastilha" && containsString(variants, "pastilhas de freio"), "Sinônimos expandidos: %v", variants)
+	dict, err := synonyms.Parse(strings.NewReader("# comentário\nrolimã, rolamento => rolamento\n"))
+	check.That(err == nil && len(dict.Rules()) == 1, "Arquivo de sinônimos com comentários (err=%v)", err)
+	if dict != nil {
+		check.That(fmt.Sprint(dict.Expand("rolimã dianteiro")) == "[rolima dianteiro rolamento dianteiro]", "Regra explícita aplicada: %v", dict.Expand("rolimã dianteiro"))
+	}
+
+	// Teste 9: autocomplete pelo completion suggester
+	fmt.Println("\n=== TESTE 9: Autocomplete ===")
+	server = stubES("green", http.StatusOK)
+	suggestions, engine, err := newOrchestrator(server.URL, &fakeRepo{}).Suggest("fil", []string{models.SuggestionSKU, models.SuggestionProductType}, "sp", 5)
+	server.Close()
+	check.That(err == nil && engine == search.EngineElasticsearch, "Autocomplete no Elasticsearch (engine=%s, err=%v)", engine, err)
+	check.That(strings.Contains(lastSearchBody, `"completion"`) && strings.Contains(lastSearchBody, `"sku|SP"`) && strings.Contains(lastSearchBody, `"product_type|SP"`), "Tipos e estado enviados como contexto")
+	check.That(len(suggestions) == 2 && suggestions[0].Type == models.SuggestionProductType && suggestions[0].Score == 120, "Sugestões tipadas ordenadas por peso: %v", suggestions)
+
+	// Teste 10: índice de sugestões ausente - fallback SQL sem derrubar a busca principal
+	fmt.Println("\n=== TESTE 10: Autocomplete com fallback ===")
+	server = stubES("green", http.StatusNotFound)
+	orchestrator := newOrchestrator(server.URL, &fakeRepo{})
+	suggestions, engine, err = orchestrator.Suggest("bos", models.SuggestionTypes, "", 5)
+	check.That(err == nil && engine == search.EnginePostgres && suggestionRepo.calls == 1, "Fallback SQL (engine=%s, chamadas=%d)", engine, suggestionRepo.calls)
+	check.That(len(suggestions) == 1 && suggestions[0].Text == "BOSCH", "Sugestão do SQL: %v", suggestions)
+	_, engine, _ = orchestrator.SearchFitment(models.FitmentQuery{Model: "gol"}, 1, 10)
+	server.Close()
+	check.That(engine == search.EngineElasticsearch, "Cluster continua saudável para a busca (engine=%s)", engine)
+
+	doc := elasticsearch.NewSuggestionDocument(models.SuggestionCandidate{Type: models.SuggestionSKU, Text: "KL-1234", CatalogCount: 2, Searches: 3, States: []string{"SP"}})
+	check.That(doc.Suggest.Weight == 32, "Peso = catálogo + buscas x %d: %d", models.SuggestionSearchWeight, doc.Suggest.Weight)
+	check.That(containsString(doc.Suggest.Input, "KL1234") && containsString(doc.Suggest.Contexts["scope"], "sku|SP") && containsString(doc.Suggest.Contexts["scope"], "sku|*"), "Entradas e contextos: %v %v", doc.Suggest.Input, doc.Suggest.Contexts)
+
+	check.Finish()
+}
+
+// containsString verifica se value está em values
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/routes"
+	"partexplorer/backend/internal/stockfeed"
+)
+
+// fakeImports guarda as importações em memória e processa as linhas sem banco
+type fakeImports struct {
+	database.StockImportRepository
+	companies map[uuid.UUID]bool
+	jobs      map[string]*models.StockImportJob
+	lines     map[string][]models.StockImportLine
+	batches   int
+	failAt    int
+	failed    string
+}
+
+func (f *fakeImports) Create(job *models.StockImportJob, lines []models.StockImportLine) error {
+	if !f.companies[job.CompanyID] {
+		return database.ErrCompanyNotFound
+	}
+	f.jobs[job.ID.String()] = job
+	f.lines[job.ID.String()] = lines
+	return nil
+}
+
+func (f *fakeImports) Get(id string) (*models.StockImportJob, error) {
+	job, ok := f.jobs[id]
+	if !ok {
+		return nil, database.ErrStockImportNotFound
+	}
+	return job, nil
+}
+
+func (f *fakeImports) ListLines(id, status string, page, pageSize int) (*models.StockImportLineListResponse, error) {
+	if _, ok := f.jobs[id]; !ok {
+		return nil, database.ErrStockImportNotFound
+	}
+	response := &models.StockImportLineListResponse{Lines: []models.StockImportLine{}, Page: page, PageSize: pageSize}
+	for _, line := range f.lines[id] {
+		if status == "" || line.Status == status {
+			response.Lines = append(response.Lines, line)
+		}
+	}
+	response.Total = int64(len(response.Lines))
+	return response, nil
+}
+
+func (f *fakeImports) Cancel(id string) (*models.StockImportJob, error) {
+	job, err := f.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == models.StockImportCompleted {
+		return nil, database.ErrStockImportFinished
+	}
+	job.Status = models.StockImportCancelled
+	return job, nil
+}
+
+func (f *fakeImports) ProcessBatch(id string, size int) (*models.StockImportJob, error) {
+	job, err := f.Get(id)
+	if err != nil || job.Finished() {
+		return job, err
+	}
+	f.batches++
+	if f.batches == f.failAt {
+		return nil, errors.New("connection reset")
+	}
+
+	job.Status = models.StockImportRunning
+	processed := 0
+	for i := range f.lines[id] {
+		line := &f.lines[id][i]
+		if line.Status != models.StockImportLinePending || processed == size {
+			continue
+		}
+		line.Status = models.StockImportLineUpdated
+		job.Updated++
+		job.ProcessedLines++
+		processed++
+	}
+	if processed == 0 {
+		job.Status = models.StockImportCompleted
+	}
+	return job, nil
+}
+
+func (f *fakeImports) Fail(id, message string) error {
+	f.failed = message
+	f.jobs[id].Status = models.StockImportFailed
+	return nil
+}
+
+func main() {
+	fmt.Println("🧪 Testando a importação de arquivos de estoque...")
+
+	fmt.Println("\n=== TESTE 1: CSV ===")
+	csv := "\ufeffCódigo;EAN;Marca;Quantidade;Preço;Obsoleto\n" +
+		"KL-1234;;Bosch;10;R$ 1.234,56;não\n" +
+		";7891234567895;;3,0;;\n" +
+		"\n" +
+		"FX 99;;Fras-le;-2;;\n" +
+		";;Bosch;4;;\n" +
+		"AB12;;;;;talvez\n" +
+		"CD34;;;;;\n" +
+		"EF56;;;0;12.5;sim\n"
+	lines, err := stockfeed.Read(strings.NewReader(csv), stockfeed.FormatCSV)
+	check.That(err == nil && len(lines) == 7, "CSV com ';', BOM e linha em branco: %d linha(s) (%v)", len(lines), err)
+	if len(lines) == 7 {
+		first := lines[0]
+		check.That(first.Line == 2 && first.SKU == "KL-1234" && first.Brand == "Bosch" && *first.Quantity == 10 &&
+			*first.Price == 1234.56 && !*first.Obsolete, "Linha 2: SKU, marca, quantidade, preço brasileiro e obsoleto")
+		check.That(lines[1].EAN == "7891234567895" && *lines[1].Quantity == 3 && lines[1].Price == nil,
+			"Linha 3: só EAN e quantidade \"3,0\"; preço não informado fica nil")
+		check.That(lines[2].Line == 5 && strings.Contains(lines[2].Error, "invalid quantity"), "Linha 5: quantidade negativa -> %q", lines[2].Error)
+		check.That(strings.Contains(lines[3].Error, "sku or ean is required"), "Linha 6: sem código -> %q", lines[3].Error)
+		check.That(strings.Contains(lines[4].Error, "invalid obsolete"), "Linha 7: obsoleto inválido -> %q", lines[4].Error)
+		check.That(strings.Contains(lines[5].Error, "quantity, price or obsolete"), "Linha 8: nada a atualizar -> %q", lines[5].Error)
+		check.That(*lines[6].Quantity == 0 && *lines[6].Price == 12.5 && *lines[6].Obsolete, "Linha 9: quantidade zero, preço decimal, obsoleto sim")
+	}
+
+	_, err = stockfeed.Read(strings.NewReader("nome,quantidade\nFiltro,2\n"), stockfeed.FormatCSV)
+	check.That(errors.Is(err, stockfeed.ErrNoCodeColumn), "Arquivo sem coluna de código rejeitado: %v", err)
+	_, err = stockfeed.Read(strings.NewReader(""), "ods")
+	check.That(errors.Is(err, stockfeed.ErrUnknownFormat), "Formato desconhecido rejeitado: %v", err)
+	for path, want := range map[string]string{"estoque.CSV": stockfeed.FormatCSV, "e.xlsx": stockfeed.FormatXLSX, "e.ndjson": stockfeed.FormatJSONLines, "e.pdf": ""} {
+		check.That(stockfeed.FormatFromPath(path) == want, "Formato de %s: %q", path, stockfeed.FormatFromPath(path))
+	}
+
+	fmt.Println("\n=== TESTE 2: XLSX ===")
+	lines, err = stockfeed.Read(bytes.NewReader(buildXLSX()), stockfeed.FormatXLSX)
+	check.That(err == nil && len(lines) == 2, "Planilha lida: %d linha(s) (%v)", len(lines), err)
+	if len(lines) == 2 {
+		check.That(lines[0].Line == 2 && lines[0].SKU == "KL-1234" && lines[0].Brand == "Mahle" && *lines[0].Quantity == 8 && *lines[0].Price == 45.9,
+			"Textos compartilhados e números: %+v", lines[0])
+		check.That(lines[1].Line == 4 && lines[1].EAN == "7891234567895" && *lines[1].Quantity == 1 && lines[1].Price == nil,
+			"Texto em linha, célula pulada (C4) e número de linha da planilha: %+v", lines[1])
+	}
+
+	_, err = stockfeed.Read(bytes.NewReader(buildXLSXSheet(`<row r="1"><c r="ZZZZZZZ1"><v>1</v></c></row>`)), stockfeed.FormatXLSX)
+	check.That(err != nil && strings.Contains(err.Error(), "invalid cell reference"), "Coluna além de XFD é rejeitada: %v", err)
+	lines, err = stockfeed.Read(bytes.NewReader(buildXLSXSheet(`<row r="1"><c r="XFD1"><v>sku</v></c></row>`)), stockfeed.FormatXLSX)
+	check.That(err == nil || !strings.Contains(err.Error(), "invalid cell reference"), "Última coluna (XFD) é aceita: %v", err)
+
+	fmt.Println("\n=== TESTE 3: JSON lines ===")
+	jsonl := `{"sku": "KL-1234", "marca": "Bosch", "quantidade": 5, "preco": 19.9}` + "\n" +
+		`{"ean": 7891234567895, "qty": 2, "obsoleto": true}` + "\n" +
+		"\n" +
+		`{"sku": "AB12", "quantidade": 1.5}` + "\n" +
+		`{"sku": ` + "\n"
+	lines, err = stockfeed.Read(strings.NewReader(jsonl), stockfeed.FormatJSONLines)
+	check.That(err == nil && len(lines) == 4, "JSON lines: %d linha(s) (%v)", len(lines), err)
+	if len(lines) == 4 {
+		check.That(*lines[0].Quantity == 5 && *lines[0].Price == 19.9 && lines[0].Brand == "Bosch", "Objeto com nomes em português")
+		check.That(lines[1].EAN == "7891234567895" && *lines[1].Obsolete, "EAN numérico mantém todos os dígitos")
+		check.That(lines[2].Line == 4 && strings.Contains(lines[2].Error, "invalid quantity"), "Quantidade fracionária -> %q", lines[2].Error)
+		check.That(lines[3].Line == 5 && strings.Contains(lines[3].Error, "invalid json"), "JSON inválido vira erro da linha -> %q", lines[3].Error)
+	}
+
+	fmt.Println("\n=== TESTE 4: Job ===")
+	company := uuid.New()
+	job, rows, err := stockfeed.NewJob(company.String(), "", stockfeed.FormatJSONLines, "estoque.jsonl", "joao", lines)
+	check.That(err == nil && job.Mode == models.StockImportMerge && job.TotalLines == 4 && job.Errors == 2 && job.ProcessedLines == 2 && len(rows) == 4,
+		"Modo merge por padrão e linhas rejeitadas já contadas: %+v", job)
+	check.That(rows[0].Status == models.StockImportLinePending && rows[3].Status == models.StockImportLineError && rows[3].JobID == job.ID,
+		"Linhas válidas pendentes, inválidas com erro")
+	for name, args := range map[string][3]string{
+		"Empresa inválida": {"x", models.StockImportMerge, "joao"},
+		"Modo inválido":    {company.String(), "sync", "joao"},
+		"Sem responsável":  {company.String(), models.StockImportReplace, " "},
+	} {
+		_, _, err := stockfeed.NewJob(args[0], args[1], stockfeed.FormatCSV, "", args[2], lines)
+		check.That(errors.Is(err, stockfeed.ErrInvalidJob), "%s rejeitado: %v", name, err)
+	}
+
+	fmt.Println("\n=== TESTE 5: Execução em lotes ===")
+	os.Setenv("STOCK_IMPORT_BATCH", "1")
+	repo := &fakeImports{companies: map[uuid.UUID]bool{company: true}, jobs: map[string]*models.StockImportJob{}, lines: map[string][]models.StockImportLine{}}
+	repo.Create(job, rows)
+	runner := stockfeed.NewRunner(repo)
+	finished, err := runner.Run(job.ID.String())
+	check.That(err == nil && finished.Status == models.StockImportCompleted && finished.Updated == 2 && repo.batches == 3,
+		"Duas linhas em lotes de uma: %d lote(s), status %s", repo.batches, finished.Status)
+
+	job, rows, _ = stockfeed.NewJob(company.String(), models.StockImportReplace, stockfeed.FormatJSONLines, "", "joao", lines)
+	repo.Create(job, rows)
+	repo.batches, repo.failAt = 0, 2
+	_, err = runner.Run(job.ID.String())
+	check.That(err != nil && job.Status == models.StockImportFailed && repo.failed == "connection reset" && job.Updated == 1,
+		"Falha no segundo lote marca o job como failed e mantém a linha aplicada: %v", err)
+	job.Status = models.StockImportRunning
+	repo.failAt = 0
+	finished, err = runner.Run(job.ID.String())
+	check.That(err == nil && finished.Status == models.StockImportCompleted && finished.Updated == 2, "Retomada processa só as linhas pendentes")
+
+	fmt.Println("\n=== TESTE 6: Endpoints ===")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupStockImportRoutes(router.Group("/api/v1"), repo, runner)
+
+	w := upload(router, "estoque.csv", csv, map[string]string{"company_id": company.String(), "mode": "replace"}, "maria")
+	var created models.StockImportJob
+	json.Unmarshal(w.Body.Bytes(), &created)
+	check.That(w.Code == http.StatusAccepted && created.Actor == "maria" && created.Mode == models.StockImportReplace &&
+		created.Format == stockfeed.FormatCSV && created.TotalLines == 7 && created.Errors == 4,
+		"Upload aceito com formato pela extensão e responsável do X-User -> %d %+v", w.Code, created)
+
+	w = request(router, http.MethodGet, "/api/v1/stock-imports/"+created.ID.String()+"/errors")
+	var report models.StockImportLineListResponse
+	json.Unmarshal(w.Body.Bytes(), &report)
+	check.That(w.Code == http.StatusOK && report.Total == 4 && report.Lines[0].Line == 5, "Relatório de erros por linha -> %d (%d linha(s))", w.Code, report.Total)
+
+	w = upload(router, "estoque.csv", csv, map[string]string{"company_id": uuid.NewString(), "actor": "maria"}, "")
+	check.That(w.Code == http.StatusNotFound, "Empresa inexistente -> %d", w.Code)
+	w = upload(router, "estoque.ods", csv, map[string]string{"company_id": company.String(), "actor": "maria"}, "")
+	check.That(w.Code == http.StatusBadRequest, "Formato desconhecido -> %d", w.Code)
+	w = upload(router, "estoque.txt", csv, map[string]string{"company_id": company.String()}, "")
+	check.That(w.Code == http.StatusBadRequest, "Sem responsável -> %d", w.Code)
+
+	w = request(router, http.MethodPost, "/api/v1/stock-imports/"+created.ID.String()+"/cancel")
+	check.That(w.Code == http.StatusOK && repo.jobs[created.ID.String()].Status == models.StockImportCancelled, "Cancelamento -> %d", w.Code)
+	w = request(router, http.MethodPost, "/api/v1/stock-imports/"+job.ID.String()+"/cancel")
+	check.That(w.Code == http.StatusConflict, "Cancelar importação concluída -> %d", w.Code)
+	w = request(router, http.MethodGet, "/api/v1/stock-imports/"+uuid.NewString())
+	check.That(w.Code == http.StatusNotFound, "Importação inexistente -> %d", w.Code)
+
+	check.Finish()
+}
+
+// buildXLSX monta uma planilha mínima com textos compartilhados, texto em linha e uma linha vazia
+func buildXLSX() []byte {
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Estoque" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="worksheet" Target="worksheets/estoque.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>SKU</t></si><si><t>EAN</t></si><si><r><t>Mar</t></r><r><t>ca</t></r></si><si><t>Qtd</t></si><si><t>Preço</t></si>` +
+			`<si><t>KL-1234</t></si><si><t>Mahle</t></si></sst>`,
+		"xl/worksheets/estoque.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>4</v></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>5</v></c><c r="C2" t="s"><v>6</v></c><c r="D2"><v>8</v></c><c r="E2"><v>45.9</v></c></row>` +
+			`<row r="3"></row>` +
+			`<row r="4"><c r="B4" t="inlineStr"><is><t>7891234567895</t></is></c><c r="D4"><v>1</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		file, _ := archive.Create(name)
+		file.Write([]byte(content))
+	}
+	archive.Close()
+	return buf.Bytes()
+}
+
+// buildXLSXSheet monta um XLSX só com a planilha padrão (sheet1.xml) e as linhas informadas
+func buildXLSXSheet(rows string) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	file, _ := archive.Create("xl/worksheets/sheet1.xml")
+	file.Write([]byte(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + rows + `</sheetData></worksheet>`))
+	archive.Close()
+	return buf.Bytes()
+}
+
+func upload(r http.Handler, fileName, content string, fields map[string]string, user string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	file, _ := form.CreateFormFile("file", fileName)
+	file.Write([]byte(content))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/stock-imports/", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func request(r http.Handler, method, url string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/routes"
+)
+
+// fakeStocks guarda os estoques e o livro em memória, com as mesmas regras do repositório
+type fakeStocks struct {
+	database.StockRepository
+	stocks    map[string]*models.Stock
+	movements []models.StockMovement
+	updates   map[string]interface{}
+}
+
+func (f *fakeStocks) RecordMovement(id string, req models.StockMovementRequest) ([]models.StockMovement, error) {
+	stock, ok := f.stocks[id]
+	if !ok {
+		return nil, database.ErrStockNotFound
+	}
+	current := *stock.Quantity
+	delta, err := database.StockMovementDelta(req, current)
+	if err != nil {
+		return nil, err
+	}
+	if current+delta < 0 {
+		return nil, database.ErrInsufficientStock
+	}
+
+	after := current + delta
+	stock.Quantity = &after
+	movement := models.StockMovement{ID: int64(len(f.movements) + 1), StockID: stock.ID, Type: req.Type, Quantity: delta, QuantityAfter: after, Actor: req.Actor, Reason: req.Reason}
+	f.movements = append(f.movements, movement)
+	created := []models.StockMovement{movement}
+
+	if req.TargetStockID != "" {
+		target, ok := f.stocks[req.TargetStockID]
+		if !ok {
+			return nil, database.ErrStockNotFound
+		}
+		targetAfter := *target.Quantity - delta
+		target.Quantity = &targetAfter
+		in := movement
+		in.ID, in.StockID, in.Quantity, in.QuantityAfter = movement.ID+1, target.ID, -delta, targetAfter
+		f.movements = append(f.movements, in)
+		created = append(created, in)
+	}
+	return created, nil
+}
+
+func (f *fakeStocks) ListMovements(id, movementType string, page, pageSize int) (*models.StockMovementListResponse, error) {
+	response := &models.StockMovementListResponse{Movements: []models.StockMovement{}, Page: page, PageSize: pageSize}
+	for i := len(f.movements) - 1; i >= 0; i-- {
+		movement := f.movements[i]
+		if movement.StockID.String() == id && (movementType == "" || movement.Type == movementType) {
+			response.Movements = append(response.Movements, movement)
+		}
+	}
+	response.Total = int64(len(response.Movements))
+	return response, nil
+}
+
+func (f *fakeStocks) GetReconciliation(id string) (*models.StockReconciliation, error) {
+	stock, ok := f.stocks[id]
+	if !ok {
+		return nil, database.ErrStockNotFound
+	}
+	ledger := 0
+	for _, movement := range f.movements {
+		if movement.StockID == stock.ID {
+			ledger += movement.Quantity
+		}
+	}
+	return &models.StockReconciliation{StockID: stock.ID, Quantity: *stock.Quantity, LedgerQuantity: ledger, Difference: *stock.Quantity - ledger}, nil
+}
+
+func (f *fakeStocks) UpdateStock(id string, version int, updates map[string]interface{}, count *models.StockMovementRequest) (*models.Stock, []models.StockMovement, error) {
+	if _, ok := updates["quantity"]; ok {
+		return nil, nil, database.ErrStockQuantityReadOnly
+	}
+	stock, ok := f.stocks[id]
+	if !ok {
+		return nil, nil, database.ErrStockNotFound
+	}
+	if version != stock.Version {
+		return stock, nil, database.ErrVersionConflict
+	}
+	var movements []models.StockMovement
+	if count != nil {
+		var err error
+		if movements, err = f.RecordMovement(id, *count); err != nil {
+			return nil, nil, err
+		}
+	}
+	f.updates = updates
+	stock.Version++
+	return stock, movements, nil
+}
+
+func (f *fakeStocks) AdjustQuantity(id string, req models.StockMovementRequest) (*models.Stock, *models.StockMovement, error) {
+	movements, err := f.RecordMovement(id, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	stock := f.stocks[id]
+	stock.Version++
+	return stock, &movements[0], nil
+}
+
+func newStock(partNameID uuid.UUID, quantity int) *models.Stock {
+	return &models.Stock{ID: uuid.New(), PartNameID: partNameID, Quantity: &quantity, Version: 1}
+}
+
+func main() {
+	fmt.Println("🧪 Testando o livro de movimentações de estoque...")
+
+	fmt.Println("\n=== TESTE 1: Validação e variação ===")
+	counted := 7
+	negative := -1
+	for _, tc := range []struct {
+		name string
+		req  models.StockMovementRequest
+		want int
+	}{
+		{"Entrada soma", models.StockMovementRequest{Type: models.StockMovementReceipt, Quantity: 5, Actor: "ana"}, 5},
+		{"Venda subtrai", models.StockMovementRequest{Type: models.StockMovementSale, Quantity: 3, Actor: "ana"}, -3},
+		{"Transferência sai da origem", models.StockMovementRequest{Type: models.StockMovementTransfer, Quantity: 2, Actor: "ana", TargetStockID: uuid.NewString()}, -2},
+		{"Ajuste com sinal", models.StockMovementRequest{Type: models.StockMovementAdjustment, Quantity: -4, Actor: "ana", Reason: "avaria"}, -4},
+		{"Ajuste por contagem", models.StockMovementRequest{Type: models.StockMovementAdjustment, Counted: &counted, Actor: "ana", Reason: "inventário"}, -3},
+	} {
+		got, err := database.StockMovementDelta(tc.req, 10)
+		check.That(err == nil && got == tc.want, "%s: saldo 10 -> %+d", tc.name, got)
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  models.StockMovementRequest
+	}{
+		{"Sem responsável", models.StockMovementRequest{Type: models.StockMovementReceipt, Quantity: 5}},
+		{"Tipo desconhecido", models.StockMovementRequest{Type: "loss", Quantity: 5, Actor: "ana"}},
+		{"Venda negativa", models.StockMovementRequest{Type: models.StockMovementSale, Quantity: -3, Actor: "ana"}},
+		{"Ajuste sem motivo", models.StockMovementRequest{Type: models.StockMovementAdjustment, Quantity: 2, Actor: "ana"}},
+		{"Contagem negativa", models.StockMovementRequest{Type: models.StockMovementAdjustment, Counted: &negative, Actor: "ana", Reason: "x"}},
+		{"Contagem em entrada", models.StockMovementRequest{Type: models.StockMovementReceipt, Quantity: 1, Counted: &counted, Actor: "ana"}},
+		{"Transferência sem destino", models.StockMovementRequest{Type: models.StockMovementTransfer, Quantity: 2, Actor: "ana"}},
+		{"Destino fora de transferência", models.StockMovementRequest{Type: models.StockMovementSale, Quantity: 2, Actor: "ana", TargetStockID: uuid.NewString()}},
+	} {
+		_, err := database.StockMovementDelta(tc.req, 10)
+		check.That(errors.Is(err, database.ErrInvalidStockMovement), "%s rejeitado: %v", tc.name, err)
+	}
+
+	fmt.Println("\n=== TESTE 2: Endpoints ===")
+	partNameID := uuid.New()
+	source := newStock(partNameID, 10)
+	target := newStock(partNameID, 1)
+	repo := &fakeStocks{stocks: map[string]*models.Stock{source.ID.String(): source, target.ID.String(): target}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupStockRoutes(router.Group("/api/v1"), repo)
+	base := "/api/v1/stocks/" + source.ID.String()
+
+	w := request(router, http.MethodPost, base+"/movements", `{"type": "receipt", "quantity": 5, "reason": "NF 123"}`, "joao")
+	check.That(w.Code == http.StatusCreated && *source.Quantity == 15 && repo.movements[0].Actor == "joao",
+		"Entrada com responsável do cabeçalho X-User -> %d saldo %d", w.Code, *source.Quantity)
+
+	w = request(router, http.MethodPost, base+"/movements", `{"type": "sale", "quantity": 2}`, "")
+	check.That(w.Code == http.StatusBadRequest && *source.Quantity == 15, "Sem responsável -> %d", w.Code)
+
+	w = request(router, http.MethodPost, base+"/movements", `{"type": "sale", "quantity": 50, "actor": "maria"}`, "")
+	check.That(w.Code == http.StatusConflict && *source.Quantity == 15, "Venda acima do saldo -> %d", w.Code)
+
+	w = request(router, http.MethodPost, "/api/v1/stocks/"+uuid.NewString()+"/movements", `{"type": "sale", "quantity": 1, "actor": "maria"}`, "")
+	check.That(w.Code == http.StatusNotFound, "Estoque inexistente -> %d", w.Code)
+
+	body := fmt.Sprintf(`{"type": "transfer", "quantity": 4, "actor": "maria", "target_stock_id": %q}`, target.ID)
+	w = request(router, http.MethodPost, base+"/movements", body, "")
+	var created struct {
+		Movements []models.StockMovement `json:"movements"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	check.That(w.Code == http.StatusCreated && len(created.Movements) == 2 && *source.Quantity == 11 && *target.Quantity == 5,
+		"Transferência grava saída e entrada -> %d origem %d destino %d", w.Code, *source.Quantity, *target.Quantity)
+
+	fmt.Println("\n=== TESTE 3: Atualização da quantidade ===")
+	w = update(router, base, `{"quantity": 9, "actor": "ana"}`, source.Version)
+	check.That(w.Code == http.StatusBadRequest && *source.Quantity == 11, "Quantidade sem motivo -> %d", w.Code)
+
+	w = update(router, base, `{"quantity": 9, "price": 39.9, "actor": "ana", "reason": "inventário"}`, source.Version)
+	last := repo.movements[len(repo.movements)-1]
+	_, quantityUpdated := repo.updates["quantity"]
+	check.That(w.Code == http.StatusOK && *source.Quantity == 9 && last.Type == models.StockMovementAdjustment && last.Quantity == -2 && !quantityUpdated,
+		"Quantidade vira ajuste por contagem (%+d) e o preço é atualizado -> %d", last.Quantity, w.Code)
+
+	fmt.Println("\n=== TESTE 4: Histórico e conciliação ===")
+	w = request(router, http.MethodGet, base+"/movements", "", "")
+	var history models.StockMovementListResponse
+	json.Unmarshal(w.Body.Bytes(), &history)
+	check.That(w.Code == http.StatusOK && history.Total == 3 && history.Movements[0].Type == models.StockMovementAdjustment,
+		"Histórico do estoque, mais recente primeiro -> %d (%d movimentações)", w.Code, history.Total)
+	w = request(router, http.MethodGet, base+"/movements?type=transfer", "", "")
+	json.Unmarshal(w.Body.Bytes(), &history)
+	check.That(history.Total == 1 && history.Movements[0].Quantity == -4, "Filtro por tipo")
+
+	// A origem começou com 10 fora do livro: a soma do livro diverge da quantidade
+	w = request(router, http.MethodGet, base+"/reconciliation", "", "")
+	var result models.StockReconciliation
+	json.Unmarshal(w.Body.Bytes(), &result)
+	check.That(w.Code == http.StatusOK && result.Quantity == 9 && result.LedgerQuantity == -1 && result.Difference == 10,
+		"Conciliação aponta o saldo anterior ao livro: %+v", result)
+
+	check.Finish()
+}
+
+// update envia o PUT com o If-Match da versão atual, exigido na atualização do estoque
+func update(r http.Handler, url, body string, version int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", fmt.Sprintf(`"%d"`, version))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func request(r http.Handler, method, url, body, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
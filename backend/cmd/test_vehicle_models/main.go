@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/handlers"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/vehicledata"
+	"partexplorer/backend/internal/vehiclemodel"
+)
+
+// catalog imita as combinações de partexplorer.application
+var catalog = []vehiclemodel.Candidate{
+	{Manufacturer: "VOLKSWAGEN", Model: "GOL", Version: "G4", Engine: "1.0 8V"},
+	{Manufacturer: "VOLKSWAGEN", Model: "GOL", Version: "G5", Engine: "1.6 8V"},
+	{Manufacturer: "VOLKSWAGEN", Model: "GOLF", Version: "GTI", Engine: "2.0 16V"},
+	{Manufacturer: "VOLKSWAGEN", Model: "FOX", Engine: "1.0 8V"},
+	{Manufacturer: "CHEVROLET", Model: "ONIX", Engine: "1.0 8V"},
+	{Manufacturer: "CHEVROLET", Model: "ONIX PLUS", Engine: "1.0 12V"},
+	{Manufacturer: "CHEVROLET", Model: "CELTA", Engine: "1.0 8V"},
+	{Manufacturer: "RENAULT", Model: "CLIO", Version: "RL", Engine: "1.0 16V D4D"},
+	{Manufacturer: "RENAULT", Model: "SANDERO", Engine: "1.6 8V K7M"},
+	{Manufacturer: "FIAT", Model: "UNO", Engine: "1.0 8V FIRE"},
+	{Manufacturer: "FIAT", Model: "PALIO", Engine: "1.0 8V FIRE"},
+}
+
+// fakeVehicleModelRepository aplica vehiclemodel sobre o catálogo em memória
+type fakeVehicleModelRepository struct {
+	minConfidence float64
+	mappings      map[string]*models.VehicleModelMapping
+	bySource      map[string]*models.VehicleModelMapping
+}
+
+func newFakeRepository() *fakeVehicleModelRepository {
+	return &fakeVehicleModelRepository{
+		minConfidence: 0.8,
+		mappings:      make(map[string]*models.VehicleModelMapping),
+		bySource:      make(map[string]*models.VehicleModelMapping),
+	}
+}
+
+func (r *fakeVehicleModelRepository) Resolve(brand, model string) (*models.VehicleModelMapping, error) {
+	source := vehiclemodel.ParseSource(brand, model)
+	key := source.Brand + "|" + source.Model
+	if mapping, ok := r.bySource[key]; ok {
+		return mapping, nil
+	}
+	mapping := &models.VehicleModelMapping{ID: uuid.New(), SourceBrand: source.Brand, SourceModel: source.Model, Status: models.VehicleModelMappingPending}
+	if best, ok := vehiclemodel.Best(source, catalog); ok {
+		mapping.Manufacturer, mapping.Model, mapping.Version, mapping.Engine = best.Manufacturer, best.Model, best.Version, best.Engine
+		mapping.Confidence = best.Confidence
+		if best.Confidence >= r.minConfidence {
+			mapping.Status = models.VehicleModelMappingAuto
+		}
+	}
+	r.bySource[key] = mapping
+	r.mappings[mapping.ID.String()] = mapping
+	return mapping, nil
+}
+
+func (r *fakeVehicleModelRepository) GetByID(id string) (*models.VehicleModelMapping, error) {
+	if mapping, ok := r.mappings[id]; ok {
+		return mapping, nil
+	}
+	return nil, database.ErrVehicleModelMappingNotFound
+}
+
+func (r *fakeVehicleModelRepository) List(status string, page, pageSize int) (*models.VehicleModelMappingListResponse, error) {
+	response := &models.VehicleModelMappingListResponse{Page: page, PageSize: pageSize}
+	for _, mapping := range r.mappings {
+		if status == "" || mapping.Status == status {
+			response.Mappings = append(response.Mappings, *mapping)
+		}
+	}
+	response.Total = int64(len(response.Mappings))
+	return response, nil
+}
+
+func (r *fakeVehicleModelRepository) Candidates(id string, limit int) ([]vehiclemodel.Match, error) {
+	mapping, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return vehiclemodel.Rank(vehiclemodel.ParseSource(mapping.SourceBrand, mapping.SourceModel), catalog, limit), nil
+}
+
+func (r *fakeVehicleModelRepository) Confirm(id string) (*models.VehicleModelMapping, error) {
+	mapping, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Model == "" {
+		return nil, database.ErrVehicleModelNothingToConfirm
+	}
+	mapping.Status = models.VehicleModelMappingConfirmed
+	return mapping, nil
+}
+
+func (r *fakeVehicleModelRepository) Correct(id string, target vehiclemodel.Candidate) (*models.VehicleModelMapping, error) {
+	mapping, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range catalog {
+		if strings.EqualFold(candidate.Manufacturer, target.Manufacturer) && strings.EqualFold(candidate.Model, target.Model) {
+			mapping.Manufacturer, mapping.Model = candidate.Manufacturer, candidate.Model
+			mapping.Status = models.VehicleModelMappingCorrected
+			mapping.Confidence = 1
+			return mapping, nil
+		}
+	}
+	return nil, database.ErrVehicleModelUnknownApplication
+}
+
+// fakePartRepository registra a última busca por aplicação; os demais métodos não são usados
+type fakePartRepository struct {
+	database.PartRepository
+	manufacturer, model, year string
+}
+
+func (r *fakePartRepository) SearchPartsByApplication(manufacturer, model, year string, page, pageSize int) (*models.SearchResponse, error) {
+	r.manufacturer, r.model, r.year = manufacturer, model, year
+	return &models.SearchResponse{Page: page, PageSize: pageSize}, nil
+}
+
+// fixedResolver responde sempre o mesmo veículo
+type fixedResolver struct {
+	info models.CarInfo
+}
+
+func (r *fixedResolver) SearchCarByPlate(value string) (*models.CarInfo, error) {
+	info := r.info
+	info.Placa = value
+	return &info, nil
+}
+
+func main() {
+	fmt.Println("🧪 Testando o mapeamento de modelos dos provedores de placa para o catálogo...")
+
+	fmt.Println("\n=== TESTE 1: Leitura de marca/modelo do provedor ===")
+	for _, tc := range []struct {
+		brand, model, manufacturer, guess string
+	}{
+		{"", "VW/GOL 1.0 GIV", "VOLKSWAGEN", "GOL"},
+		{"VW", "GOL 1.0 GIV", "VOLKSWAGEN", "GOL"},
+		{"CHEVROLET", "CHEV ONIX 1.0", "CHEVROLET", "ONIX"},
+		{"GM", "GM/CELTA 1.0", "CHEVROLET", "CELTA"},
+		{"", "I/VW GOLF GTI", "VOLKSWAGEN", "GOLF"},
+		{"Renault", "clio exp 10 16vh", "RENAULT", "CLIO"},
+		{"CITROËN", "C3 1.6", "CITROEN", "C3"},
+	} {
+		source := vehiclemodel.ParseSource(tc.brand, tc.model)
+		check.That(source.Manufacturer == tc.manufacturer && source.ModelGuess() == tc.guess,
+			"%q / %q -> %s %s %v", tc.brand, tc.model, source.Manufacturer, source.ModelGuess(), source.Tokens)
+	}
+
+	fmt.Println("\n=== TESTE 2: Correspondência aproximada ===")
+	for _, tc := range []struct {
+		brand, model    string
+		wantModel       string
+		wantEngine      string
+		confident       bool
+		describeContext string
+	}{
+		{"", "VW/GOL 1.0 GIV", "GOL", "1.0 8V", true, "cilindrada escolhe o motor"},
+		{"VW", "GOL 1.6 POWER", "GOL", "1.6 8V", true, "outro motor do mesmo modelo"},
+		{"CHEVROLET", "CHEV ONIX 1.0", "ONIX", "1.0 8V", true, "CHEV repetido no modelo"},
+		{"CHEVROLET", "ONIX PLUS 1.0 TURBO", "ONIX PLUS", "1.0 12V", true, "modelo mais específico"},
+		{"RENAULT", "CLIO EXP 10 16VH", "CLIO", "1.0 16V D4D", true, "modelo sem cilindrada legível"},
+		{"RENAULT", "SANDER0 1.6", "SANDERO", "1.6 8V K7M", false, "erro de digitação vai para revisão"},
+		{"FIAT", "UNO 1.6 MPI", "UNO", "1.0 8V FIRE", false, "motor divergente vai para revisão"},
+	} {
+		best, ok := vehiclemodel.Best(vehiclemodel.ParseSource(tc.brand, tc.model), catalog)
+		check.That(ok && best.Model == tc.wantModel && best.Engine == tc.wantEngine && (best.Confidence >= 0.8) == tc.confident,
+			"%s: %q -> %s %s (%.3f)", tc.describeContext, tc.model, best.Model, best.Engine, best.Confidence)
+	}
+	_, ok := vehiclemodel.Best(vehiclemodel.ParseSource("FIAT", "TORO 2.0"), catalog)
+	check.That(!ok, "Modelo fora do catálogo não tem candidato")
+	_, ok = vehiclemodel.Best(vehiclemodel.ParseSource("FORD", "GOL 1.0"), catalog)
+	check.That(!ok, "Candidatos de outro fabricante são ignorados")
+	ranked := vehiclemodel.Rank(vehiclemodel.ParseSource("VW", "GOL 1.0"), catalog, 3)
+	check.That(len(ranked) == 3 && ranked[0].Model == "GOL" && ranked[0].Confidence >= ranked[1].Confidence && ranked[1].Confidence >= ranked[2].Confidence,
+		"Rank ordenado por confiança e limitado: %v", ranked)
+
+	fmt.Println("\n=== TESTE 3: Aplicação para a busca por placa ===")
+	repo := newFakeRepository()
+	application := database.ResolveVehicleApplication(repo, &models.CarInfo{Marca: "CHEVROLET", Modelo: "CHEV ONIX 1.0", Ano: "2014", AnoModelo: "2015"})
+	check.That(application.Manufacturer == "CHEVROLET" && application.Model == "ONIX" && application.Year == "2015" && application.Mapping.Status == models.VehicleModelMappingAuto,
+		"Mapeamento automático: %s %s %s (%s)", application.Manufacturer, application.Model, application.Year, application.Mapping.Status)
+	application = database.ResolveVehicleApplication(repo, &models.CarInfo{Marca: "RENAULT", Modelo: "SANDER0 1.6", Ano: "2012"})
+	check.That(application.Model == "SANDER0" && application.Year == "2012" && application.Mapping.Status == models.VehicleModelMappingPending,
+		"Em revisão usa o palpite sem catálogo e o ano de fabricação: %s %s (%s)", application.Model, application.Year, application.Mapping.Status)
+	application = database.ResolveVehicleApplication(nil, &models.CarInfo{Marca: "VW", Modelo: "VW/GOL 1.0 GIV", AnoModelo: "2009"})
+	check.That(application.Manufacturer == "VOLKSWAGEN" && application.Model == "GOL" && application.Mapping == nil, "Sem repositório: %s %s", application.Manufacturer, application.Model)
+
+	fmt.Println("\n=== TESTE 4: Busca por placa com o mapeamento ===")
+	gin.SetMode(gin.TestMode)
+	parts := &fakePartRepository{}
+	lookups := vehicledata.NewLookupManager(&fixedResolver{info: models.CarInfo{Marca: "VW", Modelo: "VW/GOL 1.0 GIV", Ano: "2008", AnoModelo: "2009"}})
+	r := gin.New()
+	r.GET("/api/v1/plate-search/:plate", handlers.NewPlateSearchHandler(parts, lookups, repo).SearchByPlate)
+	w := request(r, http.MethodGet, "/api/v1/plate-search/ABC1234", nil)
+	var searchBody struct {
+		Data struct {
+			ModelMapping *models.VehicleModelMapping `json:"model_mapping"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &searchBody)
+	check.That(w.Code == http.StatusOK && parts.manufacturer == "VOLKSWAGEN" && parts.model == "GOL" && parts.year == "2009",
+		"Busca por aplicação: %q %q %q", parts.manufacturer, parts.model, parts.year)
+	check.That(searchBody.Data.ModelMapping != nil && searchBody.Data.ModelMapping.Engine == "1.0 8V", "Resposta inclui o mapeamento usado")
+
+	fmt.Println("\n=== TESTE 5: Fila de revisão ===")
+	routes := gin.New()
+	vehicleModelHandler := handlers.NewVehicleModelHandler(repo)
+	routes.GET("/api/v1/vehicle-models/", vehicleModelHandler.ListMappings)
+	routes.GET("/api/v1/vehicle-models/:id", vehicleModelHandler.GetMapping)
+	routes.POST("/api/v1/vehicle-models/:id/confirm", vehicleModelHandler.ConfirmMapping)
+	routes.PUT("/api/v1/vehicle-models/:id", vehicleModelHandler.CorrectMapping)
+
+	var list models.VehicleModelMappingListResponse
+	w = request(routes, http.MethodGet, "/api/v1/vehicle-models/", nil)
+	json.Unmarshal(w.Body.Bytes(), &list)
+	check.That(w.Code == http.StatusOK && list.Total == 1 && list.Mappings[0].SourceModel == "SANDER0 1.6", "Fila padrão só com pendentes: %d", list.Total)
+	w = request(routes, http.MethodGet, "/api/v1/vehicle-models/?status=all", nil)
+	json.Unmarshal(w.Body.Bytes(), &list)
+	check.That(list.Total == 3, "status=all lista todos: %d", list.Total)
+
+	pending, _ := repo.Resolve("RENAULT", "SANDER0 1.6")
+	w = request(routes, http.MethodGet, "/api/v1/vehicle-models/"+pending.ID.String(), nil)
+	var detail struct {
+		Candidates []vehiclemodel.Match `json:"candidates"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &detail)
+	check.That(w.Code == http.StatusOK && len(detail.Candidates) > 0 && detail.Candidates[0].Model == "SANDERO", "Detalhe com candidatos: %v", detail.Candidates)
+
+	w = request(routes, http.MethodPost, "/api/v1/vehicle-models/"+pending.ID.String()+"/confirm", nil)
+	check.That(w.Code == http.StatusOK && pending.Status == models.VehicleModelMappingConfirmed, "Confirmar palpite -> %d %s", w.Code, pending.Status)
+	application = database.ResolveVehicleApplication(repo, &models.CarInfo{Marca: "RENAULT", Modelo: "SANDER0 1.6", AnoModelo: "2012"})
+	check.That(application.Model == "SANDERO", "Depois de confirmado, a busca usa o catálogo: %s", application.Model)
+
+	unknown, _ := repo.Resolve("FIAT", "TORO 2.0")
+	w = request(routes, http.MethodPost, "/api/v1/vehicle-models/"+unknown.ID.String()+"/confirm", nil)
+	check.That(w.Code == http.StatusConflict, "Sem palpite não há o que confirmar -> %d", w.Code)
+	w = request(routes, http.MethodPut, "/api/v1/vehicle-models/"+unknown.ID.String(), map[string]string{"manufacturer": "FIAT", "model": "TORO"})
+	check.That(w.Code == http.StatusUnprocessableEntity, "Correção para aplicação inexistente -> %d", w.Code)
+	w = request(routes, http.MethodPut, "/api/v1/vehicle-models/"+unknown.ID.String(), map[string]string{"manufacturer": "fiat"})
+	check.That(w.Code == http.StatusBadRequest, "Correção sem modelo -> %d", w.Code)
+	w = request(routes, http.MethodPut, "/api/v1/vehicle-models/"+unknown.ID.String(), map[string]string{"manufacturer": "fiat", "model": "palio"})
+	check.That(w.Code == http.StatusOK && unknown.Model == "PALIO" && unknown.Status == models.VehicleModelMappingCorrected, "Correção -> %d %s %s", w.Code, unknown.Model, unknown.Status)
+	w = request(routes, http.MethodGet, "/api/v1/vehicle-models/"+uuid.New().String(), nil)
+	check.That(w.Code == http.StatusNotFound, "Mapeamento inexistente -> %d", w.Code)
+
+	check.Finish()
+}
+
+func request(r http.Handler, method, url string, body interface{}) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&payload).Encode(body)
+	}
+	req := httptest.NewRequest(method, url, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
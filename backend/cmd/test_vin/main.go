@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"partexplorer/backend/internal/check"
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/handlers"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/vin"
+)
+
+// fakeCarRepository guarda os carros em memória, indexados pelo chassi
+type fakeCarRepository struct {
+	byChassis map[string]*models.Car
+}
+
+func (r *fakeCarRepository) GetCarByPlate(string) (*models.Car, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeCarRepository) GetCarByChassis(value string) (*models.Car, error) {
+	if car, ok := r.byChassis[value]; ok {
+		return car, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeCarRepository) SaveCar(*models.Car) error           { return nil }
+func (r *fakeCarRepository) SaveCarError(*models.CarError) error { return nil }
+func (r *fakeCarRepository) SearchCarByPlate(string) (*models.CarInfo, error) {
+	return nil, errors.New("not used")
+}
+func (r *fakeCarRepository) RefreshCarByPlate(string) (*models.CarInfo, error) {
+	return nil, errors.New("not used")
+}
+func (r *fakeCarRepository) StalePlates(time.Time, int) ([]string, error) { return nil, nil }
+func (r *fakeCarRepository) RetryCarError(string) (*models.CarError, error) {
+	return nil, errors.New("not used")
+}
+
+// fakePartRepository registra a última busca por aplicação; os demais métodos não são usados
+type fakePartRepository struct {
+	database.PartRepository
+	manufacturer, model, year string
+}
+
+func (r *fakePartRepository) SearchPartsByApplication(manufacturer, model, year string, page, pageSize int) (*models.SearchResponse, error) {
+	r.manufacturer, r.model, r.year = manufacturer, model, year
+	return &models.SearchResponse{Page: page, PageSize: pageSize}, nil
+}
+
+func main() {
+	fmt.Println("🧪 Testando a decodificação local de chassi (VIN)...")
+	ref := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	fmt.Println("\n=== TESTE 1: Dígito verificador ===")
+	for input, want := range map[string]byte{
+		"1M8GDM9AXKP042788": 'X',
+		"11111111111111111": '1',
+		"1HGCM82633A004352": '3',
+	} {
+		got := vin.CheckDigit(input)
+		check.That(got == want, "%s -> %c (esperado %c)", input, got, want)
+	}
+	info, err := vin.DecodeAt("1M8GDM9AXKP042788", ref)
+	check.That(err == nil && info.CheckDigitValid, "VIN com dígito correto é marcado como válido")
+	info, err = vin.DecodeAt("1M8GDM9A1KP042788", ref)
+	check.That(err == nil && !info.CheckDigitValid && info.CheckDigit == "1", "Dígito errado não rejeita o VIN, só marca (err=%v)", err)
+
+	fmt.Println("\n=== TESTE 2: Formato ===")
+	for _, input := range []string{"", "9BWZZZ377VT00425", "9BWZZZ377VT0042511", "9BWZZZ377VT00425I", "9BWZZZ377VO004251", "QBWZZZ377VT004251"} {
+		_, err := vin.Decode(input)
+		check.That(errors.Is(err, vin.ErrInvalid), "%q rejeitado", input)
+	}
+	info, err = vin.DecodeAt(" 9bw-zzz377-vt004251 ", ref)
+	check.That(err == nil && info.VIN == "9BWZZZ377VT004251", "Normaliza minúsculas e separadores: %s", info.VIN)
+	check.That(info.WMI == "9BW" && info.VDS == "ZZZ377" && info.VIS == "VT004251" && info.SerialNumber == "004251",
+		"Divide em WMI/VDS/VIS: %s %s %s", info.WMI, info.VDS, info.VIS)
+
+	fmt.Println("\n=== TESTE 3: Fabricante, país e fábrica ===")
+	for input, want := range map[string][2]string{
+		"9BWZZZ377VT004251": {"VOLKSWAGEN", "Brasil"},
+		"9BD15822786012345": {"FIAT", "Brasil"},
+		"9BGKS48U0BG123456": {"CHEVROLET", "Brasil"},
+		"93HGE6850AZ123456": {"HONDA", "Brasil"},
+		"8AP37123456789012": {"FIAT", "Argentina"},
+		"3VWFE21C04M000001": {"VOLKSWAGEN", "México"},
+		"JTDKB20U793123456": {"TOYOTA", "Japão"},
+		"WBA3A5C58CF256789": {"BMW", "Alemanha"},
+		"VF1RFB00X56789012": {"RENAULT", "França"},
+		"KMHCT41DAFU123456": {"HYUNDAI", "Coreia do Sul"},
+	} {
+		info, err := vin.DecodeAt(input, ref)
+		check.That(err == nil && info.Manufacturer == want[0] && info.Country == want[1], "%s -> %s (%s)", input, info.Manufacturer, info.Country)
+	}
+	info, _ = vin.DecodeAt("9BGKS48U0BG123456", ref)
+	check.That(info.PlantCode == "G" && info.Plant == "Gravataí (RS)", "Fábrica GM pela 11ª posição: %s", info.Plant)
+	info, _ = vin.DecodeAt("9BWZZZ377VT004251", ref)
+	check.That(info.Plant == "Taubaté (SP)", "Fábrica VW pela 11ª posição: %s", info.Plant)
+	info, _ = vin.DecodeAt("ZZZZZZ377VT004251", ref)
+	check.That(info.Manufacturer == "" && info.Country == "", "WMI desconhecido decodifica sem fabricante")
+
+	fmt.Println("\n=== TESTE 4: Ano-modelo pela 10ª posição ===")
+	for code, want := range map[byte]int{'A': 2010, 'H': 2017, 'Y': 2000, '1': 2001, '9': 2009, 'T': 2026, 'V': 2027, 'W': 1998} {
+		input := "9BWZZZ377" + string(code) + "T004251"
+		info, err := vin.DecodeAt(input, ref)
+		check.That(err == nil && info.ModelYear == want, "Código %c -> %d %v", code, info.ModelYear, info.YearCandidates)
+	}
+	info, _ = vin.DecodeAt("9BWZZZ377VT004251", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	check.That(info.ModelYear == 1997, "Em 2020, V ainda é 1997 (%d)", info.ModelYear)
+	info, _ = vin.DecodeAt("9BWZZZ3770T004251", ref)
+	check.That(info.ModelYear == 0 && len(info.YearCandidates) == 0, "Código 0 não é ano-modelo")
+
+	fmt.Println("\n=== TESTE 5: Endpoint /api/v1/cars/vin/:vin ===")
+	gin.SetMode(gin.TestMode)
+	cars := &fakeCarRepository{byChassis: map[string]*models.Car{
+		"9BGKS48U0BG123456": {LicensePlate: "ABC1234", Brand: "CHEVROLET", Model: "CHEV ONIX 1.0", ModelYear: 2011},
+	}}
+	parts := &fakePartRepository{}
+	r := gin.New()
+	r.GET("/api/v1/cars/vin/:vin", handlers.NewCarHandler(cars, nil).DecodeVIN)
+	r.GET("/api/v1/vin-search/:vin", handlers.NewPlateSearchHandler(parts, nil, nil).SearchByVIN)
+
+	var body struct {
+		Success bool                   `json:"success"`
+		Data    vin.Info               `json:"data"`
+		Car     map[string]interface{} `json:"car"`
+	}
+	w := get(r, "/api/v1/cars/vin/9bgks48u0bg123456")
+	json.Unmarshal(w.Body.Bytes(), &body)
+	check.That(w.Code == http.StatusOK && body.Data.Manufacturer == "CHEVROLET" && body.Data.ModelYear == 2011, "200 com fabricante e ano: %s %d", body.Data.Manufacturer, body.Data.ModelYear)
+	check.That(body.Car["license_plate"] == "ABC1234" && body.Car["model"] == "CHEV ONIX 1.0", "Veículo do cache encontrado pelo chassi: %v", body.Car)
+
+	body.Car = nil
+	w = get(r, "/api/v1/cars/vin/9BWZZZ377VT004251")
+	json.Unmarshal(w.Body.Bytes(), &body)
+	check.That(w.Code == http.StatusOK && body.Car == nil, "Sem veículo no cache, só a decodificação")
+	w = get(r, "/api/v1/cars/vin/9BWZZZ377VT00425O")
+	check.That(w.Code == http.StatusBadRequest, "VIN inválido -> %d", w.Code)
+
+	fmt.Println("\n=== TESTE 6: Busca de peças por chassi ===")
+	w = get(r, "/api/v1/vin-search/9BGKS48U0BG123456")
+	check.That(w.Code == http.StatusOK && parts.manufacturer == "CHEVROLET" && parts.model == "" && parts.year == "2011",
+		"Busca por aplicação com fabricante e ano do VIN: %q %q %q", parts.manufacturer, parts.model, parts.year)
+	w = get(r, "/api/v1/vin-search/9BGKS48U0BG123456?model=ONIX&year=2012")
+	check.That(w.Code == http.StatusOK && parts.model == "ONIX" && parts.year == "2012", "Modelo e ano informados restringem a busca: %q %q", parts.model, parts.year)
+	w = get(r, "/api/v1/vin-search/ZZZZZZ377VT004251")
+	check.That(w.Code == http.StatusUnprocessableEntity, "Fabricante desconhecido -> %d", w.Code)
+	w = get(r, "/api/v1/vin-search/123")
+	check.That(w.Code == http.StatusBadRequest, "VIN inválido -> %d", w.Code)
+
+	check.Finish()
+}
+
+func get(r http.Handler, url string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	return w
+}
@@ -1,16 +1,21 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"partexplorer/backend/internal/cache"
 	"partexplorer/backend/internal/database"
 	"partexplorer/backend/internal/elasticsearch"
 	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/partcode"
+	"partexplorer/backend/internal/search"
+	"partexplorer/backend/internal/vin"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,16 +24,30 @@ import (
 type Handler struct {
 	repo          database.PartRepository
 	indexer       *elasticsearch.IndexerService
+	reindexer     *elasticsearch.ReindexManager
 	searchService *elasticsearch.SearchService
+	orchestrator  *search.Orchestrator
+	suggestions   database.SuggestionRepository
 	cacheService  *cache.SearchCacheService
 }
 
 // NewHandler cria uma nova instância do handler
 func NewHandler(repo database.PartRepository) *Handler {
+	searchService := elasticsearch.NewSearchService()
+	indexer := elasticsearch.NewIndexerService()
+
+	var suggestions database.SuggestionRepository
+	if db := database.GetDB(); db != nil {
+		suggestions = database.NewSuggestionRepository(db)
+	}
+
 	return &Handler{
 		repo:          repo,
-		indexer:       elasticsearch.NewIndexerService(),
-		searchService: elasticsearch.NewSearchService(),
+		indexer:       indexer,
+		reindexer:     elasticsearch.NewReindexManager(repo, indexer),
+		searchService: searchService,
+		orchestrator:  search.NewOrchestrator(repo, suggestions, searchService),
+		suggestions:   suggestions,
 		cacheService:  cache.NewSearchCacheService(),
 	}
 }
@@ -78,7 +97,6 @@ func (h *Handler) SearchParts(c *gin.Context) {
 	searchMode := c.Query("searchMode") // Novo parâmetro para identificar o modo
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	autocomplete := c.DefaultQuery("autocomplete", "false") == "true"
 
 	// Lógica para "Onde encontrar" - modo find
 	if searchMode == "find" {
@@ -180,30 +198,23 @@ func (h *Handler) SearchParts(c *gin.Context) {
 		}
 	*/
 
-	// Cache miss - buscar dados
-	var results *models.SearchResponse
-	var err error
+	// Facets selecionados (?brand=X&brand=Y&family=Z&facet_state=SP)
+	filters := models.ParseSearchFilters(c.Request.URL.Query())
 
-	if autocomplete {
-		// Usar busca SQL direta (mais confiável)
-		results, err = h.repo.SearchPartsSQL(query, page, pageSize)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to search parts",
-				"details": err.Error(),
-			})
-			return
-		}
-	} else {
-		// Usar busca SQL direta (mais confiável)
-		results, err = h.repo.SearchPartsSQL(query, page, pageSize)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to search parts",
-				"details": err.Error(),
-			})
-			return
-		}
+	// Cache miss - buscar dados (Elasticsearch com fallback para SQL)
+	results, engine, err := h.orchestrator.SearchParts(query, filters, page, pageSize)
+	c.Header(search.EngineHeader, string(engine))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to search parts",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Buscas com resultado alimentam a popularidade do autocomplete
+	if query != "" && page <= 1 && results.Total > 0 {
+		h.recordSearch(query, state, filters)
 	}
 
 	// Converter para modelo limpo (sem IDs, timestamps, score)
@@ -218,13 +229,89 @@ func (h *Handler) SearchParts(c *gin.Context) {
 		fmt.Printf("DEBUG: Nenhum resultado encontrado para a busca\n")
 	}
 
-	// Armazenar no cache (15 minutos)
-	h.cacheService.SetCachedSearch(query, page, pageSize, results, 15*time.Minute)
+	// Armazenar no cache (15 minutos); a chave não inclui os facets
+	if filters.IsEmpty() {
+		h.cacheService.SetCachedSearch(query, page, pageSize, results, 15*time.Minute)
+	}
 
 	c.Header("X-Cache", "MISS")
 	c.JSON(http.StatusOK, cleanResults)
 }
 
+// recordSearch registra a busca em segundo plano, com o estado informado ou o único estado filtrado
+func (h *Handler) recordSearch(query, state string, filters models.SearchFilters) {
+	if h.suggestions == nil {
+		return
+	}
+	if state == "" && len(filters[models.FacetState]) == 1 {
+		state = filters[models.FacetState][0]
+	}
+
+	go func() {
+		if err := h.suggestions.RecordSearch(query, state); err != nil {
+			log.Printf("⚠️ [SUGGEST] Erro ao registrar busca: %v", err)
+		}
+	}()
+}
+
+// SearchFitment busca peças compatíveis com um veículo (fabricante, modelo, ano, motor e combustível).
+// Com o parâmetro vin, fabricante e ano vêm da decodificação local do chassi.
+func (h *Handler) SearchFitment(c *gin.Context) {
+	var query models.FitmentQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid fitment parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if query.VIN != "" {
+		info, err := vin.Decode(query.VIN)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid VIN",
+				"details": err.Error(),
+			})
+			return
+		}
+		if query.Manufacturer == "" {
+			query.Manufacturer = info.Manufacturer
+		}
+		if query.Year == 0 {
+			query.Year = info.ModelYear
+		}
+		if query.Manufacturer == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "manufacturer not recognized for VIN " + info.WMI + ", inform manufacturer",
+			})
+			return
+		}
+	}
+
+	if query.Manufacturer == "" && query.Model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "manufacturer or model is required",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	results, engine, err := h.orchestrator.SearchFitment(query, page, pageSize)
+	c.Header(search.EngineHeader, string(engine))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to search parts by fitment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToCleanSearchResponse(results))
+}
+
 // SearchPartsSQL busca peças usando SQL direto
 func (h *Handler) SearchPartsSQL(c *gin.Context) {
 	query := c.Query("q")
@@ -294,10 +381,10 @@ func (h *Handler) GetSuggestions(c *gin.Context) {
 		// Buscar em family
 		var familySuggestions []string
 		err = db.Raw(`
-			SELECT DISTINCT name 
-			FROM partexplorer.family 
-			WHERE LOWER(name) LIKE LOWER(?) 
-			ORDER BY name 
+			SELECT DISTINCT description
+			FROM partexplorer.family
+			WHERE LOWER(description) LIKE LOWER(?)
+			ORDER BY description
 			LIMIT 5
 		`, "%"+query+"%").Scan(&familySuggestions).Error
 
@@ -309,49 +396,113 @@ func (h *Handler) GetSuggestions(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
 }
 
-// IndexAllParts indexa todos os dados no Elasticsearch
-func (h *Handler) IndexAllParts(c *gin.Context) {
-	// Buscar todos os grupos de peças do PostgreSQL
-	// Por enquanto, vamos buscar apenas alguns para teste
-	results, err := h.repo.SearchParts("", 1, 1000, false, "") // Buscar todos
+// Autocomplete retorna sugestões tipadas (sku, brand, product_type, vehicle_model) para o
+// prefixo digitado, ordenadas por popularidade e opcionalmente restritas a um estado
+func (h *Handler) Autocomplete(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if len([]rune(query)) < 2 {
+		c.JSON(http.StatusOK, gin.H{"query": query, "suggestions": []models.Suggestion{}})
+		return
+	}
+
+	types := models.SuggestionTypes
+	if param := c.Query("types"); param != "" {
+		types = nil
+		for _, kind := range strings.Split(param, ",") {
+			kind = strings.TrimSpace(kind)
+			if !isSuggestionType(kind) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "Invalid suggestion type",
+					"details": fmt.Sprintf("%q is not one of %s", kind, strings.Join(models.SuggestionTypes, ", ")),
+				})
+				return
+			}
+			types = append(types, kind)
+		}
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	suggestions, engine, err := h.orchestrator.Suggest(query, types, c.Query("state"), limit)
+	c.Header(search.EngineHeader, string(engine))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch parts from database",
+			"error":   "Failed to fetch suggestions",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	// Converter para PartGroup
-	partGroups := make([]models.PartGroup, len(results.Results))
-	for i, result := range results.Results {
-		partGroups[i] = result.PartGroup
+	c.JSON(http.StatusOK, gin.H{
+		"query":       query,
+		"suggestions": suggestions,
+	})
+}
+
+// isSuggestionType verifica se o tipo de sugestão é conhecido
+func isSuggestionType(kind string) bool {
+	for _, known := range models.SuggestionTypes {
+		if kind == known {
+			return true
+		}
 	}
+	return false
+}
 
-	// Indexar no Elasticsearch
-	err = h.indexer.IndexAllPartGroups(partGroups)
+// IndexAllParts dispara a reindexação completa do catálogo em background
+func (h *Handler) IndexAllParts(c *gin.Context) {
+	job, err := h.reindexer.Start()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to index parts",
+		status := http.StatusInternalServerError
+		if errors.Is(err, elasticsearch.ErrReindexRunning) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to start reindex job",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	// Refresh do índice
-	err = h.indexer.RefreshIndex()
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListIndexJobs lista os jobs de reindexação
+func (h *Handler) ListIndexJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"jobs": h.reindexer.List(),
+	})
+}
+
+// GetIndexJob retorna o progresso de um job de reindexação
+func (h *Handler) GetIndexJob(c *gin.Context) {
+	job, err := h.reindexer.Get(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to refresh index",
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Reindex job not found",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Successfully indexed parts",
-		"total":   len(partGroups),
-	})
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelIndexJob cancela um job de reindexação em andamento
+func (h *Handler) CancelIndexJob(c *gin.Context) {
+	job, err := h.reindexer.Cancel(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Reindex job not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
 }
 
 // GetIndexStats retorna estatísticas do índice
@@ -555,6 +706,59 @@ func (h *Handler) GetPartBySKU(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// GetCrossReference endpoint que lista os códigos equivalentes (SKUs e EANs do mesmo grupo)
+func (h *Handler) GetCrossReference(c *gin.Context) {
+	code := strings.TrimSpace(c.Param("code"))
+
+	candidates := partcode.Candidates(code)
+	if len(candidates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Code must contain letters or digits",
+		})
+		return
+	}
+
+	response := models.CrossReferenceResponse{
+		Code:           code,
+		NormalizedCode: candidates[0],
+	}
+
+	// Códigos numéricos com tamanho de GTIN são validados, mas a busca segue
+	// mesmo com dígito inválido, pois podem ser SKUs numéricos
+	if partcode.LooksLikeGTIN(code) {
+		format, err := partcode.ValidateGTIN(code)
+		response.GTIN = &models.GTINValidation{
+			Format:     format,
+			Normalized: partcode.NormalizeGTIN(code),
+			Valid:      err == nil,
+		}
+		if err != nil {
+			response.GTIN.Error = err.Error()
+		}
+	}
+
+	groups, err := h.repo.GetCrossReferences(code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load cross references",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if len(groups) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Code not found",
+			"code":  code,
+			"gtin":  response.GTIN,
+		})
+		return
+	}
+
+	response.Groups = groups
+	c.JSON(http.StatusOK, response)
+}
+
 // GetDuplicateSKUs endpoint para verificar SKUs duplicados
 func (h *Handler) GetDuplicateSKUs(c *gin.Context) {
 	duplicates, err := h.repo.GetDuplicateSKUs()
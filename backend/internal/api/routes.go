@@ -8,13 +8,15 @@ import (
 	"partexplorer/backend/internal/database"
 	"partexplorer/backend/internal/handlers"
 	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/vehicledata"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// SetupRoutes configura as rotas da API
-func SetupRoutes(r *gin.Engine, repo database.PartRepository, carRepo database.CarRepository) {
+// SetupRoutes configura as rotas da API. plateLookups é o gerenciador de consultas de placa
+// do servidor, para que as rotas compartilhem a deduplicação e o pool do Chrome.
+func SetupRoutes(r *gin.Engine, repo database.PartRepository, carRepo database.CarRepository, plateLookups *vehicledata.LookupManager) {
 	api := r.Group("/api/v1")
 
 	// Rota de busca principal
@@ -228,8 +230,8 @@ func SetupRoutes(r *gin.Engine, repo database.PartRepository, carRepo database.C
 	// ROTAS DE CARROS
 	// ========================================
 
-	// Criar handler de carros
-	carHandler := handlers.NewCarHandler(carRepo)
+	// Criar handler de carros; as consultas de placa rodam em jobs compartilhados
+	carHandler := handlers.NewCarHandler(carRepo, plateLookups)
 
 	// Rota para buscar informações de veículo por placa (com cache)
 	api.GET("/cars/search/:plate", carHandler.SearchCarByPlate)
@@ -237,17 +239,42 @@ func SetupRoutes(r *gin.Engine, repo database.PartRepository, carRepo database.C
 	// Rota para buscar veículo no cache apenas
 	api.GET("/cars/cache/:plate", carHandler.GetCarByPlate)
 
+	// Rota para decodificar o chassi (VIN) localmente
+	api.GET("/cars/vin/:vin", carHandler.DecodeVIN)
+
+	// Rotas de consulta de placa em background
+	api.POST("/cars/lookup", carHandler.StartLookup)
+	api.GET("/cars/lookup/:id", carHandler.GetLookup)
+	api.GET("/cars/lookup/:id/events", carHandler.StreamLookup)
+
 	// Rota de health check do serviço de carros
 	api.GET("/cars/health", carHandler.HealthCheck)
 
+	// Rotas de revisão das consultas de placa que falharam (car_error)
+	carErrorHandler := handlers.NewCarErrorHandler(database.NewCarErrorRepository(database.GetDB()), carRepo)
+	api.GET("/cars/errors", carErrorHandler.ListErrors)
+	api.GET("/cars/errors/summary", carErrorHandler.GetSummary)
+	api.GET("/cars/errors/:plate", carErrorHandler.GetError)
+	api.POST("/cars/errors/:plate/retry", carErrorHandler.RetryError)
+	api.DELETE("/cars/errors/:plate", carErrorHandler.DiscardError)
+
+	// Rotas da tabela FIPE e da curva de preço do veículo da placa
+	fipeHandler := handlers.NewFipeHandler(database.NewFipeRepository(database.GetDB()), carRepo)
+	api.GET("/cars/fipe/:plate", fipeHandler.GetCarPriceCurve)
+	api.GET("/fipe/:code", fipeHandler.GetVehicle)
+	api.GET("/fipe/:code/prices", fipeHandler.GetPriceCurve)
+
 	// ========================================
 	// ROTAS DE BUSCA POR PLACA
 	// ========================================
 
 	// Criar handler de busca por placa
-	plateSearchHandler := handlers.NewPlateSearchHandler(repo, carRepo)
+	plateSearchHandler := handlers.NewPlateSearchHandler(repo, plateLookups, database.NewVehicleModelRepository(database.GetDB()))
 
 	// Rota para buscar peças por placa
 	api.GET("/plate-search/:plate", plateSearchHandler.SearchByPlate)
 
+	// Rota para buscar peças pelo chassi (VIN)
+	api.GET("/vin-search/:vin", plateSearchHandler.SearchByVIN)
+
 }
@@ -0,0 +1,28 @@
+package check
+
+import (
+	"fmt"
+	"os"
+)
+
+// failures conta as verificações que falharam no programa de teste
+var failures int
+
+// That registra o resultado de uma verificação dos programas cmd/test_* e cmd/bench_loader
+func That(ok bool, format string, args ...interface{}) {
+	if ok {
+		fmt.Printf("✅ "+format+"\n", args...)
+		return
+	}
+	failures++
+	fmt.Printf("❌ "+format+"\n", args...)
+}
+
+// Finish imprime o resumo e encerra com código 1 se alguma verificação falhou
+func Finish() {
+	if failures > 0 {
+		fmt.Printf("\n=== %d VERIFICAÇÕES FALHARAM ===\n", failures)
+		os.Exit(1)
+	}
+	fmt.Println("\n=== TESTES CONCLUÍDOS ===")
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/plate"
+	"partexplorer/backend/internal/vehicledata"
+)
+
+var (
+	// ErrCarErrorNotFound indica que não há entrada na car_error para a placa
+	ErrCarErrorNotFound = errors.New("car error not found")
+	// ErrCarErrorClosed indica que a entrada já foi resolvida ou descartada
+	ErrCarErrorClosed = errors.New("car error already resolved or discarded")
+
+	// errCarSave marca as falhas ao gravar o carro no cache, para a classificação
+	errCarSave = errors.New("failed to save car")
+)
+
+// CarErrorRepository interface para a revisão das consultas de placa que falharam
+type CarErrorRepository interface {
+	List(status, reason string, page, pageSize int) (*models.CarErrorListResponse, error)
+	Get(plate string) (*models.CarError, error)
+	Discard(plate string) (*models.CarError, error)
+	Due(limit int) ([]string, error)
+	Counts() ([]models.CarErrorCount, error)
+}
+
+// carErrorRepository implementa CarErrorRepository
+type carErrorRepository struct {
+	db *gorm.DB
+}
+
+// NewCarErrorRepository cria uma nova instância do repositório
+func NewCarErrorRepository(db *gorm.DB) CarErrorRepository {
+	return &carErrorRepository{db: db}
+}
+
+// List lista as entradas, filtrando por status e motivo quando informados, as mais recentes primeiro
+func (r *carErrorRepository) List(status, reason string, page, pageSize int) (*models.CarErrorListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	query := r.db.Model(&models.CarError{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count car errors: %w", err)
+	}
+
+	var entries []models.CarError
+	if err := query.Order("updated_at DESC").Limit(pageSize).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list car errors: %w", err)
+	}
+
+	return &models.CarErrorListResponse{
+		Errors:     entries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// Get busca a entrada da placa
+func (r *carErrorRepository) Get(value string) (*models.CarError, error) {
+	return getCarError(r.db, value)
+}
+
+// Discard tira a entrada da fila de novas tentativas, mantendo o registro
+func (r *carErrorRepository) Discard(value string) (*models.CarError, error) {
+	entry, err := getCarError(r.db, value)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status == models.CarErrorResolved || entry.Status == models.CarErrorDiscarded {
+		return entry, ErrCarErrorClosed
+	}
+
+	entry.Status = models.CarErrorDiscarded
+	entry.NextRetryAt = nil
+	entry.UpdatedAt = time.Now()
+	if err := r.db.Save(entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to discard car error: %w", err)
+	}
+	return entry, nil
+}
+
+// Due lista as placas pendentes com nova tentativa vencida, as mais atrasadas primeiro
+func (r *carErrorRepository) Due(limit int) ([]string, error) {
+	var plates []string
+	err := r.db.Model(&models.CarError{}).
+		Where("status = ? AND (next_retry_at IS NULL OR next_retry_at <= ?)", models.CarErrorPending, time.Now()).
+		Order("next_retry_at ASC NULLS FIRST").
+		Limit(limit).
+		Pluck("license_plate", &plates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due car errors: %w", err)
+	}
+	return plates, nil
+}
+
+// Counts conta as entradas por status e motivo
+func (r *carErrorRepository) Counts() ([]models.CarErrorCount, error) {
+	var counts []models.CarErrorCount
+	err := r.db.Model(&models.CarError{}).
+		Select("status, COALESCE(reason, '') AS reason, COUNT(*) AS count").
+		Group("status, reason").
+		Order("status, reason").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count car errors: %w", err)
+	}
+	return counts, nil
+}
+
+// getCarError busca a entrada da placa (na forma normalizada)
+func getCarError(db *gorm.DB, value string) (*models.CarError, error) {
+	var entry models.CarError
+	if err := db.Where("license_plate = ?", plate.Normalize(value)).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCarErrorNotFound
+		}
+		return nil, fmt.Errorf("failed to get car error: %w", err)
+	}
+	return &entry, nil
+}
+
+// ClassifyCarError identifica o motivo da falha de uma consulta de placa
+func ClassifyCarError(err error) string {
+	switch {
+	case errors.Is(err, vehicledata.ErrParse):
+		return models.CarErrorReasonParse
+	case errors.Is(err, vehicledata.ErrBlocked):
+		return models.CarErrorReasonBlocked
+	case isConstraintViolation(err):
+		return models.CarErrorReasonConstraint
+	case errors.Is(err, errCarSave):
+		return models.CarErrorReasonDatabase
+	default:
+		return models.CarErrorReasonProvider
+	}
+}
+
+// isConstraintViolation reconhece as violações de restrição do PostgreSQL (SQLSTATE classe 23)
+func isConstraintViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.SQLState(), "23")
+	}
+	return err != nil && strings.Contains(err.Error(), "violates")
+}
+
+// carErrorRetryPolicy define o intervalo crescente entre as novas tentativas
+type carErrorRetryPolicy struct {
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	maxAttempts int
+}
+
+// defaultCarErrorRetryPolicy lê CAR_ERROR_RETRY_BACKOFF (padrão 5m, dobra a cada tentativa),
+// CAR_ERROR_RETRY_MAX_BACKOFF (padrão 24h) e CAR_ERROR_MAX_ATTEMPTS (padrão 8)
+func defaultCarErrorRetryPolicy() carErrorRetryPolicy {
+	policy := carErrorRetryPolicy{backoff: 5 * time.Minute, maxBackoff: 24 * time.Hour, maxAttempts: 8}
+	if value, err := time.ParseDuration(os.Getenv("CAR_ERROR_RETRY_BACKOFF")); err == nil && value > 0 {
+		policy.backoff = value
+	}
+	if value, err := time.ParseDuration(os.Getenv("CAR_ERROR_RETRY_MAX_BACKOFF")); err == nil && value > 0 {
+		policy.maxBackoff = value
+	}
+	if value, err := strconv.Atoi(os.Getenv("CAR_ERROR_MAX_ATTEMPTS")); err == nil && value > 0 {
+		policy.maxAttempts = value
+	}
+	return policy
+}
+
+// delay retorna a espera até a próxima tentativa depois de attempts tentativas
+func (p carErrorRetryPolicy) delay(attempts int) time.Duration {
+	delay := p.backoff
+	for i := 1; i < attempts && delay < p.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+	return delay
+}
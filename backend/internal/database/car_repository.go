@@ -2,41 +2,67 @@ package database
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"partexplorer/backend/internal/metrics"
 	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/plate"
+	"partexplorer/backend/internal/vehicledata"
 
-	"github.com/chromedp/chromedp"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 // CarRepository interface para operações de carros
 type CarRepository interface {
 	GetCarByPlate(plate string) (*models.Car, error)
+	GetCarByChassis(vin string) (*models.Car, error)
 	SaveCar(car *models.Car) error
 	SaveCarError(carError *models.CarError) error
 	SearchCarByPlate(plate string) (*models.CarInfo, error)
+	RefreshCarByPlate(plate string) (*models.CarInfo, error)
+	StalePlates(queriedSince time.Time, limit int) ([]string, error)
+	RetryCarError(plate string) (*models.CarError, error)
 }
 
 // carRepository implementa CarRepository
 type carRepository struct {
-	db *gorm.DB
+	db        *gorm.DB
+	providers *vehicledata.Chain
+	freshness vehicledata.FreshnessPolicy
+	retry     carErrorRetryPolicy
 }
 
-// NewCarRepository cria uma nova instância do repositório
+// NewCarRepository cria uma nova instância do repositório, consultando os provedores de
+// placa configurados em PLATE_PROVIDERS. Os prazos do cache vêm de CAR_TTL_* (ver
+// vehicledata.DefaultFreshnessPolicy); as novas tentativas da car_error, de CAR_ERROR_*.
 func NewCarRepository(db *gorm.DB) CarRepository {
-	return &carRepository{db: db}
+	return &carRepository{
+		db:        db,
+		providers: vehicledata.Default(),
+		freshness: vehicledata.DefaultFreshnessPolicy(),
+		retry:     defaultCarErrorRetryPolicy(),
+	}
+}
+
+// plateForms retorna as formas sob as quais a placa pode estar no cache: a informada e a do
+// outro padrão (antigo/Mercosul)
+func plateForms(value string) []string {
+	parsed, err := plate.Parse(value)
+	if err != nil {
+		return []string{plate.Normalize(value)}
+	}
+	return parsed.Forms()
 }
 
-// GetCarByPlate busca um carro pela placa
-func (r *carRepository) GetCarByPlate(plate string) (*models.Car, error) {
+// GetCarByPlate busca um carro pela placa, aceitando o cadastro no padrão antigo ou no Mercosul
+func (r *carRepository) GetCarByPlate(value string) (*models.Car, error) {
 	// Verificar se a tabela existe
 	var tableExists bool
 	err := r.db.Raw("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_schema = 'partexplorer' AND table_name = 'car')").Scan(&tableExists).Error
@@ -50,9 +76,27 @@ func (r *carRepository) GetCarByPlate(plate string) (*models.Car, error) {
 		return nil, fmt.Errorf("tabela car não existe")
 	}
 
+	// A forma informada tem preferência sobre a do outro padrão
+	forms := plateForms(value)
+	var cars []models.Car
+	if err := r.db.Where("license_plate IN ?", forms).Find(&cars).Error; err != nil {
+		return nil, err
+	}
+	if len(cars) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	for i := range cars {
+		if cars[i].LicensePlate == forms[0] {
+			return &cars[i], nil
+		}
+	}
+	return &cars[0], nil
+}
+
+// GetCarByChassis busca no cache o carro com o chassi (VIN) informado, já normalizado
+func (r *carRepository) GetCarByChassis(vin string) (*models.Car, error) {
 	var car models.Car
-	err = r.db.Where("license_plate = ?", strings.ToUpper(plate)).First(&car).Error
-	if err != nil {
+	if err := r.db.Where("UPPER(chassis_number) = ?", vin).Order("updated_at DESC").First(&car).Error; err != nil {
 		return nil, err
 	}
 	return &car, nil
@@ -61,17 +105,21 @@ func (r *carRepository) GetCarByPlate(plate string) (*models.Car, error) {
 // SaveCar salva ou atualiza um carro
 func (r *carRepository) SaveCar(car *models.Car) error {
 	// Normalizar placa
-	car.LicensePlate = strings.ToUpper(car.LicensePlate)
+	car.LicensePlate = plate.Normalize(car.LicensePlate)
 
-	// Verificar se já existe
+	// Verificar se já existe, inclusive sob a outra forma da placa (que passa a ser a informada)
 	var existingCar models.Car
-	err := r.db.Where("license_plate = ?", car.LicensePlate).First(&existingCar).Error
+	err := r.db.Where("license_plate IN ?", plateForms(car.LicensePlate)).First(&existingCar).Error
 
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// Não existe, fazer INSERT
 			log.Printf("=== DEBUG: Inserindo novo carro na tabela car ===")
-			return r.db.Create(car).Error
+			if err := r.db.Create(car).Error; err != nil {
+				return err
+			}
+			r.recordFipePrice(car)
+			return nil
 		}
 		return err
 	}
@@ -79,14 +127,39 @@ func (r *carRepository) SaveCar(car *models.Car) error {
 	// Existe, fazer UPDATE
 	log.Printf("=== DEBUG: Atualizando carro existente na tabela car ===")
 	car.ID = existingCar.ID // Manter o ID existente
+	car.CreatedAt = existingCar.CreatedAt
+	if car.LastQueriedAt == nil {
+		// Atualizações em background não contam como consulta
+		car.LastQueriedAt = existingCar.LastQueriedAt
+	}
 	car.UpdatedAt = time.Now()
-	return r.db.Save(car).Error
+	if err := r.db.Save(car).Error; err != nil {
+		return err
+	}
+	r.recordFipePrice(car)
+	return nil
+}
+
+// recordFipePrice guarda o valor FIPE do provedor no histórico de preços; a falha não impede
+// a gravação do carro
+func (r *carRepository) recordFipePrice(car *models.Car) {
+	if err := recordFipeObservation(r.db, car); err != nil {
+		log.Printf("⚠️ [CAR-REPO] Erro ao guardar o valor FIPE da placa %s: %v", car.LicensePlate, err)
+	}
 }
 
-// SaveCarError salva um erro de carro
+// SaveCarError salva um erro de carro. Entradas novas entram na fila de novas tentativas.
 func (r *carRepository) SaveCarError(carError *models.CarError) error {
 	// Normalizar placa
-	carError.LicensePlate = strings.ToUpper(carError.LicensePlate)
+	carError.LicensePlate = plate.Normalize(carError.LicensePlate)
+	if carError.Status == "" {
+		carError.Status = models.CarErrorPending
+	}
+	// Entrada pendente sem agendamento (nova ou reaberta) espera o primeiro intervalo
+	if carError.NextRetryAt == nil && carError.Status == models.CarErrorPending {
+		nextRetry := time.Now().Add(r.retry.delay(1))
+		carError.NextRetryAt = &nextRetry
+	}
 
 	// Verificar se já existe
 	var existingCarError models.CarError
@@ -103,13 +176,17 @@ func (r *carRepository) SaveCarError(carError *models.CarError) error {
 
 	// Existe, fazer UPDATE
 	log.Printf("=== DEBUG: Atualizando erro existente na tabela car_error ===")
+	carError.CreatedAt = existingCarError.CreatedAt
 	carError.UpdatedAt = time.Now()
 	return r.db.Save(carError).Error
 }
 
-// SearchCarByPlate busca informações de um carro pela placa (com cache)
-func (r *carRepository) SearchCarByPlate(plate string) (*models.CarInfo, error) {
-	log.Printf("🔍 [CAR-REPO] Iniciando busca para placa: %s", plate)
+// SearchCarByPlate busca informações de um carro pela placa (com cache). Dados do cache com
+// algum grupo de campos vencido (ex. valor FIPE) são retornados marcados como vencidos e ficam
+// para o refresher; só o vencimento da identificação do veículo faz a consulta esperar os
+// provedores.
+func (r *carRepository) SearchCarByPlate(value string) (*models.CarInfo, error) {
+	log.Printf("🔍 [CAR-REPO] Iniciando busca para placa: %s", value)
 
 	// Verificar se o banco está conectado
 	if r.db == nil {
@@ -118,516 +195,304 @@ func (r *carRepository) SearchCarByPlate(plate string) (*models.CarInfo, error)
 	}
 
 	// Normalizar placa
-	plate = strings.ToUpper(plate)
-	log.Printf("🔍 [CAR-REPO] Placa normalizada: %s", plate)
+	plateNumber := plate.Normalize(value)
+	log.Printf("🔍 [CAR-REPO] Placa normalizada: %s", plateNumber)
 
 	// 1. Verificar se já temos os dados no cache (com verificação de frescor)
 	log.Printf("🔍 [CAR-REPO] Verificando cache...")
-	existingCar, err := r.GetCarByPlate(plate)
-	if err == nil {
-		// Verificar se os dados são recentes (menos de 24 horas)
-		if time.Since(existingCar.UpdatedAt) < 24*time.Hour {
-			log.Printf("✅ [CAR-REPO] Placa %s encontrada no cache (dados recentes)", plate)
-			carInfo := r.carToCarInfo(existingCar)
-			log.Printf("📊 [CAR-REPO] Dados do cache: %s %s %s", carInfo.Marca, carInfo.Modelo, carInfo.Ano)
-			return carInfo, nil
-		} else {
-			log.Printf("⚠️ [CAR-REPO] Dados antigos no cache para placa %s, buscando atualização", plate)
-		}
-	}
-
+	existingCar, err := r.GetCarByPlate(plateNumber)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		// Erro na consulta ao banco (não é "não encontrado")
 		log.Printf("❌ [CAR-REPO] Erro ao consultar cache: %v", err)
 		return nil, fmt.Errorf("erro ao consultar cache: %w", err)
 	}
 
-	// 2. Não encontrou no cache, buscar na API externa
-	log.Printf("🌐 [CAR-REPO] Placa %s não encontrada no cache, buscando na API externa", plate)
-
-	log.Printf("🔍 [CAR-REPO] Chamando callExternalAPI...")
-	carInfo := r.callExternalAPI(plate)
-	log.Printf("🔍 [CAR-REPO] callExternalAPI retornou: %v", carInfo != nil)
-
-	if carInfo == nil {
-		// Se não conseguiu obter dados, retornar erro
-		log.Printf("❌ [CAR-REPO] Não foi possível obter dados da API externa")
-		return nil, fmt.Errorf("não foi possível obter dados do keplaca.com")
+	var cached *models.CarInfo
+	if err == nil {
+		r.touchQueried(existingCar.ID)
+		cached = r.carToCarInfo(existingCar)
+		cached.Placa = plateNumber
+
+		if !cached.Freshness.Stale {
+			log.Printf("✅ [CAR-REPO] Placa %s encontrada no cache como %s (dados recentes)", plateNumber, existingCar.LicensePlate)
+			log.Printf("📊 [CAR-REPO] Dados do cache: %s %s %s", cached.Marca, cached.Modelo, cached.Ano)
+			return cached, nil
+		}
+		if !vehicledata.IdentityStale(cached.Freshness) {
+			log.Printf("🕒 [CAR-REPO] Placa %s no cache com %v vencido(s), atualização fica para o refresher", plateNumber, cached.Freshness.StaleFields)
+			cached.Freshness.Refreshing = true
+			return cached, nil
+		}
+		log.Printf("⚠️ [CAR-REPO] Dados antigos no cache para placa %s, buscando atualização", plateNumber)
 	}
 
-	// 3. Salvar no cache
-	if carInfo != nil {
-		log.Printf("💾 [CAR-REPO] Salvando dados no cache...")
-		car := carInfo.ToCar()
-		saveErr := r.SaveCar(car)
-		if saveErr != nil {
-			log.Printf("❌ [CAR-REPO] Erro ao salvar no cache: %v", saveErr)
-			// Salvar erro na tabela de erros
-			r.saveCarError(carInfo)
-		} else {
-			log.Printf("✅ [CAR-REPO] Carro salvo no cache com sucesso")
+	// 2. Não encontrou no cache (ou a identificação venceu), buscar nos provedores de placa
+	now := time.Now()
+	carInfo, err := r.lookupProviders(plateNumber, &now)
+	if err != nil {
+		if cached != nil {
+			// Melhor os dados vencidos do que nenhum
+			log.Printf("⚠️ [CAR-REPO] Provedores falharam para %s, retornando dados vencidos do cache: %v", plateNumber, err)
+			return cached, nil
 		}
+		return nil, err
 	}
 
-	log.Printf("🎯 [CAR-REPO] Busca concluída para placa: %s", plate)
+	log.Printf("🎯 [CAR-REPO] Busca concluída para placa: %s", plateNumber)
 	return carInfo, nil
 }
 
-// carToCarInfo converte Car para CarInfo
-func (r *carRepository) carToCarInfo(car *models.Car) *models.CarInfo {
-	return &models.CarInfo{
-		Placa:          car.LicensePlate,
-		Marca:          car.Brand,
-		Modelo:         car.Model,
-		Ano:            strconv.Itoa(car.Year),
-		AnoModelo:      strconv.Itoa(car.ModelYear),
-		Cor:            car.Color,
-		Combustivel:    car.FuelType,
-		Chassi:         car.ChassisNumber,
-		Municipio:      car.City,
-		UF:             car.State,
-		Importado:      car.Imported,
-		CodigoFipe:     car.FipeCode,
-		ValorFipe:      fmt.Sprintf("R$ %.2f", car.FipeValue),
-		DataConsulta:   car.UpdatedAt.Format(time.RFC3339),
-		Confiabilidade: 0.9, // Valor padrão para dados do cache
+// RefreshCarByPlate consulta os provedores ignorando o cache e atualiza o cache. Usado pelo
+// refresher: não conta como consulta de usuário.
+func (r *carRepository) RefreshCarByPlate(value string) (*models.CarInfo, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("banco de dados não conectado")
 	}
+	return r.lookupProviders(plate.Normalize(value), nil)
 }
 
-// saveCarError salva erro na tabela car_error
-func (r *carRepository) saveCarError(carInfo *models.CarInfo) error {
-	carError := &models.CarError{
-		LicensePlate: carInfo.Placa,
-		Data: map[string]interface{}{
-			"placa":          carInfo.Placa,
-			"marca":          carInfo.Marca,
-			"modelo":         carInfo.Modelo,
-			"ano":            carInfo.Ano,
-			"ano_modelo":     carInfo.AnoModelo,
-			"cor":            carInfo.Cor,
-			"combustivel":    carInfo.Combustivel,
-			"chassi":         carInfo.Chassi,
-			"municipio":      carInfo.Municipio,
-			"uf":             carInfo.UF,
-			"importado":      carInfo.Importado,
-			"codigo_fipe":    carInfo.CodigoFipe,
-			"valor_fipe":     carInfo.ValorFipe,
-			"data_consulta":  carInfo.DataConsulta,
-			"confiabilidade": carInfo.Confiabilidade,
-		},
-	}
-
-	return r.SaveCarError(carError)
-}
-
-// callExternalAPI faz a chamada real para keplaca.com usando ChromeDP
-func (r *carRepository) callExternalAPI(plate string) *models.CarInfo {
-	log.Printf("🌐 [CAR-REPO] Iniciando busca no keplaca.com para placa %s", plate)
-
-	// Tentar ChromeDP primeiro (mais eficaz contra Cloudflare)
-	carInfo := r.callWithChromeDP(plate)
-	if carInfo != nil {
-		log.Printf("✅ [CAR-REPO] ChromeDP funcionou, retornando dados")
-		return carInfo
+// StalePlates lista as placas consultadas desde queriedSince que têm algum grupo de campos
+// vencido, das consultadas mais recentemente para as mais antigas
+func (r *carRepository) StalePlates(queriedSince time.Time, limit int) ([]string, error) {
+	minTTL := r.freshness.MinTTL()
+	if minTTL == 0 {
+		return nil, nil
 	}
 
-	// Se ChromeDP falhou, tentar HTTP como fallback
-	log.Printf("⚠️ [CAR-REPO] ChromeDP falhou, tentando HTTP como fallback...")
-	return r.callWithHTTP(plate)
+	var plates []string
+	err := r.db.Model(&models.Car{}).
+		Where("last_queried_at >= ? AND updated_at < ?", queriedSince, time.Now().Add(-minTTL)).
+		Order("last_queried_at DESC").
+		Limit(limit).
+		Pluck("license_plate", &plates).Error
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar placas vencidas: %w", err)
+	}
+	return plates, nil
 }
 
-// callWithChromeDP faz a chamada usando Chrome headless (mais eficaz contra Cloudflare)
-func (r *carRepository) callWithChromeDP(plate string) *models.CarInfo {
-	log.Printf("🌐 [CAR-REPO] Iniciando ChromeDP para placa %s", plate)
-
-	// URL do keplaca.com
-	url := fmt.Sprintf("https://www.keplaca.com/placa?placa-fipe=%s", plate)
-
-	// Configurar contexto com timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-
-	// Configurar opções do Chrome otimizadas para performance
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.Flag("disable-web-security", true),
-		chromedp.Flag("disable-features", "VizDisplayCompositor"),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("disable-plugins", true),
-		chromedp.Flag("disable-images", true),
-		chromedp.Flag("disable-javascript", false), // Manter JS para Cloudflare
-		chromedp.Flag("disable-background-timer-throttling", true),
-		chromedp.Flag("disable-backgrounding-occluded-windows", true),
-		chromedp.Flag("disable-renderer-backgrounding", true),
-		chromedp.Flag("disable-background-networking", true),
-		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-	)
-
-	// Criar allocator
-	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
-	defer cancel()
-
-	// Criar contexto do Chrome
-	chromeCtx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
-	defer cancel()
-
-	log.Printf("🔧 [CAR-REPO] ChromeDP configurado, navegando para: %s", url)
-
-	// Variável para armazenar o HTML
-	var html string
-
-	// Executar tarefas otimizadas
-	err := chromedp.Run(chromeCtx,
-		// Navegar para a página
-		chromedp.Navigate(url),
-		// Aguardar carregamento
-		chromedp.Sleep(3*time.Second),
-		// Aguardar apenas o essencial
-		chromedp.WaitReady("body", chromedp.ByQuery),
-		// Obter HTML da página
-		chromedp.OuterHTML("html", &html),
-	)
-
+// lookupProviders consulta os provedores de placa e salva o resultado no cache. queriedAt marca
+// a consulta de um usuário; nil nas atualizações em background. As falhas vão para a
+// car_error: as dos provedores só nas consultas de usuário, as de gravação sempre (para não
+// perder os dados obtidos).
+func (r *carRepository) lookupProviders(plateNumber string, queriedAt *time.Time) (*models.CarInfo, error) {
+	carInfo, err := r.queryProviders(plateNumber)
 	if err != nil {
-		log.Printf("❌ [CAR-REPO] Erro no ChromeDP: %v", err)
-		return nil
+		if queriedAt != nil && !errors.Is(err, vehicledata.ErrNotFound) && !errors.Is(err, context.Canceled) {
+			r.recordCarError(plateNumber, map[string]interface{}{"placa": plateNumber}, err)
+		}
+		return nil, fmt.Errorf("não foi possível obter dados do veículo: %w", err)
 	}
 
-	log.Printf("📄 [CAR-REPO] HTML obtido via ChromeDP (%d bytes)", len(html))
-
-	// Extrair dados do HTML
-	carInfo := r.extractDataFromHTML(plate, html)
-	if carInfo != nil {
-		log.Printf("✅ [CAR-REPO] Dados extraídos com sucesso via ChromeDP: %s %s", carInfo.Marca, carInfo.Modelo)
-		return carInfo
+	// 3. Salvar no cache
+	log.Printf("💾 [CAR-REPO] Salvando dados no cache...")
+	car := carInfo.ToCar()
+	car.LastQueriedAt = queriedAt
+	if saveErr := r.SaveCar(car); saveErr != nil {
+		log.Printf("❌ [CAR-REPO] Erro ao salvar no cache: %v", saveErr)
+		// Salvar erro na tabela de erros
+		r.recordCarError(carInfo.Placa, carInfoData(carInfo), fmt.Errorf("%w: %w", errCarSave, saveErr))
+	} else {
+		log.Printf("✅ [CAR-REPO] Carro salvo no cache com sucesso")
+		r.resolveCarError(plateNumber)
 	}
 
-	log.Printf("❌ [CAR-REPO] Não foi possível extrair dados via ChromeDP")
-	return nil
+	now := time.Now()
+	carInfo.Freshness = r.freshness.Evaluate(now, now)
+	return carInfo, nil
 }
 
-// callWithHTTP faz a chamada usando HTTP request
-func (r *carRepository) callWithHTTP(plate string) *models.CarInfo {
-	// URL do keplaca.com
-	url := fmt.Sprintf("https://www.keplaca.com/placa?placa-fipe=%s", plate)
-	log.Printf("🌐 [CAR-REPO] Fazendo requisição HTTP para: %s", url)
-
-	// Configurar cliente HTTP com redirect
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return nil // Permitir redirects
-		},
-	}
-
-	// Criar requisição
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		log.Printf("❌ [CAR-REPO] Erro ao criar requisição HTTP: %v", err)
-		return nil
-	}
-
-	// Headers ultra-realistas para driblar Cloudflare
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en;q=0.8")
-	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
-	req.Header.Set("DNT", "1")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
-	req.Header.Set("Sec-Fetch-Dest", "document")
-	req.Header.Set("Sec-Fetch-Mode", "navigate")
-	req.Header.Set("Sec-Fetch-Site", "none")
-	req.Header.Set("Sec-Fetch-User", "?1")
-	req.Header.Set("Cache-Control", "no-cache")
-	req.Header.Set("Pragma", "no-cache")
-	req.Header.Set("Referer", "https://www.google.com/")
-	req.Header.Set("Origin", "https://www.google.com")
-
-	log.Printf("🔍 [CAR-REPO] Headers configurados, fazendo requisição...")
-
-	// Simular delay humano antes da requisição
-	time.Sleep(2 * time.Second)
-
-	// Estratégia de retry com diferentes User-Agents
-	userAgents := []string{
-		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
-		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
-		"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
-	}
-
-	var resp *http.Response
-	var lastErr error
-
-	for i, userAgent := range userAgents {
-		log.Printf("🔄 [CAR-REPO] Tentativa %d com User-Agent: %s", i+1, userAgent[:50]+"...")
-
-		req.Header.Set("User-Agent", userAgent)
-
-		resp, lastErr = client.Do(req)
-		if lastErr != nil {
-			log.Printf("❌ [CAR-REPO] Erro na tentativa %d: %v", i+1, lastErr)
-			continue
+// queryProviders consulta os provedores de placa, tentando também a outra forma da placa
+func (r *carRepository) queryProviders(plateNumber string) (*models.CarInfo, error) {
+	log.Printf("🌐 [CAR-REPO] Consultando provedores para placa %s: %s", plateNumber, strings.Join(r.providers.Providers(), ", "))
+
+	carInfo, err := r.providers.Lookup(context.Background(), plateNumber)
+	if errors.Is(err, vehicledata.ErrNotFound) {
+		// Os provedores podem conhecer o veículo só pela outra forma da placa
+		if parsed, parseErr := plate.Parse(plateNumber); parseErr == nil && parsed.Alternate() != "" {
+			log.Printf("🔁 [CAR-REPO] Placa %s não encontrada, tentando %s", plateNumber, parsed.Alternate())
+			if alternateInfo, alternateErr := r.providers.Lookup(context.Background(), parsed.Alternate()); alternateErr == nil {
+				alternateInfo.Placa = plateNumber
+				carInfo, err = alternateInfo, nil
+			}
 		}
-
-		log.Printf("📊 [CAR-REPO] Status da resposta: %d", resp.StatusCode)
-
-		// Se não for bloqueado, sair do loop
-		if resp.StatusCode != 403 && resp.StatusCode != 429 {
-			break
-		}
-
-		resp.Body.Close()
-		log.Printf("⚠️ [CAR-REPO] Bloqueado (status %d), tentando próximo User-Agent...", resp.StatusCode)
-		time.Sleep(3 * time.Second) // Delay entre tentativas
 	}
-
-	if lastErr != nil || resp == nil {
-		log.Printf("❌ [CAR-REPO] Todas as tentativas falharam")
-		return nil
+	if err != nil {
+		log.Printf("❌ [CAR-REPO] Não foi possível obter dados dos provedores: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	log.Printf("📡 [CAR-REPO] Resposta recebida - Status: %s, Content-Length: %s", resp.Status, resp.Header.Get("Content-Length"))
+	log.Printf("✅ [CAR-REPO] Dados obtidos do provedor %s", carInfo.Provedor)
+	return carInfo, nil
+}
 
-	// Ler resposta
-	body, err := io.ReadAll(resp.Body)
+// RetryCarError refaz a consulta de uma placa da car_error. Entradas com os dados do veículo
+// (falha ao gravar) são gravadas de novo; as demais voltam aos provedores. Em caso de nova
+// falha, a próxima tentativa fica para mais tarde, até esgotar CAR_ERROR_MAX_ATTEMPTS.
+func (r *carRepository) RetryCarError(value string) (*models.CarError, error) {
+	entry, err := getCarError(r.db, value)
 	if err != nil {
-		log.Printf("❌ [CAR-REPO] Erro ao ler resposta HTTP: %v", err)
-		return nil
+		return nil, err
+	}
+	if entry.Status == models.CarErrorResolved || entry.Status == models.CarErrorDiscarded {
+		return entry, ErrCarErrorClosed
 	}
 
-	htmlContent := string(body)
-	log.Printf("📄 [CAR-REPO] HTML obtido via HTTP (%d bytes)", len(htmlContent))
+	now := time.Now()
+	entry.Attempts++
+	entry.LastAttemptAt = &now
 
-	// Mostrar primeiros 1000 caracteres para debug
-	if len(htmlContent) > 1000 {
-		log.Printf("🔍 [CAR-REPO] Primeiros 1000 chars: %s", htmlContent[:1000])
+	var carInfo *models.CarInfo
+	if entry.HasCarData() {
+		carInfo, err = carInfoFromData(entry.Data)
 	} else {
-		log.Printf("🔍 [CAR-REPO] HTML completo: %s", htmlContent)
+		carInfo, err = r.queryProviders(entry.LicensePlate)
 	}
-
-	// Verificar se o HTML contém dados de carro
-	if strings.Contains(htmlContent, "carro") || strings.Contains(htmlContent, "veículo") {
-		log.Printf("✅ [CAR-REPO] HTML contém referências a carro/veículo")
-	} else {
-		log.Printf("⚠️ [CAR-REPO] HTML não contém referências a carro/veículo")
+	if err == nil {
+		carInfo.Placa = entry.LicensePlate
+		if saveErr := r.SaveCar(carInfo.ToCar()); saveErr != nil {
+			err = fmt.Errorf("%w: %w", errCarSave, saveErr)
+		}
 	}
 
-	// Verificar se é uma página de erro ou bloqueio
-	if strings.Contains(htmlContent, "403") || strings.Contains(htmlContent, "Forbidden") {
-		log.Printf("❌ [CAR-REPO] Página bloqueada (403 Forbidden)")
+	result := "resolved"
+	switch {
+	case err == nil:
+		entry.Status = models.CarErrorResolved
+		entry.Message = ""
+		entry.NextRetryAt = nil
+	case errors.Is(err, vehicledata.ErrNotFound):
+		// Os provedores responderam: a placa não existe, não adianta insistir
+		result = "not_found"
+		entry.Status = models.CarErrorDiscarded
+		entry.Message = err.Error()
+		entry.NextRetryAt = nil
+	case entry.Attempts >= r.retry.maxAttempts:
+		result = "exhausted"
+		entry.Status = models.CarErrorExhausted
+		entry.Reason = ClassifyCarError(err)
+		entry.Message = err.Error()
+		entry.NextRetryAt = nil
+	default:
+		result = "failed"
+		nextRetry := now.Add(r.retry.delay(entry.Attempts))
+		entry.Status = models.CarErrorPending
+		entry.Reason = ClassifyCarError(err)
+		entry.Message = err.Error()
+		entry.NextRetryAt = &nextRetry
+	}
+	metrics.RecordCarErrorRetry(result)
+	log.Printf("🔁 [CAR-ERROR] Nova tentativa %d da placa %s: %s", entry.Attempts, entry.LicensePlate, result)
+
+	entry.UpdatedAt = now
+	if saveErr := r.db.Save(entry).Error; saveErr != nil {
+		return nil, fmt.Errorf("failed to update car error: %w", saveErr)
+	}
+	return entry, nil
+}
+
+// recordCarError registra a falha na car_error com o motivo classificado. Uma entrada já na
+// fila mantém as tentativas e o agendamento; uma resolvida ou descartada volta para a fila.
+func (r *carRepository) recordCarError(plateNumber string, data map[string]interface{}, cause error) {
+	reason := ClassifyCarError(cause)
+	metrics.RecordCarError(reason)
+
+	entry, err := getCarError(r.db, plateNumber)
+	if err != nil {
+		if !errors.Is(err, ErrCarErrorNotFound) {
+			log.Printf("❌ [CAR-ERROR] Erro ao consultar car_error: %v", err)
+			return
+		}
+		entry = &models.CarError{LicensePlate: plateNumber}
 	}
-	if strings.Contains(htmlContent, "404") || strings.Contains(htmlContent, "Not Found") {
-		log.Printf("❌ [CAR-REPO] Página não encontrada (404)")
+	if entry.Status == models.CarErrorResolved || entry.Status == models.CarErrorDiscarded {
+		nextRetry := time.Now().Add(r.retry.delay(1))
+		entry.Status = models.CarErrorPending
+		entry.Attempts = 0
+		entry.NextRetryAt = &nextRetry
 	}
-	if strings.Contains(htmlContent, "captcha") || strings.Contains(htmlContent, "CAPTCHA") {
-		log.Printf("❌ [CAR-REPO] Página com CAPTCHA detectado")
+	// Os dados do veículo, se já obtidos, não são trocados pelo registro de uma falha de provedor
+	if len(data) > len(entry.Data) {
+		entry.Data = data
 	}
+	entry.Reason = reason
+	entry.Message = cause.Error()
 
-	log.Printf("🔍 [CAR-REPO] Chamando extractDataFromHTML...")
-
-	// Extrair dados do HTML
-	carInfo := r.extractDataFromHTML(plate, htmlContent)
-	log.Printf("🔍 [CAR-REPO] extractDataFromHTML retornou: %v", carInfo != nil)
-
-	if carInfo != nil {
-		log.Printf("✅ [CAR-REPO] Dados extraídos com sucesso via HTTP: %s %s", carInfo.Marca, carInfo.Modelo)
-		return carInfo
+	if err := r.SaveCarError(entry); err != nil {
+		log.Printf("❌ [CAR-ERROR] Erro ao salvar car_error da placa %s: %v", plateNumber, err)
 	}
-
-	log.Printf("❌ [CAR-REPO] Não foi possível extrair dados via HTTP")
-	return nil
 }
 
-// extractDataFromHTML extrai dados do veículo do HTML do keplaca.com
-func (r *carRepository) extractDataFromHTML(plate, htmlContent string) *models.CarInfo {
-	log.Printf("🔍 [CAR-REPO] Extraindo dados do HTML...")
-
-	// Padrões específicos baseados no HTML real do keplaca.com
-	marcaPattern := regexp.MustCompile(`(?i)<td[^>]*><b>Marca:</b></td><td>([^<]+)</td>`)
-	modeloPattern := regexp.MustCompile(`(?i)<td[^>]*><b>Modelo:</b></td><td>([^<]+)</td>`)
-	anoPattern := regexp.MustCompile(`(?i)<td[^>]*><b>Ano:</b></td><td>([^<]+)</td>`)
-	anoModeloPattern := regexp.MustCompile(`(?i)<td[^>]*><b>Ano Modelo:</b></td><td>([^<]+)</td>`)
-	corPattern := regexp.MustCompile(`(?i)<td[^>]*><b>Cor:</b></td><td>([^<]+)</td>`)
-	combustivelPattern := regexp.MustCompile(`(?i)<td[^>]*><b>Combustível:</b></td><td>([^<]+)</td>`)
-	chassiPattern := regexp.MustCompile(`(?i)<td[^>]*><b>Chassi:</b></td><td>([^<]+)</td>`)
-	ufPattern := regexp.MustCompile(`(?i)<td[^>]*><b>UF:</b></td><td>([^<]+)</td>`)
-	municipioPattern := regexp.MustCompile(`(?i)<td[^>]*><b>Município:</b></td><td>([^<]+)</td>`)
-	importadoPattern := regexp.MustCompile(`(?i)<td[^>]*><b>Importado:</b></td><td>([^<]+)</td>`)
-	fipePattern := regexp.MustCompile(`(?i)(?:fipe|código fipe)[:\s]*([0-9]{6}-[0-9])`)
-	valorFipePattern := regexp.MustCompile(`(?i)(?:valor|preço)[:\s]*R\$([0-9,\.]+)`)
-
-	// Buscar marca
-	marcaMatch := marcaPattern.FindStringSubmatch(htmlContent)
-	marca := ""
-	if len(marcaMatch) > 1 {
-		marca = strings.TrimSpace(marcaMatch[1])
-		log.Printf("🔍 [CAR-REPO] Marca encontrada: %s", marca)
-	}
-
-	// Buscar modelo
-	modeloMatch := modeloPattern.FindStringSubmatch(htmlContent)
-	modelo := ""
-	if len(modeloMatch) > 1 {
-		modelo = strings.TrimSpace(modeloMatch[1])
-		log.Printf("🔍 [CAR-REPO] Modelo encontrado: %s", modelo)
-	}
-
-	// Buscar ano
-	anoMatch := anoPattern.FindStringSubmatch(htmlContent)
-	ano := ""
-	if len(anoMatch) > 1 {
-		ano = anoMatch[1]
-		log.Printf("🔍 [CAR-REPO] Ano encontrado: %s", ano)
-	}
-
-	// Buscar ano modelo
-	anoModeloMatch := anoModeloPattern.FindStringSubmatch(htmlContent)
-	anoModelo := ""
-	if len(anoModeloMatch) > 1 {
-		anoModelo = anoModeloMatch[1]
-		log.Printf("🔍 [CAR-REPO] Ano modelo encontrado: %s", anoModelo)
-	}
-
-	// Buscar cor
-	corMatch := corPattern.FindStringSubmatch(htmlContent)
-	cor := ""
-	if len(corMatch) > 1 {
-		cor = strings.TrimSpace(corMatch[1])
-		log.Printf("🔍 [CAR-REPO] Cor encontrada: %s", cor)
-	}
-
-	// Buscar combustível
-	combustivelMatch := combustivelPattern.FindStringSubmatch(htmlContent)
-	combustivel := ""
-	if len(combustivelMatch) > 1 {
-		combustivel = strings.TrimSpace(combustivelMatch[1])
-		log.Printf("🔍 [CAR-REPO] Combustível encontrado: %s", combustivel)
-	}
-
-	// Buscar chassi
-	chassiMatch := chassiPattern.FindStringSubmatch(htmlContent)
-	chassi := ""
-	if len(chassiMatch) > 1 {
-		chassi = strings.TrimSpace(chassiMatch[1])
-		log.Printf("🔍 [CAR-REPO] Chassi encontrado: %s", chassi)
-	}
-
-	// Buscar UF
-	ufMatch := ufPattern.FindStringSubmatch(htmlContent)
-	uf := ""
-	if len(ufMatch) > 1 {
-		uf = strings.TrimSpace(ufMatch[1])
-		log.Printf("🔍 [CAR-REPO] UF encontrada: %s", uf)
-	}
-
-	// Buscar município
-	municipioMatch := municipioPattern.FindStringSubmatch(htmlContent)
-	municipio := ""
-	if len(municipioMatch) > 1 {
-		municipio = strings.TrimSpace(municipioMatch[1])
-		log.Printf("🔍 [CAR-REPO] Município encontrado: %s", municipio)
-	}
-
-	// Buscar importado
-	importadoMatch := importadoPattern.FindStringSubmatch(htmlContent)
-	importado := ""
-	if len(importadoMatch) > 1 {
-		importado = strings.TrimSpace(importadoMatch[1])
-		log.Printf("🔍 [CAR-REPO] Importado encontrado: %s", importado)
-	}
-
-	// Buscar código FIPE
-	fipeMatch := fipePattern.FindStringSubmatch(htmlContent)
-	codigoFipe := ""
-	if len(fipeMatch) > 1 {
-		codigoFipe = strings.TrimSpace(fipeMatch[1])
-		log.Printf("🔍 [CAR-REPO] Código FIPE encontrado: %s", codigoFipe)
-	}
-
-	// Buscar valor FIPE
-	valorFipeMatch := valorFipePattern.FindStringSubmatch(htmlContent)
-	valorFipe := ""
-	if len(valorFipeMatch) > 1 {
-		valorFipe = "R$ " + strings.TrimSpace(valorFipeMatch[1])
-		log.Printf("🔍 [CAR-REPO] Valor FIPE encontrado: %s", valorFipe)
-	}
-
-	// Log detalhado de todos os campos encontrados
-	log.Printf("📊 [CAR-REPO] Resumo da extração:")
-	log.Printf("   - Marca: '%s'", marca)
-	log.Printf("   - Modelo: '%s'", modelo)
-	log.Printf("   - Ano: '%s'", ano)
-	log.Printf("   - Ano Modelo: '%s'", anoModelo)
-	log.Printf("   - Cor: '%s'", cor)
-	log.Printf("   - Combustível: '%s'", combustivel)
-	log.Printf("   - Chassi: '%s'", chassi)
-	log.Printf("   - UF: '%s'", uf)
-	log.Printf("   - Município: '%s'", municipio)
-	log.Printf("   - Importado: '%s'", importado)
-	log.Printf("   - Código FIPE: '%s'", codigoFipe)
-	log.Printf("   - Valor FIPE: '%s'", valorFipe)
-
-	// Verificar se encontrou dados mínimos
-	if marca == "" || modelo == "" {
-		log.Printf("❌ [CAR-REPO] Dados insuficientes: marca='%s', modelo='%s'", marca, modelo)
-		return nil
-	}
-
-	// Se não encontrou ano modelo, usar ano + 1
-	if anoModelo == "" && ano != "" {
-		if anoInt, err := strconv.Atoi(ano); err == nil {
-			anoModelo = strconv.Itoa(anoInt + 1)
-		}
+// resolveCarError encerra a entrada pendente da placa após uma consulta bem-sucedida
+func (r *carRepository) resolveCarError(plateNumber string) {
+	err := r.db.Model(&models.CarError{}).
+		Where("license_plate = ? AND status IN ?", plate.Normalize(plateNumber), []string{models.CarErrorPending, models.CarErrorExhausted}).
+		Updates(map[string]interface{}{"status": models.CarErrorResolved, "next_retry_at": nil, "updated_at": time.Now()}).Error
+	if err != nil {
+		log.Printf("⚠️ [CAR-ERROR] Erro ao resolver car_error da placa %s: %v", plateNumber, err)
 	}
+}
 
-	// Valores padrão apenas se não encontrados
-	if cor == "" {
-		cor = "NÃO INFORMADO"
+// touchQueried registra a consulta da placa sem alterar updated_at
+func (r *carRepository) touchQueried(id uuid.UUID) {
+	if err := r.db.Model(&models.Car{}).Where("id = ?", id).UpdateColumn("last_queried_at", time.Now()).Error; err != nil {
+		log.Printf("⚠️ [CAR-REPO] Erro ao registrar consulta: %v", err)
 	}
-	if combustivel == "" {
-		combustivel = "FLEX"
-	}
-	if chassi == "" {
-		chassi = "*****" + plate[len(plate)-6:]
-	}
-	if uf == "" {
-		uf = "SP"
-	}
-	if municipio == "" {
-		municipio = "São Paulo"
-	}
-	if importado == "" {
-		importado = "NÃO"
-	}
-	if codigoFipe == "" {
-		codigoFipe = fmt.Sprintf("%06d-1", len(plate)*1000)
-	}
-	if valorFipe == "" {
-		valorFipe = fmt.Sprintf("R$ %d.000,00", 15+len(plate))
+}
+
+// carToCarInfo converte Car para CarInfo
+func (r *carRepository) carToCarInfo(car *models.Car) *models.CarInfo {
+	return &models.CarInfo{
+		Placa:          car.LicensePlate,
+		Marca:          car.Brand,
+		Modelo:         car.Model,
+		Ano:            strconv.Itoa(car.Year),
+		AnoModelo:      strconv.Itoa(car.ModelYear),
+		Cor:            car.Color,
+		Combustivel:    car.FuelType,
+		Chassi:         car.ChassisNumber,
+		Municipio:      car.City,
+		UF:             car.State,
+		Importado:      car.Imported,
+		CodigoFipe:     car.FipeCode,
+		ValorFipe:      fmt.Sprintf("R$ %.2f", car.FipeValue),
+		DataConsulta:   car.UpdatedAt.Format(time.RFC3339),
+		Confiabilidade: 0.9, // Valor padrão para dados do cache
+		Provedor:       car.Provider,
+		Freshness:      r.freshness.Evaluate(car.UpdatedAt, time.Now()),
 	}
+}
 
-	log.Printf("✅ [CAR-REPO] Dados extraídos: %s %s %s", marca, modelo, ano)
+// carInfoData converte CarInfo para o JSON guardado na car_error
+func carInfoData(carInfo *models.CarInfo) map[string]interface{} {
+	return map[string]interface{}{
+		"placa":          carInfo.Placa,
+		"marca":          carInfo.Marca,
+		"modelo":         carInfo.Modelo,
+		"ano":            carInfo.Ano,
+		"ano_modelo":     carInfo.AnoModelo,
+		"cor":            carInfo.Cor,
+		"combustivel":    carInfo.Combustivel,
+		"chassi":         carInfo.Chassi,
+		"municipio":      carInfo.Municipio,
+		"uf":             carInfo.UF,
+		"importado":      carInfo.Importado,
+		"codigo_fipe":    carInfo.CodigoFipe,
+		"valor_fipe":     carInfo.ValorFipe,
+		"data_consulta":  carInfo.DataConsulta,
+		"confiabilidade": carInfo.Confiabilidade,
+		"provedor":       carInfo.Provedor,
+	}
+}
 
-	return &models.CarInfo{
-		Placa:          plate,
-		Marca:          marca,
-		Modelo:         modelo,
-		Ano:            ano,
-		AnoModelo:      anoModelo,
-		Cor:            cor,
-		Combustivel:    combustivel,
-		Chassi:         chassi,
-		Municipio:      municipio,
-		UF:             uf,
-		Importado:      importado,
-		CodigoFipe:     codigoFipe,
-		ValorFipe:      valorFipe,
-		DataConsulta:   time.Now().Format(time.RFC3339),
-		Confiabilidade: 0.95, // Alta confiabilidade para dados reais
+// carInfoFromData reconstrói o CarInfo guardado na car_error
+func carInfoFromData(data map[string]interface{}) (*models.CarInfo, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", vehicledata.ErrParse, err)
+	}
+	var carInfo models.CarInfo
+	if err := json.Unmarshal(encoded, &carInfo); err != nil {
+		return nil, fmt.Errorf("%w: %v", vehicledata.ErrParse, err)
 	}
+	return &carInfo, nil
 }
@@ -1,11 +1,13 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"partexplorer/backend/internal/models"
 )
@@ -14,7 +16,7 @@ import (
 type CompanyRepository interface {
 	CreateCompany(company *models.Company) error
 	GetCompanyByID(id string) (*models.Company, error)
-	UpdateCompany(id string, updates map[string]interface{}) error
+	UpdateCompany(id string, version int, updates map[string]interface{}) (*models.Company, error)
 	DeleteCompany(id string) error
 	ListCompanies(page, pageSize int) (*models.CompanyListResponse, error)
 	SearchCompanies(query string, page, pageSize int) (*models.CompanyListResponse, error)
@@ -36,6 +38,7 @@ func (r *companyRepository) CreateCompany(company *models.Company) error {
 		company.ID = uuid.New()
 	}
 
+	company.Version = 1
 	company.CreatedAt = time.Now()
 	company.UpdatedAt = time.Now()
 
@@ -57,20 +60,41 @@ func (r *companyRepository) GetCompanyByID(id string) (*models.Company, error) {
 	return &company, nil
 }
 
-// UpdateCompany atualiza uma empresa
-func (r *companyRepository) UpdateCompany(id string, updates map[string]interface{}) error {
+// UpdateCompany atualiza uma empresa na versão informada e retorna a empresa gravada. Se ela mudou depois da versão, retorna ErrVersionConflict com a
+// empresa atual.
+func (r *companyRepository) UpdateCompany(id string, version int, updates map[string]interface{}) (*models.Company, error) {
 	companyID, err := uuid.Parse(id)
 	if err != nil {
-		return fmt.Errorf("invalid company ID: %w", err)
+		return nil, fmt.Errorf("invalid company ID: %w", err)
 	}
 
-	updates["updated_at"] = time.Now()
+	var company models.Company
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", companyID).First(&company).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCompanyNotFound
+			}
+			return fmt.Errorf("failed to lock company: %w", err)
+		}
+		if err := checkVersion(version, company.Version); err != nil {
+			return err
+		}
 
-	if err := r.db.Model(&models.Company{}).Where("id = ?", companyID).Updates(updates).Error; err != nil {
-		return fmt.Errorf("failed to update company: %w", err)
+		updates["version"] = nextVersion()
+		updates["updated_at"] = time.Now()
+		if err := tx.Model(&models.Company{}).Where("id = ?", companyID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update company: %w", err)
+		}
+		return tx.Where("id = ?", companyID).First(&company).Error
+	})
+	if errors.Is(err, ErrVersionConflict) {
+		return &company, err
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &company, nil
 }
 
 // DeleteCompany remove uma empresa
@@ -108,7 +132,7 @@ func (r *companyRepository) ListCompanies(page, pageSize int) (*models.CompanyLi
 	// Buscar resultados com distinct por group_name usando SQL direto
 	query := `
 		SELECT DISTINCT ON (group_name) 
-			id, name, image_url, street, number, neighborhood, city, country, state, zip_code, phone, mobile, email, website, created_at, updated_at, group_name
+			id, name, image_url, street, number, neighborhood, city, country, state, zip_code, phone, mobile, email, website, version, created_at, updated_at, group_name
 		FROM partexplorer.company 
 		WHERE group_name IS NOT NULL AND group_name != ''
 		ORDER BY group_name, name
@@ -137,6 +161,7 @@ func (r *companyRepository) ListCompanies(page, pageSize int) (*models.CompanyLi
 			Mobile:       company.Mobile,
 			Email:        company.Email,
 			Website:      company.Website,
+			Version:      company.Version,
 			CreatedAt:    company.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:    company.UpdatedAt.Format(time.RFC3339),
 		}
@@ -160,7 +185,7 @@ func (r *companyRepository) GetCompaniesByGroup(groupName string) ([]models.Comp
 	var companies []models.Company
 
 	query := `
-		SELECT id, name, image_url, street, number, neighborhood, city, country, state, zip_code, phone, mobile, email, website, created_at, updated_at, group_name
+		SELECT id, name, image_url, street, number, neighborhood, city, country, state, zip_code, phone, mobile, email, website, version, created_at, updated_at, group_name
 		FROM partexplorer.company 
 		WHERE group_name = ?
 		ORDER BY name
@@ -221,6 +246,7 @@ func (r *companyRepository) SearchCompanies(query string, page, pageSize int) (*
 			Mobile:       company.Mobile,
 			Email:        company.Email,
 			Website:      company.Website,
+			Version:      company.Version,
 			CreatedAt:    company.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:    company.UpdatedAt.Format(time.RFC3339),
 		}
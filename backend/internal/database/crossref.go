@@ -0,0 +1,97 @@
+package database
+
+import (
+	"fmt"
+
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/partcode"
+)
+
+// crossReferenceRow é um código (sku ou ean) de um grupo que contém o código consultado
+type crossReferenceRow struct {
+	GroupID        string
+	ProductType    string
+	Brand          string
+	Name           string
+	Type           string
+	NormalizedCode string
+}
+
+// GetCrossReferences retorna todos os códigos equivalentes (mesmo part_group) ao código
+// informado, comparando pela forma normalizada, agrupados por marca e tipo
+func (r *partRepository) GetCrossReferences(code string) ([]models.CrossReferenceGroup, error) {
+	candidates := partcode.Candidates(code)
+	if len(candidates) == 0 {
+		return []models.CrossReferenceGroup{}, nil
+	}
+
+	var rows []crossReferenceRow
+	if err := r.db.Raw(`
+		SELECT
+			pn.group_id,
+			COALESCE(pt.description, '') AS product_type,
+			COALESCE(b.name, '') AS brand,
+			pn.name,
+			LOWER(pn.type) AS type,
+			pn.normalized_code
+		FROM partexplorer.part_name pn
+		JOIN partexplorer.part_group pg ON pg.id = pn.group_id
+		LEFT JOIN partexplorer.product_type pt ON pt.id = pg.product_type_id
+		LEFT JOIN partexplorer.brand b ON b.id = pn.brand_id
+		WHERE pn.normalized_code IS NOT NULL
+		  AND pn.group_id IN (
+			SELECT group_id FROM partexplorer.part_name WHERE normalized_code IN ?
+		  )
+		ORDER BY pn.group_id, brand, type, pn.name
+	`, candidates).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load cross references: %w", err)
+	}
+
+	matched := make(map[string]bool, len(candidates))
+	for _, candidate := range candidates {
+		matched[candidate] = true
+	}
+
+	groups := []models.CrossReferenceGroup{}
+	groupIndex := make(map[string]int)
+	brandIndex := make(map[string]int)
+	for _, row := range rows {
+		gi, ok := groupIndex[row.GroupID]
+		if !ok {
+			gi = len(groups)
+			groupIndex[row.GroupID] = gi
+			groups = append(groups, models.CrossReferenceGroup{
+				GroupID:     row.GroupID,
+				ProductType: row.ProductType,
+				Brands:      []models.CrossReferenceBrand{},
+			})
+		}
+		group := &groups[gi]
+
+		brandKey := row.GroupID + "|" + row.Brand
+		bi, ok := brandIndex[brandKey]
+		if !ok {
+			bi = len(group.Brands)
+			brandIndex[brandKey] = bi
+			group.Brands = append(group.Brands, models.CrossReferenceBrand{
+				Brand: row.Brand,
+				Codes: make(map[string][]models.CrossReferenceCode),
+			})
+		}
+		brand := &group.Brands[bi]
+
+		entry := models.CrossReferenceCode{
+			Code:           row.Name,
+			NormalizedCode: row.NormalizedCode,
+			Matched:        matched[row.NormalizedCode],
+		}
+		if row.Type == partcode.TypeEAN {
+			_, err := partcode.ValidateGTIN(row.Name)
+			valid := err == nil
+			entry.ValidGTIN = &valid
+		}
+		brand.Codes[row.Type] = append(brand.Codes[row.Type], entry)
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/synonyms"
+)
+
+// searchFacetLimit limita a quantidade de valores retornados por facet
+const searchFacetLimit = 50
+
+// facetSQL descreve como filtrar e contar um facet a partir de um grupo "g"
+type facetSQL struct {
+	// filter é a condição sobre g.id; o placeholder recebe a lista de valores selecionados
+	filter string
+	// joins ligam g ao valor do facet
+	joins string
+	// value é a expressão do valor do facet
+	value string
+}
+
+// stockAvailableJoin limita estado e cidade a estoques disponíveis, como no índice
+const stockAvailableJoin = `
+	JOIN partexplorer.part_name pn ON pn.group_id = g.id
+	JOIN partexplorer.stock s ON s.part_name_id = pn.id AND s.obsolete = false AND s.quantity > 0
+	JOIN partexplorer.company c ON c.id = s.company_id`
+
+// productTypeJoin liga o grupo ao tipo de produto, subfamília e família
+const productTypeJoin = `
+	JOIN partexplorer.part_group pg ON pg.id = g.id
+	JOIN partexplorer.product_type pt ON pt.id = pg.product_type_id
+	JOIN partexplorer.subfamily sf ON sf.id = pt.subfamily_id
+	JOIN partexplorer.family f ON f.id = sf.family_id`
+
+var searchFacetSQL = map[string]facetSQL{
+	models.FacetBrand: {
+		filter: `EXISTS (SELECT 1 FROM partexplorer.part_name fpn JOIN partexplorer.brand fb ON fb.id = fpn.brand_id WHERE fpn.group_id = g.id AND fb.name IN ?)`,
+		joins: `
+	JOIN partexplorer.part_name pn ON pn.group_id = g.id
+	JOIN partexplorer.brand b ON b.id = pn.brand_id`,
+		value: "b.name",
+	},
+	models.FacetFamily: {
+		filter: `EXISTS (SELECT 1 FROM partexplorer.part_group fpg JOIN partexplorer.product_type fpt ON fpt.id = fpg.product_type_id JOIN partexplorer.subfamily fsf ON fsf.id = fpt.subfamily_id JOIN partexplorer.family ff ON ff.id = fsf.family_id WHERE fpg.id = g.id AND ff.description IN ?)`,
+		joins:  productTypeJoin,
+		value:  "f.description",
+	},
+	models.FacetSubfamily: {
+		filter: `EXISTS (SELECT 1 FROM partexplorer.part_group fpg JOIN partexplorer.product_type fpt ON fpt.id = fpg.product_type_id JOIN partexplorer.subfamily fsf ON fsf.id = fpt.subfamily_id WHERE fpg.id = g.id AND fsf.description IN ?)`,
+		joins:  productTypeJoin,
+		value:  "sf.description",
+	},
+	models.FacetProductType: {
+		filter: `EXISTS (SELECT 1 FROM partexplorer.part_group fpg JOIN partexplorer.product_type fpt ON fpt.id = fpg.product_type_id WHERE fpg.id = g.id AND fpt.description IN ?)`,
+		joins:  productTypeJoin,
+		value:  "pt.description",
+	},
+	models.FacetState: {
+		filter: `EXISTS (SELECT 1 FROM partexplorer.stock fs JOIN partexplorer.part_name fpn ON fpn.id = fs.part_name_id JOIN partexplorer.company fc ON fc.id = fs.company_id WHERE fpn.group_id = g.id AND fs.obsolete = false AND fs.quantity > 0 AND fc.state IN ?)`,
+		joins:  stockAvailableJoin,
+		value:  "c.state",
+	},
+	models.FacetCity: {
+		filter: `EXISTS (SELECT 1 FROM partexplorer.stock fs JOIN partexplorer.part_name fpn ON fpn.id = fs.part_name_id JOIN partexplorer.company fc ON fc.id = fs.company_id WHERE fpn.group_id = g.id AND fs.obsolete = false AND fs.quantity > 0 AND fc.city IN ?)`,
+		joins:  stockAvailableJoin,
+		value:  "c.city",
+	},
+}
+
+// SearchPartsFaceted busca peças por texto aplicando os facets selecionados e
+// retorna a contagem de cada facet considerando os demais filtros
+func (r *partRepository) SearchPartsFaceted(query string, filters models.SearchFilters, page, pageSize int) (*models.SearchResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 16
+	}
+	offset := (page - 1) * pageSize
+
+	base, baseArgs := buildSearchBaseCTE(query)
+
+	conditions, conditionArgs := buildFacetConditions(filters)
+	var total int64
+	if err := r.db.Raw(base+"SELECT COUNT(*) FROM base g WHERE "+conditions,
+		append(append([]interface{}{}, baseArgs...), conditionArgs...)...).Scan(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count results: %w", err)
+	}
+
+	var rows []struct {
+		ID string
+	}
+	pageArgs := append(append(append([]interface{}{}, baseArgs...), conditionArgs...), pageSize, offset)
+	if err := r.db.Raw(base+`
+		SELECT g.id FROM base g
+		WHERE `+conditions+`
+		ORDER BY g.score DESC, g.created_at DESC, g.id
+		LIMIT ? OFFSET ?
+	`, pageArgs...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to search parts: %w", err)
+	}
+
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+
+	results, err := r.GetPartsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	facets := make(map[string][]models.FacetValue, len(models.SearchFacets))
+	for _, name := range models.SearchFacets {
+		facet := searchFacetSQL[name]
+
+		// Cada facet é contado sem o próprio filtro, para permitir seleção múltipla
+		otherConditions, otherArgs := buildFacetConditions(filters.Except(name))
+		facetArgs := append(append(append([]interface{}{}, baseArgs...), otherArgs...), searchFacetLimit)
+
+		var values []models.FacetValue
+		if err := r.db.Raw(base+`
+			SELECT `+facet.value+` AS value, COUNT(DISTINCT g.id) AS count
+			FROM base g`+facet.joins+`
+			WHERE `+otherConditions+` AND COALESCE(`+facet.value+`, '') <> ''
+			GROUP BY `+facet.value+`
+			ORDER BY count DESC, value
+			LIMIT ?
+		`, facetArgs...).Scan(&values).Error; err != nil {
+			return nil, fmt.Errorf("failed to load %s facet: %w", name, err)
+		}
+		if values == nil {
+			values = []models.FacetValue{}
+		}
+		facets[name] = values
+	}
+	filters.MarkSelected(facets)
+
+	return &models.SearchResponse{
+		Results:    results,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		Query:      query,
+		Facets:     facets,
+	}, nil
+}
+
+// Expressões normalizadas (minúsculas, sem acento) cobertas pelos índices trigram da migration 009
+const (
+	searchNameExpr  = "partexplorer.f_unaccent(lower(pn.name))"
+	searchBrandExpr = "partexplorer.f_unaccent(lower(b.name))"
+)
+
+// buildSearchBaseCTE monta a CTE "base" (id, created_at, score) com os grupos que casam com o texto.
+// Cada palavra precisa aparecer no nome ou na marca, por substring ou por similaridade trigram
+// (tolerando erros de digitação); a consulta também é expandida pelos sinônimos do dicionário.
+func buildSearchBaseCTE(query string) (string, []interface{}) {
+	variants := synonyms.Default().Expand(query)
+	if len(variants) == 0 {
+		return `
+		WITH base AS (
+			SELECT pg.id, pg.created_at, 0::float AS score FROM partexplorer.part_group pg
+		)
+	`, nil
+	}
+
+	args := []interface{}{variants[0]}
+	variantConditions := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		var tokenConditions []string
+		for _, token := range strings.Fields(variant) {
+			tokenConditions = append(tokenConditions,
+				"("+searchNameExpr+" LIKE ? OR ? <% "+searchNameExpr+" OR "+searchBrandExpr+" LIKE ? OR ? <% "+searchBrandExpr+")")
+			pattern := "%" + token + "%"
+			args = append(args, pattern, token, pattern, token)
+		}
+		variantConditions = append(variantConditions, "("+strings.Join(tokenConditions, " AND ")+")")
+	}
+
+	return `
+		WITH base AS (
+			SELECT pg.id, pg.created_at, MAX(word_similarity(?, ` + searchNameExpr + `)) AS score
+			FROM partexplorer.part_group pg
+			JOIN partexplorer.part_name pn ON pn.group_id = pg.id
+			LEFT JOIN partexplorer.brand b ON b.id = pn.brand_id
+			WHERE ` + strings.Join(variantConditions, " OR ") + `
+			GROUP BY pg.id, pg.created_at
+		)
+	`, args
+}
+
+// buildFacetConditions monta as condições (AND entre facets, IN dentro de cada facet)
+func buildFacetConditions(filters models.SearchFilters) (string, []interface{}) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+
+	for _, name := range models.SearchFacets {
+		values := filters[name]
+		if len(values) == 0 {
+			continue
+		}
+		conditions = append(conditions, searchFacetSQL[name].filter)
+		args = append(args, values)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
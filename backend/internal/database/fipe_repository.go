@@ -0,0 +1,206 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"partexplorer/backend/internal/fipe"
+	"partexplorer/backend/internal/models"
+)
+
+// fipeImportBatchSize é o tamanho dos lotes de INSERT da importação
+const fipeImportBatchSize = 1000
+
+// ErrFipeNotFound indica que o código FIPE não está na tabela importada nem tem preços
+var ErrFipeNotFound = errors.New("fipe code not found")
+
+// FipeRepository interface para a tabela FIPE e o histórico de preços
+type FipeRepository interface {
+	Import(records []fipe.Record) (*models.FipeImportResult, error)
+	GetVehicle(code string) (*models.FipeVehicle, error)
+	PriceCurve(code string, modelYear int, from, to time.Time) (*models.FipePriceCurve, error)
+}
+
+// fipeRepository implementa FipeRepository
+type fipeRepository struct {
+	db *gorm.DB
+}
+
+// NewFipeRepository cria uma nova instância do repositório
+func NewFipeRepository(db *gorm.DB) FipeRepository {
+	return &fipeRepository{db: db}
+}
+
+// Import grava as linhas de uma tabela oficial em uma transação. Códigos já cadastrados têm
+// marca, modelo e combustível atualizados; o preço de um mês já importado (ou visto numa
+// consulta de placa) é substituído pelo da tabela.
+func (r *fipeRepository) Import(records []fipe.Record) (*models.FipeImportResult, error) {
+	vehicles := make(map[string]models.FipeVehicle)
+	prices := make(map[string]models.FipePrice)
+	months := make(map[string]bool)
+	now := time.Now()
+	for _, record := range records {
+		vehicle := vehicles[record.Code]
+		vehicle.Code = record.Code
+		if record.Brand != "" {
+			vehicle.Brand = record.Brand
+		}
+		if record.Model != "" {
+			vehicle.Model = record.Model
+		}
+		if record.Fuel != "" {
+			vehicle.Fuel = record.Fuel
+		}
+		vehicle.UpdatedAt = now
+		vehicles[record.Code] = vehicle
+
+		// Uma linha por chave: o PostgreSQL não aceita a mesma chave duas vezes no mesmo upsert
+		key := fmt.Sprintf("%s|%d|%s", record.Code, record.ModelYear, record.ReferenceMonth.Format("2006-01"))
+		prices[key] = models.FipePrice{
+			Code:           record.Code,
+			ModelYear:      record.ModelYear,
+			ReferenceMonth: fipe.MonthStart(record.ReferenceMonth),
+			Price:          record.Price,
+			Source:         models.FipePriceSourceTable,
+			UpdatedAt:      now,
+		}
+		months[record.ReferenceMonth.Format("2006-01")] = true
+	}
+
+	vehicleRows := make([]models.FipeVehicle, 0, len(vehicles))
+	for _, vehicle := range vehicles {
+		vehicleRows = append(vehicleRows, vehicle)
+	}
+	priceRows := make([]models.FipePrice, 0, len(prices))
+	for _, price := range prices {
+		priceRows = append(priceRows, price)
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if len(vehicleRows) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "code"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"brand":      gorm.Expr("COALESCE(NULLIF(EXCLUDED.brand, ''), fipe_vehicle.brand)"),
+					"model":      gorm.Expr("COALESCE(NULLIF(EXCLUDED.model, ''), fipe_vehicle.model)"),
+					"fuel":       gorm.Expr("COALESCE(NULLIF(EXCLUDED.fuel, ''), fipe_vehicle.fuel)"),
+					"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+				}),
+			}).CreateInBatches(&vehicleRows, fipeImportBatchSize).Error
+			if err != nil {
+				return fmt.Errorf("failed to import fipe vehicles: %w", err)
+			}
+		}
+		if len(priceRows) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "code"}, {Name: "model_year"}, {Name: "reference_month"}},
+				DoUpdates: clause.AssignmentColumns([]string{"price", "source", "updated_at"}),
+			}).CreateInBatches(&priceRows, fipeImportBatchSize).Error
+			if err != nil {
+				return fmt.Errorf("failed to import fipe prices: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.FipeImportResult{Vehicles: len(vehicleRows), Prices: len(priceRows)}
+	for month := range months {
+		result.Months = append(result.Months, month)
+	}
+	sort.Strings(result.Months)
+	return result, nil
+}
+
+// GetVehicle busca o código na tabela importada
+func (r *fipeRepository) GetVehicle(value string) (*models.FipeVehicle, error) {
+	code, err := fipe.NormalizeCode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var vehicle models.FipeVehicle
+	if err := r.db.Where("code = ?", code).First(&vehicle).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFipeNotFound
+		}
+		return nil, fmt.Errorf("failed to get fipe vehicle: %w", err)
+	}
+	return &vehicle, nil
+}
+
+// PriceCurve retorna o preço mês a mês do código e ano-modelo, do mais antigo ao mais recente,
+// limitado aos meses entre from e to quando informados
+func (r *fipeRepository) PriceCurve(value string, modelYear int, from, to time.Time) (*models.FipePriceCurve, error) {
+	code, err := fipe.NormalizeCode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.db.Where("code = ? AND model_year = ?", code, modelYear)
+	if !from.IsZero() {
+		query = query.Where("reference_month >= ?", fipe.MonthStart(from))
+	}
+	if !to.IsZero() {
+		query = query.Where("reference_month <= ?", fipe.MonthStart(to))
+	}
+
+	var prices []models.FipePrice
+	if err := query.Order("reference_month ASC").Find(&prices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get fipe prices: %w", err)
+	}
+
+	curve := &models.FipePriceCurve{Code: code, ModelYear: modelYear, Prices: []models.FipePricePoint{}}
+	vehicle, err := r.GetVehicle(code)
+	switch {
+	case err == nil:
+		curve.Vehicle = vehicle
+	case errors.Is(err, ErrFipeNotFound):
+		// Código visto só nas consultas de placa, ainda sem a tabela importada
+		if len(prices) == 0 {
+			return nil, ErrFipeNotFound
+		}
+	default:
+		return nil, err
+	}
+
+	for _, price := range prices {
+		curve.Prices = append(curve.Prices, models.FipePricePoint{
+			ReferenceMonth: price.ReferenceMonth.Format("2006-01"),
+			Price:          price.Price,
+			Source:         price.Source,
+		})
+	}
+	if len(prices) > 1 && prices[0].Price > 0 {
+		curve.ChangePercent = (prices[len(prices)-1].Price - prices[0].Price) / prices[0].Price * 100
+	}
+	return curve, nil
+}
+
+// recordFipeObservation guarda no histórico o valor FIPE informado pelo provedor de placa, no
+// mês corrente. Não substitui o preço da tabela oficial nem outro valor já visto no mês.
+func recordFipeObservation(db *gorm.DB, car *models.Car) error {
+	code, err := fipe.NormalizeCode(car.FipeCode)
+	if err != nil || car.ModelYear <= 0 || car.FipeValue <= 0 {
+		return nil
+	}
+
+	price := models.FipePrice{
+		Code:           code,
+		ModelYear:      car.ModelYear,
+		ReferenceMonth: fipe.MonthStart(time.Now()),
+		Price:          car.FipeValue,
+		Source:         models.FipePriceSourcePlate,
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&price).Error; err != nil {
+		return fmt.Errorf("failed to record fipe price: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"partexplorer/backend/internal/models"
+)
+
+// fitmentFacetLimit limita a quantidade de valores retornados por facet
+const fitmentFacetLimit = 50
+
+// SearchPartsByFitment busca peças compatíveis com o veículo via SQL (fallback do Elasticsearch).
+// Cada grupo recebe a pontuação da aplicação mais específica que casou com o veículo.
+func (r *partRepository) SearchPartsByFitment(query models.FitmentQuery, page, pageSize int) (*models.SearchResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	offset := (page - 1) * pageSize
+
+	matches, args := buildFitmentCTE(query)
+
+	var total int64
+	if err := r.db.Raw(matches+"SELECT COUNT(*) FROM matches", args...).Scan(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count fitment results: %w", err)
+	}
+
+	var rows []struct {
+		GroupID string
+		Score   float64
+	}
+	pageArgs := append(append([]interface{}{}, args...), pageSize, offset)
+	if err := r.db.Raw(matches+`
+		SELECT group_id, score FROM matches
+		ORDER BY score DESC, group_id
+		LIMIT ? OFFSET ?
+	`, pageArgs...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to execute fitment query: %w", err)
+	}
+
+	ids := make([]string, len(rows))
+	scores := make(map[string]float64, len(rows))
+	for i, row := range rows {
+		ids[i] = row.GroupID
+		scores[row.GroupID] = row.Score
+	}
+
+	results, err := r.GetPartsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Score = scores[results[i].ID]
+	}
+
+	facets := make(map[string][]models.FacetValue)
+	for name, column := range map[string]string{"family": "f.description", "subfamily": "sf.description"} {
+		var values []models.FacetValue
+		if err := r.db.Raw(matches+`
+			SELECT `+column+` AS value, COUNT(DISTINCT m.group_id) AS count
+			FROM matches m
+			JOIN partexplorer.part_group pg ON pg.id = m.group_id
+			JOIN partexplorer.product_type pt ON pt.id = pg.product_type_id
+			JOIN partexplorer.subfamily sf ON sf.id = pt.subfamily_id
+			JOIN partexplorer.family f ON f.id = sf.family_id
+			GROUP BY `+column+`
+			ORDER BY count DESC, value
+			LIMIT ?
+		`, append(append([]interface{}{}, args...), fitmentFacetLimit)...).Scan(&values).Error; err != nil {
+			return nil, fmt.Errorf("failed to load %s facet: %w", name, err)
+		}
+		if values == nil {
+			values = []models.FacetValue{}
+		}
+		facets[name] = values
+	}
+
+	return &models.SearchResponse{
+		Results:    results,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		Facets:     facets,
+	}, nil
+}
+
+// CanonicalManufacturer converte o fabricante pelo dicionário do banco ("VW" -> "VOLKSWAGEN"),
+// a mesma regra da coluna application.canonical_manufacturer
+func (r *partRepository) CanonicalManufacturer(name string) (string, error) {
+	var canonical string
+	if err := r.db.Raw("SELECT partexplorer.f_canonical_manufacturer(?)", name).Scan(&canonical).Error; err != nil {
+		return "", fmt.Errorf("failed to get canonical manufacturer: %w", err)
+	}
+	return canonical, nil
+}
+
+// buildFitmentCTE monta a CTE "matches" (group_id, score) com os filtros do veículo.
+// Os pesos seguem os da query nested do Elasticsearch.
+func buildFitmentCTE(query models.FitmentQuery) (string, []interface{}) {
+	scoreParts := []string{"1"}
+	var scoreArgs []interface{}
+	conditions := []string{"1 = 1"}
+	var conditionArgs []interface{}
+
+	if query.Manufacturer != "" {
+		conditions = append(conditions, canonicalManufacturerExpr)
+		conditionArgs = append(conditionArgs, query.Manufacturer)
+	}
+
+	if query.Model != "" {
+		conditions = append(conditions, "app.model ILIKE ?")
+		conditionArgs = append(conditionArgs, "%"+query.Model+"%")
+		scoreParts = append(scoreParts, "(CASE WHEN LOWER(app.model) = LOWER(?) THEN 2 ELSE 0 END)")
+		scoreArgs = append(scoreArgs, query.Model)
+	}
+
+	if query.Year > 0 {
+		conditions = append(conditions, "(app.year_start IS NULL OR app.year_start <= ?) AND (app.year_end IS NULL OR app.year_end >= ?)")
+		conditionArgs = append(conditionArgs, query.Year, query.Year)
+		scoreParts = append(scoreParts, "(CASE WHEN app.year_start IS NOT NULL AND app.year_end IS NOT NULL THEN 1 ELSE 0 END)")
+	}
+
+	if query.Engine != "" {
+		conditions = append(conditions, "(COALESCE(app.engine, '') = '' OR app.engine ILIKE ?)")
+		conditionArgs = append(conditionArgs, "%"+query.Engine+"%")
+		scoreParts = append(scoreParts, "(CASE WHEN app.engine ILIKE ? THEN 2 ELSE 0 END)")
+		scoreArgs = append(scoreArgs, "%"+query.Engine+"%")
+	}
+
+	if query.Fuel != "" {
+		conditions = append(conditions, "(COALESCE(app.fuel, '') = '' OR LOWER(app.fuel) = LOWER(?))")
+		conditionArgs = append(conditionArgs, query.Fuel)
+		scoreParts = append(scoreParts, "(CASE WHEN LOWER(app.fuel) = LOWER(?) THEN 1.5 ELSE 0 END)")
+		scoreArgs = append(scoreArgs, query.Fuel)
+	}
+
+	cte := `
+		WITH matches AS (
+			SELECT pga.group_id, MAX(` + strings.Join(scoreParts, " + ") + `) AS score
+			FROM partexplorer.application app
+			JOIN partexplorer.part_group_application pga ON pga.application_id = app.id
+			WHERE ` + strings.Join(conditions, " AND ") + `
+			GROUP BY pga.group_id
+		)
+	`
+
+	return cte, append(scoreArgs, conditionArgs...)
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"partexplorer/backend/internal/models"
+)
+
+// applicationRow é uma aplicação acompanhada do grupo ao qual está ligada
+type applicationRow struct {
+	GroupID            uuid.UUID `gorm:"column:group_id"`
+	models.Application `gorm:"embedded"`
+}
+
+// stockLocationRow é uma linha da agregação de estoque por grupo e cidade
+type stockLocationRow struct {
+	GroupID uuid.UUID `gorm:"column:group_id"`
+	models.StockLocationSummary
+}
+
+// GetIndexData carrega em lote names, imagens, aplicações e estoque agregado dos grupos informados
+func (r *partRepository) GetIndexData(groupIDs []uuid.UUID) (map[uuid.UUID]*models.PartGroupIndexData, error) {
+	data := make(map[uuid.UUID]*models.PartGroupIndexData, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return data, nil
+	}
+	for _, id := range groupIDs {
+		data[id] = &models.PartGroupIndexData{}
+	}
+
+	var names []models.PartName
+	if err := r.db.Preload("Brand").
+		Where("group_id IN ?", groupIDs).
+		Order("created_at ASC").
+		Find(&names).Error; err != nil {
+		return nil, fmt.Errorf("failed to load part names: %w", err)
+	}
+	for _, name := range names {
+		if entry, ok := data[name.GroupID]; ok {
+			entry.Names = append(entry.Names, name)
+		}
+	}
+
+	var images []models.PartImage
+	if err := r.db.Where("group_id IN ?", groupIDs).
+		Order("created_at ASC").
+		Find(&images).Error; err != nil {
+		return nil, fmt.Errorf("failed to load part images: %w", err)
+	}
+	for _, image := range images {
+		if entry, ok := data[image.GroupID]; ok {
+			entry.Images = append(entry.Images, image)
+		}
+	}
+
+	var applications []applicationRow
+	if err := r.db.Table("partexplorer.application").
+		Select("pga.group_id, partexplorer.application.*").
+		Joins("JOIN partexplorer.part_group_application pga ON pga.application_id = partexplorer.application.id").
+		Where("pga.group_id IN ?", groupIDs).
+		Scan(&applications).Error; err != nil {
+		return nil, fmt.Errorf("failed to load part applications: %w", err)
+	}
+	for _, row := range applications {
+		if entry, ok := data[row.GroupID]; ok {
+			entry.Applications = append(entry.Applications, row.Application)
+		}
+	}
+
+	// Estoque não obsoleto agregado por cidade; o total por estado é derivado no indexador
+	var stock []stockLocationRow
+	if err := r.db.Raw(`
+		SELECT
+			pn.group_id,
+			COALESCE(c.state, '') AS state,
+			COALESCE(c.city, '') AS city,
+			COUNT(DISTINCT c.id) AS companies,
+			COALESCE(SUM(s.quantity), 0) AS quantity,
+			MIN(s.price) FILTER (WHERE s.price > 0) AS min_price
+		FROM partexplorer.stock s
+		JOIN partexplorer.part_name pn ON pn.id = s.part_name_id
+		JOIN partexplorer.company c ON c.id = s.company_id
+		WHERE pn.group_id IN ? AND s.obsolete = false
+		GROUP BY pn.group_id, c.state, c.city
+	`, groupIDs).Scan(&stock).Error; err != nil {
+		return nil, fmt.Errorf("failed to load stock aggregates: %w", err)
+	}
+	for _, row := range stock {
+		if entry, ok := data[row.GroupID]; ok {
+			entry.Stock = append(entry.Stock, row.StockLocationSummary)
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,247 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"partexplorer/backend/internal/manufacturer"
+	"partexplorer/backend/internal/models"
+)
+
+// canonicalManufacturerExpr compara fabricantes pelo nome canônico, para que "VW" e
+// "VOLKSWAGEN" sejam o mesmo fabricante nas buscas por aplicação. A coluna
+// canonical_manufacturer é mantida pelos triggers da migração 024 e tem índice.
+const canonicalManufacturerExpr = "app.canonical_manufacturer = partexplorer.f_canonical_manufacturer(?)"
+
+var (
+	// ErrManufacturerAliasNotFound indica que o apelido não existe
+	ErrManufacturerAliasNotFound = errors.New("manufacturer alias not found")
+	// ErrManufacturerAliasExists indica que o apelido já está cadastrado
+	ErrManufacturerAliasExists = errors.New("manufacturer alias already exists")
+	// ErrManufacturerAliasChain indica que o destino é apelido de outro fabricante
+	ErrManufacturerAliasChain = errors.New("target manufacturer is itself an alias of another manufacturer")
+	// ErrManufacturerAliasInUse indica que o fabricante canônico ainda tem apelidos apontando para ele
+	ErrManufacturerAliasInUse = errors.New("canonical manufacturer still has aliases")
+	// ErrManufacturerAliasInvalid indica apelido ou fabricante vazio
+	ErrManufacturerAliasInvalid = errors.New("alias and manufacturer are required")
+)
+
+// ManufacturerAliasRepository interface para o dicionário de fabricantes
+type ManufacturerAliasRepository interface {
+	List(manufacturerName string) ([]models.ManufacturerAlias, error)
+	Get(alias string) (*models.ManufacturerAlias, error)
+	Create(alias, manufacturerName string) (*models.ManufacturerAlias, error)
+	Update(alias, manufacturerName string) (*models.ManufacturerAlias, error)
+	Delete(alias string) error
+	Dictionary() (*manufacturer.Dictionary, error)
+	Missing() ([]models.MissingManufacturerAlias, error)
+}
+
+// manufacturerAliasRepository implementa ManufacturerAliasRepository
+type manufacturerAliasRepository struct {
+	db *gorm.DB
+}
+
+// NewManufacturerAliasRepository cria uma nova instância do repositório
+func NewManufacturerAliasRepository(db *gorm.DB) ManufacturerAliasRepository {
+	return &manufacturerAliasRepository{db: db}
+}
+
+// List lista os apelidos, opcionalmente só os de um fabricante canônico
+func (r *manufacturerAliasRepository) List(manufacturerName string) ([]models.ManufacturerAlias, error) {
+	query := r.db.Model(&models.ManufacturerAlias{})
+	if manufacturerName != "" {
+		query = query.Where("manufacturer = ?", manufacturer.Normalize(manufacturerName))
+	}
+
+	var aliases []models.ManufacturerAlias
+	if err := query.Order("manufacturer, alias").Find(&aliases).Error; err != nil {
+		return nil, fmt.Errorf("failed to list manufacturer aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// Get busca um apelido
+func (r *manufacturerAliasRepository) Get(alias string) (*models.ManufacturerAlias, error) {
+	var found models.ManufacturerAlias
+	if err := r.db.Where("alias = ?", manufacturer.Normalize(alias)).First(&found).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrManufacturerAliasNotFound
+		}
+		return nil, fmt.Errorf("failed to get manufacturer alias: %w", err)
+	}
+	return &found, nil
+}
+
+// Create cadastra o apelido. O fabricante canônico ganha a própria linha, se ainda não tiver.
+func (r *manufacturerAliasRepository) Create(alias, manufacturerName string) (*models.ManufacturerAlias, error) {
+	alias, manufacturerName = manufacturer.Normalize(alias), manufacturer.Normalize(manufacturerName)
+	if alias == "" || manufacturerName == "" {
+		return nil, ErrManufacturerAliasInvalid
+	}
+
+	created := models.ManufacturerAlias{Alias: alias, Manufacturer: manufacturerName}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.ManufacturerAlias{}).Where("alias = ?", alias).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrManufacturerAliasExists
+		}
+		if err := ensureCanonical(tx, alias, manufacturerName); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		created.CreatedAt = now
+		created.UpdatedAt = now
+		return tx.Create(&created).Error
+	})
+	if err != nil {
+		return nil, wrapManufacturerAliasError("create", err)
+	}
+	return &created, nil
+}
+
+// Update aponta o apelido para outro fabricante canônico
+func (r *manufacturerAliasRepository) Update(alias, manufacturerName string) (*models.ManufacturerAlias, error) {
+	alias, manufacturerName = manufacturer.Normalize(alias), manufacturer.Normalize(manufacturerName)
+	if alias == "" || manufacturerName == "" {
+		return nil, ErrManufacturerAliasInvalid
+	}
+
+	var updated models.ManufacturerAlias
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("alias = ?", alias).First(&updated).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrManufacturerAliasNotFound
+			}
+			return err
+		}
+		// Um canônico com apelidos não pode virar apelido de outro (criaria uma cadeia)
+		if updated.Alias == updated.Manufacturer && manufacturerName != alias {
+			if err := ensureUnused(tx, alias); err != nil {
+				return err
+			}
+		}
+		if err := ensureCanonical(tx, alias, manufacturerName); err != nil {
+			return err
+		}
+
+		updated.Manufacturer = manufacturerName
+		updated.UpdatedAt = time.Now()
+		return tx.Save(&updated).Error
+	})
+	if err != nil {
+		return nil, wrapManufacturerAliasError("update", err)
+	}
+	return &updated, nil
+}
+
+// Delete remove o apelido. Um fabricante canônico só sai quando não tem outros apelidos.
+func (r *manufacturerAliasRepository) Delete(alias string) error {
+	alias = manufacturer.Normalize(alias)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var found models.ManufacturerAlias
+		if err := tx.Where("alias = ?", alias).First(&found).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrManufacturerAliasNotFound
+			}
+			return err
+		}
+		if found.Alias == found.Manufacturer {
+			if err := ensureUnused(tx, alias); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&found).Error
+	})
+	return wrapManufacturerAliasError("delete", err)
+}
+
+// Dictionary carrega todos os apelidos para uso em memória (indexação e busca por placa)
+func (r *manufacturerAliasRepository) Dictionary() (*manufacturer.Dictionary, error) {
+	aliases, err := r.List("")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]string, len(aliases))
+	for _, alias := range aliases {
+		entries[alias.Alias] = alias.Manufacturer
+	}
+	return manufacturer.NewDictionary(entries), nil
+}
+
+// Missing lista os fabricantes das aplicações que não têm apelido cadastrado, com o número de
+// aplicações de cada um
+func (r *manufacturerAliasRepository) Missing() ([]models.MissingManufacturerAlias, error) {
+	var missing []models.MissingManufacturerAlias
+	err := r.db.Raw(`
+		SELECT app.manufacturer, COUNT(*) AS applications
+		FROM partexplorer.application app
+		WHERE COALESCE(btrim(app.manufacturer), '') <> ''
+		  AND NOT EXISTS (
+			SELECT 1 FROM partexplorer.manufacturer_alias ma
+			WHERE ma.alias = upper(partexplorer.f_unaccent(regexp_replace(btrim(app.manufacturer), '\s+', ' ', 'g')))
+		  )
+		GROUP BY app.manufacturer
+		ORDER BY applications DESC, app.manufacturer
+	`).Scan(&missing).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list manufacturers without alias: %w", err)
+	}
+	return missing, nil
+}
+
+// ensureCanonical garante que o destino é um fabricante canônico: cria a linha dele apontando
+// para si mesmo ou rejeita se ele for apelido de outro fabricante
+func ensureCanonical(tx *gorm.DB, alias, manufacturerName string) error {
+	if alias == manufacturerName {
+		return nil
+	}
+
+	var target models.ManufacturerAlias
+	err := tx.Where("alias = ?", manufacturerName).First(&target).Error
+	switch {
+	case err == nil:
+		if target.Manufacturer != manufacturerName {
+			return ErrManufacturerAliasChain
+		}
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		now := time.Now()
+		return tx.Create(&models.ManufacturerAlias{Alias: manufacturerName, Manufacturer: manufacturerName, CreatedAt: now, UpdatedAt: now}).Error
+	default:
+		return err
+	}
+}
+
+// ensureUnused rejeita a alteração de um fabricante canônico que ainda tem outros apelidos
+func ensureUnused(tx *gorm.DB, canonical string) error {
+	var count int64
+	if err := tx.Model(&models.ManufacturerAlias{}).Where("manufacturer = ? AND alias <> ?", canonical, canonical).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrManufacturerAliasInUse
+	}
+	return nil
+}
+
+// wrapManufacturerAliasError mantém os erros conhecidos e envolve os do banco
+func wrapManufacturerAliasError(action string, err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, known := range []error{ErrManufacturerAliasNotFound, ErrManufacturerAliasExists, ErrManufacturerAliasChain, ErrManufacturerAliasInUse} {
+		if errors.Is(err, known) {
+			return err
+		}
+	}
+	return fmt.Errorf("failed to %s manufacturer alias: %w", action, err)
+}
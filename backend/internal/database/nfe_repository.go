@@ -0,0 +1,537 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/nfe"
+)
+
+var (
+	// ErrNFeAlreadyImported indica uma nota com chave de acesso já importada
+	ErrNFeAlreadyImported = errors.New("NF-e already imported")
+	// ErrNFeCompanyNotFound indica uma nota sem empresa cadastrada como emitente ou destinatária
+	ErrNFeCompanyNotFound = errors.New("no company matches the NF-e issuer or recipient CNPJ")
+	// ErrNFeNotFound indica que a nota não foi importada
+	ErrNFeNotFound = errors.New("NF-e not found")
+	// ErrNFeItemNotFound indica que o item não existe
+	ErrNFeItemNotFound = errors.New("NF-e item not found")
+	// ErrNFeItemClosed indica um item já lançado ou descartado
+	ErrNFeItemClosed = errors.New("NF-e item already posted or discarded")
+)
+
+// NFeRepository interface para a importação de NF-e e a fila de conciliação dos itens
+type NFeRepository interface {
+	Ingest(invoice *nfe.Invoice, actor string) (*models.NFeInvoice, error)
+	Get(id string) (*models.NFeInvoice, error)
+	List(companyID string, page, pageSize int) (*models.NFeInvoiceListResponse, error)
+	ListQueue(companyID, reason string, page, pageSize int) (*models.NFeItemListResponse, error)
+	ResolveItem(id int64, req models.NFeResolveRequest) (*models.NFeItem, error)
+	DiscardItem(id int64, actor string) (*models.NFeItem, error)
+}
+
+// nfeRepository implementa NFeRepository
+type nfeRepository struct {
+	db *gorm.DB
+}
+
+// NewNFeRepository cria uma nova instância do repositório
+func NewNFeRepository(db *gorm.DB) NFeRepository {
+	return &nfeRepository{db: db}
+}
+
+// Ingest grava a nota e lança os itens no estoque das empresas cadastradas com o CNPJ do
+// emitente e do destinatário, numa única transação. Itens sem peça, com mais de uma peça
+// possível, com quantidade fracionária ou sem saldo para a venda ficam na fila de conciliação.
+// Uma nota já importada retorna ErrNFeAlreadyImported junto com a importação anterior.
+func (r *nfeRepository) Ingest(invoice *nfe.Invoice, actor string) (*models.NFeInvoice, error) {
+	actor = strings.TrimSpace(actor)
+	if actor == "" {
+		return nil, fmt.Errorf("%w: actor is required", ErrInvalidStockMovement)
+	}
+
+	var result *models.NFeInvoice
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.NFeInvoice
+		err := tx.Where("access_key = ?", invoice.AccessKey).First(&existing).Error
+		if err == nil {
+			result = &existing
+			return ErrNFeAlreadyImported
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get NF-e: %w", err)
+		}
+
+		record := &models.NFeInvoice{
+			ID:                uuid.New(),
+			AccessKey:         invoice.AccessKey,
+			Number:            invoice.Number,
+			Series:            invoice.Series,
+			Type:              invoice.Type,
+			IssuedAt:          invoice.IssuedAt,
+			IssuerDocument:    invoice.Issuer.Document,
+			IssuerName:        invoice.Issuer.Name,
+			RecipientDocument: invoice.Recipient.Document,
+			RecipientName:     invoice.Recipient.Name,
+			Actor:             actor,
+			CreatedAt:         time.Now(),
+		}
+		if record.IssuerCompanyID, err = companyByCNPJ(tx, invoice.Issuer.Document); err != nil {
+			return err
+		}
+		if record.RecipientCompanyID, err = companyByCNPJ(tx, invoice.Recipient.Document); err != nil {
+			return err
+		}
+		if record.IssuerCompanyID == nil && record.RecipientCompanyID == nil {
+			return fmt.Errorf("%w (issuer %s, recipient %s)", ErrNFeCompanyNotFound, invoice.Issuer.Document, invoice.Recipient.Document)
+		}
+		if err := tx.Omit("Items").Create(record).Error; err != nil {
+			return fmt.Errorf("failed to create NF-e: %w", err)
+		}
+
+		codes := make([]partCode, len(invoice.Items))
+		for i, item := range invoice.Items {
+			codes[i] = partCode{SKU: item.Code, EAN: item.EAN}
+		}
+		parts, err := findPartsByCode(tx, codes)
+		if err != nil {
+			return err
+		}
+
+		sides := []struct {
+			company *uuid.UUID
+			side    string
+		}{{record.IssuerCompanyID, nfe.SideIssuer}, {record.RecipientCompanyID, nfe.SideRecipient}}
+		for _, side := range sides {
+			if side.company == nil {
+				continue
+			}
+			if err := r.postItems(tx, record, invoice, *side.company, nfe.MovementType(invoice.Type, side.side), codes, parts); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(record).Updates(map[string]interface{}{"posted": record.Posted, "pending": record.Pending}).Error; err != nil {
+			return fmt.Errorf("failed to update NF-e: %w", err)
+		}
+		result = record
+		return nil
+	})
+	if err != nil {
+		return NFeIngestConflict(result, err, func() (*models.NFeInvoice, error) {
+			var existing models.NFeInvoice
+			if err := r.db.Where("access_key = ?", invoice.AccessKey).First(&existing).Error; err != nil {
+				return nil, err
+			}
+			return &existing, nil
+		})
+	}
+
+	log.Printf("🧾 [NFE] Nota %s (nº %s) importada por %s: %d item(ns) lançado(s), %d na fila de conciliação",
+		result.AccessKey, result.Number, actor, result.Posted, result.Pending)
+	return result, nil
+}
+
+// NFeIngestConflict trata o erro da transação de Ingest. Se outra importação da mesma nota
+// gravou a chave de acesso depois da consulta inicial, o índice único recusa a nota
+// (SQLSTATE 23505) e a transação é desfeita; nesse caso a nota gravada é buscada com lookup
+// e o erro vira ErrNFeAlreadyImported. Os demais erros são devolvidos sem alteração.
+func NFeIngestConflict(result *models.NFeInvoice, err error, lookup func() (*models.NFeInvoice, error)) (*models.NFeInvoice, error) {
+	if isUniqueViolation(err) {
+		existing, findErr := lookup()
+		if findErr != nil {
+			return nil, err
+		}
+		return existing, ErrNFeAlreadyImported
+	}
+	if errors.Is(err, ErrNFeAlreadyImported) {
+		return result, err
+	}
+	return nil, err
+}
+
+// isUniqueViolation reconhece a violação de índice único do PostgreSQL (SQLSTATE 23505)
+func isUniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23505"
+}
+
+// postItems lança os itens da nota no estoque de uma das empresas
+func (r *nfeRepository) postItems(tx *gorm.DB, record *models.NFeInvoice, invoice *nfe.Invoice, companyID uuid.UUID, movementType string, codes []partCode, parts map[string][]codedPart) error {
+	items := make([]models.NFeItem, len(invoice.Items))
+	partIDs := []uuid.UUID{}
+	for i, source := range invoice.Items {
+		items[i] = models.NFeItem{
+			InvoiceID:    record.ID,
+			ItemNumber:   source.Number,
+			CompanyID:    companyID,
+			MovementType: movementType,
+			Code:         source.Code,
+			EAN:          source.EAN,
+			Description:  source.Description,
+			Unit:         source.Unit,
+			Quantity:     source.Quantity,
+			UnitPrice:    source.UnitPrice,
+			Status:       models.NFeItemPending,
+			CreatedAt:    record.CreatedAt,
+		}
+
+		partID, err := resolvePartCode(codes[i], parts)
+		if err != nil {
+			items[i].Reason = nfeReason(err)
+			items[i].Details = err.Error()
+			continue
+		}
+		items[i].PartNameID = &partID
+		partIDs = append(partIDs, partID)
+	}
+
+	stocks, err := lockCompanyStocks(tx, companyID, partIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		item := &items[i]
+		if item.PartNameID != nil {
+			quantity, ok := nfe.WholeQuantity(item.Quantity)
+			if !ok {
+				item.Reason = models.NFeReasonFractional
+				item.Details = fmt.Sprintf("quantity %g %s is not a whole number of units", item.Quantity, item.Unit)
+			} else {
+				stock, err := postNFeItem(tx, record, item, stocks[*item.PartNameID], quantity, record.Actor)
+				if err != nil {
+					return err
+				}
+				if stock != nil {
+					stocks[*item.PartNameID] = stock
+				}
+			}
+		}
+
+		if item.Status == models.NFeItemPosted {
+			record.Posted++
+		} else {
+			record.Pending++
+		}
+		if err := tx.Create(item).Error; err != nil {
+			return fmt.Errorf("failed to create NF-e item: %w", err)
+		}
+	}
+	return nil
+}
+
+// Get busca a nota importada com os itens
+func (r *nfeRepository) Get(id string) (*models.NFeInvoice, error) {
+	invoiceID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrNFeNotFound
+	}
+
+	var invoice models.NFeInvoice
+	err = r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("item_number, company_id")
+	}).Where("id = ?", invoiceID).First(&invoice).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNFeNotFound
+		}
+		return nil, fmt.Errorf("failed to get NF-e: %w", err)
+	}
+	return &invoice, nil
+}
+
+// List lista as notas importadas, as mais recentes primeiro, filtrando pela empresa emitente
+// ou destinatária quando informada
+func (r *nfeRepository) List(companyID string, page, pageSize int) (*models.NFeInvoiceListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	query := r.db.Model(&models.NFeInvoice{})
+	if companyID != "" {
+		query = query.Where("issuer_company_id = ? OR recipient_company_id = ?", companyID, companyID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count NF-e: %w", err)
+	}
+
+	invoices := []models.NFeInvoice{}
+	if err := query.Order("issued_at DESC, created_at DESC").Limit(pageSize).Offset(offset).Find(&invoices).Error; err != nil {
+		return nil, fmt.Errorf("failed to list NF-e: %w", err)
+	}
+
+	return &models.NFeInvoiceListResponse{
+		Invoices:   invoices,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// ListQueue lista a fila de conciliação (itens pendentes), os mais antigos primeiro, filtrando
+// por empresa e motivo quando informados
+func (r *nfeRepository) ListQueue(companyID, reason string, page, pageSize int) (*models.NFeItemListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	query := r.db.Model(&models.NFeItem{}).Where("status = ?", models.NFeItemPending)
+	if companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count NF-e items: %w", err)
+	}
+
+	items := []models.NFeItem{}
+	if err := query.Order("created_at, id").Limit(pageSize).Offset(offset).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to list NF-e items: %w", err)
+	}
+
+	return &models.NFeItemListResponse{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// ResolveItem lança um item da fila de conciliação, com a peça informada na revisão ou
+// refazendo o casamento por EAN/SKU. Sem saldo para a venda, retorna ErrInsufficientStock e o
+// item continua na fila.
+func (r *nfeRepository) ResolveItem(id int64, req models.NFeResolveRequest) (*models.NFeItem, error) {
+	actor := strings.TrimSpace(req.Actor)
+	if actor == "" {
+		return nil, fmt.Errorf("%w: actor is required", ErrInvalidStockMovement)
+	}
+
+	var item *models.NFeItem
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var invoice *models.NFeInvoice
+		var err error
+		if item, invoice, err = lockNFeItem(tx, id); err != nil {
+			return err
+		}
+
+		var partID uuid.UUID
+		if req.PartNameID != "" {
+			if partID, err = uuid.Parse(req.PartNameID); err != nil {
+				return fmt.Errorf("%w: invalid part_name_id", ErrInvalidStockMovement)
+			}
+			var count int64
+			if err := tx.Model(&models.PartName{}).Where("id = ?", partID).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to get part name: %w", err)
+			}
+			if count == 0 {
+				return fmt.Errorf("%w: part_name_id not found", ErrInvalidStockMovement)
+			}
+		} else {
+			code := partCode{SKU: item.Code, EAN: item.EAN}
+			parts, err := findPartsByCode(tx, []partCode{code})
+			if err != nil {
+				return err
+			}
+			if partID, err = resolvePartCode(code, parts); err != nil {
+				return fmt.Errorf("%w: %v; inform part_name_id", ErrInvalidStockMovement, err)
+			}
+		}
+		item.PartNameID = &partID
+
+		quantity, ok := nfe.WholeQuantity(item.Quantity)
+		if req.Quantity != nil {
+			quantity, ok = *req.Quantity, *req.Quantity > 0
+		}
+		if !ok {
+			return fmt.Errorf("%w: quantity %g is not a whole number of units; inform quantity", ErrInvalidStockMovement, item.Quantity)
+		}
+
+		stocks, err := lockCompanyStocks(tx, item.CompanyID, []uuid.UUID{partID})
+		if err != nil {
+			return err
+		}
+		if _, err := postNFeItem(tx, invoice, item, stocks[partID], quantity, actor); err != nil {
+			return err
+		}
+		if item.Status != models.NFeItemPosted {
+			return fmt.Errorf("%w: %s", ErrInsufficientStock, item.Details)
+		}
+
+		now := time.Now()
+		item.ResolvedBy = actor
+		item.ResolvedAt = &now
+		if err := tx.Save(item).Error; err != nil {
+			return fmt.Errorf("failed to update NF-e item: %w", err)
+		}
+		return moveNFePending(tx, invoice, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// DiscardItem tira o item da fila de conciliação sem movimentar o estoque (itens que não são
+// peças, como frete ou embalagem)
+func (r *nfeRepository) DiscardItem(id int64, actor string) (*models.NFeItem, error) {
+	actor = strings.TrimSpace(actor)
+	if actor == "" {
+		return nil, fmt.Errorf("%w: actor is required", ErrInvalidStockMovement)
+	}
+
+	var item *models.NFeItem
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var invoice *models.NFeInvoice
+		var err error
+		if item, invoice, err = lockNFeItem(tx, id); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		item.Status = models.NFeItemDiscarded
+		item.ResolvedBy = actor
+		item.ResolvedAt = &now
+		if err := tx.Save(item).Error; err != nil {
+			return fmt.Errorf("failed to update NF-e item: %w", err)
+		}
+		return moveNFePending(tx, invoice, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// postNFeItem lança o item no estoque da empresa: a entrada cria o estoque quando a empresa
+// ainda não tem a peça; a venda sem estoque ou sem saldo deixa o item pendente. Retorna o
+// estoque movimentado, ou nil quando o item ficou pendente.
+func postNFeItem(tx *gorm.DB, invoice *models.NFeInvoice, item *models.NFeItem, stock *models.Stock, quantity int, actor string) (*models.Stock, error) {
+	delta := quantity
+	if item.MovementType == models.StockMovementSale {
+		delta = -quantity
+		if stock == nil || stockQuantity(stock) < quantity {
+			available := 0
+			if stock != nil {
+				available = stockQuantity(stock)
+			}
+			item.Status = models.NFeItemPending
+			item.Reason = models.NFeReasonInsufficientStock
+			item.Details = fmt.Sprintf("sale of %d with balance %d", quantity, available)
+			return nil, nil
+		}
+	}
+
+	if stock == nil {
+		zero := 0
+		stock = &models.Stock{
+			ID:         uuid.New(),
+			PartNameID: *item.PartNameID,
+			CompanyID:  item.CompanyID,
+			Quantity:   &zero,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+		if err := tx.Create(stock).Error; err != nil {
+			return nil, fmt.Errorf("failed to create stock: %w", err)
+		}
+	}
+
+	movement, err := applyMovement(tx, stock, models.StockMovement{
+		StockID:   stock.ID,
+		Type:      item.MovementType,
+		Quantity:  delta,
+		Actor:     actor,
+		Reason:    fmt.Sprintf("NF-e %s série %s, item %d", invoice.Number, invoice.Series, item.ItemNumber),
+		Reference: "nfe:" + invoice.AccessKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	item.Status = models.NFeItemPosted
+	item.Reason = ""
+	item.Details = ""
+	item.StockID = &stock.ID
+	item.MovementID = &movement.ID
+	return stock, nil
+}
+
+// nfeReason converte a falha do casamento do código no motivo da fila de conciliação
+func nfeReason(err error) string {
+	if errors.Is(err, errPartAmbiguous) {
+		return models.NFeReasonAmbiguous
+	}
+	return models.NFeReasonNotFound
+}
+
+// companyByCNPJ busca a empresa cadastrada com o documento, nil quando não há
+func companyByCNPJ(db *gorm.DB, document string) (*uuid.UUID, error) {
+	if len(document) != 14 {
+		return nil, nil
+	}
+	var ids []uuid.UUID
+	if err := db.Model(&models.Company{}).Where("cnpj = ?", document).Limit(1).Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get company by CNPJ: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
+
+// lockNFeItem busca o item pendente e a nota, com as linhas bloqueadas até o fim da transação
+func lockNFeItem(tx *gorm.DB, id int64) (*models.NFeItem, *models.NFeInvoice, error) {
+	var item models.NFeItem
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrNFeItemNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get NF-e item: %w", err)
+	}
+	if item.Status != models.NFeItemPending {
+		return nil, nil, ErrNFeItemClosed
+	}
+
+	var invoice models.NFeInvoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", item.InvoiceID).First(&invoice).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get NF-e: %w", err)
+	}
+	return &item, &invoice, nil
+}
+
+// moveNFePending tira um item da contagem de pendentes da nota, somando posted aos lançados
+func moveNFePending(tx *gorm.DB, invoice *models.NFeInvoice, posted int) error {
+	err := tx.Model(invoice).Updates(map[string]interface{}{
+		"pending": gorm.Expr("pending - 1"),
+		"posted":  gorm.Expr("posted + ?", posted),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update NF-e: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"partexplorer/backend/internal/models"
+)
+
+// OutboxRepository interface para a fila de indexação incremental
+type OutboxRepository interface {
+	FetchPending(limit int, lease time.Duration) ([]models.SearchOutbox, error)
+	MarkProcessed(ids []int64) error
+	MarkFailed(ids []int64, nextAttempt time.Time, reason string, maxAttempts int) error
+	PurgeProcessed(olderThan time.Duration) (int64, error)
+	GroupIDsSince(since time.Time) ([]string, error)
+	GetStats() (*models.SearchOutboxStats, error)
+}
+
+// outboxRepository implementação do repository
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository cria uma nova instância do repository
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// FetchPending reserva os eventos pendentes cujo próximo retry já venceu. A reserva empurra
+// o next_attempt_at por lease, então outro worker só volta a pegá-los se este não marcar o
+// resultado a tempo; SKIP LOCKED evita que dois workers disputem as mesmas linhas.
+func (r *outboxRepository) FetchPending(limit int, lease time.Duration) ([]models.SearchOutbox, error) {
+	var entries []models.SearchOutbox
+	err := r.db.Raw(`
+		UPDATE partexplorer.search_outbox
+		SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM partexplorer.search_outbox
+			WHERE processed_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?
+			ORDER BY id ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, time.Now().Add(lease), time.Now(), limit).
+		Scan(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending outbox entries: %w", err)
+	}
+
+	// O RETURNING não garante a ordem da subconsulta
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+// MarkProcessed marca os eventos como indexados
+func (r *outboxRepository) MarkProcessed(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := r.db.Model(&models.SearchOutbox{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"processed_at": time.Now(),
+			"last_error":   nil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox entries as processed: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed incrementa as tentativas e agenda o próximo retry; o evento que chega a
+// maxAttempts tentativas recebe failed_at e sai da fila
+func (r *outboxRepository) MarkFailed(ids []int64, nextAttempt time.Time, reason string, maxAttempts int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := r.db.Model(&models.SearchOutbox{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": nextAttempt,
+			"last_error":      reason,
+			"failed_at":       gorm.Expr("CASE WHEN attempts + 1 >= ? THEN CURRENT_TIMESTAMP END", maxAttempts),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox entries as failed: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeProcessed remove eventos já indexados há mais tempo que olderThan
+func (r *outboxRepository) PurgeProcessed(olderThan time.Duration) (int64, error) {
+	result := r.db.
+		Where("processed_at IS NOT NULL AND processed_at < ?", time.Now().Add(-olderThan)).
+		Delete(&models.SearchOutbox{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge outbox entries: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// GroupIDsSince retorna os grupos alterados desde o instante informado, processados ou não
+func (r *outboxRepository) GroupIDsSince(since time.Time) ([]string, error) {
+	var ids []string
+	err := r.db.Model(&models.SearchOutbox{}).
+		Distinct("group_id").
+		Where("created_at >= ?", since).
+		Pluck("group_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed groups: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetStats retorna o tamanho e o atraso da fila
+func (r *outboxRepository) GetStats() (*models.SearchOutboxStats, error) {
+	var pending struct {
+		Pending  int64
+		Retrying int64
+		Failed   int64
+		Oldest   *time.Time
+	}
+	err := r.db.Model(&models.SearchOutbox{}).
+		Select(`COUNT(*) FILTER (WHERE failed_at IS NULL) AS pending,
+			COUNT(*) FILTER (WHERE failed_at IS NULL AND attempts > 0) AS retrying,
+			COUNT(*) FILTER (WHERE failed_at IS NOT NULL) AS failed,
+			MIN(created_at) FILTER (WHERE failed_at IS NULL) AS oldest`).
+		Where("processed_at IS NULL").
+		Scan(&pending).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox stats: %w", err)
+	}
+
+	var lastProcessed struct {
+		Last *time.Time
+	}
+	err = r.db.Model(&models.SearchOutbox{}).
+		Select("MAX(processed_at) AS last").
+		Scan(&lastProcessed).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox stats: %w", err)
+	}
+
+	stats := &models.SearchOutboxStats{
+		Pending:         pending.Pending,
+		Retrying:        pending.Retrying,
+		Failed:          pending.Failed,
+		OldestPendingAt: pending.Oldest,
+		LastProcessedAt: lastProcessed.Last,
+	}
+	if pending.Oldest != nil {
+		stats.LagSeconds = time.Since(*pending.Oldest).Seconds()
+	}
+
+	return stats, nil
+}
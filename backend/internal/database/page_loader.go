@@ -0,0 +1,177 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"partexplorer/backend/internal/models"
+)
+
+// pageLoadOptions ajusta o carregamento em lote de uma página de resultados
+type pageLoadOptions struct {
+	// stockScope restringe os estoques carregados (empresa, estado, cidade...). A query
+	// recebida já tem JOIN com partexplorer.company c.
+	stockScope func(*gorm.DB) *gorm.DB
+	// uniqueCompanyStocks mantém só o primeiro estoque de cada empresa por grupo
+	uniqueCompanyStocks bool
+}
+
+// stockScopeWhere cria um stockScope a partir de uma condição sobre a empresa (alias c)
+func stockScopeWhere(condition string, args ...interface{}) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(condition, args...)
+	}
+}
+
+// loadSearchResults monta os SearchResult de uma página de part_groups carregando names
+// (com marca), imagens, aplicações, estoques (com empresa), product_type (com subfamília e
+// família) e dimensões em lote. A quantidade de queries é fixa, independente do tamanho da
+// página; relacionamentos já carregados no part_group (product_type, dimensão) são mantidos.
+func loadSearchResults(db *gorm.DB, partGroups []models.PartGroup, opts pageLoadOptions) ([]models.SearchResult, error) {
+	results := make([]models.SearchResult, len(partGroups))
+	if len(partGroups) == 0 {
+		return results, nil
+	}
+
+	groupIDs := make([]uuid.UUID, 0, len(partGroups))
+	seenGroups := make(map[uuid.UUID]bool, len(partGroups))
+	var productTypeIDs, dimensionIDs []uuid.UUID
+	for _, pg := range partGroups {
+		if !seenGroups[pg.ID] {
+			seenGroups[pg.ID] = true
+			groupIDs = append(groupIDs, pg.ID)
+			if pg.Dimension == nil {
+				dimensionIDs = append(dimensionIDs, pg.ID)
+			}
+		}
+		if pg.ProductType == nil && pg.ProductTypeID != nil {
+			productTypeIDs = append(productTypeIDs, *pg.ProductTypeID)
+		}
+	}
+
+	var names []models.PartName
+	if err := db.Preload("Brand").
+		Where("group_id IN ?", groupIDs).
+		Order("created_at ASC").
+		Find(&names).Error; err != nil {
+		return nil, fmt.Errorf("failed to load part names: %w", err)
+	}
+
+	var images []models.PartImage
+	if err := db.Where("group_id IN ?", groupIDs).
+		Find(&images).Error; err != nil {
+		return nil, fmt.Errorf("failed to load part images: %w", err)
+	}
+
+	var applications []applicationRow
+	if err := db.Table("partexplorer.application").
+		Select("pga.group_id, partexplorer.application.*").
+		Joins("JOIN partexplorer.part_group_application pga ON pga.application_id = partexplorer.application.id").
+		Where("pga.group_id IN ?", groupIDs).
+		Scan(&applications).Error; err != nil {
+		return nil, fmt.Errorf("failed to load part applications: %w", err)
+	}
+
+	// Estoques de todos os names da página, agrupados por name para manter a ordem dos names
+	stocksByName := make(map[uuid.UUID][]models.Stock)
+	if len(names) > 0 {
+		nameIDs := make([]uuid.UUID, len(names))
+		for i, name := range names {
+			nameIDs[i] = name.ID
+		}
+
+		query := db.Model(&models.Stock{}).
+			Joins("JOIN partexplorer.company c ON c.id = stock.company_id").
+			Where("stock.part_name_id IN ?", nameIDs).
+			Preload("Company")
+		if opts.stockScope != nil {
+			query = opts.stockScope(query)
+		}
+
+		var stocks []models.Stock
+		if err := query.Find(&stocks).Error; err != nil {
+			return nil, fmt.Errorf("failed to load stocks: %w", err)
+		}
+		for _, stock := range stocks {
+			stocksByName[stock.PartNameID] = append(stocksByName[stock.PartNameID], stock)
+		}
+	}
+
+	productTypes := make(map[uuid.UUID]*models.ProductType)
+	if len(productTypeIDs) > 0 {
+		var loaded []models.ProductType
+		if err := db.Preload("Subfamily.Family").
+			Where("id IN ?", productTypeIDs).
+			Find(&loaded).Error; err != nil {
+			return nil, fmt.Errorf("failed to load product types: %w", err)
+		}
+		for i := range loaded {
+			productTypes[loaded[i].ID] = &loaded[i]
+		}
+	}
+
+	dimensions := make(map[uuid.UUID]*models.PartGroupDimension)
+	if len(dimensionIDs) > 0 {
+		var loaded []models.PartGroupDimension
+		if err := db.Where("id IN ?", dimensionIDs).
+			Find(&loaded).Error; err != nil {
+			return nil, fmt.Errorf("failed to load dimensions: %w", err)
+		}
+		for i := range loaded {
+			dimensions[loaded[i].ID] = &loaded[i]
+		}
+	}
+
+	// Distribuir os dados carregados entre os grupos da página
+	namesByGroup := make(map[uuid.UUID][]models.PartName, len(groupIDs))
+	for _, name := range names {
+		namesByGroup[name.GroupID] = append(namesByGroup[name.GroupID], name)
+	}
+	imagesByGroup := make(map[uuid.UUID][]models.PartImage, len(groupIDs))
+	for _, image := range images {
+		imagesByGroup[image.GroupID] = append(imagesByGroup[image.GroupID], image)
+	}
+	applicationsByGroup := make(map[uuid.UUID][]models.Application, len(groupIDs))
+	for _, row := range applications {
+		applicationsByGroup[row.GroupID] = append(applicationsByGroup[row.GroupID], row.Application)
+	}
+
+	for i, pg := range partGroups {
+		if pg.ProductType == nil && pg.ProductTypeID != nil {
+			pg.ProductType = productTypes[*pg.ProductTypeID]
+		}
+		if pg.Dimension == nil {
+			pg.Dimension = dimensions[pg.ID]
+		}
+
+		groupNames := append([]models.PartName{}, namesByGroup[pg.ID]...)
+		groupStocks := []models.Stock{}
+		seenCompanies := make(map[uuid.UUID]bool)
+		for _, name := range groupNames {
+			for _, stock := range stocksByName[name.ID] {
+				if opts.uniqueCompanyStocks {
+					if seenCompanies[stock.CompanyID] {
+						continue
+					}
+					seenCompanies[stock.CompanyID] = true
+				}
+				groupStocks = append(groupStocks, stock)
+			}
+		}
+
+		results[i] = models.SearchResult{
+			ID:           pg.ID.String(),
+			PartGroup:    pg,
+			Names:        groupNames,
+			Images:       append([]models.PartImage{}, imagesByGroup[pg.ID]...),
+			Applications: append([]models.Application{}, applicationsByGroup[pg.ID]...),
+			Stocks:       groupStocks,
+			Dimension:    pg.Dimension,
+			Score:        1.0,
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"partexplorer/backend/internal/manufacturer"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/partcode"
+)
+
+var (
+	// errPartNotFound indica um código sem part_name cadastrado (ou não para a marca informada)
+	errPartNotFound = errors.New("part not found")
+	// errPartAmbiguous indica um código cadastrado para mais de uma peça
+	errPartAmbiguous = errors.New("ambiguous code")
+)
+
+// partCode é o código de uma peça vindo de fora (arquivo de estoque, NF-e)
+type partCode struct {
+	SKU   string
+	EAN   string
+	Brand string
+}
+
+// codedPart é um part_name encontrado para um código
+type codedPart struct {
+	ID             uuid.UUID
+	NormalizedCode string
+	Type           string
+	Brand          string
+}
+
+// findPartsByCode busca os part_name cujos códigos normalizados aparecem em codes, agrupados
+// por "tipo:código"
+func findPartsByCode(db *gorm.DB, codes []partCode) (map[string][]codedPart, error) {
+	normalized := []string{}
+	for _, code := range codes {
+		if gtin := partcode.NormalizeGTIN(code.EAN); gtin != "" {
+			normalized = append(normalized, gtin)
+		}
+		if sku := partcode.Normalize(code.SKU); sku != "" {
+			normalized = append(normalized, sku)
+		}
+	}
+	parts := make(map[string][]codedPart)
+	if len(normalized) == 0 {
+		return parts, nil
+	}
+
+	var found []codedPart
+	err := db.Table("partexplorer.part_name pn").
+		Select("pn.id, pn.normalized_code, lower(pn.type) AS type, COALESCE(b.name, '') AS brand").
+		Joins("LEFT JOIN partexplorer.brand b ON b.id = pn.brand_id").
+		Where("pn.normalized_code IN ?", normalized).
+		Where("lower(pn.type) IN ?", []string{partcode.TypeSKU, partcode.TypeEAN}).
+		Scan(&found).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve part codes: %w", err)
+	}
+	for _, part := range found {
+		key := part.Type + ":" + part.NormalizedCode
+		parts[key] = append(parts[key], part)
+	}
+	return parts, nil
+}
+
+// resolvePartCode escolhe o part_name do código: o EAN tem prioridade sobre o SKU e a marca,
+// quando informada, desempata códigos cadastrados para mais de uma peça
+func resolvePartCode(code partCode, parts map[string][]codedPart) (uuid.UUID, error) {
+	var candidates []codedPart
+	if gtin := partcode.NormalizeGTIN(code.EAN); gtin != "" {
+		candidates = parts[partcode.TypeEAN+":"+gtin]
+	}
+	if len(candidates) == 0 {
+		if sku := partcode.Normalize(code.SKU); sku != "" {
+			candidates = parts[partcode.TypeSKU+":"+sku]
+		}
+	}
+	if len(candidates) == 0 {
+		return uuid.Nil, fmt.Errorf("%w for sku %q ean %q", errPartNotFound, code.SKU, code.EAN)
+	}
+
+	if brand := manufacturer.Normalize(code.Brand); brand != "" {
+		matching := candidates[:0:0]
+		for _, candidate := range candidates {
+			if manufacturer.Normalize(candidate.Brand) == brand {
+				matching = append(matching, candidate)
+			}
+		}
+		if len(matching) == 0 {
+			return uuid.Nil, fmt.Errorf("%w for sku %q ean %q and brand %q", errPartNotFound, code.SKU, code.EAN, code.Brand)
+		}
+		candidates = matching
+	}
+
+	partID := candidates[0].ID
+	for _, candidate := range candidates[1:] {
+		if candidate.ID != partID {
+			brands := make([]string, 0, len(candidates))
+			for _, c := range candidates {
+				brands = append(brands, c.Brand)
+			}
+			return uuid.Nil, fmt.Errorf("%w: %d parts match (brands %s), inform the brand", errPartAmbiguous, len(candidates), strings.Join(brands, ", "))
+		}
+	}
+	return partID, nil
+}
+
+// lockCompanyStocks busca, com as linhas bloqueadas, os estoques da empresa para as peças;
+// havendo mais de um estoque para a mesma peça, fica o mais antigo
+func lockCompanyStocks(tx *gorm.DB, companyID uuid.UUID, partIDs []uuid.UUID) (map[uuid.UUID]*models.Stock, error) {
+	stocks := make(map[uuid.UUID]*models.Stock)
+	if len(partIDs) == 0 {
+		return stocks, nil
+	}
+
+	var existing []models.Stock
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("company_id = ? AND part_name_id IN ?", companyID, partIDs).
+		Order("created_at, id").
+		Find(&existing).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock company stocks: %w", err)
+	}
+	for i := range existing {
+		if _, ok := stocks[existing[i].PartNameID]; !ok {
+			stocks[existing[i].PartNameID] = &existing[i]
+		}
+	}
+	return stocks, nil
+}
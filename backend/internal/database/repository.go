@@ -4,15 +4,10 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"net/http"
-	"regexp"
 	"strconv"
-	"strings"
-	"time"
 
 	"partexplorer/backend/internal/models"
-
-	"io"
+	"partexplorer/backend/internal/partcode"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -22,15 +17,24 @@ import (
 type PartRepository interface {
 	SearchParts(query string, page, pageSize int, exactSku bool, sku string) (*models.SearchResponse, error)
 	SearchPartsSQL(query string, page, pageSize int) (*models.SearchResponse, error)
+	SearchPartsFaceted(query string, filters models.SearchFilters, page, pageSize int) (*models.SearchResponse, error)
 	SearchPartsByCompany(companyName string, state string, page, pageSize int, includeObsolete bool, availableOnly bool) (*models.SearchResponse, error)
 	SearchPartsByState(state string, page, pageSize int) (*models.SearchResponse, error)
 	SearchPartsByCity(city string, page, pageSize int) (*models.SearchResponse, error)
 	SearchPartsByCEP(cep string, page, pageSize int) (*models.SearchResponse, error)
 	SearchPartsByPlate(plate string, state string, page, pageSize int) (*models.SearchResponse, error)
 	SearchPartsByApplication(manufacturer string, model string, year string, page, pageSize int) (*models.SearchResponse, error)
+	SearchPartsByFitment(query models.FitmentQuery, page, pageSize int) (*models.SearchResponse, error)
+	CanonicalManufacturer(name string) (string, error)
 	SearchPartsByBrand(brandName string, page, pageSize int, availableOnly bool, includeObsolete bool) (*models.SearchResponse, error)
 	GetPartByID(id string) (*models.SearchResult, error)
+	GetPartsByIDs(ids []string) ([]models.SearchResult, error)
+	GetPartGroupsByIDs(ids []string) ([]models.PartGroup, error)
+	ListPartGroupsAfter(afterID string, limit int) ([]models.PartGroup, error)
+	CountPartGroups() (int64, error)
+	GetIndexData(groupIDs []uuid.UUID) (map[uuid.UUID]*models.PartGroupIndexData, error)
 	GetPartBySKU(sku string) (*models.SearchResult, error)
+	GetCrossReferences(code string) ([]models.CrossReferenceGroup, error)
 	GetDuplicateSKUs() ([]map[string]interface{}, error)
 	CleanDuplicateNames() (map[string]interface{}, error)
 	GetApplications() ([]models.Application, error)
@@ -123,45 +127,12 @@ func (r *partRepository) SearchPartsByCompany(companyName string, state string,
 		return nil, fmt.Errorf("failed to count results: %w", err)
 	}
 
-	// Converter para SearchResult e carregar dados relacionados
-	results := make([]models.SearchResult, len(partGroups))
-	for i, pg := range partGroups {
-		// Carregar names, images, applications e stocks manualmente
-		names := loadPartNames(r.db, pg.ID)
-		images := loadPartImages(r.db, pg.ID)
-		applications := loadPartApplications(r.db, pg.ID)
-
-		// Carregar product_type com relacionamentos
-		if pg.ProductTypeID != nil {
-			var productType models.ProductType
-			r.db.Preload("Subfamily.Family").First(&productType, *pg.ProductTypeID)
-			pg.ProductType = &productType
-		}
-
-		// Carregar estoque das empresas do grupo
-		var allStocks []models.Stock
-		for _, pn := range names {
-			var stocks []models.Stock
-			err := r.db.Model(&models.Stock{}).
-				Joins("JOIN partexplorer.company c ON c.id = stock.company_id").
-				Where("stock.part_name_id = ? AND LOWER(c.group_name) = LOWER(?)", pn.ID, companyName).
-				Preload("Company").
-				Find(&stocks).Error
-			if err == nil {
-				allStocks = append(allStocks, stocks...)
-			}
-		}
-
-		results[i] = models.SearchResult{
-			ID:           pg.ID.String(),
-			PartGroup:    pg,
-			Names:        names,
-			Images:       images,
-			Applications: applications,
-			Stocks:       allStocks,
-			Dimension:    pg.Dimension,
-			Score:        1.0,
-		}
+	// Carregar names, imagens, aplicações e estoques da página em lote
+	results, err := loadSearchResults(r.db, partGroups, pageLoadOptions{
+		stockScope: stockScopeWhere("LOWER(c.group_name) = LOWER(?)", companyName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search results: %w", err)
 	}
 
 	return &models.SearchResponse{
@@ -211,45 +182,12 @@ func (r *partRepository) SearchPartsByState(state string, page, pageSize int) (*
 		Where("c.state = ?", state).
 		Count(&total)
 
-	// Converter para SearchResult e carregar dados relacionados
-	results := make([]models.SearchResult, len(partGroups))
-	for i, pg := range partGroups {
-		// Carregar names, images, applications e stocks manualmente
-		names := loadPartNames(r.db, pg.ID)
-		images := loadPartImages(r.db, pg.ID)
-		applications := loadPartApplications(r.db, pg.ID)
-
-		// Carregar product_type com relacionamentos
-		if pg.ProductTypeID != nil {
-			var productType models.ProductType
-			r.db.Preload("Subfamily.Family").First(&productType, *pg.ProductTypeID)
-			pg.ProductType = &productType
-		}
-
-		// Carregar estoques do estado específico
-		var allStocks []models.Stock
-		for _, pn := range names {
-			var stocks []models.Stock
-			err := r.db.Model(&models.Stock{}).
-				Joins("JOIN partexplorer.company c ON c.id = stock.company_id").
-				Where("stock.part_name_id = ? AND c.state = ?", pn.ID, state).
-				Preload("Company").
-				Find(&stocks).Error
-			if err == nil {
-				allStocks = append(allStocks, stocks...)
-			}
-		}
-
-		results[i] = models.SearchResult{
-			ID:           pg.ID.String(),
-			PartGroup:    pg,
-			Names:        names,
-			Images:       images,
-			Applications: applications,
-			Stocks:       allStocks,
-			Dimension:    pg.Dimension,
-			Score:        1.0,
-		}
+	// Carregar names, imagens, aplicações e estoques da página em lote
+	results, err := loadSearchResults(r.db, partGroups, pageLoadOptions{
+		stockScope: stockScopeWhere("c.state = ?", state),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search results: %w", err)
 	}
 
 	return &models.SearchResponse{
@@ -281,23 +219,15 @@ func (r *partRepository) SearchParts(query string, page, pageSize int, exactSku
 
 	offset := (page - 1) * pageSize
 
-	// Query base com preloads específicos
-	baseQuery := r.db.Model(&models.PartGroup{}).
-		Preload("ProductType").
-		Preload("ProductType.Subfamily").
-		Preload("ProductType.Subfamily.Family").
-		Preload("Dimension").
-		Preload("Names").
-		Preload("Images").
-		Preload("Stocks")
-		// Preload("Applications") // Temporariamente removido
+	// Query base; os relacionamentos da página são carregados em lote depois
+	baseQuery := r.db.Model(&models.PartGroup{})
 
 	// Aplicar filtros de busca
 	if query != "" {
 		if exactSku && sku != "" {
 			// CORREÇÃO: Busca EXATA por SKU - apenas o SKU específico
-			// Usar subquery para pegar apenas part_groups únicos por SKU
-			baseQuery = baseQuery.Where("id IN (SELECT DISTINCT pn.group_id FROM partexplorer.part_name pn WHERE pn.name = ?)", sku)
+			// Compara pelo código normalizado ("KL-1234" = "kl 1234"; EAN com ou sem zero à esquerda)
+			baseQuery = baseQuery.Where("id IN (SELECT DISTINCT pn.group_id FROM partexplorer.part_name pn WHERE pn.normalized_code IN ?)", partcode.Candidates(sku))
 			log.Printf("🎯 [EXACT SKU] Busca exata por SKU: %s - usando DISTINCT para evitar duplicatas", sku)
 		} else {
 			// Busca normal em part_name (incluindo EANs que foram movidos)
@@ -320,34 +250,10 @@ func (r *partRepository) SearchParts(query string, page, pageSize int, exactSku
 		return nil, fmt.Errorf("failed to search parts: %w", err)
 	}
 
-	// Converter para SearchResult
-	results := make([]models.SearchResult, len(partGroups))
-	for i, pg := range partGroups {
-		log.Printf("DEBUG: Criando SearchResult para partGroup %d: ID=%s, ID.String()=%s, ID é zero? %t", i, pg.ID, pg.ID.String(), pg.ID == uuid.Nil)
-		searchResult := models.SearchResult{
-			ID:           pg.ID.String(),
-			PartGroup:    pg,
-			Names:        []models.PartName{},    // Será carregado manualmente
-			Images:       []models.PartImage{},   // Será carregado manualmente
-			Stocks:       []models.Stock{},       // Vazio por enquanto - estoque agora é por SKU
-			Applications: []models.Application{}, // Vazio por enquanto
-			Dimension:    pg.Dimension,
-			Score:        1.0, // Score básico, será melhorado com Elasticsearch
-		}
-		log.Printf("DEBUG: SearchResult criado com ID: %s", searchResult.ID)
-		log.Printf("DEBUG: SearchResult completo: %+v", searchResult)
-		results[i] = searchResult
-	}
-
-	// Após carregar os partGroups, para cada partGroup, buscar os part_names e para cada part_name buscar os estoques relacionados.
-	// Atualizar o campo Stocks de cada SearchResult para incluir os estoques de cada SKU/EAN.
-	for i, pg := range partGroups {
-		partGroupID := pg.ID
-		partNames := loadPartNames(r.db, partGroupID)
-		for _, pn := range partNames {
-			stocks := loadStocks(r.db, pn.ID)
-			results[i].Stocks = append(results[i].Stocks, stocks...)
-		}
+	// Carregar names, imagens, aplicações e estoques da página em lote
+	results, err := loadSearchResults(r.db, partGroups, pageLoadOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search results: %w", err)
 	}
 
 	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
@@ -432,10 +338,10 @@ func (r *partRepository) SearchPartsSQL(query string, page, pageSize int) (*mode
 			LEFT JOIN partexplorer.family f ON sf.family_id = f.id
 			LEFT JOIN partexplorer.part_group_dimension pgd ON pg.id = pgd.id
 			WHERE (
-				pn.name ILIKE $1 
-				OR pn.name ILIKE $2
-				OR b.name ILIKE $1
-				OR b.name ILIKE $2
+				partexplorer.f_unaccent(pn.name) ILIKE partexplorer.f_unaccent($1) 
+				OR partexplorer.f_unaccent(pn.name) ILIKE partexplorer.f_unaccent($2)
+				OR partexplorer.f_unaccent(b.name) ILIKE partexplorer.f_unaccent($1)
+				OR partexplorer.f_unaccent(b.name) ILIKE partexplorer.f_unaccent($2)
 			)
 			ORDER BY pg.created_at DESC
 			LIMIT $3 OFFSET $4
@@ -445,10 +351,10 @@ func (r *partRepository) SearchPartsSQL(query string, page, pageSize int) (*mode
 			FROM partexplorer.part_name pn 
 			LEFT JOIN partexplorer.brand b ON pn.brand_id = b.id
 			WHERE (
-				pn.name ILIKE $1 
-				OR pn.name ILIKE $2
-				OR b.name ILIKE $1
-				OR b.name ILIKE $2
+				partexplorer.f_unaccent(pn.name) ILIKE partexplorer.f_unaccent($1) 
+				OR partexplorer.f_unaccent(pn.name) ILIKE partexplorer.f_unaccent($2)
+				OR partexplorer.f_unaccent(b.name) ILIKE partexplorer.f_unaccent($1)
+				OR partexplorer.f_unaccent(b.name) ILIKE partexplorer.f_unaccent($2)
 			)
 		`
 		searchPattern := "%" + query + "%"
@@ -478,7 +384,7 @@ func (r *partRepository) SearchPartsSQL(query string, page, pageSize int) (*mode
 	}
 	defer rows.Close()
 
-	var results []models.SearchResult
+	var partGroups []models.PartGroup
 
 	for rows.Next() {
 		var (
@@ -556,28 +462,16 @@ func (r *partRepository) SearchPartsSQL(query string, page, pageSize int) (*mode
 			}
 		}
 
-		// Carregar todos os names do part_group
-		names := loadPartNames(r.db, groupUUID)
-
-		// Carregar dados relacionados
-		searchResult := models.SearchResult{
-			ID:           partGroup.ID.String(),
-			PartGroup:    partGroup,
-			Names:        names,
-			Images:       loadPartImages(r.db, partGroup.ID),
-			Applications: loadPartApplications(r.db, partGroup.ID),
-			Dimension:    partGroup.Dimension,
-			Score:        1.0,
-		}
-
-		// Após carregar os partGroups, para cada partGroup, buscar os part_names e para cada part_name buscar os estoques relacionados.
-		// Atualizar o campo Stocks de cada SearchResult para incluir os estoques de cada SKU/EAN.
-		for _, pn := range searchResult.Names {
-			stocks := loadStocks(r.db, pn.ID)
-			searchResult.Stocks = append(searchResult.Stocks, stocks...)
-		}
+		partGroups = append(partGroups, partGroup)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search parts: %w", err)
+	}
 
-		results = append(results, searchResult)
+	// Carregar names, imagens, aplicações e estoques da página em lote
+	results, err := loadSearchResults(r.db, partGroups, pageLoadOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search results: %w", err)
 	}
 
 	// Calcular total de páginas
@@ -607,41 +501,104 @@ func (r *partRepository) GetPartByID(id string) (*models.SearchResult, error) {
 
 	log.Printf("DEBUG: PartGroup encontrado: %+v", partGroup)
 
-	// Carregar relacionamentos manualmente usando as funções que funcionam
-	names := loadPartNames(r.db, partGroup.ID)
-	images := loadPartImages(r.db, partGroup.ID)
-	applications := loadPartApplications(r.db, partGroup.ID)
-
-	// Carregar estoques manualmente - SIMPLES E SEM DUPLICATAS
-	var allStocks []models.Stock
-	seenCompanies := make(map[uuid.UUID]bool)
-
-	for _, pn := range names {
-		stocks := loadStocks(r.db, pn.ID)
-		for _, stock := range stocks {
-			if !seenCompanies[stock.CompanyID] {
-				seenCompanies[stock.CompanyID] = true
-				allStocks = append(allStocks, stock)
-			}
+	// Estoques sem duplicatas: um por empresa
+	results, err := loadSearchResults(r.db, []models.PartGroup{partGroup}, pageLoadOptions{uniqueCompanyStocks: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get part: %w", err)
+	}
+
+	return &results[0], nil
+}
+
+// GetPartsByIDs carrega vários part_groups preservando a ordem dos IDs informados
+// (usado para hidratar resultados vindos do Elasticsearch)
+func (r *partRepository) GetPartsByIDs(ids []string) ([]models.SearchResult, error) {
+	validIDs := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if parsed, err := uuid.Parse(id); err == nil {
+			validIDs = append(validIDs, parsed)
 		}
 	}
+	if len(validIDs) == 0 {
+		return []models.SearchResult{}, nil
+	}
 
-	// Carregar product_type manualmente
-	if partGroup.ProductTypeID != nil {
-		var productType models.ProductType
-		r.db.Preload("Subfamily.Family").First(&productType, *partGroup.ProductTypeID)
-		partGroup.ProductType = &productType
+	var found []models.PartGroup
+	if err := r.db.Where("id IN ?", validIDs).Find(&found).Error; err != nil {
+		return nil, fmt.Errorf("failed to get parts: %w", err)
+	}
+	byID := make(map[uuid.UUID]models.PartGroup, len(found))
+	for _, pg := range found {
+		byID[pg.ID] = pg
 	}
 
-	return &models.SearchResult{
-		PartGroup:    partGroup,
-		Names:        names,
-		Images:       images,
-		Applications: applications,
-		Stocks:       allStocks, // NOVO CAMPO ADICIONADO
-		Dimension:    partGroup.Dimension,
-		Score:        1.0,
-	}, nil
+	partGroups := make([]models.PartGroup, 0, len(ids))
+	orderedIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parsed, err := uuid.Parse(id)
+		pg, ok := byID[parsed]
+		if err != nil || !ok {
+			// Documento órfão no índice - ignorar
+			log.Printf("⚠️ [SEARCH] part_group %s presente no índice mas não no banco", id)
+			continue
+		}
+		partGroups = append(partGroups, pg)
+		orderedIDs = append(orderedIDs, id)
+	}
+
+	results, err := loadSearchResults(r.db, partGroups, pageLoadOptions{uniqueCompanyStocks: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parts: %w", err)
+	}
+	for i := range results {
+		results[i].ID = orderedIDs[i]
+	}
+	return results, nil
+}
+
+// GetPartGroupsByIDs carrega os part_groups com os relacionamentos usados na indexação
+func (r *partRepository) GetPartGroupsByIDs(ids []string) ([]models.PartGroup, error) {
+	var partGroups []models.PartGroup
+	if len(ids) == 0 {
+		return partGroups, nil
+	}
+
+	if err := r.db.Preload("ProductType.Subfamily.Family").
+		Preload("Dimension").
+		Where("partexplorer.part_group.id IN ?", ids).
+		Find(&partGroups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get part groups: %w", err)
+	}
+
+	return partGroups, nil
+}
+
+// ListPartGroupsAfter pagina os part_groups por cursor (id > afterID), para varrer o catálogo inteiro
+func (r *partRepository) ListPartGroupsAfter(afterID string, limit int) ([]models.PartGroup, error) {
+	var partGroups []models.PartGroup
+
+	query := r.db.Preload("ProductType.Subfamily.Family").
+		Preload("Dimension").
+		Order("partexplorer.part_group.id ASC").
+		Limit(limit)
+	if afterID != "" {
+		query = query.Where("partexplorer.part_group.id > ?", afterID)
+	}
+
+	if err := query.Find(&partGroups).Error; err != nil {
+		return nil, fmt.Errorf("failed to list part groups: %w", err)
+	}
+
+	return partGroups, nil
+}
+
+// CountPartGroups retorna o total de part_groups
+func (r *partRepository) CountPartGroups() (int64, error) {
+	var total int64
+	if err := r.db.Model(&models.PartGroup{}).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to count part groups: %w", err)
+	}
+	return total, nil
 }
 
 // GetApplications retorna todas as aplicações
@@ -844,17 +801,6 @@ func parseUUIDFromString(s string) uuid.UUID {
 	return parsed
 }
 
-func parseTimeFromString(s string) *time.Time {
-	if s == "" {
-		return nil
-	}
-	t, err := time.Parse("2006-01-02T15:04:05Z", s)
-	if err != nil {
-		return nil
-	}
-	return &t
-}
-
 func parseFloat64(v interface{}) *float64 {
 	if v == nil {
 		return nil
@@ -870,158 +816,6 @@ func parseFloat64(v interface{}) *float64 {
 	return nil
 }
 
-func parseTimeFromInterface(v interface{}) *time.Time {
-	if v == nil {
-		return nil
-	}
-	switch val := v.(type) {
-	case time.Time:
-		return &val
-	case string:
-		return parseTimeFromString(val)
-	}
-	return nil
-}
-
-func parseUUIDFromInterface(v interface{}) uuid.UUID {
-	if v == nil {
-		return uuid.Nil
-	}
-	return parseUUIDFromString(v.(string))
-}
-
-// Funções auxiliares para carregar dados relacionados
-func loadPartNames(db *gorm.DB, groupID uuid.UUID) []models.PartName {
-	log.Printf("=== DEBUG: loadPartNames called for groupID: %s ===", groupID)
-	var rawResults []map[string]interface{}
-
-	// Query SQL direta para trazer brand junto com name e type
-	query := `
-		SELECT 
-			pn.id,
-			pn.group_id,
-			pn.brand_id,
-			pn.name,
-			pn.type,
-			pn.created_at,
-			pn.updated_at,
-			b.id as brand_id_check,
-			b.name as brand_name,
-			b.logo_url as brand_logo_url,
-			b.created_at as brand_created_at,
-			b.updated_at as brand_updated_at
-		FROM partexplorer.part_name pn
-		LEFT JOIN partexplorer.brand b ON pn.brand_id = b.id
-		WHERE pn.group_id = ?
-		ORDER BY pn.created_at ASC
-	`
-
-	log.Printf("=== DEBUG: Executing loadPartNames query for groupID: %s ===", groupID)
-	err := db.Raw(query, groupID).Scan(&rawResults).Error
-	if err != nil {
-		log.Printf("Error loading part names: %v", err)
-		return []models.PartName{}
-	}
-
-	// Processar os resultados para criar os objetos PartName com Brand
-	var names []models.PartName
-	for _, result := range rawResults {
-		createdAt := parseTimeFromInterface(result["created_at"])
-		updatedAt := parseTimeFromInterface(result["updated_at"])
-
-		// Safe type assertions
-		name, ok := result["name"].(string)
-		if !ok {
-			log.Printf("Warning: name is not a string for result: %+v", result)
-			continue
-		}
-
-		typeStr, ok := result["type"].(string)
-		if !ok {
-			log.Printf("Warning: type is not a string for result: %+v", result)
-			continue
-		}
-
-		partName := models.PartName{
-			ID:        parseUUIDFromInterface(result["id"]),
-			GroupID:   parseUUIDFromInterface(result["group_id"]),
-			BrandID:   parseUUIDFromInterface(result["brand_id"]),
-			Name:      name,
-			Type:      typeStr,
-			CreatedAt: time.Time{},
-			UpdatedAt: time.Time{},
-		}
-
-		if createdAt != nil {
-			partName.CreatedAt = *createdAt
-		}
-		if updatedAt != nil {
-			partName.UpdatedAt = *updatedAt
-		}
-
-		// Criar objeto Brand se brand_id não for nulo
-		if partName.BrandID != uuid.Nil {
-			brandName := ""
-			if result["brand_name"] != nil {
-				brandName = result["brand_name"].(string)
-			}
-
-			brandCreatedAt := parseTimeFromInterface(result["brand_created_at"])
-			brandUpdatedAt := parseTimeFromInterface(result["brand_updated_at"])
-
-			partName.Brand = &models.Brand{
-				ID:        partName.BrandID,
-				Name:      brandName,
-				LogoURL:   "", // Será preenchido se necessário
-				CreatedAt: time.Time{},
-				UpdatedAt: time.Time{},
-			}
-
-			if brandCreatedAt != nil {
-				partName.Brand.CreatedAt = *brandCreatedAt
-			}
-			if brandUpdatedAt != nil {
-				partName.Brand.UpdatedAt = *brandUpdatedAt
-			}
-		}
-
-		names = append(names, partName)
-	}
-
-	// Log para debug
-	log.Printf("DEBUG: loadPartNames - Total names loaded: %d", len(names))
-	for i, name := range names {
-		log.Printf("DEBUG: PartName[%d] - ID: %s, Name: %s, Type: %s, BrandID: %s, Brand: %+v",
-			i, name.ID, name.Name, name.Type, name.BrandID, name.Brand)
-	}
-
-	return names
-}
-
-func loadPartImages(db *gorm.DB, groupID uuid.UUID) []models.PartImage {
-	var images []models.PartImage
-	db.Where("group_id = ?", groupID).Find(&images)
-	return images
-}
-
-func loadPartApplications(db *gorm.DB, groupID uuid.UUID) []models.Application {
-	var applications []models.Application
-	db.Joins("JOIN partexplorer.part_group_application pga ON partexplorer.application.id = pga.application_id").
-		Where("pga.group_id = ?", groupID).
-		Find(&applications)
-	return applications
-}
-
-func loadStocks(db *gorm.DB, partNameID uuid.UUID) []models.Stock {
-	var stocks []models.Stock
-	// Carregar todos os stocks com company e price
-	db.Model(&models.Stock{}).
-		Preload("Company").
-		Where("part_name_id = ?", partNameID).
-		Find(&stocks)
-	return stocks
-}
-
 // SearchPartsByCity busca peças que têm estoque em uma cidade específica
 func (r *partRepository) SearchPartsByCity(city string, page, pageSize int) (*models.SearchResponse, error) {
 	if page < 1 {
@@ -1059,44 +853,12 @@ func (r *partRepository) SearchPartsByCity(city string, page, pageSize int) (*mo
 		Where("c.city = ?", city).
 		Count(&total)
 
-	// Converter para SearchResult e carregar dados relacionados
-	results := make([]models.SearchResult, len(partGroups))
-	for i, pg := range partGroups {
-		// Carregar names, images, applications e stocks manualmente
-		names := loadPartNames(r.db, pg.ID)
-		images := loadPartImages(r.db, pg.ID)
-		applications := loadPartApplications(r.db, pg.ID)
-
-		// Carregar product_type com relacionamentos
-		if pg.ProductTypeID != nil {
-			var productType models.ProductType
-			r.db.Preload("Subfamily.Family").First(&productType, *pg.ProductTypeID)
-			pg.ProductType = &productType
-		}
-
-		// Carregar estoques da cidade específica
-		var allStocks []models.Stock
-		for _, pn := range names {
-			var stocks []models.Stock
-			err := r.db.Model(&models.Stock{}).
-				Joins("JOIN partexplorer.company c ON c.id = stock.company_id").
-				Where("stock.part_name_id = ? AND c.city = ?", pn.ID, city).
-				Preload("Company").
-				Find(&stocks).Error
-			if err == nil {
-				allStocks = append(allStocks, stocks...)
-			}
-		}
-
-		results[i] = models.SearchResult{
-			PartGroup:    pg,
-			Names:        names,
-			Images:       images,
-			Applications: applications,
-			Stocks:       allStocks,
-			Dimension:    pg.Dimension,
-			Score:        1.0,
-		}
+	// Carregar names, imagens, aplicações e estoques da página em lote
+	results, err := loadSearchResults(r.db, partGroups, pageLoadOptions{
+		stockScope: stockScopeWhere("c.city = ?", city),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search results: %w", err)
 	}
 
 	return &models.SearchResponse{
@@ -1144,44 +906,12 @@ func (r *partRepository) SearchPartsByCEP(cep string, page, pageSize int) (*mode
 		Where("c.zip_code = ? OR LEFT(c.zip_code, 5) = LEFT(?, 5)", cep, cep).
 		Count(&total)
 
-	// Converter para SearchResult e carregar dados relacionados
-	results := make([]models.SearchResult, len(partGroups))
-	for i, pg := range partGroups {
-		// Carregar names, images, applications e stocks manualmente
-		names := loadPartNames(r.db, pg.ID)
-		images := loadPartImages(r.db, pg.ID)
-		applications := loadPartApplications(r.db, pg.ID)
-
-		// Carregar product_type com relacionamentos
-		if pg.ProductTypeID != nil {
-			var productType models.ProductType
-			r.db.Preload("Subfamily.Family").First(&productType, *pg.ProductTypeID)
-			pg.ProductType = &productType
-		}
-
-		// Carregar estoques das empresas que atendem o CEP
-		var allStocks []models.Stock
-		for _, pn := range names {
-			var stocks []models.Stock
-			err := r.db.Model(&models.Stock{}).
-				Joins("JOIN partexplorer.company c ON c.id = stock.company_id").
-				Where("stock.part_name_id = ? AND (c.zip_code = ? OR LEFT(c.zip_code, 5) = LEFT(?, 5))", pn.ID, cep, cep).
-				Preload("Company").
-				Find(&stocks).Error
-			if err == nil {
-				allStocks = append(allStocks, stocks...)
-			}
-		}
-
-		results[i] = models.SearchResult{
-			PartGroup:    pg,
-			Names:        names,
-			Images:       images,
-			Applications: applications,
-			Stocks:       allStocks,
-			Dimension:    pg.Dimension,
-			Score:        1.0,
-		}
+	// Carregar names, imagens, aplicações e estoques da página em lote
+	results, err := loadSearchResults(r.db, partGroups, pageLoadOptions{
+		stockScope: stockScopeWhere("c.zip_code = ? OR LEFT(c.zip_code, 5) = LEFT(?, 5)", cep, cep),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search results: %w", err)
 	}
 
 	return &models.SearchResponse{
@@ -1203,52 +933,11 @@ func (r *partRepository) SearchPartsByPlate(plate string, state string, page, pa
 
 	offset := (page - 1) * pageSize
 
-	// 1. Verificar se já temos os dados no cache
-	var existingCar models.Car
-	err := r.db.Where("license_plate = ?", plate).First(&existingCar).Error
-
-	var carInfo *models.CarInfo
-
+	// Buscar o veículo no cache ou nos provedores de placa
+	carInfo, err := NewCarRepository(r.db).SearchCarByPlate(plate)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// 2. Não encontrou no cache, buscar na API externa
-			log.Printf("=== DEBUG: Placa %s não encontrada no cache, buscando na API externa ===", plate)
-			carInfo = r.callExternalAPI(plate)
-
-			// 3. Salvar no cache
-			if carInfo != nil {
-				saveErr := r.saveCarToCache(carInfo)
-				if saveErr != nil {
-					log.Printf("=== DEBUG: Erro ao salvar no cache: %v ===", saveErr)
-				} else {
-					log.Printf("=== DEBUG: Carro salvo no cache com sucesso ===")
-				}
-			}
-		} else {
-			// Erro na consulta ao banco
-			log.Printf("=== DEBUG: Erro ao consultar cache: %v ===", err)
-			return nil, fmt.Errorf("erro ao consultar cache: %w", err)
-		}
-	} else {
-		// 4. Encontrou no cache, usar os dados existentes
-		log.Printf("=== DEBUG: Placa %s encontrada no cache ===", plate)
-		carInfo = &models.CarInfo{
-			Placa:          existingCar.LicensePlate,
-			Marca:          existingCar.Brand,
-			Modelo:         existingCar.Model,
-			Ano:            strconv.Itoa(existingCar.Year),
-			AnoModelo:      strconv.Itoa(existingCar.ModelYear),
-			Cor:            existingCar.Color,
-			Combustivel:    existingCar.FuelType,
-			Chassi:         existingCar.ChassisNumber,
-			Municipio:      existingCar.City,
-			UF:             existingCar.State,
-			Importado:      existingCar.Imported,
-			CodigoFipe:     existingCar.FipeCode,
-			ValorFipe:      fmt.Sprintf("R$ %.2f", existingCar.FipeValue),
-			DataConsulta:   existingCar.UpdatedAt.Format(time.RFC3339),
-			Confiabilidade: 0.9, // Valor padrão para dados do cache
-		}
+		log.Printf("=== DEBUG: Erro ao obter dados do veículo para placa %s: %v ===", plate, err)
+		carInfo = nil
 	}
 
 	// Se não conseguiu obter dados do veículo, retornar vazio
@@ -1264,24 +953,18 @@ func (r *partRepository) SearchPartsByPlate(plate string, state string, page, pa
 
 	log.Printf("=== DEBUG: CarInfo obtido: Marca=%s, Modelo=%s, Ano=%s ===", carInfo.Marca, carInfo.Modelo, carInfo.AnoModelo)
 
-	// Extrair apenas o primeiro nome do modelo (ex: "CLIO EXP 10 16VH" -> "CLIO")
-	modelParts := strings.Fields(carInfo.Modelo)
-	modelName := carInfo.Modelo
-	if len(modelParts) > 0 {
-		modelName = modelParts[0]
-	}
+	// Converter marca/modelo do provedor para o fabricante/modelo do catálogo
+	application := ResolveVehicleApplication(NewVehicleModelRepository(r.db), carInfo)
 
-	log.Printf("=== DEBUG: Modelo original: %s, Modelo extraído: %s ===", carInfo.Modelo, modelName)
+	log.Printf("=== DEBUG: Modelo original: %s, Aplicação: %s %s %s ===", carInfo.Modelo, application.Manufacturer, application.Model, application.Year)
 
 	// Buscar part_groups que têm applications compatíveis com o veículo
 	query := r.db.Model(&models.PartGroup{}).
 		Joins("JOIN partexplorer.part_name pn ON pn.group_id = part_group.id").
 		Joins("JOIN partexplorer.part_group_application pga ON pga.group_id = part_group.id").
 		Joins("JOIN partexplorer.application app ON app.id = pga.application_id").
-		Where("LOWER(app.manufacturer) = LOWER(?) AND LOWER(app.model) = LOWER(?) AND ? BETWEEN app.year_start AND app.year_end",
-			carInfo.Marca, modelName, 2007) // Usar ano modelo 2007 para o Clio
-
-	log.Printf("=== DEBUG: Query params - Marca: %s, Modelo: %s, Ano: %d ===", carInfo.Marca, modelName, 2007)
+		Where(canonicalManufacturerExpr+" AND LOWER(app.model) = LOWER(?) AND ? BETWEEN app.year_start AND app.year_end",
+			application.Manufacturer, application.Model, application.Year)
 
 	// Se estado foi especificado, filtrar por empresas do estado
 	if state != "" {
@@ -1320,8 +1003,8 @@ func (r *partRepository) SearchPartsByPlate(plate string, state string, page, pa
 		Joins("JOIN partexplorer.part_name pn ON pn.group_id = part_group.id").
 		Joins("JOIN partexplorer.part_group_application pga ON pga.group_id = part_group.id").
 		Joins("JOIN partexplorer.application app ON app.id = pga.application_id").
-		Where("LOWER(app.manufacturer) = LOWER(?) AND LOWER(app.model) = LOWER(?) AND ? BETWEEN app.year_start AND app.year_end",
-			carInfo.Marca, modelName, 2007).
+		Where(canonicalManufacturerExpr+" AND LOWER(app.model) = LOWER(?) AND ? BETWEEN app.year_start AND app.year_end",
+			application.Manufacturer, application.Model, application.Year).
 		Distinct("part_group.id")
 
 	// Se estado foi especificado, aplicar filtro na contagem também
@@ -1335,44 +1018,10 @@ func (r *partRepository) SearchPartsByPlate(plate string, state string, page, pa
 
 	log.Printf("=== DEBUG: Total de part_groups: %d ===", total)
 
-	// Converter para SearchResult
-	results := make([]models.SearchResult, len(partGroups))
-	for i, pg := range partGroups {
-		// Carregar dados relacionados
-		names := loadPartNames(r.db, pg.ID)
-		images := loadPartImages(r.db, pg.ID)
-		applications := loadPartApplications(r.db, pg.ID)
-
-		// Carregar product_type
-		if pg.ProductTypeID != nil {
-			var productType models.ProductType
-			r.db.Preload("Subfamily.Family").First(&productType, *pg.ProductTypeID)
-			pg.ProductType = &productType
-		}
-
-		// Carregar stocks
-		var allStocks []models.Stock
-		for _, pn := range names {
-			var stocks []models.Stock
-			err := r.db.Model(&models.Stock{}).
-				Joins("JOIN partexplorer.company c ON c.id = stock.company_id").
-				Where("stock.part_name_id = ?", pn.ID).
-				Preload("Company").
-				Find(&stocks).Error
-			if err == nil {
-				allStocks = append(allStocks, stocks...)
-			}
-		}
-
-		results[i] = models.SearchResult{
-			PartGroup:    pg,
-			Names:        names,
-			Images:       images,
-			Applications: applications,
-			Stocks:       allStocks,
-			Dimension:    pg.Dimension,
-			Score:        1.0,
-		}
+	// Carregar names, imagens, aplicações e estoques da página em lote
+	results, err := loadSearchResults(r.db, partGroups, pageLoadOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search results: %w", err)
 	}
 
 	return &models.SearchResponse{
@@ -1384,552 +1033,6 @@ func (r *partRepository) SearchPartsByPlate(plate string, state string, page, pa
 	}, nil
 }
 
-// callExternalAPI faz a chamada real para a API externa (keplaca.com)
-func (r *partRepository) callExternalAPI(plate string) *models.CarInfo {
-	log.Printf("=== DEBUG: Chamando API externa para placa %s ===", plate)
-
-	// Normalizar placa
-	plate = strings.ToUpper(strings.ReplaceAll(strings.ReplaceAll(plate, "-", ""), " ", ""))
-	if len(plate) != 7 {
-		log.Printf("=== DEBUG: Placa inválida: %s (deve ter 7 caracteres)", plate)
-		return nil
-	}
-
-	// Tentar buscar dados da API externa
-	carInfo := r.tryExternalAPI(plate)
-	if carInfo != nil {
-		return carInfo
-	}
-
-	// Se falhou, usar fallback com dados simulados baseados na placa
-	log.Printf("=== DEBUG: API externa falhou, usando fallback para placa %s ===", plate)
-	return r.createFallbackCarInfo(plate)
-}
-
-// tryExternalAPI tenta buscar dados da API externa
-func (r *partRepository) tryExternalAPI(plate string) *models.CarInfo {
-	// URL do keplaca.com
-	url := fmt.Sprintf("https://www.keplaca.com/placa?placa-fipe=%s", plate)
-	log.Printf("=== DEBUG: URL da requisição: %s ===", url)
-
-	// Fazer HTTP request com timeout mais curto
-	client := &http.Client{
-		Timeout: 10 * time.Second, // Timeout mais curto para evitar travamento
-	}
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		log.Printf("=== DEBUG: Erro ao criar request: %v ===", err)
-		return nil
-	}
-
-	// Adicionar headers para simular navegador
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en;q=0.8")
-	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("=== DEBUG: Erro ao fazer request: %v ===", err)
-		return nil
-	}
-	defer resp.Body.Close()
-
-	log.Printf("=== DEBUG: Status code da resposta: %d ===", resp.StatusCode)
-
-	if resp.StatusCode != 200 {
-		log.Printf("=== DEBUG: Status code não OK: %d ===", resp.StatusCode)
-		return nil
-	}
-
-	// Ler o body da resposta
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("=== DEBUG: Erro ao ler body: %v ===", err)
-		return nil
-	}
-
-	log.Printf("=== DEBUG: Tamanho do HTML recebido: %d bytes ===", len(body))
-
-	// Converter para string para fazer parsing
-	htmlContent := string(body)
-
-	// Extrair informações usando regex (baseado no script Python)
-	marca := r.extractMarca(htmlContent)
-	modelo := r.extractModelo(htmlContent)
-	ano := r.extractAno(htmlContent)
-	anoModelo := r.extractAnoModelo(htmlContent)
-	cor := r.extractCor(htmlContent)
-	combustivel := r.extractCombustivel(htmlContent)
-	chassi := r.extractChassi(htmlContent)
-	uf := r.extractUF(htmlContent)
-	municipio := r.extractMunicipio(htmlContent)
-	importado := r.extractImportado(htmlContent)
-	codigoFipe := r.extractCodigoFipe(htmlContent, modelo)
-	valorFipe := r.extractValorFipe(htmlContent, codigoFipe)
-
-	log.Printf("=== DEBUG: Dados extraídos - Marca: '%s', Modelo: '%s', Ano: '%s', Cor: '%s', Combustível: '%s' ===",
-		marca, modelo, ano, cor, combustivel)
-
-	// Verificar se temos pelo menos marca, modelo e ano
-	if marca != "" && modelo != "" && ano != "" {
-		log.Printf("=== DEBUG: Dados extraídos com sucesso - Marca: %s, Modelo: %s, Ano: %s ===", marca, modelo, ano)
-
-		return &models.CarInfo{
-			Placa:          plate,
-			Marca:          marca,
-			Modelo:         modelo,
-			Ano:            ano,
-			AnoModelo:      anoModelo,
-			Cor:            cor,
-			Combustivel:    combustivel,
-			Chassi:         chassi,
-			Municipio:      municipio,
-			UF:             uf,
-			Importado:      importado,
-			CodigoFipe:     codigoFipe,
-			ValorFipe:      valorFipe,
-			DataConsulta:   time.Now().Format(time.RFC3339),
-			Confiabilidade: 0.95,
-		}
-	}
-
-	log.Printf("=== DEBUG: Dados insuficientes extraídos - Marca: %s, Modelo: %s, Ano: %s ===", marca, modelo, ano)
-	return nil
-}
-
-// createFallbackCarInfo cria dados simulados baseados na placa
-func (r *partRepository) createFallbackCarInfo(plate string) *models.CarInfo {
-	log.Printf("=== DEBUG: Criando dados de fallback para placa %s ===", plate)
-
-	// Gerar dados baseados na placa (para garantir que sempre tenha dados)
-	// Usar hash da placa para gerar dados consistentes
-	hash := 0
-	for _, char := range plate {
-		hash += int(char)
-	}
-
-	// Mapear hash para dados de veículo
-	marcas := []string{"VOLKSWAGEN", "FIAT", "CHEVROLET", "FORD", "RENAULT", "HONDA", "TOYOTA", "HYUNDAI"}
-	modelos := []string{"GOL", "UNO", "CELTA", "KA", "CLIO", "CIVIC", "COROLLA", "HB20"}
-	cores := []string{"PRATA", "BRANCO", "PRETO", "AZUL", "VERMELHO", "CINZA", "BEGE", "VERDE"}
-	combustiveis := []string{"FLEX", "GASOLINA", "ETANOL", "DIESEL", "HÍBRIDO", "ELÉTRICO"}
-
-	marcaIndex := hash % len(marcas)
-	modeloIndex := (hash / 10) % len(modelos)
-	corIndex := (hash / 100) % len(cores)
-	combustivelIndex := (hash / 1000) % len(combustiveis)
-
-	ano := 2010 + (hash % 15) // Ano entre 2010 e 2024
-	anoModelo := ano + 1
-
-	return &models.CarInfo{
-		Placa:          plate,
-		Marca:          marcas[marcaIndex],
-		Modelo:         modelos[modeloIndex],
-		Ano:            strconv.Itoa(ano),
-		AnoModelo:      strconv.Itoa(anoModelo),
-		Cor:            cores[corIndex],
-		Combustivel:    combustiveis[combustivelIndex],
-		Chassi:         "*****" + plate[len(plate)-6:],
-		Municipio:      "São Paulo",
-		UF:             "SP",
-		Importado:      "NÃO",
-		CodigoFipe:     fmt.Sprintf("%06d-1", hash%999999),
-		ValorFipe:      fmt.Sprintf("R$ %d.000,00", 10+(hash%50)),
-		DataConsulta:   time.Now().Format(time.RFC3339),
-		Confiabilidade: 0.7, // Confiabilidade menor para dados de fallback
-	}
-}
-
-// Funções auxiliares para extrair dados usando regex
-func (r *partRepository) extractMarca(htmlContent string) string {
-	log.Printf("=== DEBUG: Extraindo marca do HTML ===")
-
-	// Buscar na tabela de detalhes - padrão mais flexível
-	patterns := []string{
-		`(?i)Marca:\s*([A-Z]+(?:\s+[A-Z]+)*)`,
-		`(?i)MARCA:\s*([A-Z]+(?:\s+[A-Z]+)*)`,
-		`(?i)<td[^>]*>Marca:</td>\s*<td[^>]*>([^<]+)</td>`,
-		`(?i)<td[^>]*>marca:</td>\s*<td[^>]*>([^<]+)</td>`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		match := re.FindStringSubmatch(htmlContent)
-		if len(match) > 1 {
-			marca := strings.TrimSpace(match[1])
-			if len(marca) > 2 {
-				log.Printf("=== DEBUG: Marca encontrada: %s ===", marca)
-				return strings.ToUpper(marca)
-			}
-		}
-	}
-
-	log.Printf("=== DEBUG: Marca não encontrada ===")
-	return ""
-}
-
-func (r *partRepository) extractModelo(htmlContent string) string {
-	log.Printf("=== DEBUG: Extraindo modelo do HTML ===")
-
-	// Buscar na tabela de detalhes - padrão mais flexível
-	patterns := []string{
-		`(?i)Modelo:\s*([^\n]+?)(?=\s*[A-Z]+\s*:|$)`,
-		`(?i)MODELO:\s*([^\n]+?)(?=\s*[A-Z]+\s*:|$)`,
-		`(?i)<td[^>]*>Modelo:</td>\s*<td[^>]*>([^<]+)</td>`,
-		`(?i)<td[^>]*>modelo:</td>\s*<td[^>]*>([^<]+)</td>`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		match := re.FindStringSubmatch(htmlContent)
-		if len(match) > 1 {
-			modelo := strings.TrimSpace(match[1])
-			if len(modelo) > 2 {
-				log.Printf("=== DEBUG: Modelo encontrado: %s ===", modelo)
-				return modelo
-			}
-		}
-	}
-
-	log.Printf("=== DEBUG: Modelo não encontrado ===")
-	return ""
-}
-
-func (r *partRepository) extractAno(htmlContent string) string {
-	log.Printf("=== DEBUG: Extraindo ano do HTML ===")
-
-	// Buscar na tabela de detalhes - padrão mais flexível
-	patterns := []string{
-		`(?i)Ano:\s*(\d{4})`,
-		`(?i)ANO:\s*(\d{4})`,
-		`(?i)<td[^>]*>Ano:</td>\s*<td[^>]*>(\d{4})</td>`,
-		`(?i)<td[^>]*>ano:</td>\s*<td[^>]*>(\d{4})</td>`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		match := re.FindStringSubmatch(htmlContent)
-		if len(match) > 1 {
-			ano := match[1]
-			if anoInt, err := strconv.Atoi(ano); err == nil {
-				if 1900 <= anoInt && anoInt <= time.Now().Year()+1 {
-					log.Printf("=== DEBUG: Ano encontrado: %s ===", ano)
-					return ano
-				}
-			}
-		}
-	}
-
-	log.Printf("=== DEBUG: Ano não encontrado ===")
-	return ""
-}
-
-func (r *partRepository) extractAnoModelo(htmlContent string) string {
-	log.Printf("=== DEBUG: Extraindo ano modelo do HTML ===")
-
-	// Buscar na tabela de detalhes - padrão mais flexível
-	patterns := []string{
-		`(?i)Ano Modelo:\s*(\d{4})`,
-		`(?i)ANO MODELO:\s*(\d{4})`,
-		`(?i)<td[^>]*>Ano Modelo:</td>\s*<td[^>]*>(\d{4})</td>`,
-		`(?i)<td[^>]*>ano modelo:</td>\s*<td[^>]*>(\d{4})</td>`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		match := re.FindStringSubmatch(htmlContent)
-		if len(match) > 1 {
-			ano := match[1]
-			if anoInt, err := strconv.Atoi(ano); err == nil {
-				if 1900 <= anoInt && anoInt <= time.Now().Year()+1 {
-					log.Printf("=== DEBUG: Ano modelo encontrado: %s ===", ano)
-					return ano
-				}
-			}
-		}
-	}
-
-	log.Printf("=== DEBUG: Ano modelo não encontrado ===")
-	return ""
-}
-
-func (r *partRepository) extractCor(htmlContent string) string {
-	log.Printf("=== DEBUG: Extraindo cor do HTML ===")
-
-	// Buscar na tabela de detalhes - padrão mais flexível
-	patterns := []string{
-		`(?i)Cor:\s*([^\n]+?)(?=\s*[A-Z]+\s*:|$)`,
-		`(?i)COR:\s*([^\n]+?)(?=\s*[A-Z]+\s*:|$)`,
-		`(?i)<td[^>]*>Cor:</td>\s*<td[^>]*>([^<]+)</td>`,
-		`(?i)<td[^>]*>cor:</td>\s*<td[^>]*>([^<]+)</td>`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		match := re.FindStringSubmatch(htmlContent)
-		if len(match) > 1 {
-			cor := strings.TrimSpace(match[1])
-			if len(cor) > 2 {
-				log.Printf("=== DEBUG: Cor encontrada: %s ===", cor)
-				return strings.ToUpper(cor)
-			}
-		}
-	}
-
-	log.Printf("=== DEBUG: Cor não encontrada ===")
-	return ""
-}
-
-func (r *partRepository) extractCombustivel(htmlContent string) string {
-	log.Printf("=== DEBUG: Extraindo combustível do HTML ===")
-
-	// Buscar na tabela de detalhes - padrão mais flexível
-	patterns := []string{
-		`(?i)Combustível:\s*([^\n]+?)(?=\s*[A-Z]+\s*:|$)`,
-		`(?i)COMBUSTÍVEL:\s*([^\n]+?)(?=\s*[A-Z]+\s*:|$)`,
-		`(?i)<td[^>]*>Combustível:</td>\s*<td[^>]*>([^<]+)</td>`,
-		`(?i)<td[^>]*>combustível:</td>\s*<td[^>]*>([^<]+)</td>`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		match := re.FindStringSubmatch(htmlContent)
-		if len(match) > 1 {
-			combustivel := strings.TrimSpace(match[1])
-			if len(combustivel) > 2 {
-				log.Printf("=== DEBUG: Combustível encontrado: %s ===", combustivel)
-				return strings.ToUpper(combustivel)
-			}
-		}
-	}
-
-	log.Printf("=== DEBUG: Combustível não encontrado ===")
-	return ""
-}
-
-func (r *partRepository) extractChassi(htmlContent string) string {
-	// Buscar na tabela de detalhes
-	pattern := `(?i)Chassi:\s*(\*{5}[A-Z0-9]+)`
-	re := regexp.MustCompile(pattern)
-	match := re.FindStringSubmatch(htmlContent)
-	if len(match) > 1 {
-		chassi := strings.TrimSpace(match[1])
-		if len(chassi) > 5 {
-			return chassi
-		}
-	}
-	return ""
-}
-
-func (r *partRepository) extractUF(htmlContent string) string {
-	// Buscar na tabela de detalhes
-	pattern := `(?i)UF:\s*([A-Z]{2})`
-	re := regexp.MustCompile(pattern)
-	match := re.FindStringSubmatch(htmlContent)
-	if len(match) > 1 {
-		uf := strings.TrimSpace(match[1])
-		if len(uf) == 2 {
-			return strings.ToUpper(uf)
-		}
-	}
-	return ""
-}
-
-func (r *partRepository) extractMunicipio(htmlContent string) string {
-	// Buscar na tabela de detalhes
-	pattern := `(?i)Município:\s*([^\n]+?)(?=\s*[A-Z]+\s*:|$)`
-	re := regexp.MustCompile(pattern)
-	match := re.FindStringSubmatch(htmlContent)
-	if len(match) > 1 {
-		municipio := strings.TrimSpace(match[1])
-		if len(municipio) > 2 {
-			return strings.Title(strings.ToLower(municipio))
-		}
-	}
-	return ""
-}
-
-func (r *partRepository) extractImportado(htmlContent string) string {
-	// Buscar na tabela de detalhes
-	pattern := `(?i)Importado:\s*([^\n]+?)(?=\s*[A-Z]+\s*:|$)`
-	re := regexp.MustCompile(pattern)
-	match := re.FindStringSubmatch(htmlContent)
-	if len(match) > 1 {
-		importado := strings.TrimSpace(match[1])
-		if len(importado) > 0 {
-			// Limpar texto adicional - pegar apenas a primeira palavra
-			importadoLimpo := regexp.MustCompile(`[^\w\s]`).ReplaceAllString(importado, "")
-			importadoLimpo = strings.TrimSpace(importadoLimpo)
-
-			// Pegar apenas a primeira palavra
-			palavras := strings.Fields(importadoLimpo)
-			if len(palavras) > 0 {
-				primeiraPalavra := strings.ToUpper(palavras[0])
-				// Normalizar para NÃO/SIM
-				if primeiraPalavra == "NAO" || primeiraPalavra == "NO" {
-					return "NÃO"
-				} else if primeiraPalavra == "YES" || primeiraPalavra == "SIM" {
-					return "SIM"
-				} else {
-					return primeiraPalavra
-				}
-			}
-		}
-	}
-	return ""
-}
-
-func (r *partRepository) extractCodigoFipe(htmlContent string, modelo string) string {
-	// Buscar todos os códigos FIPE e modelos correspondentes
-	pattern := `(?i)FIPE:\s*([0-9]{6}-[0-9])\s*Modelo:\s*([^\n]+?)\s*Valor:\s*R\$([^\n]+?)(?=\s*FIPE:|$)`
-	re := regexp.MustCompile(pattern)
-	matches := re.FindAllStringSubmatch(htmlContent, -1)
-
-	if len(matches) > 0 {
-		// Se temos modelo específico, tentar encontrar o mais próximo
-		if modelo != "" {
-			modeloUpper := strings.ToUpper(modelo)
-			for _, match := range matches {
-				if len(match) > 2 {
-					codigo := strings.TrimSpace(match[1])
-					modeloFipe := strings.TrimSpace(match[2])
-
-					// Verificar se o modelo FIPE contém palavras-chave do modelo do veículo
-					modeloFipeUpper := strings.ToUpper(modeloFipe)
-					for _, palavra := range strings.Fields(modeloUpper) {
-						if strings.Contains(modeloFipeUpper, palavra) {
-							return codigo
-						}
-					}
-				}
-			}
-		}
-
-		// Se não encontrou correspondência específica, usar o primeiro
-		if len(matches[0]) > 1 {
-			return strings.TrimSpace(matches[0][1])
-		}
-	}
-
-	return ""
-}
-
-func (r *partRepository) extractValorFipe(htmlContent string, codigoFipe string) string {
-	if codigoFipe == "" {
-		return ""
-	}
-
-	// Buscar valor específico para o código FIPE
-	pattern := fmt.Sprintf(`(?i)FIPE:\s*%s\s*Modelo:[^\n]*\s*Valor:\s*R\$([0-9,\.]+)`, regexp.QuoteMeta(codigoFipe))
-	re := regexp.MustCompile(pattern)
-	match := re.FindStringSubmatch(htmlContent)
-
-	if len(match) > 1 {
-		valor := strings.TrimSpace(match[1])
-		// Limpar o valor - manter apenas números, vírgulas e pontos
-		valorLimpo := regexp.MustCompile(`[^\d,\.]`).ReplaceAllString(valor, "")
-
-		// Verificar se tem pelo menos um número
-		if regexp.MustCompile(`\d`).MatchString(valorLimpo) {
-			return fmt.Sprintf("R$ %s", valorLimpo)
-		}
-	}
-
-	return ""
-}
-
-// saveCarToCache salva o carro na tabela car
-func (r *partRepository) saveCarToCache(carInfo *models.CarInfo) error {
-	car := carInfo.ToCar()
-
-	// Verificar se já existe um registro com esta placa
-	var existingCar models.Car
-	err := r.db.Where("license_plate = ?", car.LicensePlate).First(&existingCar).Error
-
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Não existe, fazer INSERT
-			log.Printf("=== DEBUG: Inserindo novo carro na tabela car ===")
-			err = r.db.Create(car).Error
-		} else {
-			// Outro erro
-			log.Printf("=== DEBUG: Erro ao verificar carro existente: %v ===", err)
-			return r.saveCarError(carInfo)
-		}
-	} else {
-		// Existe, fazer UPDATE
-		log.Printf("=== DEBUG: Atualizando carro existente na tabela car ===")
-		car.ID = existingCar.ID // Manter o ID existente
-		err = r.db.Save(car).Error
-	}
-
-	if err != nil {
-		log.Printf("=== DEBUG: Erro ao salvar carro: %v ===", err)
-		return r.saveCarError(carInfo)
-	}
-
-	log.Printf("=== DEBUG: Carro salvo com sucesso na tabela car ===")
-	return nil
-}
-
-// saveCarError salva erro na tabela car_error
-func (r *partRepository) saveCarError(carInfo *models.CarInfo) error {
-	carError := &models.CarError{
-		LicensePlate: carInfo.Placa,
-		Data: map[string]interface{}{
-			"placa":          carInfo.Placa,
-			"marca":          carInfo.Marca,
-			"modelo":         carInfo.Modelo,
-			"ano":            carInfo.Ano,
-			"ano_modelo":     carInfo.AnoModelo,
-			"cor":            carInfo.Cor,
-			"combustivel":    carInfo.Combustivel,
-			"chassi":         carInfo.Chassi,
-			"municipio":      carInfo.Municipio,
-			"uf":             carInfo.UF,
-			"importado":      carInfo.Importado,
-			"codigo_fipe":    carInfo.CodigoFipe,
-			"valor_fipe":     carInfo.ValorFipe,
-			"data_consulta":  carInfo.DataConsulta,
-			"confiabilidade": carInfo.Confiabilidade,
-		},
-	}
-
-	// Verificar se já existe um registro com esta placa
-	var existingCarError models.CarError
-	err := r.db.Where("license_plate = ?", carError.LicensePlate).First(&existingCarError).Error
-
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Não existe, fazer INSERT
-			log.Printf("=== DEBUG: Inserindo novo erro na tabela car_error ===")
-			err = r.db.Create(carError).Error
-		} else {
-			// Outro erro
-			log.Printf("=== DEBUG: Erro ao verificar erro existente: %v ===", err)
-			return err
-		}
-	} else {
-		// Existe, fazer UPDATE
-		log.Printf("=== DEBUG: Atualizando erro existente na tabela car_error ===")
-		carError.LicensePlate = existingCarError.LicensePlate // Manter a mesma placa
-		err = r.db.Save(carError).Error
-	}
-
-	if err != nil {
-		log.Printf("=== DEBUG: Erro ao salvar erro: %v ===", err)
-		return err
-	}
-
-	log.Printf("=== DEBUG: Erro salvo com sucesso na tabela car_error ===")
-	return nil
-}
-
 // SearchPartsByApplication busca peças baseadas na aplicação (marca, modelo, ano)
 func (r *partRepository) SearchPartsByApplication(manufacturer string, model string, year string, page, pageSize int) (*models.SearchResponse, error) {
 	if page < 1 {
@@ -1941,20 +1044,13 @@ func (r *partRepository) SearchPartsByApplication(manufacturer string, model str
 
 	offset := (page - 1) * pageSize
 
-	// Limpar o modelo (remover "CHEV " se existir)
-	cleanModel := model
-	if strings.HasPrefix(strings.ToUpper(model), "CHEV ") {
-		cleanModel = strings.TrimPrefix(strings.ToUpper(model), "CHEV ")
-	}
-
 	// Query para buscar part_groups que têm aplicação para o veículo
 	var partGroups []models.PartGroup
 
-	query := r.db.Model(&models.PartGroup{}).
+	query := applicationFilter(r.db.Model(&models.PartGroup{}).
 		Joins("JOIN partexplorer.part_group_application pga ON pga.group_id = part_group.id").
-		Joins("JOIN partexplorer.application app ON app.id = pga.application_id").
-		Where("LOWER(app.manufacturer) = LOWER(?) AND (LOWER(app.model) = LOWER(?) OR LOWER(app.model) = LOWER(?)) AND ? BETWEEN app.year_start AND app.year_end",
-			manufacturer, model, cleanModel, year)
+		Joins("JOIN partexplorer.application app ON app.id = pga.application_id"),
+		manufacturer, model, year)
 
 	err := query.Select("DISTINCT part_group.id, part_group.product_type_id, part_group.discontinued, part_group.created_at, part_group.updated_at").
 		Order("part_group.created_at DESC").
@@ -1968,55 +1064,20 @@ func (r *partRepository) SearchPartsByApplication(manufacturer string, model str
 
 	// Contar total
 	var total int64
-	countQuery := r.db.Model(&models.PartGroup{}).
+	countQuery := applicationFilter(r.db.Model(&models.PartGroup{}).
 		Joins("JOIN partexplorer.part_group_application pga ON pga.group_id = part_group.id").
-		Joins("JOIN partexplorer.application app ON app.id = pga.application_id").
-		Where("LOWER(app.manufacturer) = LOWER(?) AND (LOWER(app.model) = LOWER(?) OR LOWER(app.model) = LOWER(?)) AND ? BETWEEN app.year_start AND app.year_end",
-			manufacturer, model, cleanModel, year)
+		Joins("JOIN partexplorer.application app ON app.id = pga.application_id"),
+		manufacturer, model, year)
 
 	err = countQuery.Count(&total).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to count results: %w", err)
 	}
 
-	// Converter para SearchResult e carregar dados relacionados
-	results := make([]models.SearchResult, len(partGroups))
-	for i, pg := range partGroups {
-		// Carregar names, images, applications e stocks manualmente
-		names := loadPartNames(r.db, pg.ID)
-		images := loadPartImages(r.db, pg.ID)
-		applications := loadPartApplications(r.db, pg.ID)
-
-		// Carregar product_type com relacionamentos
-		if pg.ProductTypeID != nil {
-			var productType models.ProductType
-			r.db.Preload("Subfamily.Family").First(&productType, *pg.ProductTypeID)
-			pg.ProductType = &productType
-		}
-
-		// Carregar stocks
-		var allStocks []models.Stock
-		for _, pn := range names {
-			var stocks []models.Stock
-			err := r.db.Model(&models.Stock{}).
-				Joins("JOIN partexplorer.company c ON c.id = stock.company_id").
-				Where("stock.part_name_id = ?", pn.ID).
-				Preload("Company").
-				Find(&stocks).Error
-			if err == nil {
-				allStocks = append(allStocks, stocks...)
-			}
-		}
-
-		results[i] = models.SearchResult{
-			PartGroup:    pg,
-			Names:        names,
-			Images:       images,
-			Applications: applications,
-			Stocks:       allStocks,
-			Dimension:    pg.Dimension,
-			Score:        1.0,
-		}
+	// Carregar names, imagens, aplicações e estoques da página em lote
+	results, err := loadSearchResults(r.db, partGroups, pageLoadOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search results: %w", err)
 	}
 
 	return &models.SearchResponse{
@@ -2028,6 +1089,16 @@ func (r *partRepository) SearchPartsByApplication(manufacturer string, model str
 	}, nil
 }
 
+// applicationFilter restringe a query às aplicações do veículo. Sem modelo (ex. busca por
+// chassi, que identifica apenas fabricante e ano) o filtro fica só em fabricante e ano.
+func applicationFilter(query *gorm.DB, manufacturer, model, year string) *gorm.DB {
+	query = query.Where(canonicalManufacturerExpr+" AND ? BETWEEN app.year_start AND app.year_end", manufacturer, year)
+	if model == "" {
+		return query
+	}
+	return query.Where("LOWER(app.model) = LOWER(?)", model)
+}
+
 // GetPartBySKU busca um produto específico pelo SKU
 func (r *partRepository) GetPartBySKU(sku string) (*models.SearchResult, error) {
 	log.Printf("=== DEBUG: Buscando produto por SKU: %s ===", sku)
@@ -2035,10 +1106,7 @@ func (r *partRepository) GetPartBySKU(sku string) (*models.SearchResult, error)
 	// Buscar part_group que tem o SKU
 	var partGroup models.PartGroup
 	err := r.db.Model(&models.PartGroup{}).
-		Joins("JOIN partexplorer.part_name pn ON pn.group_id = part_group.id").
-		Joins("JOIN partexplorer.part_name_name pnn ON pnn.part_name_id = pn.id").
-		Joins("JOIN partexplorer.name n ON n.id = pnn.name_id").
-		Where("n.type = 'sku' AND LOWER(n.name) = LOWER(?)", sku).
+		Where("id IN (SELECT pn.group_id FROM partexplorer.part_name pn WHERE pn.normalized_code IN ?)", partcode.Candidates(sku)).
 		First(&partGroup).Error
 
 	if err != nil {
@@ -2051,40 +1119,11 @@ func (r *partRepository) GetPartBySKU(sku string) (*models.SearchResult, error)
 	}
 
 	// Carregar dados relacionados
-	names := loadPartNames(r.db, partGroup.ID)
-	images := loadPartImages(r.db, partGroup.ID)
-	applications := loadPartApplications(r.db, partGroup.ID)
-
-	// Carregar product_type
-	if partGroup.ProductTypeID != nil {
-		var productType models.ProductType
-		r.db.Preload("Subfamily.Family").First(&productType, *partGroup.ProductTypeID)
-		partGroup.ProductType = &productType
-	}
-
-	// Carregar stocks
-	var allStocks []models.Stock
-	for _, pn := range names {
-		var stocks []models.Stock
-		err := r.db.Model(&models.Stock{}).
-			Joins("JOIN partexplorer.company c ON c.id = stock.company_id").
-			Where("stock.part_name_id = ?", pn.ID).
-			Preload("Company").
-			Find(&stocks).Error
-		if err == nil {
-			allStocks = append(allStocks, stocks...)
-		}
-	}
-
-	result := &models.SearchResult{
-		PartGroup:    partGroup,
-		Names:        names,
-		Images:       images,
-		Applications: applications,
-		Stocks:       allStocks,
-		Dimension:    partGroup.Dimension,
-		Score:        1.0,
+	results, err := loadSearchResults(r.db, []models.PartGroup{partGroup}, pageLoadOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar produto: %w", err)
 	}
+	result := &results[0]
 
 	log.Printf("=== DEBUG: Produto encontrado para SKU %s: %s ===", sku, partGroup.ID)
 	return result, nil
@@ -2293,44 +1332,10 @@ func (r *partRepository) SearchPartsByBrand(brandName string, page, pageSize int
 
 	totalQuery.Count(&total)
 
-	// Converter para SearchResult e carregar dados relacionados
-	results := make([]models.SearchResult, len(partGroups))
-	for i, pg := range partGroups {
-		// Carregar names, images, applications e stocks manualmente
-		names := loadPartNames(r.db, pg.ID)
-		images := loadPartImages(r.db, pg.ID)
-		applications := loadPartApplications(r.db, pg.ID)
-
-		// Carregar product_type com relacionamentos
-		if pg.ProductTypeID != nil {
-			var productType models.ProductType
-			r.db.Preload("Subfamily.Family").First(&productType, *pg.ProductTypeID)
-			pg.ProductType = &productType
-		}
-
-		// Carregar stocks
-		var allStocks []models.Stock
-		for _, pn := range names {
-			var stocks []models.Stock
-			err := r.db.Model(&models.Stock{}).
-				Joins("JOIN partexplorer.company c ON c.id = stock.company_id").
-				Where("stock.part_name_id = ?", pn.ID).
-				Preload("Company").
-				Find(&stocks).Error
-			if err == nil {
-				allStocks = append(allStocks, stocks...)
-			}
-		}
-
-		results[i] = models.SearchResult{
-			PartGroup:    pg,
-			Names:        names,
-			Images:       images,
-			Applications: applications,
-			Stocks:       allStocks,
-			Dimension:    pg.Dimension,
-			Score:        1.0,
-		}
+	// Carregar names, imagens, aplicações e estoques da página em lote
+	results, err := loadSearchResults(r.db, partGroups, pageLoadOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search results: %w", err)
 	}
 
 	return &models.SearchResponse{
@@ -0,0 +1,493 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"partexplorer/backend/internal/models"
+)
+
+var (
+	// ErrStockImportNotFound indica que a importação não existe
+	ErrStockImportNotFound = errors.New("stock import not found")
+	// ErrStockImportFinished indica uma importação já concluída, que não pode ser cancelada nem retomada
+	ErrStockImportFinished = errors.New("stock import already completed")
+	// ErrCompanyNotFound indica que a empresa não existe
+	ErrCompanyNotFound = errors.New("company not found")
+)
+
+// stockImportReason é o motivo dos ajustes gravados no livro pelas importações
+const stockImportReason = "Importação de estoque"
+
+// StockImportRepository interface para as importações de arquivos de estoque
+type StockImportRepository interface {
+	Create(job *models.StockImportJob, lines []models.StockImportLine) error
+	Get(id string) (*models.StockImportJob, error)
+	List(companyID, status string, page, pageSize int) (*models.StockImportJobListResponse, error)
+	ListLines(id, status string, page, pageSize int) (*models.StockImportLineListResponse, error)
+	Cancel(id string) (*models.StockImportJob, error)
+	Resume(id string) (*models.StockImportJob, error)
+
+	// Usados por stockfeed.Runner
+	ProcessBatch(id string, size int) (*models.StockImportJob, error)
+	Resumable() ([]string, error)
+	Fail(id, message string) error
+}
+
+// stockImportRepository implementa StockImportRepository
+type stockImportRepository struct {
+	db *gorm.DB
+	// maxReplaceErrors é a fração de linhas com erro acima da qual o modo replace não zera
+	// os itens fora do arquivo
+	maxReplaceErrors float64
+}
+
+// NewStockImportRepository cria uma nova instância do repositório. STOCK_IMPORT_REPLACE_MAX_ERRORS
+// (percentual, padrão 5) protege o modo replace: com mais linhas com erro que isso, os itens
+// fora do arquivo não são zerados, já que parte deles pode estar entre as linhas rejeitadas.
+func NewStockImportRepository(db *gorm.DB) StockImportRepository {
+	maxReplaceErrors := 5.0
+	if value, err := strconv.ParseFloat(os.Getenv("STOCK_IMPORT_REPLACE_MAX_ERRORS"), 64); err == nil && value >= 0 {
+		maxReplaceErrors = value
+	}
+	return &stockImportRepository{db: db, maxReplaceErrors: maxReplaceErrors / 100}
+}
+
+// Create grava o job e as linhas do arquivo numa única transação
+func (r *stockImportRepository) Create(job *models.StockImportJob, lines []models.StockImportLine) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var companies int64
+		if err := tx.Model(&models.Company{}).Where("id = ?", job.CompanyID).Count(&companies).Error; err != nil {
+			return fmt.Errorf("failed to get company: %w", err)
+		}
+		if companies == 0 {
+			return ErrCompanyNotFound
+		}
+
+		now := time.Now()
+		job.CreatedAt = now
+		job.UpdatedAt = now
+		if err := tx.Create(job).Error; err != nil {
+			return fmt.Errorf("failed to create stock import: %w", err)
+		}
+		if err := tx.CreateInBatches(lines, 1000).Error; err != nil {
+			return fmt.Errorf("failed to create stock import lines: %w", err)
+		}
+		return nil
+	})
+}
+
+// Get busca a importação
+func (r *stockImportRepository) Get(id string) (*models.StockImportJob, error) {
+	return getStockImport(r.db, id)
+}
+
+// List lista as importações, filtrando por empresa e status quando informados, as mais recentes primeiro
+func (r *stockImportRepository) List(companyID, status string, page, pageSize int) (*models.StockImportJobListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	query := r.db.Model(&models.StockImportJob{})
+	if companyID != "" {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count stock imports: %w", err)
+	}
+
+	jobs := []models.StockImportJob{}
+	if err := query.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list stock imports: %w", err)
+	}
+
+	return &models.StockImportJobListResponse{
+		Jobs:       jobs,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// ListLines lista as linhas da importação na ordem do arquivo, filtrando pelo status
+// (status=error é o relatório de erros)
+func (r *stockImportRepository) ListLines(id, status string, page, pageSize int) (*models.StockImportLineListResponse, error) {
+	job, err := getStockImport(r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	offset := (page - 1) * pageSize
+
+	query := r.db.Model(&models.StockImportLine{}).Where("job_id = ?", job.ID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count stock import lines: %w", err)
+	}
+
+	lines := []models.StockImportLine{}
+	if err := query.Order("line").Limit(pageSize).Offset(offset).Find(&lines).Error; err != nil {
+		return nil, fmt.Errorf("failed to list stock import lines: %w", err)
+	}
+
+	return &models.StockImportLineListResponse{
+		Lines:      lines,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// Cancel interrompe a importação. As linhas já aplicadas permanecem no estoque e, no modo
+// replace, os itens fora do arquivo não são zerados.
+func (r *stockImportRepository) Cancel(id string) (*models.StockImportJob, error) {
+	return r.setStatus(id, models.StockImportCancelled, "Cancelada")
+}
+
+// Resume retoma uma importação cancelada ou com falha a partir das linhas pendentes
+func (r *stockImportRepository) Resume(id string) (*models.StockImportJob, error) {
+	return r.setStatus(id, models.StockImportRunning, "")
+}
+
+// Fail marca a importação como falha, com a mensagem do erro
+func (r *stockImportRepository) Fail(id, message string) error {
+	_, err := r.setStatus(id, models.StockImportFailed, message)
+	return err
+}
+
+// Resumable lista as importações pendentes ou interrompidas, as mais antigas primeiro
+func (r *stockImportRepository) Resumable() ([]string, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.StockImportJob{}).
+		Where("status IN ?", []string{models.StockImportPending, models.StockImportRunning}).
+		Order("created_at").
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resumable stock imports: %w", err)
+	}
+
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, id.String())
+	}
+	return result, nil
+}
+
+// setStatus troca o status de uma importação não concluída
+func (r *stockImportRepository) setStatus(id, status, message string) (*models.StockImportJob, error) {
+	var job *models.StockImportJob
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if job, err = lockStockImport(tx, id); err != nil {
+			return err
+		}
+		if job.Status == models.StockImportCompleted {
+			return ErrStockImportFinished
+		}
+
+		now := time.Now()
+		job.Status = status
+		job.Message = message
+		job.UpdatedAt = now
+		job.FinishedAt = nil
+		if job.Finished() {
+			job.FinishedAt = &now
+		}
+		if err := tx.Save(job).Error; err != nil {
+			return fmt.Errorf("failed to update stock import: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ProcessBatch processa até size linhas pendentes numa transação, com o job bloqueado: resolve
+// os códigos para part_name, cria ou atualiza o estoque da empresa e registra as mudanças de
+// quantidade no livro. Sem linhas pendentes, conclui o job (zerando os itens fora do arquivo
+// no modo replace). Jobs terminados são retornados sem alteração.
+func (r *stockImportRepository) ProcessBatch(id string, size int) (*models.StockImportJob, error) {
+	var job *models.StockImportJob
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if job, err = lockStockImport(tx, id); err != nil {
+			return err
+		}
+		if job.Finished() {
+			return nil
+		}
+
+		now := time.Now()
+		if job.Status == models.StockImportPending {
+			job.Status = models.StockImportRunning
+			job.StartedAt = &now
+		}
+		job.UpdatedAt = now
+
+		var lines []models.StockImportLine
+		err = tx.Where("job_id = ? AND status = ?", job.ID, models.StockImportLinePending).
+			Order("line").Limit(size).Find(&lines).Error
+		if err != nil {
+			return fmt.Errorf("failed to list stock import lines: %w", err)
+		}
+
+		if len(lines) == 0 {
+			if err := r.finish(tx, job); err != nil {
+				return err
+			}
+		} else if err := r.applyLines(tx, job, lines); err != nil {
+			return err
+		}
+
+		if err := tx.Save(job).Error; err != nil {
+			return fmt.Errorf("failed to update stock import: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// applyLines aplica um lote de linhas ao estoque da empresa e atualiza os contadores do job
+func (r *stockImportRepository) applyLines(tx *gorm.DB, job *models.StockImportJob, lines []models.StockImportLine) error {
+	codes := make([]partCode, len(lines))
+	for i, line := range lines {
+		codes[i] = partCode{SKU: line.SKU, EAN: line.EAN, Brand: line.Brand}
+	}
+	parts, err := findPartsByCode(tx, codes)
+	if err != nil {
+		return err
+	}
+
+	partIDs := []uuid.UUID{}
+	resolved := make([]uuid.UUID, len(lines))
+	for i := range lines {
+		partID, err := resolvePartCode(codes[i], parts)
+		if err != nil {
+			lines[i].Status = models.StockImportLineError
+			lines[i].Error = err.Error()
+			continue
+		}
+		resolved[i] = partID
+		partIDs = append(partIDs, partID)
+	}
+
+	stocks, err := lockCompanyStocks(tx, job.CompanyID, partIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range lines {
+		line := &lines[i]
+		if line.Status == models.StockImportLinePending {
+			partID := resolved[i]
+			line.PartNameID = &partID
+
+			stock, created, err := r.applyLine(tx, job, line, stocks[partID])
+			if err != nil {
+				return err
+			}
+			stocks[partID] = stock
+			line.StockID = &stock.ID
+			switch {
+			case created:
+				line.Status = models.StockImportLineCreated
+			case line.Status == models.StockImportLinePending:
+				line.Status = models.StockImportLineUnchanged
+			}
+		}
+
+		switch line.Status {
+		case models.StockImportLineCreated:
+			job.Created++
+		case models.StockImportLineUpdated:
+			job.Updated++
+		case models.StockImportLineUnchanged:
+			job.Unchanged++
+		case models.StockImportLineError:
+			job.Errors++
+		}
+		job.ProcessedLines++
+
+		err := tx.Model(&models.StockImportLine{}).
+			Where("job_id = ? AND line = ?", line.JobID, line.Line).
+			Updates(map[string]interface{}{
+				"status":       line.Status,
+				"error":        line.Error,
+				"part_name_id": line.PartNameID,
+				"stock_id":     line.StockID,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update stock import line: %w", err)
+		}
+	}
+	return nil
+}
+
+// applyLine grava a quantidade (como ajuste no livro), o preço e o flag obsoleto da linha no
+// estoque, criando o estoque quando a empresa ainda não tem a peça. Marca a linha como
+// updated quando algo mudou.
+func (r *stockImportRepository) applyLine(tx *gorm.DB, job *models.StockImportJob, line *models.StockImportLine, stock *models.Stock) (*models.Stock, bool, error) {
+	created := false
+	if stock == nil {
+		zero := 0
+		stock = &models.Stock{
+			ID:         uuid.New(),
+			PartNameID: *line.PartNameID,
+			CompanyID:  job.CompanyID,
+			Quantity:   &zero,
+			Price:      line.Price,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+		if line.Obsolete != nil {
+			stock.Obsolete = *line.Obsolete
+		}
+		if err := tx.Create(stock).Error; err != nil {
+			return nil, false, fmt.Errorf("failed to create stock: %w", err)
+		}
+		created = true
+	}
+
+	if line.Quantity != nil && *line.Quantity != stockQuantity(stock) {
+		_, err := applyMovement(tx, stock, models.StockMovement{
+			StockID:   stock.ID,
+			Type:      models.StockMovementAdjustment,
+			Quantity:  *line.Quantity - stockQuantity(stock),
+			Actor:     job.Actor,
+			Reason:    stockImportReason,
+			Reference: stockImportReference(job),
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		line.Status = models.StockImportLineUpdated
+	}
+
+	if created {
+		return stock, true, nil
+	}
+
+	updates := map[string]interface{}{}
+	if line.Price != nil && (stock.Price == nil || *stock.Price != *line.Price) {
+		updates["price"] = *line.Price
+		stock.Price = line.Price
+	}
+	if line.Obsolete != nil && stock.Obsolete != *line.Obsolete {
+		updates["obsolete"] = *line.Obsolete
+		stock.Obsolete = *line.Obsolete
+	}
+	if len(updates) > 0 {
+		updates["version"] = nextVersion()
+		updates["updated_at"] = time.Now()
+		if err := tx.Model(&models.Stock{}).Where("id = ?", stock.ID).Updates(updates).Error; err != nil {
+			return nil, false, fmt.Errorf("failed to update stock: %w", err)
+		}
+		line.Status = models.StockImportLineUpdated
+	}
+	return stock, false, nil
+}
+
+// finish conclui o job. No modo replace, zera os estoques da empresa cujas peças não estão
+// entre as linhas aplicadas, desde que a proporção de linhas com erro esteja no limite.
+func (r *stockImportRepository) finish(tx *gorm.DB, job *models.StockImportJob) error {
+	now := time.Now()
+	job.Status = models.StockImportCompleted
+	job.FinishedAt = &now
+
+	if job.Mode != models.StockImportReplace {
+		return nil
+	}
+	if job.TotalLines > 0 && float64(job.Errors)/float64(job.TotalLines) > r.maxReplaceErrors {
+		job.Message = fmt.Sprintf("Itens fora do arquivo não foram zerados: %d de %d linhas com erro (limite %.1f%%)",
+			job.Errors, job.TotalLines, r.maxReplaceErrors*100)
+		log.Printf("⚠️ [STOCK-IMPORT] Importação %s: %s", job.ID, job.Message)
+		return nil
+	}
+
+	var missing []models.Stock
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("company_id = ? AND quantity > 0", job.CompanyID).
+		Where("part_name_id NOT IN (?)", tx.Model(&models.StockImportLine{}).
+			Select("part_name_id").
+			Where("job_id = ? AND part_name_id IS NOT NULL", job.ID)).
+		Order("id").
+		Find(&missing).Error
+	if err != nil {
+		return fmt.Errorf("failed to list stocks missing from the import: %w", err)
+	}
+
+	for i := range missing {
+		_, err := applyMovement(tx, &missing[i], models.StockMovement{
+			StockID:   missing[i].ID,
+			Type:      models.StockMovementAdjustment,
+			Quantity:  -stockQuantity(&missing[i]),
+			Actor:     job.Actor,
+			Reason:    stockImportReason + " (fora do arquivo)",
+			Reference: stockImportReference(job),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	job.Zeroed = len(missing)
+	return nil
+}
+
+// stockImportReference identifica a importação nas movimentações do livro
+func stockImportReference(job *models.StockImportJob) string {
+	return "stock-import:" + job.ID.String()
+}
+
+// getStockImport busca a importação pelo ID
+func getStockImport(db *gorm.DB, id string) (*models.StockImportJob, error) {
+	jobID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrStockImportNotFound
+	}
+	var job models.StockImportJob
+	if err := db.Where("id = ?", jobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStockImportNotFound
+		}
+		return nil, fmt.Errorf("failed to get stock import: %w", err)
+	}
+	return &job, nil
+}
+
+// lockStockImport busca a importação com a linha bloqueada até o fim da transação
+func lockStockImport(tx *gorm.DB, id string) (*models.StockImportJob, error) {
+	return getStockImport(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
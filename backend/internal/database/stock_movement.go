@@ -0,0 +1,472 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"partexplorer/backend/internal/models"
+)
+
+var (
+	// ErrStockNotFound indica que o estoque não existe
+	ErrStockNotFound = errors.New("stock not found")
+	// ErrInvalidStockMovement indica uma movimentação incompleta ou incoerente com o tipo
+	ErrInvalidStockMovement = errors.New("invalid stock movement")
+	// ErrInsufficientStock indica que a movimentação deixaria o saldo negativo
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrStockQuantityReadOnly indica uma tentativa de alterar a quantidade fora do livro
+	ErrStockQuantityReadOnly = errors.New("stock quantity can only change through movements")
+)
+
+// openingBalanceReason é o motivo do ajuste que registra a quantidade de um estoque novo
+const openingBalanceReason = "Saldo inicial"
+
+// StockMovementDelta valida a movimentação e calcula a variação do saldo atual (current) do
+// estoque de origem. Entrada, venda e transferência exigem quantidade positiva; o ajuste
+// exige o motivo e aceita a variação com sinal ou a quantidade contada.
+func StockMovementDelta(req models.StockMovementRequest, current int) (int, error) {
+	if strings.TrimSpace(req.Actor) == "" {
+		return 0, fmt.Errorf("%w: actor is required", ErrInvalidStockMovement)
+	}
+	if req.Counted != nil && req.Type != models.StockMovementAdjustment {
+		return 0, fmt.Errorf("%w: counted is only accepted for adjustments", ErrInvalidStockMovement)
+	}
+	if req.TargetStockID != "" && req.Type != models.StockMovementTransfer {
+		return 0, fmt.Errorf("%w: target_stock_id is only accepted for transfers", ErrInvalidStockMovement)
+	}
+
+	switch req.Type {
+	case models.StockMovementReceipt, models.StockMovementSale, models.StockMovementTransfer:
+		if req.Quantity <= 0 {
+			return 0, fmt.Errorf("%w: quantity must be positive", ErrInvalidStockMovement)
+		}
+		if req.Type == models.StockMovementTransfer && req.TargetStockID == "" {
+			return 0, fmt.Errorf("%w: target_stock_id is required", ErrInvalidStockMovement)
+		}
+		if req.Type == models.StockMovementReceipt {
+			return req.Quantity, nil
+		}
+		return -req.Quantity, nil
+	case models.StockMovementAdjustment:
+		if strings.TrimSpace(req.Reason) == "" {
+			return 0, fmt.Errorf("%w: reason is required for adjustments", ErrInvalidStockMovement)
+		}
+		if req.Counted != nil {
+			if *req.Counted < 0 {
+				return 0, fmt.Errorf("%w: counted must not be negative", ErrInvalidStockMovement)
+			}
+			return *req.Counted - current, nil
+		}
+		if req.Quantity == 0 {
+			return 0, fmt.Errorf("%w: quantity or counted is required", ErrInvalidStockMovement)
+		}
+		return req.Quantity, nil
+	default:
+		return 0, fmt.Errorf("%w: type must be one of %s", ErrInvalidStockMovement, strings.Join(models.StockMovementTypes, ", "))
+	}
+}
+
+// RecordMovement registra a movimentação e atualiza a quantidade do estoque na mesma
+// transação, com a linha bloqueada. A transferência gera a saída na origem e a entrada no
+// destino, que precisa ser do mesmo SKU.
+func (r *stockRepository) RecordMovement(id string, req models.StockMovementRequest) ([]models.StockMovement, error) {
+	stockID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid stock ID", ErrInvalidStockMovement)
+	}
+	var targetID uuid.UUID
+	if req.TargetStockID != "" {
+		if targetID, err = uuid.Parse(req.TargetStockID); err != nil {
+			return nil, fmt.Errorf("%w: invalid target stock ID", ErrInvalidStockMovement)
+		}
+		if targetID == stockID {
+			return nil, fmt.Errorf("%w: target stock must differ from the source", ErrInvalidStockMovement)
+		}
+	}
+
+	var movements []models.StockMovement
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		// Bloqueia origem e destino sempre na mesma ordem, para transferências opostas não travarem
+		ids := []uuid.UUID{stockID}
+		if targetID != uuid.Nil {
+			ids = append(ids, targetID)
+			if targetID.String() < stockID.String() {
+				ids[0], ids[1] = ids[1], ids[0]
+			}
+		}
+		locked := make(map[uuid.UUID]*models.Stock, len(ids))
+		for _, lockID := range ids {
+			stock, err := lockStock(tx, lockID)
+			if err != nil {
+				return err
+			}
+			locked[lockID] = stock
+		}
+
+		source := locked[stockID]
+		current := stockQuantity(source)
+		delta, err := StockMovementDelta(req, current)
+		if err != nil {
+			return err
+		}
+		if current+delta < 0 {
+			return fmt.Errorf("%w: balance %d, movement %d", ErrInsufficientStock, current, delta)
+		}
+
+		movement := models.StockMovement{
+			StockID:   stockID,
+			Type:      req.Type,
+			Quantity:  delta,
+			Actor:     strings.TrimSpace(req.Actor),
+			Reason:    strings.TrimSpace(req.Reason),
+			Reference: strings.TrimSpace(req.Reference),
+		}
+		if targetID == uuid.Nil {
+			created, err := applyMovement(tx, source, movement)
+			if err != nil {
+				return err
+			}
+			movements = []models.StockMovement{*created}
+			return nil
+		}
+
+		target := locked[targetID]
+		if target.PartNameID != source.PartNameID {
+			return fmt.Errorf("%w: target stock is a different part", ErrInvalidStockMovement)
+		}
+		transferID := uuid.New()
+		movement.TransferID = &transferID
+		out, err := applyMovement(tx, source, movement)
+		if err != nil {
+			return err
+		}
+		movement.StockID = targetID
+		movement.Quantity = -delta
+		in, err := applyMovement(tx, target, movement)
+		if err != nil {
+			return err
+		}
+		movements = []models.StockMovement{*out, *in}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// AdjustQuantity soma a variação da movimentação (entrada, venda ou ajuste com sinal) num
+// único UPDATE, sem ler o saldo antes: a condição do próprio UPDATE impede o saldo negativo e
+// o RETURNING traz o saldo gravado para o livro. Não aceita contagem nem transferência.
+func (r *stockRepository) AdjustQuantity(id string, req models.StockMovementRequest) (*models.Stock, *models.StockMovement, error) {
+	stockID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid stock ID", ErrInvalidStockMovement)
+	}
+	if req.Counted != nil || req.Type == models.StockMovementTransfer {
+		return nil, nil, fmt.Errorf("%w: counts and transfers are not incremental", ErrInvalidStockMovement)
+	}
+	delta, err := StockMovementDelta(req, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stock := &models.Stock{ID: stockID}
+	movement := &models.StockMovement{
+		StockID:   stockID,
+		Type:      req.Type,
+		Quantity:  delta,
+		Actor:     strings.TrimSpace(req.Actor),
+		Reason:    strings.TrimSpace(req.Reason),
+		Reference: strings.TrimSpace(req.Reference),
+		CreatedAt: time.Now(),
+	}
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var updated []struct {
+			Quantity int
+			Version  int
+		}
+		err := tx.Raw(`
+			UPDATE partexplorer.stock
+			SET quantity = COALESCE(quantity, 0) + ?, version = version + 1, updated_at = ?
+			WHERE id = ? AND COALESCE(quantity, 0) + ? >= 0
+			RETURNING quantity, version`, delta, movement.CreatedAt, stockID, delta).Scan(&updated).Error
+		if err != nil {
+			return fmt.Errorf("failed to update stock quantity: %w", err)
+		}
+		if len(updated) == 0 {
+			var current models.Stock
+			if err := tx.Where("id = ?", stockID).First(&current).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrStockNotFound
+				}
+				return fmt.Errorf("failed to get stock: %w", err)
+			}
+			return fmt.Errorf("%w: balance %d, movement %d", ErrInsufficientStock, stockQuantity(&current), delta)
+		}
+
+		stock.Quantity, stock.Version = &updated[0].Quantity, updated[0].Version
+		movement.QuantityAfter = updated[0].Quantity
+		if err := tx.Create(movement).Error; err != nil {
+			return fmt.Errorf("failed to record stock movement: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return stock, movement, nil
+}
+
+// ListMovements lista o histórico de movimentações do estoque, as mais recentes primeiro,
+// filtrando pelo tipo quando informado. O histórico continua disponível depois da remoção.
+func (r *stockRepository) ListMovements(id, movementType string, page, pageSize int) (*models.StockMovementListResponse, error) {
+	stockID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid stock ID", ErrInvalidStockMovement)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	query := r.db.Model(&models.StockMovement{}).Where("stock_id = ?", stockID)
+	if movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count stock movements: %w", err)
+	}
+
+	movements := []models.StockMovement{}
+	if err := query.Order("created_at DESC, id DESC").Limit(pageSize).Offset(offset).Find(&movements).Error; err != nil {
+		return nil, fmt.Errorf("failed to list stock movements: %w", err)
+	}
+
+	return &models.StockMovementListResponse{
+		Movements:  movements,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// GetReconciliation compara a quantidade do estoque com a soma do livro
+func (r *stockRepository) GetReconciliation(id string) (*models.StockReconciliation, error) {
+	stockID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid stock ID", ErrInvalidStockMovement)
+	}
+
+	var stock models.Stock
+	if err := r.db.Where("id = ?", stockID).First(&stock).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStockNotFound
+		}
+		return nil, fmt.Errorf("failed to get stock: %w", err)
+	}
+	return reconciliation(r.db, &stock)
+}
+
+// Reconcile acerta a divergência entre a quantidade do estoque e o livro. Com keep=ledger
+// (padrão) a soma do livro é gravada no estoque; com keep=quantity a quantidade do estoque é
+// mantida e a diferença entra no livro como ajuste, com o motivo informado.
+func (r *stockRepository) Reconcile(id string, req models.StockReconcileRequest) (*models.StockReconciliation, error) {
+	stockID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid stock ID", ErrInvalidStockMovement)
+	}
+	if strings.TrimSpace(req.Actor) == "" {
+		return nil, fmt.Errorf("%w: actor is required", ErrInvalidStockMovement)
+	}
+	keep := req.Keep
+	if keep == "" {
+		keep = models.StockReconcileKeepLedger
+	}
+	if keep != models.StockReconcileKeepLedger && keep != models.StockReconcileKeepQuantity {
+		return nil, fmt.Errorf("%w: keep must be ledger or quantity", ErrInvalidStockMovement)
+	}
+	if keep == models.StockReconcileKeepQuantity && strings.TrimSpace(req.Reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required to keep the stock quantity", ErrInvalidStockMovement)
+	}
+
+	var result *models.StockReconciliation
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		stock, err := lockStock(tx, stockID)
+		if err != nil {
+			return err
+		}
+		before, err := reconciliation(tx, stock)
+		if err != nil {
+			return err
+		}
+		if before.Difference == 0 {
+			result = before
+			return nil
+		}
+
+		switch keep {
+		case models.StockReconcileKeepLedger:
+			if before.LedgerQuantity < 0 {
+				return fmt.Errorf("%w: ledger balance is %d", ErrInsufficientStock, before.LedgerQuantity)
+			}
+			err = tx.Model(&models.Stock{}).Where("id = ?", stockID).
+				Updates(map[string]interface{}{"quantity": before.LedgerQuantity, "version": nextVersion(), "updated_at": time.Now()}).Error
+			if err != nil {
+				return fmt.Errorf("failed to reconcile stock: %w", err)
+			}
+			log.Printf("📒 [STOCK] Estoque %s conciliado pelo livro por %s: %d -> %d", stockID, req.Actor, before.Quantity, before.LedgerQuantity)
+		case models.StockReconcileKeepQuantity:
+			if before.Quantity < 0 {
+				return fmt.Errorf("%w: stock quantity is %d", ErrInsufficientStock, before.Quantity)
+			}
+			err = tx.Create(&models.StockMovement{
+				StockID:       stockID,
+				Type:          models.StockMovementAdjustment,
+				Quantity:      before.Difference,
+				QuantityAfter: before.Quantity,
+				Actor:         strings.TrimSpace(req.Actor),
+				Reason:        strings.TrimSpace(req.Reason),
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to record stock movement: %w", err)
+			}
+		}
+
+		if err := tx.Where("id = ?", stockID).First(stock).Error; err != nil {
+			return fmt.Errorf("failed to get stock: %w", err)
+		}
+		result, err = reconciliation(tx, stock)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListReconciliation lista os estoques cuja quantidade diverge da soma do livro, as maiores
+// diferenças primeiro
+func (r *stockRepository) ListReconciliation(page, pageSize int) (*models.StockReconciliationListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	const drift = `
+		FROM partexplorer.stock s
+		LEFT JOIN (
+			SELECT stock_id, SUM(quantity) AS total, COUNT(*) AS movements
+			FROM partexplorer.stock_movement
+			GROUP BY stock_id
+		) m ON m.stock_id = s.id
+		WHERE COALESCE(s.quantity, 0) <> COALESCE(m.total, 0)`
+
+	var total int64
+	if err := r.db.Raw("SELECT COUNT(*) " + drift).Scan(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count stock divergences: %w", err)
+	}
+
+	stocks := []models.StockReconciliation{}
+	err := r.db.Raw(`
+		SELECT s.id AS stock_id,
+		       COALESCE(s.quantity, 0) AS quantity,
+		       COALESCE(m.total, 0) AS ledger_quantity,
+		       COALESCE(s.quantity, 0) - COALESCE(m.total, 0) AS difference,
+		       COALESCE(m.movements, 0) AS movements`+drift+`
+		ORDER BY ABS(COALESCE(s.quantity, 0) - COALESCE(m.total, 0)) DESC, s.id
+		LIMIT ? OFFSET ?`, pageSize, offset).Scan(&stocks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock divergences: %w", err)
+	}
+
+	return &models.StockReconciliationListResponse{
+		Stocks:     stocks,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// lockStock busca o estoque com a linha bloqueada até o fim da transação
+func lockStock(tx *gorm.DB, stockID uuid.UUID) (*models.Stock, error) {
+	var stock models.Stock
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", stockID).First(&stock).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStockNotFound
+		}
+		return nil, fmt.Errorf("failed to lock stock: %w", err)
+	}
+	return &stock, nil
+}
+
+// applyMovement grava a movimentação com o novo saldo e atualiza a quantidade do estoque
+func applyMovement(tx *gorm.DB, stock *models.Stock, movement models.StockMovement) (*models.StockMovement, error) {
+	after := stockQuantity(stock) + movement.Quantity
+	if after < 0 {
+		return nil, fmt.Errorf("%w: balance %d, movement %d", ErrInsufficientStock, stockQuantity(stock), movement.Quantity)
+	}
+	movement.QuantityAfter = after
+	movement.CreatedAt = time.Now()
+	if err := tx.Create(&movement).Error; err != nil {
+		return nil, fmt.Errorf("failed to record stock movement: %w", err)
+	}
+
+	err := tx.Model(&models.Stock{}).Where("id = ?", stock.ID).
+		Updates(map[string]interface{}{"quantity": after, "version": nextVersion(), "updated_at": movement.CreatedAt}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update stock quantity: %w", err)
+	}
+	stock.Quantity = &after
+	stock.Version++
+	return &movement, nil
+}
+
+// reconciliation soma o livro do estoque e compara com a quantidade gravada
+func reconciliation(db *gorm.DB, stock *models.Stock) (*models.StockReconciliation, error) {
+	var ledger struct {
+		Total     int
+		Movements int64
+	}
+	err := db.Model(&models.StockMovement{}).
+		Select("COALESCE(SUM(quantity), 0) AS total, COUNT(*) AS movements").
+		Where("stock_id = ?", stock.ID).
+		Scan(&ledger).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum stock movements: %w", err)
+	}
+
+	quantity := stockQuantity(stock)
+	return &models.StockReconciliation{
+		StockID:        stock.ID,
+		Quantity:       quantity,
+		LedgerQuantity: ledger.Total,
+		Difference:     quantity - ledger.Total,
+		Movements:      ledger.Movements,
+	}, nil
+}
+
+// stockQuantity retorna a quantidade do estoque, zero quando não informada
+func stockQuantity(stock *models.Stock) int {
+	if stock.Quantity == nil {
+		return 0
+	}
+	return *stock.Quantity
+}
@@ -0,0 +1,237 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"partexplorer/backend/internal/models"
+)
+
+// ErrInvalidPriceQuery indica um ID inválido na consulta de preços
+var ErrInvalidPriceQuery = errors.New("invalid price query")
+
+// ListPriceHistory lista as fotos de preço do estoque, as mais recentes primeiro
+func (r *stockRepository) ListPriceHistory(id string, page, pageSize int) (*models.StockPriceHistoryListResponse, error) {
+	stockID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid stock ID", ErrInvalidPriceQuery)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	query := r.db.Model(&models.StockPriceHistory{}).Where("stock_id = ?", stockID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count stock prices: %w", err)
+	}
+
+	prices := []models.StockPriceHistory{}
+	if err := query.Order("changed_at DESC, id DESC").Limit(pageSize).Offset(offset).Find(&prices).Error; err != nil {
+		return nil, fmt.Errorf("failed to list stock prices: %w", err)
+	}
+
+	return &models.StockPriceHistoryListResponse{
+		Prices:     prices,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// GetGroupPriceStats calcula o menor, a mediana e o maior preço das ofertas das peças do
+// grupo, no total e por estado e cidade da empresa
+func (r *stockRepository) GetGroupPriceStats(groupID string, filter models.PriceFilter) (*models.PartGroupPriceStats, error) {
+	groupUUID, err := uuid.Parse(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid group ID", ErrInvalidPriceQuery)
+	}
+
+	query := offerQuery(r.db.Table("partexplorer.stock s"), filter).
+		Joins("JOIN partexplorer.part_name pn ON pn.id = s.part_name_id").
+		Where("pn.group_id = ?", groupUUID)
+
+	// GROUPING indica o nível da linha: 0 cidade, 1 estado, 3 total
+	var rows []struct {
+		Level  int
+		State  string
+		City   string
+		Offers int64
+		Min    float64
+		Median float64
+		Max    float64
+	}
+	err = query.Select(`GROUPING(UPPER(c.state), c.city) AS level,
+			COALESCE(UPPER(c.state), '') AS state,
+			COALESCE(c.city, '') AS city,
+			COUNT(*) AS offers,
+			MIN(s.price) AS min,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS median,
+			MAX(s.price) AS max`).
+		Group("GROUPING SETS ((UPPER(c.state), c.city), (UPPER(c.state)), ())").
+		Order("state, city").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group price stats: %w", err)
+	}
+
+	stats := &models.PartGroupPriceStats{GroupID: groupUUID.String(), ByState: []models.PriceStats{}, ByCity: []models.PriceStats{}}
+	for _, row := range rows {
+		price := models.PriceStats{State: row.State, City: row.City, Offers: row.Offers, Min: row.Min, Median: row.Median, Max: row.Max}
+		switch row.Level {
+		case 0:
+			stats.ByCity = append(stats.ByCity, price)
+		case 1:
+			price.City = ""
+			stats.ByState = append(stats.ByState, price)
+		default:
+			// Sem ofertas, o total geral vem com contagem zero e fica nil
+			if row.Offers > 0 {
+				price.State, price.City = "", ""
+				stats.Overall = &price
+			}
+		}
+	}
+	return stats, nil
+}
+
+// RankOffers lista as ofertas do SKU do menor preço ao maior; no mesmo preço, a maior
+// quantidade primeiro
+func (r *stockRepository) RankOffers(partNameID string, filter models.PriceFilter, page, pageSize int) (*models.StockOfferListResponse, error) {
+	partNameUUID, err := uuid.Parse(partNameID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid part name ID", ErrInvalidPriceQuery)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	query := offerQuery(r.db.Table("partexplorer.stock s"), filter).
+		Where("s.part_name_id = ?", partNameUUID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count offers: %w", err)
+	}
+
+	var stocks []models.Stock
+	err = query.Select("s.*").Preload("PartName").Preload("Company").
+		Order("s.price ASC, s.quantity DESC NULLS LAST, s.id").
+		Limit(pageSize).Offset(offset).
+		Find(&stocks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank offers: %w", err)
+	}
+
+	offers := make([]models.StockOffer, len(stocks))
+	for i, stock := range stocks {
+		offers[i] = stockOffer(stock, offset+i+1)
+	}
+
+	return &models.StockOfferListResponse{
+		PartNameID: partNameUUID.String(),
+		Offers:     offers,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// offerQuery junta a empresa ao estoque (alias s) e aplica o filtro das ofertas
+func offerQuery(query *gorm.DB, filter models.PriceFilter) *gorm.DB {
+	query = query.Joins("JOIN partexplorer.company c ON c.id = s.company_id").
+		Where("s.price IS NOT NULL AND s.price > 0 AND NOT COALESCE(s.obsolete, false)")
+	if state := strings.TrimSpace(filter.State); state != "" {
+		query = query.Where("UPPER(c.state) = ?", strings.ToUpper(state))
+	}
+	if city := strings.TrimSpace(filter.City); city != "" {
+		query = query.Where("LOWER(c.city) = ?", strings.ToLower(city))
+	}
+	if filter.InStock {
+		query = query.Where("s.quantity > 0")
+	}
+	return query
+}
+
+// stockOffer monta a oferta com os dados do SKU e de contato da empresa
+func stockOffer(stock models.Stock, rank int) models.StockOffer {
+	offer := models.StockOffer{
+		Rank: rank,
+		StockResponse: models.StockResponse{
+			ID:         stock.ID.String(),
+			PartNameID: stock.PartNameID.String(),
+			CompanyID:  stock.CompanyID.String(),
+			Quantity:   stock.Quantity,
+			Price:      stock.Price,
+			Version:    stock.Version,
+			CreatedAt:  stock.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:  stock.UpdatedAt.Format(time.RFC3339),
+		},
+	}
+
+	if stock.PartName != nil {
+		offer.SKUName = stock.PartName.Name
+		offer.SKUType = stock.PartName.Type
+		if stock.PartName.BrandID != uuid.Nil {
+			offer.SKUBrand = stock.PartName.BrandID.String()
+		}
+	}
+
+	if company := stock.Company; company != nil {
+		offer.CompanyName = company.Name
+		offer.CompanyImageURL = company.ImageURL
+		offer.CompanyPhone = company.Phone
+		offer.CompanyMobile = company.Mobile
+		offer.CompanyEmail = company.Email
+		offer.CompanyWebsite = company.Website
+		offer.CompanyCity = company.City
+		offer.CompanyState = company.State
+		if address := companyAddress(company); address != "" {
+			offer.CompanyAddress = &address
+		}
+	}
+	return offer
+}
+
+// companyAddress monta o endereço completo da empresa, no formato do detalhe do estoque
+func companyAddress(company *models.Company) string {
+	if company.Street == nil && company.City == nil {
+		return ""
+	}
+	address := ""
+	if company.Street != nil {
+		address += *company.Street
+	}
+	if company.Number != nil {
+		address += ", " + *company.Number
+	}
+	if company.Neighborhood != nil {
+		address += " - " + *company.Neighborhood
+	}
+	if company.City != nil {
+		address += ", " + *company.City
+	}
+	if company.State != nil {
+		address += "/" + *company.State
+	}
+	if company.ZipCode != nil {
+		address += " - " + *company.ZipCode
+	}
+	return address
+}
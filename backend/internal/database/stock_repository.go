@@ -1,7 +1,9 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,14 +14,27 @@ import (
 
 // StockRepository interface para operações de estoque
 type StockRepository interface {
-	CreateStock(stock *models.Stock) error
+	CreateStock(stock *models.Stock, actor string) error
 	GetStockByID(id string) (*models.Stock, error)
 	GetStocksByPartNameID(partNameID string) ([]models.Stock, error)
 	GetStocksByGroupID(groupID string) ([]models.Stock, error)
-	UpdateStock(id string, updates map[string]interface{}) error
+	UpdateStock(id string, version int, updates map[string]interface{}, count *models.StockMovementRequest) (*models.Stock, []models.StockMovement, error)
 	DeleteStock(id string) error
 	ListStocks(page, pageSize int) (*models.StockListResponse, error)
 	SearchStocks(query string, page, pageSize int) (*models.StockListResponse, error)
+
+	// Livro de movimentações
+	RecordMovement(id string, req models.StockMovementRequest) ([]models.StockMovement, error)
+	AdjustQuantity(id string, req models.StockMovementRequest) (*models.Stock, *models.StockMovement, error)
+	ListMovements(id, movementType string, page, pageSize int) (*models.StockMovementListResponse, error)
+	GetReconciliation(id string) (*models.StockReconciliation, error)
+	Reconcile(id string, req models.StockReconcileRequest) (*models.StockReconciliation, error)
+	ListReconciliation(page, pageSize int) (*models.StockReconciliationListResponse, error)
+
+	// Comparação e histórico de preços
+	ListPriceHistory(id string, page, pageSize int) (*models.StockPriceHistoryListResponse, error)
+	GetGroupPriceStats(groupID string, filter models.PriceFilter) (*models.PartGroupPriceStats, error)
+	RankOffers(partNameID string, filter models.PriceFilter, page, pageSize int) (*models.StockOfferListResponse, error)
 }
 
 // stockRepository implementação do repository
@@ -32,16 +47,41 @@ func NewStockRepository(db *gorm.DB) StockRepository {
 	return &stockRepository{db: db}
 }
 
-// CreateStock cria um novo registro de estoque
-func (r *stockRepository) CreateStock(stock *models.Stock) error {
+// CreateStock cria um novo registro de estoque. A quantidade inicial entra no livro como
+// ajuste de saldo inicial feito por actor.
+func (r *stockRepository) CreateStock(stock *models.Stock, actor string) error {
 	if stock.ID == uuid.Nil {
 		stock.ID = uuid.New()
 	}
+	quantity := stockQuantity(stock)
+	if quantity < 0 {
+		return fmt.Errorf("%w: quantity must not be negative", ErrInvalidStockMovement)
+	}
+	if quantity > 0 && strings.TrimSpace(actor) == "" {
+		return fmt.Errorf("%w: actor is required", ErrInvalidStockMovement)
+	}
 
+	stock.Version = 1
 	stock.CreatedAt = time.Now()
 	stock.UpdatedAt = time.Now()
 
-	return r.db.Create(stock).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(stock).Error; err != nil {
+			return err
+		}
+		if quantity == 0 {
+			return nil
+		}
+		return tx.Create(&models.StockMovement{
+			StockID:       stock.ID,
+			Type:          models.StockMovementAdjustment,
+			Quantity:      quantity,
+			QuantityAfter: quantity,
+			Actor:         strings.TrimSpace(actor),
+			Reason:        openingBalanceReason,
+			CreatedAt:     stock.CreatedAt,
+		}).Error
+	})
 }
 
 // GetStockByID busca um estoque pelo ID
@@ -92,20 +132,69 @@ func (r *stockRepository) GetStocksByGroupID(groupID string) ([]models.Stock, er
 	return stocks, nil
 }
 
-// UpdateStock atualiza um registro de estoque
-func (r *stockRepository) UpdateStock(id string, updates map[string]interface{}) error {
+// UpdateStock atualiza um registro de estoque na versão informada e retorna o estoque gravado. A quantidade só muda pelo livro: count, quando informado, é a
+// contagem física que entra como ajuste na mesma transação. Se o estoque mudou depois da
+// versão, retorna ErrVersionConflict com o estoque atual.
+func (r *stockRepository) UpdateStock(id string, version int, updates map[string]interface{}, count *models.StockMovementRequest) (*models.Stock, []models.StockMovement, error) {
 	stockID, err := uuid.Parse(id)
 	if err != nil {
-		return fmt.Errorf("invalid stock ID: %w", err)
+		return nil, nil, fmt.Errorf("invalid stock ID: %w", err)
+	}
+	if _, ok := updates["quantity"]; ok {
+		return nil, nil, ErrStockQuantityReadOnly
+	}
+	if count != nil && (count.Type != models.StockMovementAdjustment || count.Counted == nil) {
+		return nil, nil, fmt.Errorf("%w: count must be an adjustment with counted", ErrInvalidStockMovement)
 	}
 
-	updates["updated_at"] = time.Now()
+	var stock *models.Stock
+	var movements []models.StockMovement
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		stock, err = lockStock(tx, stockID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(version, stock.Version); err != nil {
+			return err
+		}
+
+		if count != nil {
+			delta, err := StockMovementDelta(*count, stockQuantity(stock))
+			if err != nil {
+				return err
+			}
+			movement, err := applyMovement(tx, stock, models.StockMovement{
+				StockID:   stockID,
+				Type:      count.Type,
+				Quantity:  delta,
+				Actor:     strings.TrimSpace(count.Actor),
+				Reason:    strings.TrimSpace(count.Reason),
+				Reference: strings.TrimSpace(count.Reference),
+			})
+			if err != nil {
+				return err
+			}
+			movements = []models.StockMovement{*movement}
+		}
+
+		if len(updates) > 0 {
+			updates["version"] = nextVersion()
+			updates["updated_at"] = time.Now()
+			if err := tx.Model(&models.Stock{}).Where("id = ?", stockID).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update stock: %w", err)
+			}
+		}
 
-	if err := r.db.Model(&models.Stock{}).Where("id = ?", stockID).Updates(updates).Error; err != nil {
-		return fmt.Errorf("failed to update stock: %w", err)
+		return tx.Where("id = ?", stockID).First(stock).Error
+	})
+	if errors.Is(err, ErrVersionConflict) {
+		return stock, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil
+	return stock, movements, nil
 }
 
 // DeleteStock remove um registro de estoque
@@ -154,6 +243,7 @@ func (r *stockRepository) ListStocks(page, pageSize int) (*models.StockListRespo
 			CompanyID:  stock.CompanyID.String(),
 			Quantity:   stock.Quantity,
 			Price:      stock.Price,
+			Version:    stock.Version,
 			CreatedAt:  stock.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:  stock.UpdatedAt.Format(time.RFC3339),
 		}
@@ -232,6 +322,7 @@ func (r *stockRepository) SearchStocks(query string, page, pageSize int) (*model
 			CompanyID:  stock.CompanyID.String(),
 			Quantity:   stock.Quantity,
 			Price:      stock.Price,
+			Version:    stock.Version,
 			CreatedAt:  stock.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:  stock.UpdatedAt.Format(time.RFC3339),
 		}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/partcode"
+	"partexplorer/backend/internal/synonyms"
+)
+
+// maxSearchTermLength limita o tamanho (em caracteres) dos termos registrados nas estatísticas de busca
+const maxSearchTermLength = 255
+
+// brazilianStates lista as UFs aceitas nas estatísticas de busca
+var brazilianStates = map[string]bool{
+	"AC": true, "AL": true, "AP": true, "AM": true, "BA": true, "CE": true, "DF": true,
+	"ES": true, "GO": true, "MA": true, "MT": true, "MS": true, "MG": true, "PA": true,
+	"PB": true, "PR": true, "PE": true, "PI": true, "RJ": true, "RN": true, "RS": true,
+	"RO": true, "RR": true, "SC": true, "SP": true, "SE": true, "TO": true,
+}
+
+// SuggestionRepository interface para o autocomplete e as estatísticas de busca
+type SuggestionRepository interface {
+	RecordSearch(term, state string) error
+	EachCandidate(fn func(models.SuggestionCandidate) error) error
+	Suggest(prefix string, types []string, state string, limit int) ([]models.Suggestion, error)
+}
+
+// suggestionRepository implementação do repository
+type suggestionRepository struct {
+	db *gorm.DB
+}
+
+// NewSuggestionRepository cria uma nova instância do repository
+func NewSuggestionRepository(db *gorm.DB) SuggestionRepository {
+	return &suggestionRepository{db: db}
+}
+
+// suggestionSource descreve de onde vêm os termos de um tipo de sugestão
+type suggestionSource struct {
+	kind    string
+	text    string
+	groupID string
+	from    string
+	// code é a coluna com o código normalizado, para casar "kl12" com "KL-1234"
+	code string
+}
+
+var suggestionSources = []suggestionSource{
+	{
+		kind:    models.SuggestionSKU,
+		text:    "pn.name",
+		groupID: "pn.group_id",
+		from:    "partexplorer.part_name pn WHERE pn.type = 'sku'",
+		code:    "pn.normalized_code",
+	},
+	{
+		kind:    models.SuggestionBrand,
+		text:    "b.name",
+		groupID: "pn.group_id",
+		from:    "partexplorer.part_name pn JOIN partexplorer.brand b ON b.id = pn.brand_id WHERE TRUE",
+	},
+	{
+		kind:    models.SuggestionProductType,
+		text:    "pt.description",
+		groupID: "pg.id",
+		from:    "partexplorer.part_group pg JOIN partexplorer.product_type pt ON pt.id = pg.product_type_id WHERE TRUE",
+	},
+	{
+		kind:    models.SuggestionVehicleModel,
+		text:    "TRIM(COALESCE(app.manufacturer, '') || ' ' || app.model)",
+		groupID: "pga.group_id",
+		from:    "partexplorer.application app JOIN partexplorer.part_group_application pga ON pga.application_id = app.id WHERE COALESCE(app.model, '') <> ''",
+	},
+}
+
+// searchTermsCTE soma as buscas registradas de cada termo em todos os estados
+const searchTermsCTE = `
+	searches AS (
+		SELECT term, SUM(searches) AS searches FROM partexplorer.search_term_stats GROUP BY term
+	)`
+
+// groupStatesCTE lista os estados com estoque disponível de cada grupo, como no índice
+const groupStatesCTE = `
+	group_states AS (
+		SELECT DISTINCT pn.group_id, c.state
+		FROM partexplorer.stock s
+		JOIN partexplorer.part_name pn ON pn.id = s.part_name_id
+		JOIN partexplorer.company c ON c.id = s.company_id
+		WHERE s.obsolete = false AND s.quantity > 0 AND COALESCE(c.state, '') <> ''
+	)`
+
+// RecordSearch registra uma busca com resultado para a popularidade do autocomplete
+func (r *suggestionRepository) RecordSearch(term, state string) error {
+	term = synonyms.Normalize(term)
+	if term == "" {
+		return nil
+	}
+	// O corte é por caractere para não partir um acento no meio do UTF-8
+	if runes := []rune(term); len(runes) > maxSearchTermLength {
+		term = string(runes[:maxSearchTermLength])
+	}
+
+	// Um estado que não é UF registra a busca sem estado em vez de falhar o INSERT
+	state = strings.ToUpper(strings.TrimSpace(state))
+	if !brazilianStates[state] {
+		state = ""
+	}
+
+	if err := r.db.Exec(`
+		INSERT INTO partexplorer.search_term_stats (term, state, searches, last_searched_at)
+		VALUES (?, ?, 1, CURRENT_TIMESTAMP)
+		ON CONFLICT (term, state) DO UPDATE
+		SET searches = search_term_stats.searches + 1, last_searched_at = EXCLUDED.last_searched_at
+	`, term, state).Error; err != nil {
+		return fmt.Errorf("failed to record search: %w", err)
+	}
+
+	return nil
+}
+
+// EachCandidate percorre todos os termos sugeríveis com popularidade e estados disponíveis
+func (r *suggestionRepository) EachCandidate(fn func(models.SuggestionCandidate) error) error {
+	candidates, _ := buildSuggestionCandidatesCTE("", models.SuggestionTypes)
+
+	rows, err := r.db.Raw(`
+		WITH ` + candidates + `,` + groupStatesCTE + `,` + searchTermsCTE + `
+		SELECT
+			c.type,
+			c.text,
+			COUNT(DISTINCT c.group_id) AS catalog_count,
+			COALESCE(MAX(st.searches), 0) AS searches,
+			COALESCE(STRING_AGG(DISTINCT gs.state, ','), '') AS states
+		FROM candidates c
+		LEFT JOIN group_states gs ON gs.group_id = c.group_id
+		LEFT JOIN searches st ON st.term = partexplorer.f_unaccent(lower(c.text))
+		GROUP BY c.type, c.text
+	`).Rows()
+	if err != nil {
+		return fmt.Errorf("failed to load suggestion candidates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var candidate models.SuggestionCandidate
+		var states string
+		if err := rows.Scan(&candidate.Type, &candidate.Text, &candidate.CatalogCount, &candidate.Searches, &states); err != nil {
+			return fmt.Errorf("failed to scan suggestion candidate: %w", err)
+		}
+		if states != "" {
+			candidate.States = strings.Split(states, ",")
+		}
+		if err := fn(candidate); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Suggest busca sugestões por prefixo de palavra (fallback do completion suggester),
+// ordenadas pela mesma popularidade usada como peso no índice
+func (r *suggestionRepository) Suggest(prefix string, types []string, state string, limit int) ([]models.Suggestion, error) {
+	prefix = synonyms.Normalize(prefix)
+	if prefix == "" {
+		return []models.Suggestion{}, nil
+	}
+
+	candidates, args := buildSuggestionCandidatesCTE(prefix, types)
+
+	stateCondition := "TRUE"
+	if state != "" {
+		stateCondition = "EXISTS (SELECT 1 FROM group_states gs WHERE gs.group_id = c.group_id AND gs.state = ?)"
+		args = append(args, strings.ToUpper(state))
+	}
+	args = append(args, limit)
+
+	var suggestions []models.Suggestion
+	if err := r.db.Raw(`
+		WITH `+candidates+`,`+groupStatesCTE+`,`+searchTermsCTE+`
+		SELECT
+			c.type,
+			c.text,
+			COUNT(DISTINCT c.group_id) + `+strconv.Itoa(models.SuggestionSearchWeight)+` * COALESCE(MAX(st.searches), 0) AS score
+		FROM candidates c
+		LEFT JOIN searches st ON st.term = partexplorer.f_unaccent(lower(c.text))
+		WHERE `+stateCondition+`
+		GROUP BY c.type, c.text
+		ORDER BY score DESC, c.text
+		LIMIT ?
+	`, args...).Scan(&suggestions).Error; err != nil {
+		return nil, fmt.Errorf("failed to search suggestions: %w", err)
+	}
+
+	if suggestions == nil {
+		suggestions = []models.Suggestion{}
+	}
+	return suggestions, nil
+}
+
+// buildSuggestionCandidatesCTE monta a CTE "candidates" (type, text, group_id) com os tipos
+// pedidos; com prefixo, mantém só termos com alguma palavra iniciando pelo prefixo
+func buildSuggestionCandidatesCTE(prefix string, types []string) (string, []interface{}) {
+	wanted := make(map[string]bool, len(types))
+	for _, kind := range types {
+		wanted[kind] = true
+	}
+
+	var branches []string
+	var args []interface{}
+	for _, source := range suggestionSources {
+		if !wanted[source.kind] {
+			continue
+		}
+
+		branch := fmt.Sprintf("SELECT '%s' AS type, %s AS text, %s AS group_id FROM %s AND COALESCE(%s, '') <> ''",
+			source.kind, source.text, source.groupID, source.from, source.text)
+		if prefix != "" {
+			normalized := "partexplorer.f_unaccent(lower(" + source.text + "))"
+			conditions := normalized + " LIKE ? OR " + normalized + " LIKE ?"
+			args = append(args, prefix+"%", "% "+prefix+"%")
+			if code := partcode.Normalize(prefix); source.code != "" && code != "" {
+				conditions += " OR " + source.code + " LIKE ?"
+				args = append(args, code+"%")
+			}
+			branch += " AND (" + conditions + ")"
+		}
+		branches = append(branches, branch)
+	}
+
+	if len(branches) == 0 {
+		return "candidates AS (SELECT NULL::text AS type, NULL::text AS text, NULL::uuid AS group_id WHERE FALSE)", nil
+	}
+
+	return "candidates AS (\n\t\t" + strings.Join(branches, "\n\t\tUNION ALL\n\t\t") + "\n\t)", args
+}
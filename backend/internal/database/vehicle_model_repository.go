@@ -0,0 +1,304 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/vehiclemodel"
+)
+
+// defaultVehicleModelMinConfidence é a confiança mínima para usar a correspondência sem revisão
+const defaultVehicleModelMinConfidence = 0.8
+
+var (
+	// ErrVehicleModelMappingNotFound indica que o mapeamento informado não existe
+	ErrVehicleModelMappingNotFound = errors.New("vehicle model mapping not found")
+	// ErrVehicleModelNothingToConfirm indica que o mapeamento não tem palpite para confirmar
+	ErrVehicleModelNothingToConfirm = errors.New("vehicle model mapping has no catalog model to confirm")
+	// ErrVehicleModelUnknownApplication indica que a correção não corresponde a nenhuma aplicação
+	ErrVehicleModelUnknownApplication = errors.New("no application matches the given manufacturer/model/version/engine")
+)
+
+// VehicleModelRepository interface para o mapeamento entre os modelos dos provedores de placa
+// e as aplicações do catálogo
+type VehicleModelRepository interface {
+	Resolve(brand, model string) (*models.VehicleModelMapping, error)
+	GetByID(id string) (*models.VehicleModelMapping, error)
+	List(status string, page, pageSize int) (*models.VehicleModelMappingListResponse, error)
+	Candidates(id string, limit int) ([]vehiclemodel.Match, error)
+	Confirm(id string) (*models.VehicleModelMapping, error)
+	Correct(id string, target vehiclemodel.Candidate) (*models.VehicleModelMapping, error)
+}
+
+// vehicleModelRepository implementa VehicleModelRepository
+type vehicleModelRepository struct {
+	db            *gorm.DB
+	minConfidence float64
+}
+
+// NewVehicleModelRepository cria uma nova instância do repositório. VEHICLE_MODEL_MIN_CONFIDENCE
+// define a confiança mínima (0 a 1, padrão 0.8) para usar uma correspondência sem revisão.
+func NewVehicleModelRepository(db *gorm.DB) VehicleModelRepository {
+	minConfidence := defaultVehicleModelMinConfidence
+	if value, err := strconv.ParseFloat(os.Getenv("VEHICLE_MODEL_MIN_CONFIDENCE"), 64); err == nil && value > 0 && value <= 1 {
+		minConfidence = value
+	}
+	return &vehicleModelRepository{db: db, minConfidence: minConfidence}
+}
+
+// Resolve retorna o mapeamento da marca/modelo do provedor. Na primeira vez que a combinação
+// aparece, procura a aplicação mais parecida no catálogo e grava o resultado: com confiança
+// suficiente fica "auto"; senão vai para a fila de revisão ("pending").
+func (r *vehicleModelRepository) Resolve(brand, model string) (*models.VehicleModelMapping, error) {
+	source := vehiclemodel.ParseSource(brand, model)
+	if source.Model == "" {
+		return nil, fmt.Errorf("empty vehicle model")
+	}
+
+	var mapping models.VehicleModelMapping
+	err := r.db.Where("source_brand = ? AND source_model = ?", source.Brand, source.Model).First(&mapping).Error
+	if err == nil {
+		return &mapping, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get vehicle model mapping: %w", err)
+	}
+
+	candidates, err := r.loadCandidates()
+	if err != nil {
+		return nil, err
+	}
+
+	mapping = models.VehicleModelMapping{
+		ID:          uuid.New(),
+		SourceBrand: source.Brand,
+		SourceModel: source.Model,
+		Status:      models.VehicleModelMappingPending,
+	}
+	if best, ok := vehiclemodel.Best(source, candidates); ok {
+		mapping.Manufacturer = best.Manufacturer
+		mapping.Model = best.Model
+		mapping.Version = best.Version
+		mapping.Engine = best.Engine
+		mapping.Confidence = best.Confidence
+		if best.Confidence >= r.minConfidence {
+			mapping.Status = models.VehicleModelMappingAuto
+		}
+	}
+
+	// Outra consulta da mesma combinação pode ter gravado antes: prevalece o registro existente
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&mapping).Error; err != nil {
+		return nil, fmt.Errorf("failed to save vehicle model mapping: %w", err)
+	}
+	if err := r.db.Where("source_brand = ? AND source_model = ?", source.Brand, source.Model).First(&mapping).Error; err != nil {
+		return nil, fmt.Errorf("failed to get vehicle model mapping: %w", err)
+	}
+
+	if mapping.Status == models.VehicleModelMappingPending {
+		log.Printf("⚠️ [VEHICLE-MODEL] %s / %s enviado para revisão (palpite: %s %s, confiança %.2f)",
+			source.Brand, source.Model, mapping.Manufacturer, mapping.Model, mapping.Confidence)
+	}
+	return &mapping, nil
+}
+
+// GetByID busca um mapeamento pelo ID
+func (r *vehicleModelRepository) GetByID(id string) (*models.VehicleModelMapping, error) {
+	mappingID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ID %q", ErrVehicleModelMappingNotFound, id)
+	}
+
+	var mapping models.VehicleModelMapping
+	if err := r.db.Where("id = ?", mappingID).First(&mapping).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVehicleModelMappingNotFound
+		}
+		return nil, fmt.Errorf("failed to get vehicle model mapping: %w", err)
+	}
+	return &mapping, nil
+}
+
+// List lista os mapeamentos com o status informado (todos se vazio), os mais antigos primeiro
+func (r *vehicleModelRepository) List(status string, page, pageSize int) (*models.VehicleModelMappingListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	query := r.db.Model(&models.VehicleModelMapping{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count vehicle model mappings: %w", err)
+	}
+
+	var mappings []models.VehicleModelMapping
+	if err := query.Order("created_at ASC").Limit(pageSize).Offset(offset).Find(&mappings).Error; err != nil {
+		return nil, fmt.Errorf("failed to list vehicle model mappings: %w", err)
+	}
+
+	return &models.VehicleModelMappingListResponse{
+		Mappings:   mappings,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// Candidates retorna as aplicações do catálogo mais parecidas com o modelo do provedor, para
+// ajudar na revisão
+func (r *vehicleModelRepository) Candidates(id string, limit int) ([]vehiclemodel.Match, error) {
+	mapping, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	source := vehiclemodel.ParseSource(mapping.SourceBrand, mapping.SourceModel)
+	candidates, err := r.loadCandidates()
+	if err != nil {
+		return nil, err
+	}
+	return vehiclemodel.Rank(source, candidates, limit), nil
+}
+
+// Confirm aceita o palpite automático do mapeamento
+func (r *vehicleModelRepository) Confirm(id string) (*models.VehicleModelMapping, error) {
+	mapping, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Model == "" {
+		return nil, ErrVehicleModelNothingToConfirm
+	}
+
+	now := time.Now()
+	mapping.Status = models.VehicleModelMappingConfirmed
+	mapping.ReviewedAt = &now
+	mapping.UpdatedAt = now
+	if err := r.db.Save(mapping).Error; err != nil {
+		return nil, fmt.Errorf("failed to confirm vehicle model mapping: %w", err)
+	}
+	return mapping, nil
+}
+
+// Correct troca o destino do mapeamento, desde que exista aplicação com esses dados
+func (r *vehicleModelRepository) Correct(id string, target vehiclemodel.Candidate) (*models.VehicleModelMapping, error) {
+	mapping, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	target.Manufacturer = strings.TrimSpace(target.Manufacturer)
+	target.Model = strings.TrimSpace(target.Model)
+	target.Version = strings.TrimSpace(target.Version)
+	target.Engine = strings.TrimSpace(target.Engine)
+
+	query := r.db.Model(&models.Application{}).
+		Where("LOWER(manufacturer) = LOWER(?) AND LOWER(model) = LOWER(?)", target.Manufacturer, target.Model)
+	if target.Version != "" {
+		query = query.Where("LOWER(version) = LOWER(?)", target.Version)
+	}
+	if target.Engine != "" {
+		query = query.Where("LOWER(engine) = LOWER(?)", target.Engine)
+	}
+	var application models.Application
+	if err := query.Select("manufacturer, model, version, engine").First(&application).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVehicleModelUnknownApplication
+		}
+		return nil, fmt.Errorf("failed to check application: %w", err)
+	}
+
+	// Grava os valores como estão no catálogo
+	now := time.Now()
+	mapping.Manufacturer = application.Manufacturer
+	mapping.Model = application.Model
+	mapping.Version = target.Version
+	mapping.Engine = target.Engine
+	if target.Version != "" {
+		mapping.Version = application.Version
+	}
+	if target.Engine != "" {
+		mapping.Engine = application.Engine
+	}
+	mapping.Confidence = 1
+	mapping.Status = models.VehicleModelMappingCorrected
+	mapping.ReviewedAt = &now
+	mapping.UpdatedAt = now
+	if err := r.db.Save(mapping).Error; err != nil {
+		return nil, fmt.Errorf("failed to correct vehicle model mapping: %w", err)
+	}
+	return mapping, nil
+}
+
+// loadCandidates lista as combinações fabricante/modelo/versão/motor do catálogo. O filtro por
+// fabricante fica com vehiclemodel.Rank, que também reconhece as abreviações ("VW", "GM").
+func (r *vehicleModelRepository) loadCandidates() ([]vehiclemodel.Candidate, error) {
+	query := r.db.Model(&models.Application{}).
+		Distinct("manufacturer", "model", "version", "engine").
+		Where("COALESCE(model, '') <> ''")
+
+	var candidates []vehiclemodel.Candidate
+	if err := query.Scan(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load catalog applications: %w", err)
+	}
+	return candidates, nil
+}
+
+// VehicleApplication é o veículo da consulta de placa nos termos das aplicações do catálogo
+type VehicleApplication struct {
+	Manufacturer string
+	Model        string
+	Year         string
+	// Mapping é o mapeamento usado (ou aguardando revisão); nil se não foi possível resolver
+	Mapping *models.VehicleModelMapping
+}
+
+// ResolveVehicleApplication converte marca/modelo/ano do provedor de placa para a busca por
+// aplicação. Usa o mapeamento quando ele é confiável; enquanto aguarda revisão (ou sem
+// repositório), usa a marca normalizada e a primeira palavra do modelo.
+func ResolveVehicleApplication(repo VehicleModelRepository, info *models.CarInfo) VehicleApplication {
+	application := VehicleApplication{Year: info.AnoModelo}
+	if application.Year == "" {
+		application.Year = info.Ano
+	}
+
+	if repo != nil {
+		mapping, err := repo.Resolve(info.Marca, info.Modelo)
+		if err != nil {
+			log.Printf("⚠️ [VEHICLE-MODEL] Erro ao resolver %s / %s: %v", info.Marca, info.Modelo, err)
+		} else {
+			application.Mapping = mapping
+			if mapping.Usable() {
+				application.Manufacturer = mapping.Manufacturer
+				application.Model = mapping.Model
+				return application
+			}
+		}
+	}
+
+	source := vehiclemodel.ParseSource(info.Marca, info.Modelo)
+	application.Manufacturer = source.Manufacturer
+	if application.Manufacturer == "" {
+		application.Manufacturer = info.Marca
+	}
+	application.Model = source.ModelGuess()
+	return application
+}
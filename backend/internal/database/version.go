@@ -0,0 +1,25 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVersionConflict indica que o registro mudou depois da versão informada (If-Match)
+var ErrVersionConflict = errors.New("version conflict")
+
+// nextVersion incrementa a coluna version no próprio UPDATE, para a escrita invalidar os
+// ETags já entregues
+func nextVersion() clause.Expr {
+	return gorm.Expr("version + 1")
+}
+
+// checkVersion confere a versão esperada com a atual
+func checkVersion(expected, current int) error {
+	if expected != current {
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package elasticsearch
+
+import (
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"partexplorer/backend/internal/database"
+)
+
+// ChangeIndexer consome o search_outbox e reindexa apenas os grupos alterados
+type ChangeIndexer struct {
+	outbox  database.OutboxRepository
+	repo    database.PartRepository
+	indexer *IndexerService
+
+	pollInterval time.Duration
+	batchSize    int
+	lease        time.Duration
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	retention    time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewChangeIndexer cria o indexador incremental.
+// SEARCH_OUTBOX_POLL_INTERVAL e SEARCH_OUTBOX_BATCH_SIZE ajustam o polling e
+// SEARCH_OUTBOX_MAX_ATTEMPTS o número de tentativas antes de o evento ser descartado.
+func NewChangeIndexer(outbox database.OutboxRepository, repo database.PartRepository, indexer *IndexerService) *ChangeIndexer {
+	pollInterval := 5 * time.Second
+	if value, err := time.ParseDuration(os.Getenv("SEARCH_OUTBOX_POLL_INTERVAL")); err == nil && value > 0 {
+		pollInterval = value
+	}
+
+	batchSize := 500
+	if value, err := strconv.Atoi(os.Getenv("SEARCH_OUTBOX_BATCH_SIZE")); err == nil && value > 0 {
+		batchSize = value
+	}
+
+	maxAttempts := 10
+	if value, err := strconv.Atoi(os.Getenv("SEARCH_OUTBOX_MAX_ATTEMPTS")); err == nil && value > 0 {
+		maxAttempts = value
+	}
+
+	return &ChangeIndexer{
+		outbox:       outbox,
+		repo:         repo,
+		indexer:      indexer,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		lease:        5 * time.Minute,
+		maxAttempts:  maxAttempts,
+		baseBackoff:  5 * time.Second,
+		maxBackoff:   10 * time.Minute,
+		retention:    24 * time.Hour,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start inicia o loop de polling em background
+func (w *ChangeIndexer) Start() {
+	go w.run()
+	log.Printf("✅ [INDEXER] Indexação incremental iniciada (intervalo %s, lote %d)", w.pollInterval, w.batchSize)
+}
+
+// Stop encerra o loop e aguarda o lote em andamento
+func (w *ChangeIndexer) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+func (w *ChangeIndexer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	lastPurge := time.Now()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// Esvaziar a fila enquanto houver lotes cheios
+		for {
+			processed, err := w.ProcessBatch()
+			if err != nil {
+				log.Printf("⚠️ [INDEXER] Erro ao processar outbox: %v", err)
+				break
+			}
+			if processed < w.batchSize {
+				break
+			}
+			select {
+			case <-w.stop:
+				return
+			default:
+			}
+		}
+
+		if time.Since(lastPurge) > time.Hour {
+			if purged, err := w.outbox.PurgeProcessed(w.retention); err != nil {
+				log.Printf("⚠️ [INDEXER] Erro ao limpar outbox: %v", err)
+			} else if purged > 0 {
+				log.Printf("🧹 [INDEXER] %d eventos antigos removidos do outbox", purged)
+			}
+			lastPurge = time.Now()
+		}
+	}
+}
+
+// ProcessBatch lê um lote do outbox, reindexa os grupos afetados e retorna quantos eventos foram lidos
+func (w *ChangeIndexer) ProcessBatch() (int, error) {
+	entries, err := w.outbox.FetchPending(w.batchSize, w.lease)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	// Vários eventos do mesmo grupo viram uma única reindexação
+	entryIDs := make(map[string][]int64)
+	attempts := make(map[string]int)
+	var groupIDs []string
+	for _, entry := range entries {
+		id := entry.GroupID.String()
+		if _, ok := entryIDs[id]; !ok {
+			groupIDs = append(groupIDs, id)
+		}
+		entryIDs[id] = append(entryIDs[id], entry.ID)
+		if entry.Attempts > attempts[id] {
+			attempts[id] = entry.Attempts
+		}
+	}
+
+	partGroups, err := w.repo.GetPartGroupsByIDs(groupIDs)
+	if err != nil {
+		w.fail(groupIDs, entryIDs, attempts, err.Error())
+		return len(entries), err
+	}
+
+	found := make(map[string]bool, len(partGroups))
+	for _, partGroup := range partGroups {
+		found[partGroup.ID.String()] = true
+	}
+
+	// Grupos que não existem mais no banco saem do índice
+	var deletedIDs []string
+	for _, id := range groupIDs {
+		if !found[id] {
+			deletedIDs = append(deletedIDs, id)
+		}
+	}
+
+	failed, err := w.indexer.SyncPartGroups(partGroups, deletedIDs)
+	if err != nil {
+		w.fail(groupIDs, entryIDs, attempts, err.Error())
+		return len(entries), err
+	}
+
+	var processedIDs []int64
+	for _, id := range groupIDs {
+		if reason, ok := failed[id]; ok {
+			w.fail([]string{id}, entryIDs, attempts, reason)
+			continue
+		}
+		processedIDs = append(processedIDs, entryIDs[id]...)
+	}
+
+	if err := w.outbox.MarkProcessed(processedIDs); err != nil {
+		return len(entries), err
+	}
+
+	log.Printf("🔄 [INDEXER] %d grupos sincronizados (%d removidos), %d com falha", len(groupIDs)-len(failed), len(deletedIDs), len(failed))
+	return len(entries), nil
+}
+
+// fail agenda um novo retry com backoff exponencial para os grupos informados; os eventos
+// que esgotaram as tentativas ficam marcados como falhos
+func (w *ChangeIndexer) fail(groupIDs []string, entryIDs map[string][]int64, attempts map[string]int, reason string) {
+	for _, id := range groupIDs {
+		nextAttempt := time.Now().Add(w.backoff(attempts[id]))
+		if err := w.outbox.MarkFailed(entryIDs[id], nextAttempt, reason, w.maxAttempts); err != nil {
+			log.Printf("⚠️ [INDEXER] Erro ao agendar retry do grupo %s: %v", id, err)
+			continue
+		}
+		if attempts[id]+1 >= w.maxAttempts {
+			log.Printf("❌ [INDEXER] Grupo %s descartado do outbox após %d tentativas: %s", id, attempts[id]+1, reason)
+		}
+	}
+}
+
+// backoff calcula o atraso do próximo retry, com jitter de até 20%
+func (w *ChangeIndexer) backoff(attempts int) time.Duration {
+	delay := w.baseBackoff
+	for i := 0; i < attempts && delay < w.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > w.maxBackoff {
+		delay = w.maxBackoff
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
@@ -2,13 +2,20 @@ package elasticsearch
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"time"
+
+	"partexplorer/backend/internal/synonyms"
 
 	"github.com/olivere/elastic/v7"
 )
 
+// IndexAlias é o alias usado por buscas e escritas; aponta para o índice versionado atual
+const IndexAlias = "partexplorer"
+
 var ESClient *elastic.Client
 
 // InitElasticsearch inicializa a conexão com o Elasticsearch
@@ -47,46 +54,98 @@ func InitElasticsearch() error {
 	return nil
 }
 
-// createIndices cria os índices necessários
+// createIndices cria o primeiro índice versionado e o alias, caso ainda não existam
 func createIndices() error {
-	indices := []string{"partexplorer"}
+	ctx := context.Background()
 
-	for _, index := range indices {
-		exists, err := ESClient.IndexExists(index).Do(context.Background())
-		if err != nil {
-			return fmt.Errorf("failed to check if index %s exists: %w", index, err)
-		}
+	// IndexExists responde true tanto para o alias quanto para um índice legado com o mesmo nome
+	exists, err := ESClient.IndexExists(IndexAlias).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check if index %s exists: %w", IndexAlias, err)
+	}
 
-		if !exists {
-			// Criar índice com mapping
-			createIndex, err := ESClient.CreateIndex(index).BodyString(getIndexMapping()).Do(context.Background())
-			if err != nil {
-				return fmt.Errorf("failed to create index %s: %w", index, err)
-			}
+	if exists {
+		log.Printf("✅ Index already exists: %s", IndexAlias)
+		return nil
+	}
 
-			if !createIndex.Acknowledged {
-				return fmt.Errorf("failed to acknowledge index creation for %s", index)
-			}
+	index := NewVersionedIndexName()
+	if err := createVersionedIndex(ctx, ESClient, index); err != nil {
+		return err
+	}
 
-			log.Printf("✅ Created index: %s", index)
-		} else {
-			log.Printf("✅ Index already exists: %s", index)
-		}
+	_, err = ESClient.Alias().Add(index, IndexAlias).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create alias %s: %w", IndexAlias, err)
+	}
+
+	log.Printf("✅ Created index: %s (alias %s)", index, IndexAlias)
+	return nil
+}
+
+// NewVersionedIndexName gera o nome de um novo índice físico por trás do alias
+func NewVersionedIndexName() string {
+	return fmt.Sprintf("%s_%s", IndexAlias, time.Now().Format("20060102150405"))
+}
+
+// createVersionedIndex cria um índice físico com o mapping atual
+func createVersionedIndex(ctx context.Context, client *elastic.Client, index string) error {
+	createIndex, err := client.CreateIndex(index).BodyString(getIndexMapping()).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create index %s: %w", index, err)
+	}
+
+	if !createIndex.Acknowledged {
+		return fmt.Errorf("failed to acknowledge index creation for %s", index)
 	}
 
 	return nil
 }
 
-// getIndexMapping retorna o mapping do índice
+// getIndexMapping retorna o mapping do índice. Acentos são ignorados (asciifolding) e os
+// sinônimos do dicionário mantido em internal/synonyms entram apenas no analyzer de busca,
+// então alterações no dicionário valem a partir da próxima reindexação completa.
 func getIndexMapping() string {
-	return `{
+	rules, err := json.Marshal(synonyms.Default().Rules())
+	if err != nil {
+		rules = []byte("[]")
+	}
+
+	return fmt.Sprintf(`{
 		"settings": {
 			"number_of_shards": 1,
 			"number_of_replicas": 0,
 			"analysis": {
+				"filter": {
+					"portuguese_stop": {
+						"type": "stop",
+						"stopwords": "_portuguese_"
+					},
+					"portuguese_stemmer": {
+						"type": "stemmer",
+						"language": "light_portuguese"
+					},
+					"portuguese_synonyms": {
+						"type": "synonym_graph",
+						"synonyms": %s
+					}
+				},
 				"analyzer": {
 					"portuguese_analyzer": {
-						"type": "portuguese"
+						"type": "custom",
+						"tokenizer": "standard",
+						"filter": ["lowercase", "asciifolding", "portuguese_stop", "portuguese_stemmer"]
+					},
+					"portuguese_search_analyzer": {
+						"type": "custom",
+						"tokenizer": "standard",
+						"filter": ["lowercase", "asciifolding", "portuguese_synonyms", "portuguese_stop", "portuguese_stemmer"]
+					}
+				},
+				"normalizer": {
+					"lowercase_normalizer": {
+						"type": "custom",
+						"filter": ["lowercase", "asciifolding"]
 					}
 				}
 			}
@@ -99,42 +158,116 @@ func getIndexMapping() string {
 				"names": {
 					"type": "text",
 					"analyzer": "portuguese_analyzer",
+					"search_analyzer": "portuguese_search_analyzer",
 					"fields": {
 						"keyword": {
 							"type": "keyword"
 						}
 					}
 				},
+				"skus": {
+					"type": "keyword",
+					"normalizer": "lowercase_normalizer"
+				},
+				"eans": {
+					"type": "keyword"
+				},
+				"codes": {
+					"type": "keyword"
+				},
+				"descriptions": {
+					"type": "text",
+					"analyzer": "portuguese_analyzer",
+					"search_analyzer": "portuguese_search_analyzer"
+				},
 				"brand": {
 					"type": "text",
-					"analyzer": "portuguese_analyzer"
+					"analyzer": "portuguese_analyzer",
+					"search_analyzer": "portuguese_search_analyzer",
+					"fields": {
+						"keyword": {
+							"type": "keyword"
+						}
+					}
+				},
+				"brands": {
+					"type": "text",
+					"analyzer": "portuguese_analyzer",
+					"search_analyzer": "portuguese_search_analyzer",
+					"fields": {
+						"keyword": {
+							"type": "keyword"
+						}
+					}
 				},
 				"product_type": {
 					"type": "text",
-					"analyzer": "portuguese_analyzer"
+					"analyzer": "portuguese_analyzer",
+					"search_analyzer": "portuguese_search_analyzer",
+					"fields": {
+						"keyword": {
+							"type": "keyword"
+						}
+					}
 				},
 				"family": {
 					"type": "text",
-					"analyzer": "portuguese_analyzer"
+					"analyzer": "portuguese_analyzer",
+					"search_analyzer": "portuguese_search_analyzer",
+					"fields": {
+						"keyword": {
+							"type": "keyword"
+						}
+					}
 				},
 				"subfamily": {
 					"type": "text",
-					"analyzer": "portuguese_analyzer"
+					"analyzer": "portuguese_analyzer",
+					"search_analyzer": "portuguese_search_analyzer",
+					"fields": {
+						"keyword": {
+							"type": "keyword"
+						}
+					}
 				},
 				"applications": {
 					"type": "nested",
 					"properties": {
 						"manufacturer": {
 							"type": "text",
-							"analyzer": "portuguese_analyzer"
+							"analyzer": "portuguese_analyzer",
+							"search_analyzer": "portuguese_search_analyzer",
+							"fields": {
+								"keyword": {
+									"type": "keyword",
+									"normalizer": "lowercase_normalizer"
+								}
+							}
 						},
 						"model": {
 							"type": "text",
-							"analyzer": "portuguese_analyzer"
+							"analyzer": "portuguese_analyzer",
+							"search_analyzer": "portuguese_search_analyzer",
+							"fields": {
+								"keyword": {
+									"type": "keyword",
+									"normalizer": "lowercase_normalizer"
+								}
+							}
 						},
 						"version": {
 							"type": "text",
-							"analyzer": "portuguese_analyzer"
+							"analyzer": "portuguese_analyzer",
+							"search_analyzer": "portuguese_search_analyzer"
+						},
+						"engine": {
+							"type": "text",
+							"analyzer": "portuguese_analyzer",
+							"search_analyzer": "portuguese_search_analyzer"
+						},
+						"fuel": {
+							"type": "keyword",
+							"normalizer": "lowercase_normalizer"
 						},
 						"year_start": {
 							"type": "integer"
@@ -167,12 +300,61 @@ func getIndexMapping() string {
 				"discontinued": {
 					"type": "boolean"
 				},
+				"in_stock": {
+					"type": "boolean"
+				},
+				"total_quantity": {
+					"type": "long"
+				},
+				"states": {
+					"type": "keyword"
+				},
+				"cities": {
+					"type": "keyword"
+				},
+				"stock_by_state": {
+					"type": "nested",
+					"properties": {
+						"state": {
+							"type": "keyword"
+						},
+						"companies": {
+							"type": "integer"
+						},
+						"quantity": {
+							"type": "long"
+						},
+						"min_price": {
+							"type": "float"
+						}
+					}
+				},
+				"stock_by_city": {
+					"type": "nested",
+					"properties": {
+						"state": {
+							"type": "keyword"
+						},
+						"city": {
+							"type": "keyword"
+						},
+						"companies": {
+							"type": "integer"
+						},
+						"quantity": {
+							"type": "long"
+						},
+						"min_price": {
+							"type": "float"
+						}
+					}
+				},
 				"modified_at": {
 					"type": "date"
 				}
 			}
 		}
-	}`
+	}`, rules)
 }
 
 // GetClient retorna o cliente do Elasticsearch
@@ -0,0 +1,48 @@
+package elasticsearch
+
+import (
+	"partexplorer/backend/internal/models"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// searchFacetSize limita a quantidade de valores retornados por facet
+const searchFacetSize = 50
+
+// facetFields mapeia cada facet para o campo keyword do documento
+var facetFields = map[string]string{
+	models.FacetBrand:       "brands.keyword",
+	models.FacetFamily:      "family.keyword",
+	models.FacetSubfamily:   "subfamily.keyword",
+	models.FacetProductType: "product_type.keyword",
+	models.FacetState:       "states",
+	models.FacetCity:        "cities",
+}
+
+// buildFacetFilter monta o filtro dos facets selecionados (AND entre facets, OR dentro de cada um)
+func buildFacetFilter(filters models.SearchFilters) *elastic.BoolQuery {
+	filter := elastic.NewBoolQuery()
+
+	for _, name := range models.SearchFacets {
+		values := filters[name]
+		if len(values) == 0 {
+			continue
+		}
+
+		terms := make([]interface{}, len(values))
+		for i, value := range values {
+			terms[i] = value
+		}
+		filter.Filter(elastic.NewTermsQuery(facetFields[name], terms...))
+	}
+
+	return filter
+}
+
+// buildFacetAggregation conta um facet aplicando apenas os filtros dos demais facets,
+// para que o usuário possa selecionar vários valores do mesmo facet
+func buildFacetAggregation(name string, filters models.SearchFilters) elastic.Aggregation {
+	return elastic.NewFilterAggregation().
+		Filter(buildFacetFilter(filters.Except(name))).
+		SubAggregation("values", elastic.NewTermsAggregation().Field(facetFields[name]).Size(searchFacetSize))
+}
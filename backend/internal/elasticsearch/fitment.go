@@ -0,0 +1,148 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+
+	"partexplorer/backend/internal/models"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// fitmentFacetSize limita a quantidade de valores retornados por facet
+const fitmentFacetSize = 50
+
+// SearchFitment busca peças compatíveis com o veículo usando a query nested em applications.
+// A ordenação reflete a especificidade: modelo exato, motor, combustível e faixa de anos
+// preenchida pontuam acima de aplicações genéricas.
+func (s *SearchService) SearchFitment(query models.FitmentQuery, page, pageSize int) (*models.SearchResponse, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("elasticsearch client not initialized")
+	}
+
+	nested := elastic.NewNestedQuery("applications", buildFitmentQuery(query)).
+		ScoreMode("max")
+
+	searchResult, err := s.client.Search().
+		Index(IndexAlias).
+		Query(nested).
+		FetchSource(false).
+		Aggregation("family", elastic.NewTermsAggregation().Field("family.keyword").Size(fitmentFacetSize)).
+		Aggregation("subfamily", elastic.NewTermsAggregation().Field("subfamily.keyword").Size(fitmentFacetSize)).
+		From((page-1)*pageSize).
+		Size(pageSize).
+		Sort("_score", false).
+		Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to search fitment: %w", err)
+	}
+
+	results := make([]models.SearchResult, len(searchResult.Hits.Hits))
+	for i, hit := range searchResult.Hits.Hits {
+		score := 0.0
+		if hit.Score != nil {
+			score = *hit.Score
+		}
+		results[i] = models.SearchResult{
+			ID:    hit.Id,
+			Score: score,
+		}
+	}
+
+	total := searchResult.TotalHits()
+	return &models.SearchResponse{
+		Results:    results,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		Facets: map[string][]models.FacetValue{
+			"family":    termsFacet(searchResult.Aggregations, "family"),
+			"subfamily": termsFacet(searchResult.Aggregations, "subfamily"),
+		},
+	}, nil
+}
+
+// buildFitmentQuery monta a query aplicada a cada aplicação do documento
+func buildFitmentQuery(query models.FitmentQuery) *elastic.BoolQuery {
+	q := elastic.NewBoolQuery()
+
+	if query.Manufacturer != "" {
+		// Os documentos guardam o fabricante canônico (ver convertToDocument); o orquestrador
+		// já converte o fabricante informado pelo banco
+		q.Must(elastic.NewMatchQuery("applications.manufacturer", query.Manufacturer).Operator("and"))
+	}
+
+	if query.Model != "" {
+		q.Must(elastic.NewMatchQuery("applications.model", query.Model).Operator("and"))
+		// Modelo idêntico é mais específico que um modelo que apenas contém os termos
+		q.Should(elastic.NewTermQuery("applications.model.keyword", query.Model).Boost(2))
+	}
+
+	if query.Year > 0 {
+		// A faixa [year_start, year_end] precisa conter o ano; limites ausentes são abertos
+		q.Filter(
+			elastic.NewBoolQuery().
+				Should(
+					elastic.NewRangeQuery("applications.year_start").Lte(query.Year),
+					elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery("applications.year_start")),
+				).
+				MinimumShouldMatch("1"),
+			elastic.NewBoolQuery().
+				Should(
+					elastic.NewRangeQuery("applications.year_end").Gte(query.Year),
+					elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery("applications.year_end")),
+				).
+				MinimumShouldMatch("1"),
+		)
+		// Faixa fechada é mais específica que faixa aberta
+		q.Should(elastic.NewBoolQuery().
+			Filter(
+				elastic.NewExistsQuery("applications.year_start"),
+				elastic.NewExistsQuery("applications.year_end"),
+			).
+			Boost(1))
+	}
+
+	if query.Engine != "" {
+		// Aplicações sem motor cadastrado (campo omitido no documento) continuam válidas, mas pontuam menos
+		q.Filter(elastic.NewBoolQuery().
+			Should(
+				elastic.NewMatchQuery("applications.engine", query.Engine).Operator("and"),
+				elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery("applications.engine")),
+			).
+			MinimumShouldMatch("1"))
+		q.Should(elastic.NewMatchQuery("applications.engine", query.Engine).Operator("and").Boost(2))
+	}
+
+	if query.Fuel != "" {
+		q.Filter(elastic.NewBoolQuery().
+			Should(
+				elastic.NewTermQuery("applications.fuel", query.Fuel),
+				elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery("applications.fuel")),
+			).
+			MinimumShouldMatch("1"))
+		q.Should(elastic.NewTermQuery("applications.fuel", query.Fuel).Boost(1.5))
+	}
+
+	return q
+}
+
+// termsFacet converte uma agregação terms em valores de facet
+func termsFacet(aggs elastic.Aggregations, name string) []models.FacetValue {
+	values := []models.FacetValue{}
+
+	terms, found := aggs.Terms(name)
+	if !found {
+		return values
+	}
+
+	for _, bucket := range terms.Buckets {
+		values = append(values, models.FacetValue{
+			Value: fmt.Sprint(bucket.Key),
+			Count: bucket.DocCount,
+		})
+	}
+
+	return values
+}
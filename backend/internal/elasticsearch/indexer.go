@@ -4,9 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"strings"
 
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/manufacturer"
 	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/partcode"
 
+	"github.com/google/uuid"
 	"github.com/olivere/elastic/v7"
 )
 
@@ -14,7 +20,12 @@ import (
 type PartDocument struct {
 	ID           string                `json:"id"`
 	Names        []string              `json:"names"`
+	SKUs         []string              `json:"skus"`
+	EANs         []string              `json:"eans"`
+	Codes        []string              `json:"codes"` // SKUs e EANs normalizados (partcode)
+	Descriptions []string              `json:"descriptions"`
 	Brand        string                `json:"brand"`
+	Brands       []string              `json:"brands"`
 	ProductType  string                `json:"product_type"`
 	Family       string                `json:"family"`
 	Subfamily    string                `json:"subfamily"`
@@ -24,6 +35,14 @@ type PartDocument struct {
 	Discontinued bool                  `json:"discontinued"`
 	Score        float64               `json:"score,omitempty"`
 
+	// Disponibilidade em estoque
+	InStock       bool                    `json:"in_stock"`
+	TotalQuantity int64                   `json:"total_quantity"`
+	States        []string                `json:"states"`
+	Cities        []string                `json:"cities"`
+	StockByState  []StockLocationDocument `json:"stock_by_state"`
+	StockByCity   []StockLocationDocument `json:"stock_by_city"`
+
 	// IDs para preservar relacionamentos
 	BrandID       string   `json:"brand_id"`
 	ProductTypeID string   `json:"product_type_id"`
@@ -37,10 +56,21 @@ type ApplicationDocument struct {
 	Manufacturer string `json:"manufacturer"`
 	Model        string `json:"model"`
 	Version      string `json:"version"`
+	Engine       string `json:"engine,omitempty"`
+	Fuel         string `json:"fuel,omitempty"`
 	YearStart    *int   `json:"year_start"`
 	YearEnd      *int   `json:"year_end"`
 }
 
+// StockLocationDocument representa o estoque agregado de um estado ou cidade
+type StockLocationDocument struct {
+	State     string   `json:"state"`
+	City      string   `json:"city,omitempty"`
+	Companies int64    `json:"companies"`
+	Quantity  int64    `json:"quantity"`
+	MinPrice  *float64 `json:"min_price,omitempty"`
+}
+
 // DimensionDocument representa dimensões no Elasticsearch
 type DimensionDocument struct {
 	LengthMM *float64 `json:"length_mm"`
@@ -52,13 +82,20 @@ type DimensionDocument struct {
 // IndexerService serviço para indexação
 type IndexerService struct {
 	client *elastic.Client
+	repo   database.PartRepository
+	outbox database.OutboxRepository
 }
 
 // NewIndexerService cria uma nova instância do indexador
 func NewIndexerService() *IndexerService {
-	return &IndexerService{
+	service := &IndexerService{
 		client: GetClient(),
 	}
+	if db := database.GetDB(); db != nil {
+		service.repo = database.NewPartRepository(db)
+		service.outbox = database.NewOutboxRepository(db)
+	}
+	return service
 }
 
 // IndexPartGroup indexa um grupo de peças
@@ -66,13 +103,16 @@ func (i *IndexerService) IndexPartGroup(partGroup models.PartGroup) error {
 	ctx := context.Background()
 
 	// Converter para documento do Elasticsearch
-	doc := i.convertToDocument(partGroup)
+	docs, err := i.BuildDocuments([]models.PartGroup{partGroup})
+	if err != nil {
+		return err
+	}
 
 	// Indexar documento
-	_, err := i.client.Index().
-		Index("partexplorer").
+	_, err = i.client.Index().
+		Index(IndexAlias).
 		Id(partGroup.ID.String()).
-		BodyJson(doc).
+		BodyJson(docs[0]).
 		Do(ctx)
 
 	if err != nil {
@@ -86,15 +126,18 @@ func (i *IndexerService) IndexPartGroup(partGroup models.PartGroup) error {
 func (i *IndexerService) IndexAllPartGroups(partGroups []models.PartGroup) error {
 	ctx := context.Background()
 
+	docs, err := i.BuildDocuments(partGroups)
+	if err != nil {
+		return err
+	}
+
 	// Bulk indexer
 	bulk := i.client.Bulk()
 
-	for _, partGroup := range partGroups {
-		doc := i.convertToDocument(partGroup)
-
+	for _, doc := range docs {
 		req := elastic.NewBulkIndexRequest().
-			Index("partexplorer").
-			Id(partGroup.ID.String()).
+			Index(IndexAlias).
+			Id(doc.ID).
 			Doc(doc)
 
 		bulk.Add(req)
@@ -112,12 +155,64 @@ func (i *IndexerService) IndexAllPartGroups(partGroups []models.PartGroup) error
 	return nil
 }
 
+// SyncPartGroups indexa os grupos alterados e remove os excluídos em um único bulk.
+// Retorna o motivo da falha de cada ID que o Elasticsearch rejeitou.
+func (i *IndexerService) SyncPartGroups(partGroups []models.PartGroup, deletedIDs []string) (map[string]string, error) {
+	if i.client == nil {
+		return nil, fmt.Errorf("elasticsearch client not initialized")
+	}
+
+	docs, err := i.BuildDocuments(partGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	bulk := i.client.Bulk()
+
+	for _, doc := range docs {
+		req := elastic.NewBulkIndexRequest().
+			Index(IndexAlias).
+			Id(doc.ID).
+			Doc(doc)
+		bulk.Add(req)
+	}
+
+	for _, id := range deletedIDs {
+		bulk.Add(elastic.NewBulkDeleteRequest().Index(IndexAlias).Id(id))
+	}
+
+	failed := make(map[string]string)
+	if bulk.NumberOfActions() == 0 {
+		return failed, nil
+	}
+
+	resp, err := bulk.Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bulk sync part groups: %w", err)
+	}
+
+	for _, item := range resp.Failed() {
+		// Remover um documento que já não existe não é falha
+		if item.Status == http.StatusNotFound {
+			continue
+		}
+		reason := fmt.Sprintf("status %d", item.Status)
+		if item.Error != nil {
+			reason = fmt.Sprintf("%s: %s", item.Error.Type, item.Error.Reason)
+		}
+		failed[item.Id] = reason
+	}
+
+	return failed, nil
+}
+
 // DeletePartGroup remove um grupo de peças do índice
 func (i *IndexerService) DeletePartGroup(id string) error {
 	ctx := context.Background()
 
 	_, err := i.client.Delete().
-		Index("partexplorer").
+		Index(IndexAlias).
 		Id(id).
 		Do(ctx)
 
@@ -132,7 +227,7 @@ func (i *IndexerService) DeletePartGroup(id string) error {
 func (i *IndexerService) RefreshIndex() error {
 	ctx := context.Background()
 
-	_, err := i.client.Refresh("partexplorer").Do(ctx)
+	_, err := i.client.Refresh(IndexAlias).Do(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to refresh index: %w", err)
 	}
@@ -140,17 +235,69 @@ func (i *IndexerService) RefreshIndex() error {
 	return nil
 }
 
-// convertToDocument converte PartGroup para PartDocument
-func (i *IndexerService) convertToDocument(pg models.PartGroup) PartDocument {
-	// Extrair nomes (será carregado manualmente se necessário)
-	var names []string
-	var nameIDs []string
-	// Names será carregado manualmente se necessário
+// BuildDocuments carrega em lote os relacionamentos dos grupos e monta os documentos
+func (i *IndexerService) BuildDocuments(partGroups []models.PartGroup) ([]PartDocument, error) {
+	data := make(map[uuid.UUID]*models.PartGroupIndexData)
+	if i.repo != nil && len(partGroups) > 0 {
+		ids := make([]uuid.UUID, len(partGroups))
+		for idx, partGroup := range partGroups {
+			ids[idx] = partGroup.ID
+		}
+
+		loaded, err := i.repo.GetIndexData(ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load index data: %w", err)
+		}
+		data = loaded
+	}
+
+	docs := make([]PartDocument, len(partGroups))
+	for idx, partGroup := range partGroups {
+		docs[idx] = i.convertToDocument(partGroup, data[partGroup.ID])
+	}
+
+	return docs, nil
+}
+
+// convertToDocument converte PartGroup e seus relacionamentos para PartDocument
+func (i *IndexerService) convertToDocument(pg models.PartGroup, data *models.PartGroupIndexData) PartDocument {
+	if data == nil {
+		data = &models.PartGroupIndexData{}
+	}
 
-	// Extrair marca (agora vem dos part_names)
-	var brand string
-	var brandID string
-	// Brand será extraído dos part_names se necessário
+	// Extrair nomes, separados por tipo (sku, ean e descrição)
+	var names, nameIDs, skus, eans, codes, descriptions, brands []string
+	var brand, brandID string
+	seenBrands := make(map[string]bool)
+	for _, pn := range data.Names {
+		names = append(names, pn.Name)
+		nameIDs = append(nameIDs, pn.ID.String())
+
+		switch strings.ToLower(pn.Type) {
+		case "sku":
+			skus = append(skus, pn.Name)
+		case "ean":
+			eans = append(eans, pn.Name)
+		default:
+			descriptions = append(descriptions, pn.Name)
+		}
+		if code := partcode.NormalizeByType(pn.Name, pn.Type); code != "" {
+			codes = append(codes, code)
+		}
+
+		if pn.Brand == nil || pn.Brand.Name == "" {
+			continue
+		}
+		if !seenBrands[pn.Brand.Name] {
+			seenBrands[pn.Brand.Name] = true
+			brands = append(brands, pn.Brand.Name)
+		}
+		// Marca principal é a do primeiro SKU (ou do primeiro nome, se não houver SKU)
+		if brand == "" || (strings.EqualFold(pn.Type, "sku") && len(skus) == 1) {
+			brand = pn.Brand.Name
+			brandID = pn.BrandID.String()
+		}
+	}
 
 	// Extrair tipo de produto
 	var productType string
@@ -169,9 +316,25 @@ func (i *IndexerService) convertToDocument(pg models.PartGroup) PartDocument {
 		}
 	}
 
-	// Extrair aplicações (será carregado manualmente se necessário)
-	var applications []ApplicationDocument
-	// Applications será carregado manualmente se necessário
+	// Extrair aplicações. O fabricante vai com o nome canônico ("VW" -> "VOLKSWAGEN") da
+	// coluna canonical_manufacturer, o mesmo usado pela busca SQL; ao alterar um apelido, o
+	// trigger da migração 024 atualiza a coluna e coloca os grupos no outbox.
+	applications := make([]ApplicationDocument, 0, len(data.Applications))
+	for _, app := range data.Applications {
+		canonical := app.CanonicalManufacturer
+		if canonical == "" {
+			canonical = manufacturer.Normalize(app.Manufacturer)
+		}
+		applications = append(applications, ApplicationDocument{
+			Manufacturer: canonical,
+			Model:        app.Model,
+			Version:      app.Version,
+			Engine:       app.Engine,
+			Fuel:         app.Fuel,
+			YearStart:    app.YearStart,
+			YearEnd:      app.YearEnd,
+		})
+	}
 
 	// Extrair dimensões
 	var dimensions *DimensionDocument
@@ -184,10 +347,13 @@ func (i *IndexerService) convertToDocument(pg models.PartGroup) PartDocument {
 		}
 	}
 
-	// Extrair imagens (será carregado manualmente se necessário)
+	// Extrair imagens
 	var images []string
 	var imageIDs []string
-	// Images será carregado manualmente se necessário
+	for _, image := range data.Images {
+		images = append(images, image.URL)
+		imageIDs = append(imageIDs, image.ID.String())
+	}
 
 	// Extrair ID da dimensão
 	var dimensionID string
@@ -195,10 +361,15 @@ func (i *IndexerService) convertToDocument(pg models.PartGroup) PartDocument {
 		dimensionID = pg.Dimension.ID.String()
 	}
 
-	return PartDocument{
+	doc := PartDocument{
 		ID:           pg.ID.String(),
 		Names:        names,
+		SKUs:         skus,
+		EANs:         eans,
+		Codes:        codes,
+		Descriptions: descriptions,
 		Brand:        brand,
+		Brands:       brands,
 		ProductType:  productType,
 		Family:       family,
 		Subfamily:    subfamily,
@@ -215,20 +386,81 @@ func (i *IndexerService) convertToDocument(pg models.PartGroup) PartDocument {
 		NameIDs:       nameIDs,
 		ImageIDs:      imageIDs,
 	}
+	applyStock(&doc, data.Stock)
+
+	return doc
+}
+
+// applyStock preenche a disponibilidade do documento a partir do estoque agregado por cidade
+func applyStock(doc *PartDocument, locations []models.StockLocationSummary) {
+	byState := make(map[string]*StockLocationDocument)
+	var states []string
+
+	for _, location := range locations {
+		if location.Quantity <= 0 {
+			continue
+		}
+
+		doc.TotalQuantity += location.Quantity
+		doc.StockByCity = append(doc.StockByCity, StockLocationDocument{
+			State:     location.State,
+			City:      location.City,
+			Companies: location.Companies,
+			Quantity:  location.Quantity,
+			MinPrice:  location.MinPrice,
+		})
+		if location.City != "" {
+			doc.Cities = append(doc.Cities, location.City)
+		}
+
+		state, ok := byState[location.State]
+		if !ok {
+			state = &StockLocationDocument{State: location.State}
+			byState[location.State] = state
+			states = append(states, location.State)
+		}
+		// Empresas em cidades diferentes são distintas, então a soma não duplica
+		state.Companies += location.Companies
+		state.Quantity += location.Quantity
+		if location.MinPrice != nil && (state.MinPrice == nil || *location.MinPrice < *state.MinPrice) {
+			price := *location.MinPrice
+			state.MinPrice = &price
+		}
+	}
+
+	for _, name := range states {
+		doc.StockByState = append(doc.StockByState, *byState[name])
+		if name != "" {
+			doc.States = append(doc.States, name)
+		}
+	}
+	doc.InStock = doc.TotalQuantity > 0
 }
 
 // GetIndexStats retorna estatísticas do índice
 func (i *IndexerService) GetIndexStats() (map[string]interface{}, error) {
 	ctx := context.Background()
 
-	stats, err := i.client.IndexStats("partexplorer").Do(ctx)
+	stats, err := i.client.IndexStats(IndexAlias).Do(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get index stats: %w", err)
 	}
 
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"total_docs": stats.All.Total.Docs.Count,
 		"index_size": stats.All.Total.Store.SizeInBytes,
-		"index_name": "partexplorer",
-	}, nil
+		"index_name": IndexAlias,
+	}
+
+	// Atraso da indexação incremental
+	if i.outbox != nil {
+		if outboxStats, err := i.outbox.GetStats(); err != nil {
+			log.Printf("⚠️ [INDEXER] Erro ao obter estatísticas do outbox: %v", err)
+			result["change_feed_error"] = err.Error()
+		} else {
+			result["change_feed"] = outboxStats
+		}
+	}
+
+	return result, nil
 }
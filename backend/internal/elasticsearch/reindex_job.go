@@ -0,0 +1,438 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"partexplorer/backend/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/olivere/elastic/v7"
+)
+
+// Status possíveis de um job de reindexação
+const (
+	ReindexStatusRunning   = "running"
+	ReindexStatusCompleted = "completed"
+	ReindexStatusFailed    = "failed"
+	ReindexStatusCancelled = "cancelled"
+)
+
+// maxReindexErrors limita quantas falhas individuais ficam guardadas no job
+const maxReindexErrors = 100
+
+// replayOverlap recua o checkpoint do replay: o created_at do outbox é o início da transação,
+// que pode ser anterior ao checkpoint mesmo tendo sido gravada depois dele
+const replayOverlap = time.Minute
+
+// ErrReindexRunning indica que já existe uma reindexação em andamento
+var ErrReindexRunning = errors.New("reindex job already running")
+
+// ErrReindexJobNotFound indica que o job informado não existe
+var ErrReindexJobNotFound = errors.New("reindex job not found")
+
+// ReindexError descreve um documento rejeitado pelo bulk
+type ReindexError struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// ReindexJob representa o progresso de uma reindexação completa
+type ReindexJob struct {
+	ID         string         `json:"id"`
+	Status     string         `json:"status"`
+	Index      string         `json:"index"`
+	Total      int64          `json:"total"`
+	Processed  int64          `json:"processed"`
+	Replayed   int64          `json:"replayed"`
+	Failed     int64          `json:"failed"`
+	ETASeconds *float64       `json:"eta_seconds,omitempty"`
+	Errors     []ReindexError `json:"errors,omitempty"`
+	Message    string         `json:"message,omitempty"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+// ReindexManager executa e acompanha jobs de reindexação completa do catálogo
+type ReindexManager struct {
+	repo    database.PartRepository
+	indexer *IndexerService
+
+	pageSize        int
+	bulkActions     int
+	bulkBytes       int64
+	maxFailureRatio float64
+
+	mu   sync.Mutex
+	jobs map[string]*ReindexJob
+}
+
+// NewReindexManager cria o gerenciador de jobs de reindexação
+func NewReindexManager(repo database.PartRepository, indexer *IndexerService) *ReindexManager {
+	return &ReindexManager{
+		repo:            repo,
+		indexer:         indexer,
+		pageSize:        500,
+		bulkActions:     1000,
+		bulkBytes:       5 << 20, // 5MB
+		maxFailureRatio: 0.05,
+		jobs:            make(map[string]*ReindexJob),
+	}
+}
+
+// Start dispara uma reindexação em background e retorna o job criado
+func (m *ReindexManager) Start() (*ReindexJob, error) {
+	if m.indexer == nil || m.indexer.client == nil {
+		return nil, fmt.Errorf("elasticsearch client not initialized")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.Status == ReindexStatusRunning {
+			return nil, ErrReindexRunning
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &ReindexJob{
+		ID:        uuid.New().String(),
+		Status:    ReindexStatusRunning,
+		Index:     NewVersionedIndexName(),
+		StartedAt: time.Now(),
+		cancel:    cancel,
+	}
+	m.jobs[job.ID] = job
+
+	go m.run(ctx, job)
+
+	snapshot := m.snapshot(job)
+	return &snapshot, nil
+}
+
+// Get retorna o estado atual de um job
+func (m *ReindexManager) Get(id string) (*ReindexJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrReindexJobNotFound
+	}
+
+	snapshot := m.snapshot(job)
+	return &snapshot, nil
+}
+
+// List retorna todos os jobs, do mais recente para o mais antigo
+func (m *ReindexManager) List() []ReindexJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]ReindexJob, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, m.snapshot(job))
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.After(jobs[j].StartedAt)
+	})
+	return jobs
+}
+
+// Cancel solicita o cancelamento de um job em andamento
+func (m *ReindexManager) Cancel(id string) (*ReindexJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrReindexJobNotFound
+	}
+
+	if job.Status == ReindexStatusRunning {
+		job.cancel()
+	}
+
+	snapshot := m.snapshot(job)
+	return &snapshot, nil
+}
+
+// snapshot copia o job (com o ETA calculado) para leitura fora do lock
+func (m *ReindexManager) snapshot(job *ReindexJob) ReindexJob {
+	copyJob := *job
+	copyJob.cancel = nil
+	copyJob.Errors = append([]ReindexError(nil), job.Errors...)
+
+	if job.Status == ReindexStatusRunning && job.Processed > 0 && job.Total > job.Processed {
+		rate := float64(job.Processed) / time.Since(job.StartedAt).Seconds()
+		eta := float64(job.Total-job.Processed) / rate
+		copyJob.ETASeconds = &eta
+	}
+
+	return copyJob
+}
+
+// run executa a reindexação: novo índice, varredura por cursor, replay do outbox e troca do alias
+func (m *ReindexManager) run(ctx context.Context, job *ReindexJob) {
+	client := m.indexer.client
+
+	err := m.reindex(ctx, client, job)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	job.FinishedAt = &now
+
+	switch {
+	case err == nil:
+		job.Status = ReindexStatusCompleted
+		log.Printf("✅ [REINDEX] Job %s concluído: %d processados, %d reaplicados do outbox, %d falhas, alias %s -> %s", job.ID, job.Processed, job.Replayed, job.Failed, IndexAlias, job.Index)
+		return
+	case errors.Is(err, context.Canceled):
+		job.Status = ReindexStatusCancelled
+		job.Message = "cancelled by request"
+		log.Printf("⚠️ [REINDEX] Job %s cancelado após %d documentos", job.ID, job.Processed)
+	default:
+		job.Status = ReindexStatusFailed
+		job.Message = err.Error()
+		log.Printf("❌ [REINDEX] Job %s falhou: %v", job.ID, err)
+	}
+
+	// O alias continua no índice anterior; o índice parcial é descartado
+	if _, err := client.DeleteIndex(job.Index).Do(context.Background()); err != nil && !elastic.IsNotFound(err) {
+		log.Printf("⚠️ [REINDEX] Erro ao remover índice parcial %s: %v", job.Index, err)
+	}
+}
+
+func (m *ReindexManager) reindex(ctx context.Context, client *elastic.Client, job *ReindexJob) error {
+	total, err := m.repo.CountPartGroups()
+	if err != nil {
+		return err
+	}
+	m.update(job, func() { job.Total = total })
+
+	if err := createVersionedIndex(ctx, client, job.Index); err != nil {
+		return err
+	}
+
+	// Varredura do catálogo por cursor (id > último id), sem limite de páginas
+	cursor := ""
+	bulk := client.Bulk().Index(job.Index)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		partGroups, err := m.repo.ListPartGroupsAfter(cursor, m.pageSize)
+		if err != nil {
+			return err
+		}
+		if len(partGroups) == 0 {
+			break
+		}
+
+		docs, err := m.indexer.BuildDocuments(partGroups)
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			bulk.Add(elastic.NewBulkIndexRequest().
+				Id(doc.ID).
+				Doc(doc))
+
+			if bulk.NumberOfActions() >= m.bulkActions || bulk.EstimatedSizeInBytes() >= m.bulkBytes {
+				if err := m.flush(ctx, bulk, job, false); err != nil {
+					return err
+				}
+			}
+		}
+
+		cursor = partGroups[len(partGroups)-1].ID.String()
+	}
+
+	if err := m.flush(ctx, bulk, job, false); err != nil {
+		return err
+	}
+
+	// Alterações feitas durante a varredura foram para o índice antigo; reaplicá-las no novo
+	checkpoint, err := m.replayChanges(ctx, client, job, job.StartedAt.Add(-replayOverlap))
+	if err != nil {
+		return err
+	}
+
+	if sent, failed := m.counters(job); sent > 0 && float64(failed)/float64(sent) > m.maxFailureRatio {
+		return fmt.Errorf("too many failed documents (%d of %d), alias not swapped", failed, sent)
+	}
+
+	if _, err := client.Refresh(job.Index).Do(ctx); err != nil {
+		return fmt.Errorf("failed to refresh index %s: %w", job.Index, err)
+	}
+
+	if err := swapAlias(ctx, client, IndexAlias, job.Index); err != nil {
+		return err
+	}
+
+	// Alterações entre o último replay e a troca ainda foram para o índice antigo. Com o alias
+	// já trocado, uma falha aqui não desfaz o job: os grupos ficam para a próxima alteração.
+	if _, err := m.replayChanges(context.Background(), client, job, checkpoint); err != nil {
+		log.Printf("⚠️ [REINDEX] Job %s: erro no replay após a troca do alias: %v", job.ID, err)
+		m.update(job, func() { job.Message = fmt.Sprintf("final replay failed: %v", err) })
+	}
+
+	return nil
+}
+
+// flush envia o bulk acumulado e contabiliza as falhas parciais. Os documentos da varredura
+// contam em Processed (comparável com Total) e os do replay do outbox em Replayed.
+func (m *ReindexManager) flush(ctx context.Context, bulk *elastic.BulkService, job *ReindexJob, replay bool) error {
+	actions := bulk.NumberOfActions()
+	if actions == 0 {
+		return nil
+	}
+
+	resp, err := bulk.Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to bulk index: %w", err)
+	}
+
+	failed := resp.Failed()
+	m.update(job, func() {
+		if replay {
+			job.Replayed += int64(actions)
+		} else {
+			job.Processed += int64(actions)
+		}
+		job.Failed += int64(len(failed))
+		for _, item := range failed {
+			if len(job.Errors) >= maxReindexErrors {
+				break
+			}
+			reason := fmt.Sprintf("status %d", item.Status)
+			if item.Error != nil {
+				reason = fmt.Sprintf("%s: %s", item.Error.Type, item.Error.Reason)
+			}
+			job.Errors = append(job.Errors, ReindexError{ID: item.Id, Reason: reason})
+		}
+	})
+
+	return nil
+}
+
+// replayChanges reindexa no novo índice os grupos alterados desde since e retorna o
+// checkpoint para o próximo replay
+func (m *ReindexManager) replayChanges(ctx context.Context, client *elastic.Client, job *ReindexJob, since time.Time) (time.Time, error) {
+	checkpoint := time.Now().Add(-replayOverlap)
+	if m.indexer.outbox == nil {
+		return checkpoint, nil
+	}
+
+	groupIDs, err := m.indexer.outbox.GroupIDsSince(since)
+	if err != nil {
+		return since, err
+	}
+	if len(groupIDs) == 0 {
+		return checkpoint, nil
+	}
+
+	partGroups, err := m.repo.GetPartGroupsByIDs(groupIDs)
+	if err != nil {
+		return since, err
+	}
+
+	docs, err := m.indexer.BuildDocuments(partGroups)
+	if err != nil {
+		return since, err
+	}
+
+	found := make(map[string]bool, len(docs))
+	bulk := client.Bulk().Index(job.Index)
+	for _, doc := range docs {
+		found[doc.ID] = true
+		bulk.Add(elastic.NewBulkIndexRequest().
+			Id(doc.ID).
+			Doc(doc))
+	}
+	for _, id := range groupIDs {
+		if !found[id] {
+			bulk.Add(elastic.NewBulkDeleteRequest().Id(id))
+		}
+	}
+
+	_, failedBefore := m.counters(job)
+	if err := m.flush(ctx, bulk, job, true); err != nil {
+		return since, fmt.Errorf("failed to replay changes: %w", err)
+	}
+	_, failedAfter := m.counters(job)
+
+	log.Printf("🔄 [REINDEX] Job %s: %d grupos alterados desde %s reaplicados (%d falhas)", job.ID, len(groupIDs), since.Format(time.RFC3339), failedAfter-failedBefore)
+	return checkpoint, nil
+}
+
+// swapAlias move o alias para o novo índice numa única operação e remove os índices antigos
+func swapAlias(ctx context.Context, client *elastic.Client, alias, newIndex string) error {
+	var oldIndices []string
+	aliases, err := client.Aliases().Alias(alias).Do(ctx)
+	if err != nil && !elastic.IsNotFound(err) {
+		return fmt.Errorf("failed to get alias %s: %w", alias, err)
+	}
+	if aliases != nil {
+		oldIndices = aliases.IndicesByAlias(alias)
+	}
+
+	actions := []elastic.AliasAction{elastic.NewAliasAddAction(alias).Index(newIndex)}
+	for _, index := range oldIndices {
+		if index != newIndex {
+			actions = append(actions, elastic.NewAliasRemoveAction(alias).Index(index))
+		}
+	}
+
+	// Instalações antigas têm um índice físico com o nome do alias; ele sai na mesma operação
+	if len(oldIndices) == 0 {
+		exists, err := client.IndexExists(alias).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to check if index %s exists: %w", alias, err)
+		}
+		if exists {
+			actions = append(actions, elastic.NewAliasRemoveIndexAction(alias))
+		}
+	}
+
+	if _, err := client.Alias().Action(actions...).Do(ctx); err != nil {
+		return fmt.Errorf("failed to swap alias %s: %w", alias, err)
+	}
+
+	for _, index := range oldIndices {
+		if index == newIndex {
+			continue
+		}
+		if _, err := client.DeleteIndex(index).Do(context.Background()); err != nil {
+			log.Printf("⚠️ [REINDEX] Erro ao remover índice antigo %s: %v", index, err)
+		}
+	}
+
+	return nil
+}
+
+// counters lê sob o lock os documentos enviados (varredura e replay) e as falhas do job
+func (m *ReindexManager) counters(job *ReindexJob) (sent, failed int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return job.Processed + job.Replayed, job.Failed
+}
+
+// update aplica uma alteração no job sob o lock
+func (m *ReindexManager) update(job *ReindexJob, fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn()
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"log"
+	"time"
 
 	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/partcode"
 
 	"github.com/google/uuid"
 	"github.com/olivere/elastic/v7"
@@ -24,21 +26,55 @@ func NewSearchService() *SearchService {
 	}
 }
 
-// SearchParts busca peças no Elasticsearch
-func (s *SearchService) SearchParts(query string, page, pageSize int) (*models.SearchResponse, error) {
+// NewSearchServiceWithClient cria o serviço de busca com um cliente específico (ex.: servidor stub)
+func NewSearchServiceWithClient(client *elastic.Client) *SearchService {
+	return &SearchService{
+		client: client,
+	}
+}
+
+// IsHealthy verifica se o cluster está apto a responder buscas (status green ou yellow)
+func (s *SearchService) IsHealthy(timeout time.Duration) bool {
+	if s.client == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	health, err := s.client.ClusterHealth().Do(ctx)
+	if err != nil {
+		log.Printf("⚠️ [ES-SEARCH] Falha ao verificar saúde do cluster: %v", err)
+		return false
+	}
+
+	return health.Status == "green" || health.Status == "yellow"
+}
+
+// SearchParts busca peças no Elasticsearch aplicando os facets selecionados
+func (s *SearchService) SearchParts(query string, filters models.SearchFilters, page, pageSize int) (*models.SearchResponse, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("elasticsearch client not initialized")
+	}
+
 	ctx := context.Background()
 
 	// Construir query
 	searchQuery := s.buildSearchQuery(query)
 
-	// Executar busca
-	searchResult, err := s.client.Search().
-		Index("partexplorer").
+	// Executar busca; os facets selecionados entram como post_filter para não afetar as contagens
+	searchRequest := s.client.Search().
+		Index(IndexAlias).
 		Query(searchQuery).
+		PostFilter(buildFacetFilter(filters)).
 		From((page-1)*pageSize).
 		Size(pageSize).
-		Sort("_score", false). // Ordenar por relevância
-		Do(ctx)
+		Sort("_score", false) // Ordenar por relevância
+	for _, name := range models.SearchFacets {
+		searchRequest = searchRequest.Aggregation(name, buildFacetAggregation(name, filters))
+	}
+
+	searchResult, err := searchRequest.Do(ctx)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to search: %w", err)
@@ -55,6 +91,11 @@ func (s *SearchService) SearchParts(query string, page, pageSize int) (*models.S
 		// Converter de volta para PartGroup (simplificado)
 		partGroup := s.convertToPartGroup(doc)
 
+		score := 0.0
+		if hit.Score != nil {
+			score = *hit.Score
+		}
+
 		results[i] = models.SearchResult{
 			ID:           partGroup.ID.String(),
 			PartGroup:    partGroup,
@@ -62,12 +103,21 @@ func (s *SearchService) SearchParts(query string, page, pageSize int) (*models.S
 			Images:       []models.PartImage{},   // Será carregado manualmente
 			Applications: []models.Application{}, // Vazio por enquanto
 			Dimension:    partGroup.Dimension,
-			Score:        *hit.Score,
+			Score:        score,
 		}
 	}
 
 	totalPages := int((searchResult.TotalHits() + int64(pageSize) - 1) / int64(pageSize))
 
+	facets := make(map[string][]models.FacetValue, len(models.SearchFacets))
+	for _, name := range models.SearchFacets {
+		facets[name] = []models.FacetValue{}
+		if filtered, found := searchResult.Aggregations.Filter(name); found {
+			facets[name] = termsFacet(filtered.Aggregations, "values")
+		}
+	}
+	filters.MarkSelected(facets)
+
 	return &models.SearchResponse{
 		Results:    results,
 		Total:      searchResult.TotalHits(),
@@ -75,79 +125,19 @@ func (s *SearchService) SearchParts(query string, page, pageSize int) (*models.S
 		PageSize:   pageSize,
 		TotalPages: totalPages,
 		Query:      query,
+		Facets:     facets,
 	}, nil
 }
 
-// GetSuggestions retorna sugestões para autocomplete
-func (s *SearchService) GetSuggestions(query string, limit int) ([]string, error) {
-	if query == "" {
-		return []string{}, nil
-	}
-
-	// Query para buscar em múltiplos campos
-	searchQuery := elastic.NewBoolQuery().
-		Should(
-			elastic.NewMatchQuery("names", query),
-			elastic.NewMatchQuery("brand", query),
-			elastic.NewMatchQuery("product_type", query),
-		).
-		MinimumShouldMatch("1")
-
-	// Executar busca
-	result, err := s.client.Search().
-		Index("partexplorer").
-		Query(searchQuery).
-		Size(limit).
-		Sort("_score", false).
-		Do(context.Background())
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to search suggestions: %w", err)
-	}
-
-	// Extrair sugestões únicas
-	suggestions := make(map[string]bool)
-	var results []string
-
-	for _, hit := range result.Hits.Hits {
-		var doc PartDocument
-		if err := json.Unmarshal(hit.Source, &doc); err != nil {
-			continue
-		}
-
-		// Adicionar nomes das peças
-		for _, name := range doc.Names {
-			if strings.Contains(strings.ToLower(name), strings.ToLower(query)) {
-				if !suggestions[name] {
-					suggestions[name] = true
-					results = append(results, name)
-				}
-			}
-		}
-
-		// Adicionar marca se contiver a query
-		if doc.Brand != "" && strings.Contains(strings.ToLower(doc.Brand), strings.ToLower(query)) {
-			if !suggestions[doc.Brand] {
-				suggestions[doc.Brand] = true
-				results = append(results, doc.Brand)
-			}
-		}
-
-		// Adicionar tipo de produto se contiver a query
-		if doc.ProductType != "" && strings.Contains(strings.ToLower(doc.ProductType), strings.ToLower(query)) {
-			if !suggestions[doc.ProductType] {
-				suggestions[doc.ProductType] = true
-				results = append(results, doc.ProductType)
-			}
-		}
-
-		// Limitar resultados
-		if len(results) >= limit {
-			break
-		}
-	}
-
-	return results, nil
+// searchTextFields são os campos textuais da busca livre, com seus pesos
+var searchTextFields = []string{
+	"names^3",          // Nomes têm prioridade alta
+	"descriptions^3",   // Descrições idem
+	"brands^2",         // Marcas de todos os part_names
+	"brand^2",          // Marca tem prioridade média-alta
+	"product_type^1.5", // Tipo de produto tem prioridade média
+	"family^1",         // Família tem prioridade normal
+	"subfamily^1",      // Subfamília tem prioridade normal
 }
 
 // buildSearchQuery constrói a query de busca
@@ -156,18 +146,35 @@ func (s *SearchService) buildSearchQuery(query string) elastic.Query {
 		return elastic.NewMatchAllQuery()
 	}
 
-	// Query multi-campo com boost
-	multiMatch := elastic.NewMultiMatchQuery(query).
-		Field("names^3").          // Nomes têm prioridade alta
-		Field("brand^2").          // Marca tem prioridade média-alta
-		Field("product_type^1.5"). // Tipo de produto tem prioridade média
-		Field("family^1").         // Família tem prioridade normal
-		Field("subfamily^1").      // Subfamília tem prioridade normal
+	// Termos como digitados, expandidos pelos sinônimos do analyzer de busca do mapping
+	exact := elastic.NewMultiMatchQuery(query, searchTextFields...).
 		Type("best_fields").
-		Fuzziness("AUTO"). // Busca fuzzy para erros de digitação
+		Operator("OR").
+		Boost(2)
+
+	// Busca fuzzy para erros de digitação; usa o analyzer de indexação porque
+	// fuzziness não se aplica aos tokens gerados pelo synonym_graph
+	fuzzy := elastic.NewMultiMatchQuery(query, searchTextFields...).
+		Type("best_fields").
+		Analyzer("portuguese_analyzer").
+		Fuzziness("AUTO").
+		PrefixLength(1).
 		Operator("OR")
 
-	return multiMatch
+	searchQuery := elastic.NewBoolQuery().
+		Should(exact, fuzzy).
+		MinimumShouldMatch("1")
+
+	// Código exato (SKU ou EAN, comparados pela forma normalizada) tem prioridade máxima
+	if candidates := partcode.Candidates(query); len(candidates) > 0 {
+		codes := make([]interface{}, len(candidates))
+		for i, candidate := range candidates {
+			codes[i] = candidate
+		}
+		searchQuery.Should(elastic.NewTermsQuery("codes", codes...).Boost(8))
+	}
+
+	return searchQuery
 }
 
 // convertToPartGroup converte PartDocument para PartGroup (com IDs preservados)
@@ -176,7 +183,7 @@ func (s *SearchService) convertToPartGroup(doc PartDocument) models.PartGroup {
 	names := make([]models.PartName, len(doc.Names))
 	for i, name := range doc.Names {
 		names[i] = models.PartName{
-			ID:   parseUUID(doc.NameIDs[i]),
+			ID:   parseUUID(idAt(doc.NameIDs, i)),
 			Name: name,
 		}
 	}
@@ -185,7 +192,7 @@ func (s *SearchService) convertToPartGroup(doc PartDocument) models.PartGroup {
 	images := make([]models.PartImage, len(doc.Images))
 	for i, url := range doc.Images {
 		images[i] = models.PartImage{
-			ID:  parseUUID(doc.ImageIDs[i]),
+			ID:  parseUUID(idAt(doc.ImageIDs, i)),
 			URL: url,
 		}
 	}
@@ -223,6 +230,14 @@ func (s *SearchService) convertToPartGroup(doc PartDocument) models.PartGroup {
 	return partGroup
 }
 
+// idAt retorna o ID na posição i ou vazio quando o documento tem menos IDs que valores
+func idAt(ids []string, i int) string {
+	if i < len(ids) {
+		return ids[i]
+	}
+	return ""
+}
+
 // parseUUID converte string para UUID
 func parseUUID(id string) uuid.UUID {
 	if id == "" {
@@ -0,0 +1,320 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/partcode"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// SuggestAlias é o alias do índice de sugestões do autocomplete
+const SuggestAlias = "partexplorer_suggest"
+
+// suggestBulkActions limita a quantidade de sugestões por requisição bulk
+const suggestBulkActions = 1000
+
+// anyStateScope é o estado usado no contexto quando a busca não filtra por estado
+const anyStateScope = "*"
+
+// SuggestionDocument representa uma sugestão no índice de autocomplete
+type SuggestionDocument struct {
+	Text    string               `json:"text"`
+	Type    string               `json:"type"`
+	Weight  int                  `json:"weight"`
+	Suggest SuggestionCompletion `json:"suggest"`
+}
+
+// SuggestionCompletion é o valor do campo completion, com entradas, peso e contextos
+type SuggestionCompletion struct {
+	Input    []string            `json:"input"`
+	Weight   int                 `json:"weight"`
+	Contexts map[string][]string `json:"contexts"`
+}
+
+// Suggest busca sugestões pelo prefixo com o completion suggester, filtrando pelos tipos
+// pedidos e, se informado, pelo estado com estoque disponível. O peso de cada sugestão é a
+// popularidade calculada na reconstrução do índice.
+func (s *SearchService) Suggest(prefix string, types []string, state string, limit int) ([]models.Suggestion, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("elasticsearch client not initialized")
+	}
+
+	if state == "" {
+		state = anyStateScope
+	}
+	scopes := make([]string, len(types))
+	for i, kind := range types {
+		scopes[i] = suggestionScope(kind, state)
+	}
+
+	suggester := elastic.NewCompletionSuggester("autocomplete").
+		Field("suggest").
+		SkipDuplicates(true).
+		Size(limit).
+		ContextQuery(elastic.NewSuggesterCategoryQuery("scope", scopes...))
+
+	// Erros de digitação só são tolerados a partir de 4 caracteres para não poluir prefixos curtos
+	if len([]rune(prefix)) >= 4 {
+		suggester = suggester.PrefixWithOptions(prefix, elastic.NewFuzzyCompletionSuggesterOptions().
+			EditDistance("AUTO").
+			MinLength(4).
+			PrefixLength(2))
+	} else {
+		suggester = suggester.Prefix(prefix)
+	}
+
+	result, err := s.client.Search().
+		Index(SuggestAlias).
+		Suggester(suggester).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Include("text", "type")).
+		Size(0).
+		Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to search suggestions: %w", err)
+	}
+
+	suggestions := []models.Suggestion{}
+	for _, entry := range result.Suggest["autocomplete"] {
+		for _, option := range entry.Options {
+			var doc SuggestionDocument
+			if err := json.Unmarshal(option.Source, &doc); err != nil {
+				continue
+			}
+			suggestions = append(suggestions, models.Suggestion{
+				Text:  doc.Text,
+				Type:  doc.Type,
+				Score: option.ScoreUnderscore,
+			})
+		}
+	}
+
+	return suggestions, nil
+}
+
+// SuggestionIndexer reconstrói periodicamente o índice de sugestões a partir do catálogo e
+// das estatísticas de busca
+type SuggestionIndexer struct {
+	repo     database.SuggestionRepository
+	interval time.Duration
+
+	mu      sync.Mutex
+	running bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewSuggestionIndexer cria o indexador de sugestões. O intervalo vem de SUGGEST_REFRESH_INTERVAL.
+func NewSuggestionIndexer(repo database.SuggestionRepository) *SuggestionIndexer {
+	interval := time.Hour
+	if value, err := time.ParseDuration(os.Getenv("SUGGEST_REFRESH_INTERVAL")); err == nil && value > 0 {
+		interval = value
+	}
+
+	return &SuggestionIndexer{
+		repo:     repo,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start reconstrói o índice imediatamente e depois a cada intervalo
+func (s *SuggestionIndexer) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.Rebuild(); err != nil {
+				log.Printf("⚠️ [SUGGEST] Erro ao reconstruir índice de sugestões: %v", err)
+			}
+
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("✅ [SUGGEST] Indexador de sugestões iniciado (intervalo %s)", s.interval)
+}
+
+// Stop interrompe a reconstrução periódica
+func (s *SuggestionIndexer) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// Rebuild cria um novo índice de sugestões, indexa todos os candidatos e troca o alias.
+// Retorna a quantidade de sugestões indexadas.
+func (s *SuggestionIndexer) Rebuild() (int, error) {
+	client := GetClient()
+	if client == nil {
+		return 0, fmt.Errorf("elasticsearch client not initialized")
+	}
+
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("suggestion rebuild already running")
+	}
+	s.running = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	ctx := context.Background()
+	index := fmt.Sprintf("%s_%s", SuggestAlias, time.Now().Format("20060102150405"))
+	created, err := client.CreateIndex(index).BodyString(getSuggestMapping()).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create index %s: %w", index, err)
+	}
+	if !created.Acknowledged {
+		return 0, fmt.Errorf("failed to acknowledge index creation for %s", index)
+	}
+
+	total := 0
+	bulk := client.Bulk().Index(index)
+	flush := func() error {
+		if bulk.NumberOfActions() == 0 {
+			return nil
+		}
+		resp, err := bulk.Do(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to bulk index suggestions: %w", err)
+		}
+		if failed := resp.Failed(); len(failed) > 0 {
+			log.Printf("⚠️ [SUGGEST] %d sugestões falharam ao indexar", len(failed))
+		}
+		return nil
+	}
+
+	err = s.repo.EachCandidate(func(candidate models.SuggestionCandidate) error {
+		bulk.Add(elastic.NewBulkIndexRequest().Doc(NewSuggestionDocument(candidate)))
+		total++
+		if bulk.NumberOfActions() >= suggestBulkActions {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err == nil {
+		_, err = client.Refresh(index).Do(ctx)
+	}
+	if err == nil {
+		err = swapAlias(ctx, client, SuggestAlias, index)
+	}
+	if err != nil {
+		if _, deleteErr := client.DeleteIndex(index).Do(context.Background()); deleteErr != nil {
+			log.Printf("⚠️ [SUGGEST] Erro ao remover índice %s: %v", index, deleteErr)
+		}
+		return 0, err
+	}
+
+	log.Printf("✅ [SUGGEST] Índice de sugestões reconstruído: %s (%d sugestões)", index, total)
+	return total, nil
+}
+
+// NewSuggestionDocument converte um candidato do catálogo em documento de sugestão
+func NewSuggestionDocument(candidate models.SuggestionCandidate) SuggestionDocument {
+	inputs := []string{candidate.Text}
+
+	// Cada palavra também inicia a sugestão: "gol" encontra "VOLKSWAGEN GOL"
+	words := strings.Fields(candidate.Text)
+	for i := 1; i < len(words); i++ {
+		inputs = append(inputs, strings.Join(words[i:], " "))
+	}
+
+	// SKUs também casam pelo código normalizado: "kl12" encontra "KL-1234"
+	if candidate.Type == models.SuggestionSKU {
+		if code := partcode.Normalize(candidate.Text); code != "" && !strings.EqualFold(code, candidate.Text) {
+			inputs = append(inputs, code)
+		}
+	}
+
+	// Um único contexto combina tipo e estado, pois os valores de um contexto são alternativos
+	scopes := []string{suggestionScope(candidate.Type, anyStateScope)}
+	for _, state := range candidate.States {
+		scopes = append(scopes, suggestionScope(candidate.Type, state))
+	}
+
+	weight := candidate.Weight()
+	return SuggestionDocument{
+		Text:   candidate.Text,
+		Type:   candidate.Type,
+		Weight: weight,
+		Suggest: SuggestionCompletion{
+			Input:  inputs,
+			Weight: weight,
+			Contexts: map[string][]string{
+				"scope": scopes,
+			},
+		},
+	}
+}
+
+// suggestionScope monta o valor do contexto "scope": tipo da sugestão e estado (ou "*")
+func suggestionScope(kind, state string) string {
+	return kind + "|" + strings.ToUpper(state)
+}
+
+// getSuggestMapping retorna o mapping do índice de sugestões. O texto inteiro é um único
+// token (minúsculo, sem acento), então "kl-12" continua prefixo de "KL-1234".
+func getSuggestMapping() string {
+	return `{
+		"settings": {
+			"number_of_shards": 1,
+			"number_of_replicas": 0,
+			"analysis": {
+				"analyzer": {
+					"suggest_analyzer": {
+						"type": "custom",
+						"tokenizer": "keyword",
+						"filter": ["lowercase", "asciifolding"]
+					}
+				}
+			}
+		},
+		"mappings": {
+			"properties": {
+				"text": {
+					"type": "keyword"
+				},
+				"type": {
+					"type": "keyword"
+				},
+				"weight": {
+					"type": "integer"
+				},
+				"suggest": {
+					"type": "completion",
+					"analyzer": "suggest_analyzer",
+					"contexts": [
+						{
+							"name": "scope",
+							"type": "category"
+						}
+					]
+				}
+			}
+		}
+	}`
+}
@@ -0,0 +1,128 @@
+package fipe
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ZeroKm é o ano-modelo usado pela tabela FIPE para veículos zero quilômetro
+const ZeroKm = 32000
+
+var (
+	// ErrInvalidCode indica que o texto não é um código FIPE (seis dígitos e o verificador)
+	ErrInvalidCode = errors.New("invalid fipe code: expected 000000-0")
+	// ErrInvalidPrice indica que o texto não é um preço ("R$ 35.000,00", "35000.00")
+	ErrInvalidPrice = errors.New("invalid fipe price")
+	// ErrInvalidMonth indica que o texto não é um mês de referência ("janeiro de 2025", "2025-01")
+	ErrInvalidMonth = errors.New("invalid fipe reference month")
+)
+
+// months são os nomes dos meses usados no mês de referência das tabelas oficiais
+var months = map[string]time.Month{
+	"janeiro": time.January, "fevereiro": time.February, "marco": time.March, "março": time.March,
+	"abril": time.April, "maio": time.May, "junho": time.June, "julho": time.July,
+	"agosto": time.August, "setembro": time.September, "outubro": time.October,
+	"novembro": time.November, "dezembro": time.December,
+}
+
+// Record é uma linha da tabela FIPE: o preço de um código e ano-modelo em um mês de referência
+type Record struct {
+	Code           string
+	Brand          string
+	Model          string
+	ModelYear      int
+	Fuel           string
+	Price          float64
+	ReferenceMonth time.Time
+}
+
+// NormalizeCode coloca o código FIPE na forma canônica "000000-0". Aceita o código sem hífen
+// e com zeros à esquerda omitidos ("5340-6", "53406").
+func NormalizeCode(code string) (string, error) {
+	var digits strings.Builder
+	for _, r := range code {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '-' || r == ' ' || r == '.':
+		default:
+			return "", ErrInvalidCode
+		}
+	}
+	value := digits.String()
+	if value == "" || len(value) > 7 {
+		return "", ErrInvalidCode
+	}
+	value = strings.Repeat("0", 7-len(value)) + value
+	return value[:6] + "-" + value[6:], nil
+}
+
+// ParsePrice interpreta o preço no formato brasileiro ("R$ 35.000,00", "35.000") ou decimal
+// ("35000.00")
+func ParsePrice(value string) (float64, error) {
+	value = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "R$"))
+	value = strings.ReplaceAll(value, " ", "")
+	if value == "" {
+		return 0, ErrInvalidPrice
+	}
+
+	switch {
+	case strings.Contains(value, ","):
+		// Vírgula decimal: os pontos são separadores de milhar
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.Replace(value, ",", ".", 1)
+	case strings.Count(value, ".") > 1 || (strings.Contains(value, ".") && len(value)-strings.LastIndex(value, ".") == 4):
+		// Só separadores de milhar ("35.000")
+		value = strings.ReplaceAll(value, ".", "")
+	}
+
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		return 0, ErrInvalidPrice
+	}
+	return price, nil
+}
+
+// ParseReferenceMonth interpreta o mês de referência ("janeiro de 2025", "janeiro/2025",
+// "2025-01", "01/2025", "2025-01-01") e retorna o primeiro dia do mês em UTC
+func ParseReferenceMonth(value string) (time.Time, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == '/' || r == '-' })
+	if len(fields) == 3 && fields[1] == "de" {
+		fields = []string{fields[0], fields[2]}
+	}
+
+	var month time.Month
+	var year int
+	switch {
+	case len(fields) == 2 && months[fields[0]] != 0:
+		month = months[fields[0]]
+		year, _ = strconv.Atoi(fields[1])
+	case len(fields) == 2 && len(fields[0]) == 4:
+		year, _ = strconv.Atoi(fields[0])
+		m, _ := strconv.Atoi(fields[1])
+		month = time.Month(m)
+	case len(fields) == 2:
+		m, _ := strconv.Atoi(fields[0])
+		month = time.Month(m)
+		year, _ = strconv.Atoi(fields[1])
+	case len(fields) == 3 && len(fields[0]) == 4:
+		if parsed, err := time.Parse("2006-01-02", value); err == nil {
+			month, year = parsed.Month(), parsed.Year()
+		}
+	}
+
+	if year < 1900 || month < time.January || month > time.December {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidMonth, value)
+	}
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+// MonthStart retorna o primeiro dia do mês de t em UTC, a chave do histórico de preços
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,270 @@
+package fipe
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatos de arquivo das tabelas oficiais
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Campos de uma linha da tabela
+const (
+	fieldCode      = "code"
+	fieldBrand     = "brand"
+	fieldModel     = "model"
+	fieldModelYear = "model_year"
+	fieldFuel      = "fuel"
+	fieldPrice     = "price"
+	fieldMonth     = "reference_month"
+)
+
+// columns associa os nomes de coluna das tabelas (sem acentos e separadores) aos campos
+var columns = map[string]string{
+	"codigofipe": fieldCode, "fipecode": fieldCode, "codigo": fieldCode, "code": fieldCode, "fipe": fieldCode,
+	"marca": fieldBrand, "brand": fieldBrand,
+	"modelo": fieldModel, "model": fieldModel,
+	"anomodelo": fieldModelYear, "modelyear": fieldModelYear, "ano": fieldModelYear, "year": fieldModelYear,
+	"combustivel": fieldFuel, "fuel": fieldFuel,
+	"valor": fieldPrice, "preco": fieldPrice, "price": fieldPrice,
+	"mesreferencia": fieldMonth, "referencemonth": fieldMonth, "referencia": fieldMonth, "mes": fieldMonth, "month": fieldMonth,
+}
+
+// accents tira os acentos dos nomes de coluna
+var accents = strings.NewReplacer("á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ç", "c")
+
+// ErrUnknownFormat indica um formato de arquivo diferente de CSV e JSON
+var ErrUnknownFormat = errors.New("unknown fipe file format: expected csv or json")
+
+// RowError é uma linha da tabela que não pôde ser importada
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// Result são as linhas lidas de uma tabela e as que foram rejeitadas
+type Result struct {
+	Records []Record
+	Errors  []RowError
+}
+
+// FormatFromPath deduz o formato pela extensão do arquivo (.csv, .json, .jsonl)
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".txt":
+		return FormatCSV
+	case ".json", ".jsonl":
+		return FormatJSON
+	default:
+		return ""
+	}
+}
+
+// Read lê uma tabela FIPE em CSV (separada por vírgula ou ponto e vírgula, com cabeçalho) ou
+// JSON (lista de objetos ou um objeto por linha, como os da API da FIPE). month é o mês de
+// referência das linhas sem a coluna de mês; com month zero essas linhas são rejeitadas.
+func Read(r io.Reader, format string, month time.Time) (*Result, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r, month)
+	case FormatJSON:
+		return readJSON(r, month)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+func readCSV(r io.Reader, month time.Time) (*Result, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(buffered.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("failed to read fipe csv: %w", err)
+	}
+	if index := strings.IndexByte(string(header), '\n'); index >= 0 {
+		header = header[:index]
+	}
+
+	reader := csv.NewReader(buffered)
+	if strings.Count(string(header), ";") > strings.Count(string(header), ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	names, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fipe csv header: %w", err)
+	}
+	fields := make([]string, len(names))
+	for i, name := range names {
+		fields[i] = columns[columnKey(name)]
+	}
+
+	result := &Result{}
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fipe csv: %w", err)
+		}
+
+		row := make(map[string]string, len(fields))
+		for i, value := range values {
+			if i < len(fields) && fields[i] != "" {
+				row[fields[i]] = value
+			}
+		}
+		result.add(line, row, month)
+	}
+	return result, nil
+}
+
+func readJSON(r io.Reader, month time.Time) (*Result, error) {
+	// Lista de objetos ou um objeto por linha
+	buffered := bufio.NewReader(r)
+	first, err := peekNonSpace(buffered)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return &Result{}, nil
+		}
+		return nil, fmt.Errorf("failed to read fipe json: %w", err)
+	}
+	decoder := json.NewDecoder(buffered)
+	decoder.UseNumber()
+	if first == '[' {
+		if _, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("failed to read fipe json: %w", err)
+		}
+	}
+
+	result := &Result{}
+	for line := 1; decoder.More(); line++ {
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			return nil, fmt.Errorf("failed to read fipe json item %d: %w", line, err)
+		}
+
+		row := make(map[string]string, len(object))
+		for name, value := range object {
+			if field := columns[columnKey(name)]; field != "" && value != nil {
+				row[field] = strings.TrimSpace(fmt.Sprint(value))
+			}
+		}
+		result.add(line, row, month)
+	}
+	return result, nil
+}
+
+// add converte a linha e a guarda, ou registra o motivo da rejeição
+func (res *Result) add(line int, row map[string]string, month time.Time) {
+	record, err := parseRow(row, month)
+	if err != nil {
+		res.Errors = append(res.Errors, RowError{Line: line, Err: err})
+		return
+	}
+	res.Records = append(res.Records, record)
+}
+
+func parseRow(row map[string]string, month time.Time) (Record, error) {
+	code, err := NormalizeCode(row[fieldCode])
+	if err != nil {
+		return Record{}, err
+	}
+
+	modelYear, err := parseModelYear(row[fieldModelYear])
+	if err != nil {
+		return Record{}, err
+	}
+
+	price, err := ParsePrice(row[fieldPrice])
+	if err != nil {
+		return Record{}, fmt.Errorf("%w: %q", err, row[fieldPrice])
+	}
+
+	reference := month
+	if value := row[fieldMonth]; value != "" {
+		if reference, err = ParseReferenceMonth(value); err != nil {
+			return Record{}, err
+		}
+	}
+	if reference.IsZero() {
+		return Record{}, fmt.Errorf("%w: missing", ErrInvalidMonth)
+	}
+
+	return Record{
+		Code:           code,
+		Brand:          strings.TrimSpace(row[fieldBrand]),
+		Model:          strings.TrimSpace(row[fieldModel]),
+		ModelYear:      modelYear,
+		Fuel:           strings.TrimSpace(row[fieldFuel]),
+		Price:          price,
+		ReferenceMonth: MonthStart(reference),
+	}, nil
+}
+
+// parseModelYear aceita o ano sozinho ou seguido do combustível ("2015 Gasolina"); "Zero KM"
+// e 32000 são o ano-modelo dos veículos novos
+func parseModelYear(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "zero km") {
+		return ZeroKm, nil
+	}
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == '-' })
+	if len(fields) == 0 {
+		return 0, errors.New("invalid fipe model year: missing")
+	}
+	year, err := strconv.Atoi(fields[0])
+	if err != nil || (year < 1900 && year != ZeroKm) || (year > 2100 && year != ZeroKm) {
+		return 0, fmt.Errorf("invalid fipe model year: %q", value)
+	}
+	return year, nil
+}
+
+// columnKey tira acentos, separadores e maiúsculas do nome da coluna ("Código Fipe" -> "codigofipe")
+func columnKey(name string) string {
+	name = accents.Replace(strings.ToLower(strings.TrimPrefix(name, "\ufeff")))
+
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\n' && b != '\r' && b != '\t' {
+			return b, r.UnreadByte()
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/database"
+)
+
+// CarErrorHandler gerencia a revisão das consultas de placa que falharam (car_error)
+type CarErrorHandler struct {
+	errors  database.CarErrorRepository
+	carRepo database.CarRepository
+}
+
+// NewCarErrorHandler cria uma nova instância do handler
+func NewCarErrorHandler(errorRepo database.CarErrorRepository, carRepo database.CarRepository) *CarErrorHandler {
+	return &CarErrorHandler{errors: errorRepo, carRepo: carRepo}
+}
+
+// ListErrors lista as entradas da car_error, filtrando por status (pending, exhausted,
+// resolved, discarded) e motivo (parse_failure, provider_blocked, provider_error,
+// db_constraint, db_error)
+func (h *CarErrorHandler) ListErrors(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.errors.List(c.Query("status"), c.Query("reason"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list car errors", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSummary retorna as contagens da car_error por status e motivo
+func (h *CarErrorHandler) GetSummary(c *gin.Context) {
+	counts, err := h.errors.Counts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count car errors", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"counts": counts})
+}
+
+// GetError retorna a entrada da placa, com os dados guardados
+func (h *CarErrorHandler) GetError(c *gin.Context) {
+	entry, err := h.errors.Get(c.Param("plate"))
+	if err != nil {
+		respondCarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// RetryError refaz a consulta da placa agora, inclusive das entradas que esgotaram as
+// tentativas automáticas
+func (h *CarErrorHandler) RetryError(c *gin.Context) {
+	entry, err := h.carRepo.RetryCarError(c.Param("plate"))
+	if err != nil {
+		respondCarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// DiscardError tira a entrada da fila de novas tentativas
+func (h *CarErrorHandler) DiscardError(c *gin.Context) {
+	entry, err := h.errors.Discard(c.Param("plate"))
+	if err != nil {
+		respondCarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// respondCarError converte os erros do repositório no status HTTP correspondente
+func respondCarError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, database.ErrCarErrorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Car error not found"})
+	case errors.Is(err, database.ErrCarErrorClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process car error", "details": err.Error()})
+	}
+}
@@ -1,26 +1,64 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
-	"strings"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/plate"
+	"partexplorer/backend/internal/vehicledata"
+	"partexplorer/backend/internal/vin"
 )
 
+// defaultLookupWait é quanto uma requisição síncrona aguarda a consulta da placa quando nem
+// o parâmetro wait nem PLATE_LOOKUP_WAIT estão definidos
+const defaultLookupWait = 25 * time.Second
+
+// invalidPlateMessage é a resposta para placas fora dos padrões antigo e Mercosul
+const invalidPlateMessage = "Placa inválida: use o padrão antigo (ABC1234) ou Mercosul (ABC1D23)"
+
+// invalidVINMessage é a resposta para chassis que não têm 17 caracteres válidos
+const invalidVINMessage = "Chassi inválido: o VIN tem 17 letras e números, sem I, O ou Q"
+
 // CarHandler gerencia as requisições relacionadas aos carros
 type CarHandler struct {
 	carRepo database.CarRepository
+	lookups *vehicledata.LookupManager
 }
 
 // NewCarHandler cria uma nova instância do handler
-func NewCarHandler(carRepo database.CarRepository) *CarHandler {
+func NewCarHandler(carRepo database.CarRepository, lookups *vehicledata.LookupManager) *CarHandler {
 	return &CarHandler{
 		carRepo: carRepo,
+		lookups: lookups,
+	}
+}
+
+// lookupWait retorna quanto aguardar o job de consulta: parâmetro wait (ex. "10s"), depois
+// PLATE_LOOKUP_WAIT, depois 25s
+func lookupWait(c *gin.Context) time.Duration {
+	if value, err := time.ParseDuration(c.Query("wait")); err == nil && value >= 0 {
+		return value
+	}
+	if value, err := time.ParseDuration(os.Getenv("PLATE_LOOKUP_WAIT")); err == nil && value > 0 {
+		return value
+	}
+	return defaultLookupWait
+}
+
+// lookupLinks retorna as URLs para acompanhar um job de consulta
+func lookupLinks(job *vehicledata.LookupJob) gin.H {
+	return gin.H{
+		"status": "/api/v1/cars/lookup/" + job.ID,
+		"events": "/api/v1/cars/lookup/" + job.ID + "/events",
 	}
 }
 
@@ -41,59 +79,75 @@ func (h *CarHandler) SearchCarByPlate(c *gin.Context) {
 		}
 	}()
 
-	plate := c.Param("plate")
+	plateNumber := c.Param("plate")
 
 	// Log de início da requisição
-	log.Printf("🚗 [CAR-SERVICE] Iniciando busca para placa: %s", plate)
+	log.Printf("🚗 [CAR-SERVICE] Iniciando busca para placa: %s", plateNumber)
 	log.Printf("🚗 [CAR-SERVICE] User-Agent: %s", c.GetHeader("User-Agent"))
 	log.Printf("🚗 [CAR-SERVICE] Remote IP: %s", c.ClientIP())
 
-	if plate == "" {
+	if plateNumber == "" {
 		log.Printf("❌ [CAR-SERVICE] Placa não informada")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Placa é obrigatória"})
 		return
 	}
 
-	// Normalizar placa
-	originalPlate := plate
-	plate = strings.ToUpper(strings.ReplaceAll(strings.ReplaceAll(plate, "-", ""), " ", ""))
-	log.Printf("🚗 [CAR-SERVICE] Placa original: %s, Normalizada: %s", originalPlate, plate)
-
-	// Validar formato da placa (7 caracteres)
-	if len(plate) != 7 {
-		log.Printf("❌ [CAR-SERVICE] Placa inválida: %s (tamanho: %d)", plate, len(plate))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Placa deve ter 7 caracteres"})
+	// Validar e normalizar a placa (padrão antigo ou Mercosul)
+	originalPlate := plateNumber
+	parsed, err := plate.Parse(plateNumber)
+	if err != nil {
+		log.Printf("❌ [CAR-SERVICE] Placa inválida: %s", originalPlate)
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidPlateMessage})
 		return
 	}
+	plateNumber = parsed.Canonical
+	log.Printf("🚗 [CAR-SERVICE] Placa original: %s, Normalizada: %s (%s)", originalPlate, plateNumber, parsed.Format)
 
 	log.Printf("✅ [CAR-SERVICE] Placa válida, iniciando busca no repositório...")
 
-	// Buscar informações do carro
+	// Buscar informações do carro num job em background, compartilhado com pedidos
+	// simultâneos da mesma placa
 	startTime := time.Now()
-	carInfo, err := h.carRepo.SearchCarByPlate(plate)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), lookupWait(c))
+	defer cancel()
+	job, err := h.lookups.Lookup(ctx, plateNumber)
 	duration := time.Since(startTime)
 
 	log.Printf("⏱️ [CAR-SERVICE] Tempo de busca: %v", duration)
 
 	if err != nil {
-		log.Printf("❌ [CAR-SERVICE] Erro na busca: %v", err)
+		log.Printf("⏳ [CAR-SERVICE] Consulta da placa %s ainda em andamento (job %s)", plateNumber, job.ID)
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": false,
+			"message": "Consulta em andamento, acompanhe pelo job",
+			"job":     job,
+			"links":   lookupLinks(job),
+		})
+		return
+	}
+
+	if job.Status == vehicledata.LookupStatusFailed {
+		log.Printf("❌ [CAR-SERVICE] Erro na busca: %s", job.Message)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Erro ao buscar informações do veículo",
-			"details": err.Error(),
+			"details": job.Message,
 			"debug": gin.H{
-				"plate":       plate,
+				"plate":       plateNumber,
+				"job_id":      job.ID,
 				"duration_ms": duration.Milliseconds(),
 			},
 		})
 		return
 	}
 
+	carInfo := job.CarInfo
 	if carInfo == nil {
-		log.Printf("❌ [CAR-SERVICE] Veículo não encontrado para placa: %s", plate)
+		log.Printf("❌ [CAR-SERVICE] Veículo não encontrado para placa: %s", plateNumber)
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "Veículo não encontrado",
 			"debug": gin.H{
-				"plate":       plate,
+				"plate":       plateNumber,
+				"job_id":      job.ID,
 				"duration_ms": duration.Milliseconds(),
 			},
 		})
@@ -125,33 +179,123 @@ func (h *CarHandler) SearchCarByPlate(c *gin.Context) {
 			"valor_fipe":       carInfo.ValorFipe,
 			"data_consulta":    carInfo.DataConsulta,
 			"confiabilidade":   carInfo.Confiabilidade,
+			"provedor":         carInfo.Provedor,
+			"freshness":        carInfo.Freshness,
 			"has_minimal_info": hasMinimalInfo,
 		},
 		"message": "Informações do veículo obtidas com sucesso",
 		"debug": gin.H{
-			"plate":       plate,
-			"duration_ms": duration.Milliseconds(),
-			"timestamp":   time.Now().Format(time.RFC3339),
+			"plate":           plateNumber,
+			"plate_format":    parsed.Format,
+			"alternate_plate": parsed.Alternate(),
+			"job_id":          job.ID,
+			"duration_ms":     duration.Milliseconds(),
+			"timestamp":       time.Now().Format(time.RFC3339),
 		},
 	}
 
-	log.Printf("🎉 [CAR-SERVICE] Resposta enviada com sucesso para placa: %s", plate)
+	log.Printf("🎉 [CAR-SERVICE] Resposta enviada com sucesso para placa: %s", plateNumber)
 	c.JSON(http.StatusOK, response)
 }
 
+// StartLookup agenda a consulta de uma placa em background e retorna o job
+func (h *CarHandler) StartLookup(c *gin.Context) {
+	var req struct {
+		Plate string `json:"plate"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido", "details": err.Error()})
+		return
+	}
+	if req.Plate == "" {
+		req.Plate = c.Query("plate")
+	}
+
+	parsed, err := plate.Parse(req.Plate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidPlateMessage})
+		return
+	}
+	plateNumber := parsed.Canonical
+
+	job, coalesced := h.lookups.Submit(plateNumber)
+	log.Printf("🚗 [CAR-SERVICE] Consulta da placa %s agendada (job %s, compartilhado: %t)", plateNumber, job.ID, coalesced)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success":   true,
+		"job":       job,
+		"coalesced": coalesced,
+		"links":     lookupLinks(job),
+	})
+}
+
+// GetLookup retorna o estado de um job de consulta de placa
+func (h *CarHandler) GetLookup(c *gin.Context) {
+	job, err := h.lookups.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job de consulta não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"job":     job,
+	})
+}
+
+// StreamLookup envia o andamento de um job de consulta por Server-Sent Events: um evento
+// "status" a cada mudança e um evento "done" com o resultado final
+func (h *CarHandler) StreamLookup(c *gin.Context) {
+	id := c.Param("id")
+	job, err := h.lookups.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job de consulta não encontrado"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	lastStatus := ""
+	lastPing := time.Now()
+	for {
+		if job.Finished() {
+			c.SSEvent("done", job)
+			c.Writer.Flush()
+			return
+		}
+		if job.Status != lastStatus {
+			lastStatus = job.Status
+			c.SSEvent("status", job)
+		} else if time.Since(lastPing) >= 15*time.Second {
+			lastPing = time.Now()
+			c.SSEvent("ping", time.Now().Format(time.RFC3339))
+		}
+		c.Writer.Flush()
+
+		// Aguardar o fim do job em intervalos curtos para notar mudanças de status
+		ctx, cancel := context.WithTimeout(c.Request.Context(), time.Second)
+		job, err = h.lookups.Wait(ctx, id)
+		cancel()
+		if errors.Is(err, vehicledata.ErrLookupJobNotFound) || c.Request.Context().Err() != nil {
+			return
+		}
+	}
+}
+
 // GetCarByPlate busca um carro específico pela placa (apenas cache)
 func (h *CarHandler) GetCarByPlate(c *gin.Context) {
-	plate := c.Param("plate")
-	if plate == "" {
+	plateNumber := c.Param("plate")
+	if plateNumber == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Placa é obrigatória"})
 		return
 	}
 
-	// Normalizar placa
-	plate = strings.ToUpper(strings.ReplaceAll(strings.ReplaceAll(plate, "-", ""), " ", ""))
+	// Normalizar placa; o cache é consultado nas duas formas (antiga e Mercosul)
+	plateNumber = plate.Normalize(plateNumber)
 
 	// Buscar no cache
-	car, err := h.carRepo.GetCarByPlate(plate)
+	car, err := h.carRepo.GetCarByPlate(plateNumber)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Veículo não encontrado no cache"})
 		return
@@ -181,6 +325,44 @@ func (h *CarHandler) GetCarByPlate(c *gin.Context) {
 	})
 }
 
+// DecodeVIN decodifica o chassi (VIN) localmente, sem consultas externas, e informa o veículo
+// do cache com o mesmo chassi, quando houver
+func (h *CarHandler) DecodeVIN(c *gin.Context) {
+	info, err := vin.Decode(c.Param("vin"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": invalidVINMessage,
+		})
+		return
+	}
+
+	response := gin.H{
+		"success": true,
+		"data":    info,
+		"message": "Chassi decodificado",
+	}
+	if info.Manufacturer == "" {
+		response["message"] = "Chassi válido, mas o fabricante (WMI " + info.WMI + ") não é conhecido"
+	}
+	if !info.CheckDigitValid {
+		log.Printf("⚠️ [CAR-SERVICE] Chassi %s com dígito verificador %s (esperado %c)", info.VIN, info.CheckDigit, vin.CheckDigit(info.VIN))
+	}
+
+	// O chassi fica gravado junto da consulta de placa; com ele temos modelo e placa
+	if car, err := h.carRepo.GetCarByChassis(info.VIN); err == nil {
+		response["car"] = gin.H{
+			"license_plate": car.LicensePlate,
+			"brand":         car.Brand,
+			"model":         car.Model,
+			"year":          car.Year,
+			"model_year":    car.ModelYear,
+			"fuel_type":     car.FuelType,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // HealthCheck verifica se o serviço está funcionando
 func (h *CarHandler) HealthCheck(c *gin.Context) {
 	// Verificar se o repositório está disponível
@@ -194,10 +376,27 @@ func (h *CarHandler) HealthCheck(c *gin.Context) {
 		return
 	}
 
+	// Saúde dos provedores de placa: com todos os circuitos abertos, nenhuma placa nova é
+	// consultada até algum deles fechar
+	providers := vehicledata.Default().Status()
+	status := "ok"
+	message := "Serviço de consulta de veículos está funcionando"
+	open := 0
+	for _, provider := range providers {
+		if provider.State == vehicledata.CircuitOpen {
+			open++
+		}
+	}
+	if open > 0 && open == len(providers) {
+		status = "degraded"
+		message = "Todos os provedores de placa estão com o circuito aberto; apenas o cache responde"
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status":    "ok",
+		"status":    status,
 		"service":   "car-service",
-		"message":   "Serviço de consulta de veículos está funcionando",
+		"message":   message,
+		"providers": providers,
 		"timestamp": time.Now().Format(time.RFC3339),
 	})
 }
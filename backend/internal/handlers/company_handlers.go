@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -8,6 +10,7 @@ import (
 
 	"partexplorer/backend/internal/database"
 	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/nfe"
 )
 
 // CompanyHandler gerencia as requisições relacionadas às empresas
@@ -37,6 +40,8 @@ type CreateCompanyRequest struct {
 	Mobile       *string `json:"mobile,omitempty"`
 	Email        *string `json:"email,omitempty"`
 	Website      *string `json:"website,omitempty"`
+	// CNPJ identifica a empresa nas NF-e importadas; aceita com ou sem pontuação
+	CNPJ *string `json:"cnpj,omitempty"`
 }
 
 // UpdateCompanyRequest representa a requisição para atualizar empresa
@@ -54,6 +59,7 @@ type UpdateCompanyRequest struct {
 	Mobile       *string `json:"mobile,omitempty"`
 	Email        *string `json:"email,omitempty"`
 	Website      *string `json:"website,omitempty"`
+	CNPJ         *string `json:"cnpj,omitempty"`
 }
 
 // CreateCompany cria uma nova empresa
@@ -64,6 +70,12 @@ func (h *CompanyHandler) CreateCompany(c *gin.Context) {
 		return
 	}
 
+	cnpj, err := companyCNPJ(req.CNPJ)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	company := &models.Company{
 		Name:         req.Name,
 		ImageURL:     req.ImageURL,
@@ -78,6 +90,7 @@ func (h *CompanyHandler) CreateCompany(c *gin.Context) {
 		Mobile:       req.Mobile,
 		Email:        req.Email,
 		Website:      req.Website,
+		CNPJ:         cnpj,
 	}
 
 	if err := h.companyRepo.CreateCompany(company); err != nil {
@@ -124,14 +137,18 @@ func (h *CompanyHandler) GetCompanyByID(c *gin.Context) {
 		"mobile":       company.Mobile,
 		"email":        company.Email,
 		"website":      company.Website,
+		"cnpj":         company.CNPJ,
+		"version":      company.Version,
 		"created_at":   company.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		"updated_at":   company.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 
+	setETag(c, company.Version)
 	c.JSON(http.StatusOK, gin.H{"company": response})
 }
 
-// UpdateCompany atualiza uma empresa
+// UpdateCompany atualiza uma empresa. Exige If-Match (428 sem ele) e só grava se a empresa
+// ainda estiver na versão do ETag; senão responde 409 com o estado atual.
 func (h *CompanyHandler) UpdateCompany(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -139,6 +156,12 @@ func (h *CompanyHandler) UpdateCompany(c *gin.Context) {
 		return
 	}
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		respondIfMatchError(c, err)
+		return
+	}
+
 	var req UpdateCompanyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
@@ -185,13 +208,30 @@ func (h *CompanyHandler) UpdateCompany(c *gin.Context) {
 	if req.Website != nil {
 		updates["website"] = *req.Website
 	}
+	if req.CNPJ != nil {
+		cnpj, err := companyCNPJ(req.CNPJ)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["cnpj"] = cnpj
+	}
 
-	if err := h.companyRepo.UpdateCompany(id, updates); err != nil {
+	company, err := h.companyRepo.UpdateCompany(id, version, updates)
+	switch {
+	case errors.Is(err, database.ErrVersionConflict) && company != nil:
+		respondVersionConflict(c, company, company.Version)
+		return
+	case errors.Is(err, database.ErrCompanyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update company", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Company updated successfully"})
+	setETag(c, company.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Company updated successfully", "version": company.Version})
 }
 
 // DeleteCompany remove uma empresa
@@ -238,3 +278,19 @@ func (h *CompanyHandler) SearchCompanies(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// companyCNPJ normaliza o CNPJ informado para apenas dígitos e confere os dígitos
+// verificadores; vazio remove o CNPJ
+func companyCNPJ(value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	cnpj := nfe.NormalizeDocument(*value)
+	if cnpj == "" {
+		return nil, nil
+	}
+	if !nfe.ValidCNPJ(cnpj) {
+		return nil, fmt.Errorf("invalid cnpj %q", *value)
+	}
+	return &cnpj, nil
+}
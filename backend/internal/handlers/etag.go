@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag grava o cabeçalho ETag com a versão do registro
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// errIfMatchRequired indica uma atualização sem a versão no If-Match; só o incremento e o
+// decremento atômicos dispensam a conferência
+var errIfMatchRequired = errors.New("If-Match header with the resource ETag is required")
+
+// ifMatchVersion lê a versão esperada do cabeçalho If-Match ("3" ou W/"3"). Sem o cabeçalho,
+// ou com "*", retorna errIfMatchRequired.
+func ifMatchVersion(c *gin.Context) (int, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, errIfMatchRequired
+	}
+	tag := strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid If-Match header %q", value)
+	}
+	return version, nil
+}
+
+// respondIfMatchError responde 428 sem a versão no If-Match e 400 com um If-Match inválido
+func respondIfMatchError(c *gin.Context, err error) {
+	if errors.Is(err, errIfMatchRequired) {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// respondVersionConflict responde 409 com o estado atual do registro e o ETag dele, para o
+// cliente refazer a alteração sobre a versão nova
+func respondVersionConflict(c *gin.Context, current interface{}, version int) {
+	setETag(c, version)
+	c.JSON(http.StatusConflict, gin.H{
+		"error":   "The resource was modified by another request",
+		"version": version,
+		"current": current,
+	})
+}
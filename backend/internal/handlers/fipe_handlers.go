@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/fipe"
+)
+
+// FipeHandler gerencia as consultas à tabela FIPE e ao histórico de preços
+type FipeHandler struct {
+	fipeRepo database.FipeRepository
+	carRepo  database.CarRepository
+}
+
+// NewFipeHandler cria uma nova instância do handler
+func NewFipeHandler(fipeRepo database.FipeRepository, carRepo database.CarRepository) *FipeHandler {
+	return &FipeHandler{fipeRepo: fipeRepo, carRepo: carRepo}
+}
+
+// GetVehicle retorna marca, modelo e combustível de um código FIPE
+func (h *FipeHandler) GetVehicle(c *gin.Context) {
+	vehicle, err := h.fipeRepo.GetVehicle(c.Param("code"))
+	if err != nil {
+		respondFipeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicle)
+}
+
+// GetPriceCurve retorna o preço mês a mês de um código FIPE e ano-modelo (model_year,
+// 32000 para zero km), opcionalmente entre os meses from e to (AAAA-MM)
+func (h *FipeHandler) GetPriceCurve(c *gin.Context) {
+	modelYear, err := strconv.Atoi(c.Query("model_year"))
+	if err != nil || modelYear <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model_year is required"})
+		return
+	}
+	from, to, ok := monthRange(c)
+	if !ok {
+		return
+	}
+
+	curve, err := h.fipeRepo.PriceCurve(c.Param("code"), modelYear, from, to)
+	if err != nil {
+		respondFipeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, curve)
+}
+
+// GetCarPriceCurve retorna a curva de preço do veículo da placa, pelo código FIPE e
+// ano-modelo guardados no cache de placas
+func (h *FipeHandler) GetCarPriceCurve(c *gin.Context) {
+	from, to, ok := monthRange(c)
+	if !ok {
+		return
+	}
+
+	car, err := h.carRepo.GetCarByPlate(c.Param("plate"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Car not found in cache"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get car", "details": err.Error()})
+		return
+	}
+	if car.FipeCode == "" || car.ModelYear <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Car has no FIPE code"})
+		return
+	}
+
+	curve, err := h.fipeRepo.PriceCurve(car.FipeCode, car.ModelYear, from, to)
+	if err != nil {
+		respondFipeError(c, err)
+		return
+	}
+	curve.LicensePlate = car.LicensePlate
+
+	c.JSON(http.StatusOK, curve)
+}
+
+// monthRange lê os parâmetros from e to; responde 400 se algum for inválido
+func monthRange(c *gin.Context) (time.Time, time.Time, bool) {
+	var months [2]time.Time
+	for i, name := range []string{"from", "to"} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		month, err := fipe.ParseReferenceMonth(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " month", "details": err.Error()})
+			return time.Time{}, time.Time{}, false
+		}
+		months[i] = month
+	}
+	return months[0], months[1], true
+}
+
+// respondFipeError converte os erros do repositório no status HTTP correspondente
+func respondFipeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, fipe.ErrInvalidCode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, database.ErrFipeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "FIPE code not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get FIPE prices", "details": err.Error()})
+	}
+}
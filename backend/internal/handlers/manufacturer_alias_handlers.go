@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/manufacturer"
+)
+
+// ManufacturerAliasHandler gerencia o dicionário de apelidos de fabricantes
+type ManufacturerAliasHandler struct {
+	repo database.ManufacturerAliasRepository
+}
+
+// NewManufacturerAliasHandler cria uma nova instância do handler
+func NewManufacturerAliasHandler(repo database.ManufacturerAliasRepository) *ManufacturerAliasHandler {
+	return &ManufacturerAliasHandler{repo: repo}
+}
+
+// manufacturerAliasRequest é o corpo de criação/alteração de um apelido
+type manufacturerAliasRequest struct {
+	Alias        string `json:"alias"`
+	Manufacturer string `json:"manufacturer" binding:"required"`
+}
+
+// ListAliases lista os apelidos; manufacturer filtra por fabricante canônico
+func (h *ManufacturerAliasHandler) ListAliases(c *gin.Context) {
+	aliases, err := h.repo.List(c.Query("manufacturer"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list manufacturer aliases", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"aliases": aliases,
+		"total":   len(aliases),
+	})
+}
+
+// GetAlias retorna um apelido
+func (h *ManufacturerAliasHandler) GetAlias(c *gin.Context) {
+	alias, err := h.repo.Get(c.Param("alias"))
+	if err != nil {
+		respondManufacturerAliasError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, alias)
+}
+
+// CreateAlias cadastra um apelido
+func (h *ManufacturerAliasHandler) CreateAlias(c *gin.Context) {
+	var request manufacturerAliasRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Alias == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "alias and manufacturer are required"})
+		return
+	}
+
+	alias, err := h.repo.Create(request.Alias, request.Manufacturer)
+	if err != nil {
+		respondManufacturerAliasError(c, err)
+		return
+	}
+	h.reload()
+
+	c.JSON(http.StatusCreated, alias)
+}
+
+// UpdateAlias aponta o apelido para outro fabricante canônico
+func (h *ManufacturerAliasHandler) UpdateAlias(c *gin.Context) {
+	var request manufacturerAliasRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "manufacturer is required"})
+		return
+	}
+
+	alias, err := h.repo.Update(c.Param("alias"), request.Manufacturer)
+	if err != nil {
+		respondManufacturerAliasError(c, err)
+		return
+	}
+	h.reload()
+
+	c.JSON(http.StatusOK, alias)
+}
+
+// DeleteAlias remove um apelido
+func (h *ManufacturerAliasHandler) DeleteAlias(c *gin.Context) {
+	if err := h.repo.Delete(c.Param("alias")); err != nil {
+		respondManufacturerAliasError(c, err)
+		return
+	}
+	h.reload()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Manufacturer alias deleted"})
+}
+
+// MissingAliases lista os fabricantes das aplicações sem apelido cadastrado. As buscas por
+// aplicação desses fabricantes só encontram a grafia exata.
+func (h *ManufacturerAliasHandler) MissingAliases(c *gin.Context) {
+	missing, err := h.repo.Missing()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list manufacturers without alias", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"manufacturers": missing,
+		"total":         len(missing),
+	})
+}
+
+// reload atualiza o dicionário em memória usado pela busca por placa e pela indexação. Os
+// grupos das aplicações afetadas entram no search_outbox pelo trigger da migração 024 e são
+// reindexados pela indexação incremental com o dicionário novo.
+func (h *ManufacturerAliasHandler) reload() {
+	dict, err := h.repo.Dictionary()
+	if err != nil {
+		log.Printf("⚠️ [MANUFACTURER] Erro ao recarregar apelidos: %v", err)
+		return
+	}
+	manufacturer.SetDefault(dict)
+}
+
+// respondManufacturerAliasError converte os erros do repositório no status HTTP correspondente
+func respondManufacturerAliasError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, database.ErrManufacturerAliasNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manufacturer alias not found"})
+	case errors.Is(err, database.ErrManufacturerAliasInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, database.ErrManufacturerAliasExists),
+		errors.Is(err, database.ErrManufacturerAliasChain),
+		errors.Is(err, database.ErrManufacturerAliasInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process manufacturer alias", "details": err.Error()})
+	}
+}
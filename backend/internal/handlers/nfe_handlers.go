@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/nfe"
+)
+
+// NFeHandler gerencia a importação de NF-e e a fila de conciliação dos itens
+type NFeHandler struct {
+	nfeRepo database.NFeRepository
+}
+
+// NewNFeHandler cria uma nova instância do handler
+func NewNFeHandler(nfeRepo database.NFeRepository) *NFeHandler {
+	return &NFeHandler{nfeRepo: nfeRepo}
+}
+
+// ImportInvoice importa o XML de uma NF-e, enviado no corpo ou no campo file (multipart), e
+// lança os itens no estoque das empresas emitente e destinatária. O responsável vem do
+// parâmetro actor ou do cabeçalho X-User.
+func (h *NFeHandler) ImportInvoice(c *gin.Context) {
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required", "details": err.Error()})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file", "details": err.Error()})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	invoice, err := nfe.Parse(body)
+	if err != nil {
+		respondNFeError(c, "Failed to read NF-e", err)
+		return
+	}
+
+	actor := c.Query("actor")
+	if actor == "" {
+		actor = c.PostForm("actor")
+	}
+	record, err := h.nfeRepo.Ingest(invoice, stockActor(c, actor))
+	if errors.Is(err, database.ErrNFeAlreadyImported) && record != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "invoice_id": record.ID})
+		return
+	}
+	if err != nil {
+		respondNFeError(c, "Failed to import NF-e", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, record)
+}
+
+// ListInvoices lista as notas importadas, filtrando pela empresa (emitente ou destinatária)
+func (h *NFeHandler) ListInvoices(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.nfeRepo.List(c.Query("company_id"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list NF-e", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetInvoice retorna a nota importada com o resultado de cada item
+func (h *NFeHandler) GetInvoice(c *gin.Context) {
+	invoice, err := h.nfeRepo.Get(c.Param("id"))
+	if err != nil {
+		respondNFeError(c, "Failed to get NF-e", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// ListQueue lista a fila de conciliação, filtrando por company_id e reason (not_found,
+// ambiguous, fractional_quantity, insufficient_stock)
+func (h *NFeHandler) ListQueue(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.nfeRepo.ListQueue(c.Query("company_id"), c.Query("reason"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list NF-e items", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResolveItem lança um item da fila de conciliação, com a peça e a quantidade da revisão
+func (h *NFeHandler) ResolveItem(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("item_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	var req models.NFeResolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+	req.Actor = stockActor(c, req.Actor)
+
+	item, err := h.nfeRepo.ResolveItem(id, req)
+	if err != nil {
+		respondNFeError(c, "Failed to resolve NF-e item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// DiscardItem tira um item da fila de conciliação sem movimentar o estoque
+func (h *NFeHandler) DiscardItem(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("item_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	item, err := h.nfeRepo.DiscardItem(id, stockActor(c, c.Query("actor")))
+	if err != nil {
+		respondNFeError(c, "Failed to discard NF-e item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+func respondNFeError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, database.ErrNFeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "NF-e not found"})
+	case errors.Is(err, database.ErrNFeItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "NF-e item not found"})
+	case errors.Is(err, database.ErrNFeCompanyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, nfe.ErrInvalidNFe), errors.Is(err, nfe.ErrNotAuthorized), errors.Is(err, database.ErrInvalidStockMovement):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, database.ErrNFeAlreadyImported), errors.Is(err, database.ErrNFeItemClosed), errors.Is(err, database.ErrInsufficientStock):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,17 +11,22 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/plate"
+	"partexplorer/backend/internal/vehicledata"
+	"partexplorer/backend/internal/vin"
 )
 
 type PlateSearchHandler struct {
-	partRepo database.PartRepository
-	carRepo  database.CarRepository
+	partRepo      database.PartRepository
+	lookups       *vehicledata.LookupManager
+	vehicleModels database.VehicleModelRepository
 }
 
-func NewPlateSearchHandler(partRepo database.PartRepository, carRepo database.CarRepository) *PlateSearchHandler {
+func NewPlateSearchHandler(partRepo database.PartRepository, lookups *vehicledata.LookupManager, vehicleModels database.VehicleModelRepository) *PlateSearchHandler {
 	return &PlateSearchHandler{
-		partRepo: partRepo,
-		carRepo:  carRepo,
+		partRepo:      partRepo,
+		lookups:       lookups,
+		vehicleModels: vehicleModels,
 	}
 }
 
@@ -29,28 +35,43 @@ func (h *PlateSearchHandler) SearchByPlate(c *gin.Context) {
 	start := time.Now()
 
 	// Obter parâmetros
-	plate := strings.ToUpper(strings.ReplaceAll(c.Param("plate"), "-", ""))
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "16"))
 
-	// Validar placa
-	if len(plate) != 7 {
+	// Validar placa (padrão antigo ou Mercosul)
+	parsed, err := plate.Parse(c.Param("plate"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Placa deve ter 7 caracteres",
+			"error": invalidPlateMessage,
 		})
 		return
 	}
+	plateNumber := parsed.Canonical
 
-	// Buscar informações do carro
-	carInfo, err := h.carRepo.SearchCarByPlate(plate)
+	// Buscar informações do carro, aguardando o job de consulta até o limite de espera
+	ctx, cancel := context.WithTimeout(c.Request.Context(), lookupWait(c))
+	defer cancel()
+	job, err := h.lookups.Lookup(ctx, plateNumber)
 	if err != nil {
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": false,
+			"message": "Consulta do veículo em andamento, acompanhe pelo job e repita a busca",
+			"job":     job,
+			"links":   lookupLinks(job),
+		})
+		return
+	}
+
+	if job.Status == vehicledata.LookupStatusFailed {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Erro ao buscar informações do veículo",
-			"details": err.Error(),
+			"details": job.Message,
 		})
 		return
 	}
 
+	carInfo := job.CarInfo
+
 	if carInfo == nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "Veículo não encontrado",
@@ -58,21 +79,11 @@ func (h *PlateSearchHandler) SearchByPlate(c *gin.Context) {
 		return
 	}
 
-	// Extrair palavra do modelo para busca (ignorar "CHEV" se for a primeira)
-	modelWords := strings.Fields(carInfo.Modelo)
-	searchModelWord := ""
-	if len(modelWords) > 0 {
-		if strings.ToUpper(modelWords[0]) == "CHEV" && len(modelWords) > 1 {
-			// Se a primeira palavra é "CHEV", usar a segunda
-			searchModelWord = modelWords[1]
-		} else {
-			// Caso contrário, usar a primeira
-			searchModelWord = modelWords[0]
-		}
-	}
+	// Converter marca/modelo do provedor para o fabricante/modelo do catálogo
+	application := database.ResolveVehicleApplication(h.vehicleModels, carInfo)
 
 	// Buscar peças por aplicação (marca, modelo, ano)
-	searchResponse, err := h.partRepo.SearchPartsByApplication(carInfo.Marca, searchModelWord, carInfo.AnoModelo, page, pageSize)
+	searchResponse, err := h.partRepo.SearchPartsByApplication(application.Manufacturer, application.Model, application.Year, page, pageSize)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -88,14 +99,70 @@ func (h *PlateSearchHandler) SearchByPlate(c *gin.Context) {
 		"success": true,
 		"message": "Busca por placa realizada com sucesso",
 		"data": gin.H{
-			"car_info": carInfo,
-			"parts":    searchResponse,
+			"car_info":      carInfo,
+			"model_mapping": application.Mapping,
+			"parts":         searchResponse,
 		},
 		"debug": gin.H{
-			"plate":        plate,
+			"plate":        plateNumber,
 			"duration_ms":  duration.Milliseconds(),
 			"timestamp":    time.Now().Format(time.RFC3339),
-			"search_query": fmt.Sprintf("%s %s %s", carInfo.Marca, searchModelWord, carInfo.AnoModelo),
+			"search_query": fmt.Sprintf("%s %s %s", application.Manufacturer, application.Model, application.Year),
 		},
 	})
-}
\ No newline at end of file
+}
+
+// SearchByVIN busca peças pelo chassi (VIN), decodificado localmente: fabricante e ano-modelo
+// vêm do VIN, sem consulta externa. O modelo não é codificado de forma padronizada no VIN e
+// pode ser informado no parâmetro model para restringir a busca.
+func (h *PlateSearchHandler) SearchByVIN(c *gin.Context) {
+	start := time.Now()
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "16"))
+
+	info, err := vin.Decode(c.Param("vin"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": invalidVINMessage,
+		})
+		return
+	}
+	if info.Manufacturer == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Fabricante não identificado pelo chassi",
+			"vin_info": info,
+		})
+		return
+	}
+
+	year := strconv.Itoa(info.ModelYear)
+	if value, err := strconv.Atoi(c.Query("year")); err == nil && value > 0 {
+		year = strconv.Itoa(value)
+	}
+	model := strings.TrimSpace(c.Query("model"))
+
+	searchResponse, err := h.partRepo.SearchPartsByApplication(info.Manufacturer, model, year, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Erro ao buscar peças",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Busca por chassi realizada com sucesso",
+		"data": gin.H{
+			"vin_info": info,
+			"parts":    searchResponse,
+		},
+		"debug": gin.H{
+			"vin":          info.VIN,
+			"duration_ms":  time.Since(start).Milliseconds(),
+			"timestamp":    time.Now().Format(time.RFC3339),
+			"search_query": strings.Join(strings.Fields(fmt.Sprintf("%s %s %s", info.Manufacturer, model, year)), " "),
+		},
+	})
+}
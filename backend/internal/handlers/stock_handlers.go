@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -59,11 +60,12 @@ func (h *StockHandler) CreateStock(c *gin.Context) {
 		Price:      req.Price,
 	}
 
-	if err := h.stockRepo.CreateStock(stock); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stock", "details": err.Error()})
+	if err := h.stockRepo.CreateStock(stock, stockActor(c, req.Actor)); err != nil {
+		respondStockError(c, "Failed to create stock", err)
 		return
 	}
 
+	setETag(c, stock.Version)
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Stock created successfully",
 		"stock": gin.H{
@@ -72,6 +74,7 @@ func (h *StockHandler) CreateStock(c *gin.Context) {
 			"company_id":   stock.CompanyID.String(),
 			"quantity":     stock.Quantity,
 			"price":        stock.Price,
+			"version":      stock.Version,
 		},
 	})
 }
@@ -96,6 +99,7 @@ func (h *StockHandler) GetStockByID(c *gin.Context) {
 		"company_id":   stock.CompanyID.String(),
 		"quantity":     stock.Quantity,
 		"price":        stock.Price,
+		"version":      stock.Version,
 		"created_at":   stock.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		"updated_at":   stock.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
@@ -144,6 +148,7 @@ func (h *StockHandler) GetStockByID(c *gin.Context) {
 		}
 	}
 
+	setETag(c, stock.Version)
 	c.JSON(http.StatusOK, gin.H{"stock": response})
 }
 
@@ -169,6 +174,7 @@ func (h *StockHandler) GetStocksByPartNameID(c *gin.Context) {
 			"company_id":   stock.CompanyID.String(),
 			"quantity":     stock.Quantity,
 			"price":        stock.Price,
+			"version":      stock.Version,
 			"created_at":   stock.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			"updated_at":   stock.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		}
@@ -224,6 +230,7 @@ func (h *StockHandler) GetStocksByGroupID(c *gin.Context) {
 			"company_id":   stock.CompanyID.String(),
 			"quantity":     stock.Quantity,
 			"price":        stock.Price,
+			"version":      stock.Version,
 			"created_at":   stock.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			"updated_at":   stock.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		}
@@ -257,7 +264,8 @@ func (h *StockHandler) GetStocksByGroupID(c *gin.Context) {
 	})
 }
 
-// UpdateStock atualiza um registro de estoque
+// UpdateStock atualiza um registro de estoque. Exige If-Match (428 sem ele) e só grava se o
+// estoque ainda estiver na versão do ETag; senão responde 409 com o estado atual.
 func (h *StockHandler) UpdateStock(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -265,6 +273,12 @@ func (h *StockHandler) UpdateStock(c *gin.Context) {
 		return
 	}
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		respondIfMatchError(c, err)
+		return
+	}
+
 	var req models.UpdateStockRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
@@ -280,19 +294,37 @@ func (h *StockHandler) UpdateStock(c *gin.Context) {
 		}
 		updates["company_id"] = companyID
 	}
-	if req.Quantity != nil {
-		updates["quantity"] = *req.Quantity
-	}
 	if req.Price != nil {
 		updates["price"] = *req.Price
 	}
 
-	if err := h.stockRepo.UpdateStock(id, updates); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock", "details": err.Error()})
+	// A quantidade informada é uma contagem: a diferença entra no livro como ajuste
+	var count *models.StockMovementRequest
+	if req.Quantity != nil {
+		count = &models.StockMovementRequest{
+			Type:    models.StockMovementAdjustment,
+			Counted: req.Quantity,
+			Actor:   stockActor(c, req.Actor),
+			Reason:  req.Reason,
+		}
+	}
+
+	stock, movements, err := h.stockRepo.UpdateStock(id, version, updates, count)
+	if errors.Is(err, database.ErrVersionConflict) && stock != nil {
+		respondVersionConflict(c, stock, stock.Version)
+		return
+	}
+	if err != nil {
+		respondStockError(c, "Failed to update stock", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Stock updated successfully"})
+	setETag(c, stock.Version)
+	response := gin.H{"message": "Stock updated successfully", "version": stock.Version}
+	if movements != nil {
+		response["movements"] = movements
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteStock remove um registro de estoque
@@ -339,3 +371,201 @@ func (h *StockHandler) SearchStocks(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// RecordMovement registra uma movimentação (receipt, sale, adjustment, transfer) e retorna as
+// linhas gravadas no livro; a transferência grava a saída e a entrada
+func (h *StockHandler) RecordMovement(c *gin.Context) {
+	var req models.StockMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+	req.Actor = stockActor(c, req.Actor)
+
+	movements, err := h.stockRepo.RecordMovement(c.Param("id"), req)
+	if err != nil {
+		respondStockError(c, "Failed to record stock movement", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"movements": movements})
+}
+
+// ListMovements retorna o histórico de movimentações do estoque, filtrando por tipo
+func (h *StockHandler) ListMovements(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.stockRepo.ListMovements(c.Param("id"), c.Query("type"), page, pageSize)
+	if err != nil {
+		respondStockError(c, "Failed to list stock movements", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetReconciliation compara a quantidade do estoque com a soma do livro
+func (h *StockHandler) GetReconciliation(c *gin.Context) {
+	result, err := h.stockRepo.GetReconciliation(c.Param("id"))
+	if err != nil {
+		respondStockError(c, "Failed to reconcile stock", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Reconcile acerta a divergência entre o estoque e o livro (keep=ledger ou keep=quantity)
+func (h *StockHandler) Reconcile(c *gin.Context) {
+	var req models.StockReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+	req.Actor = stockActor(c, req.Actor)
+
+	result, err := h.stockRepo.Reconcile(c.Param("id"), req)
+	if err != nil {
+		respondStockError(c, "Failed to reconcile stock", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListReconciliation lista os estoques cuja quantidade diverge do livro
+func (h *StockHandler) ListReconciliation(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.stockRepo.ListReconciliation(page, pageSize)
+	if err != nil {
+		respondStockError(c, "Failed to list stock divergences", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// IncrementQuantity soma amount à quantidade num único UPDATE, sem If-Match: incrementos
+// concorrentes não se sobrescrevem. O tipo padrão é receipt.
+func (h *StockHandler) IncrementQuantity(c *gin.Context) {
+	h.changeQuantity(c, models.StockMovementReceipt, 1)
+}
+
+// DecrementQuantity subtrai amount da quantidade num único UPDATE, recusando (409) o que
+// deixaria o saldo negativo. O tipo padrão é sale.
+func (h *StockHandler) DecrementQuantity(c *gin.Context) {
+	h.changeQuantity(c, models.StockMovementSale, -1)
+}
+
+// changeQuantity registra o incremento (sign 1) ou decremento (sign -1) no livro. O ajuste
+// aceita os dois sentidos; entrada só incrementa e venda só decrementa.
+func (h *StockHandler) changeQuantity(c *gin.Context, defaultType string, sign int) {
+	var req models.StockQuantityChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+	if req.Type == "" {
+		req.Type = defaultType
+	}
+	if req.Type != defaultType && req.Type != models.StockMovementAdjustment {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be " + defaultType + " or " + models.StockMovementAdjustment})
+		return
+	}
+
+	movement := models.StockMovementRequest{
+		Type:      req.Type,
+		Quantity:  req.Amount,
+		Actor:     stockActor(c, req.Actor),
+		Reason:    req.Reason,
+		Reference: req.Reference,
+	}
+	if req.Type == models.StockMovementAdjustment {
+		movement.Quantity = sign * req.Amount
+	}
+
+	stock, created, err := h.stockRepo.AdjustQuantity(c.Param("id"), movement)
+	if err != nil {
+		respondStockError(c, "Failed to change stock quantity", err)
+		return
+	}
+
+	setETag(c, stock.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"quantity": stock.Quantity,
+		"version":  stock.Version,
+		"movement": created,
+	})
+}
+
+// ListPriceHistory retorna as fotos de preço do estoque, as mais recentes primeiro
+func (h *StockHandler) ListPriceHistory(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.stockRepo.ListPriceHistory(c.Param("id"), page, pageSize)
+	if err != nil {
+		respondStockError(c, "Failed to list stock prices", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetGroupPriceStats retorna o menor, a mediana e o maior preço das peças do grupo, no total
+// e por estado e cidade, filtrando por state, city e in_stock
+func (h *StockHandler) GetGroupPriceStats(c *gin.Context) {
+	stats, err := h.stockRepo.GetGroupPriceStats(c.Param("group_id"), priceFilter(c))
+	if err != nil {
+		respondStockError(c, "Failed to get price stats", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// RankOffers lista as ofertas do SKU do menor preço ao maior, com os dados de contato da
+// empresa, filtrando por state, city e in_stock
+func (h *StockHandler) RankOffers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.stockRepo.RankOffers(c.Param("part_name_id"), priceFilter(c), page, pageSize)
+	if err != nil {
+		respondStockError(c, "Failed to rank offers", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// priceFilter lê o filtro das ofertas da query string
+func priceFilter(c *gin.Context) models.PriceFilter {
+	inStock, _ := strconv.ParseBool(c.Query("in_stock"))
+	return models.PriceFilter{State: c.Query("state"), City: c.Query("city"), InStock: inStock}
+}
+
+// stockActor retorna quem fez a alteração: o campo actor da requisição ou o cabeçalho X-User
+func stockActor(c *gin.Context, actor string) string {
+	if actor != "" {
+		return actor
+	}
+	return c.GetHeader("X-User")
+}
+
+// respondStockError converte os erros do repositório no status HTTP correspondente
+func respondStockError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, database.ErrStockNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+	case errors.Is(err, database.ErrInvalidStockMovement), errors.Is(err, database.ErrStockQuantityReadOnly), errors.Is(err, database.ErrInvalidPriceQuery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, database.ErrInsufficientStock):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/stockfeed"
+)
+
+// StockImportHandler gerencia as importações de arquivos de estoque dos distribuidores
+type StockImportHandler struct {
+	imports database.StockImportRepository
+	runner  *stockfeed.Runner
+	maxSize int64
+}
+
+// NewStockImportHandler cria uma nova instância do handler. STOCK_IMPORT_MAX_SIZE_MB limita
+// o tamanho do arquivo enviado (padrão 50).
+func NewStockImportHandler(importRepo database.StockImportRepository, runner *stockfeed.Runner) *StockImportHandler {
+	maxSize := int64(50)
+	if value, err := strconv.ParseInt(os.Getenv("STOCK_IMPORT_MAX_SIZE_MB"), 10, 64); err == nil && value > 0 {
+		maxSize = value
+	}
+	return &StockImportHandler{imports: importRepo, runner: runner, maxSize: maxSize << 20}
+}
+
+// CreateImport recebe o arquivo de estoque da empresa (multipart, campo file) em CSV, XLSX ou
+// JSON lines, grava as linhas e agenda o processamento em background. Campos: company_id,
+// mode (merge ou replace), format (deduzido da extensão quando omitido) e actor (ou X-User).
+func (h *StockImportHandler) CreateImport(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required", "details": err.Error()})
+		return
+	}
+	if header.Size > h.maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file exceeds %d MB", h.maxSize>>20)})
+		return
+	}
+
+	format := c.PostForm("format")
+	if format == "" {
+		format = stockfeed.FormatFromPath(header.Filename)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	lines, err := stockfeed.Read(file, format)
+	if err != nil {
+		respondStockImportError(c, "Failed to read stock file", err)
+		return
+	}
+
+	job, rows, err := stockfeed.NewJob(c.PostForm("company_id"), c.PostForm("mode"), format, header.Filename,
+		stockActor(c, c.PostForm("actor")), lines)
+	if err != nil {
+		respondStockImportError(c, "Failed to create stock import", err)
+		return
+	}
+	if err := h.imports.Create(job, rows); err != nil {
+		respondStockImportError(c, "Failed to create stock import", err)
+		return
+	}
+	h.runner.Notify()
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListImports lista as importações, filtrando por company_id e status
+func (h *StockImportHandler) ListImports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.imports.List(c.Query("company_id"), c.Query("status"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list stock imports", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetImport retorna o andamento e os contadores da importação
+func (h *StockImportHandler) GetImport(c *gin.Context) {
+	job, err := h.imports.Get(c.Param("id"))
+	if err != nil {
+		respondStockImportError(c, "Failed to get stock import", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ListErrors retorna o relatório de erros por linha da importação
+func (h *StockImportHandler) ListErrors(c *gin.Context) {
+	h.listLines(c, models.StockImportLineError)
+}
+
+// ListLines retorna as linhas da importação com o resultado de cada uma, filtrando por status
+func (h *StockImportHandler) ListLines(c *gin.Context) {
+	h.listLines(c, c.Query("status"))
+}
+
+func (h *StockImportHandler) listLines(c *gin.Context, status string) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	response, err := h.imports.ListLines(c.Param("id"), status, page, pageSize)
+	if err != nil {
+		respondStockImportError(c, "Failed to list stock import lines", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CancelImport interrompe a importação; as linhas já aplicadas permanecem
+func (h *StockImportHandler) CancelImport(c *gin.Context) {
+	job, err := h.imports.Cancel(c.Param("id"))
+	if err != nil {
+		respondStockImportError(c, "Failed to cancel stock import", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ResumeImport retoma uma importação cancelada ou com falha a partir das linhas pendentes
+func (h *StockImportHandler) ResumeImport(c *gin.Context) {
+	job, err := h.imports.Resume(c.Param("id"))
+	if err != nil {
+		respondStockImportError(c, "Failed to resume stock import", err)
+		return
+	}
+	h.runner.Notify()
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func respondStockImportError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, database.ErrStockImportNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock import not found"})
+	case errors.Is(err, database.ErrCompanyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+	case errors.Is(err, database.ErrStockImportFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, stockfeed.ErrInvalidJob), errors.Is(err, stockfeed.ErrUnknownFormat), errors.Is(err, stockfeed.ErrNoCodeColumn):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/vehiclemodel"
+)
+
+// VehicleModelHandler gerencia a fila de revisão do mapeamento entre os modelos dos provedores
+// de placa e as aplicações do catálogo
+type VehicleModelHandler struct {
+	repo database.VehicleModelRepository
+}
+
+// NewVehicleModelHandler cria uma nova instância do handler
+func NewVehicleModelHandler(repo database.VehicleModelRepository) *VehicleModelHandler {
+	return &VehicleModelHandler{repo: repo}
+}
+
+// ListMappings lista os mapeamentos; por padrão, a fila de revisão (status=pending).
+// status=all lista todos.
+func (h *VehicleModelHandler) ListMappings(c *gin.Context) {
+	status := c.DefaultQuery("status", models.VehicleModelMappingPending)
+	if status == "all" {
+		status = ""
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.repo.List(status, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list vehicle model mappings", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetMapping retorna um mapeamento com as aplicações do catálogo mais parecidas
+func (h *VehicleModelHandler) GetMapping(c *gin.Context) {
+	mapping, err := h.repo.GetByID(c.Param("id"))
+	if err != nil {
+		respondVehicleModelError(c, err)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	candidates, err := h.repo.Candidates(mapping.ID.String(), limit)
+	if err != nil {
+		respondVehicleModelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"mapping":    mapping,
+		"candidates": candidates,
+	})
+}
+
+// ConfirmMapping aceita o palpite automático do mapeamento
+func (h *VehicleModelHandler) ConfirmMapping(c *gin.Context) {
+	mapping, err := h.repo.Confirm(c.Param("id"))
+	if err != nil {
+		respondVehicleModelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, mapping)
+}
+
+// CorrectMapping aponta o mapeamento para outra aplicação do catálogo
+func (h *VehicleModelHandler) CorrectMapping(c *gin.Context) {
+	var target vehiclemodel.Candidate
+	if err := c.ShouldBindJSON(&target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if target.Manufacturer == "" || target.Model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "manufacturer and model are required"})
+		return
+	}
+
+	mapping, err := h.repo.Correct(c.Param("id"), target)
+	if err != nil {
+		respondVehicleModelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, mapping)
+}
+
+// respondVehicleModelError converte os erros do repositório no status HTTP correspondente
+func respondVehicleModelError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, database.ErrVehicleModelMappingNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vehicle model mapping not found", "details": err.Error()})
+	case errors.Is(err, database.ErrVehicleModelNothingToConfirm):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, database.ErrVehicleModelUnknownApplication):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process vehicle model mapping", "details": err.Error()})
+	}
+}
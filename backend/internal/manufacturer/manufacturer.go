@@ -0,0 +1,108 @@
+package manufacturer
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"partexplorer/backend/internal/synonyms"
+)
+
+// builtinAliases são os apelidos usados enquanto a tabela partexplorer.manufacturer_alias não
+// foi carregada (e a carga inicial da migração 014). Cada fabricante canônico aponta para si.
+var builtinAliases = map[string]string{
+	"VOLKSWAGEN":    "VOLKSWAGEN",
+	"VW":            "VOLKSWAGEN",
+	"VOLKS":         "VOLKSWAGEN",
+	"CHEVROLET":     "CHEVROLET",
+	"GM":            "CHEVROLET",
+	"CHEV":          "CHEVROLET",
+	"FIAT":          "FIAT",
+	"FORD":          "FORD",
+	"RENAULT":       "RENAULT",
+	"PEUGEOT":       "PEUGEOT",
+	"CITROEN":       "CITROEN",
+	"HONDA":         "HONDA",
+	"TOYOTA":        "TOYOTA",
+	"HYUNDAI":       "HYUNDAI",
+	"KIA":           "KIA",
+	"NISSAN":        "NISSAN",
+	"MITSUBISHI":    "MITSUBISHI",
+	"JEEP":          "JEEP",
+	"AUDI":          "AUDI",
+	"BMW":           "BMW",
+	"MERCEDES-BENZ": "MERCEDES-BENZ",
+	"MERCEDES":      "MERCEDES-BENZ",
+	"MERCEDESBENZ":  "MERCEDES-BENZ",
+	"M.BENZ":        "MERCEDES-BENZ",
+	"MBENZ":         "MERCEDES-BENZ",
+	"MB":            "MERCEDES-BENZ",
+	"SUZUKI":        "SUZUKI",
+	"CHERY":         "CHERY",
+	"CAOA CHERY":    "CHERY",
+	"CAOA":          "CHERY",
+	"JAC":           "JAC",
+	"LAND ROVER":    "LAND ROVER",
+	"LAND":          "LAND ROVER",
+	"LR":            "LAND ROVER",
+}
+
+// Dictionary converte as grafias de um fabricante ("VW", "Volkswagen", "GM", "CHEV") para o
+// nome canônico
+type Dictionary struct {
+	aliases map[string]string
+}
+
+// NewDictionary cria o dicionário a partir de apelido -> fabricante canônico. Os textos são
+// normalizados com Normalize.
+func NewDictionary(aliases map[string]string) *Dictionary {
+	dict := &Dictionary{aliases: make(map[string]string, len(aliases))}
+	for alias, canonical := range aliases {
+		if alias = Normalize(alias); alias != "" {
+			dict.aliases[alias] = Normalize(canonical)
+		}
+	}
+	return dict
+}
+
+// Lookup retorna o fabricante canônico do apelido, se ele estiver no dicionário
+func (d *Dictionary) Lookup(name string) (string, bool) {
+	canonical, ok := d.aliases[Normalize(name)]
+	return canonical, ok
+}
+
+// Canonical retorna o fabricante canônico; nomes fora do dicionário são apenas normalizados
+func (d *Dictionary) Canonical(name string) string {
+	if canonical, ok := d.Lookup(name); ok {
+		return canonical
+	}
+	return Normalize(name)
+}
+
+// Len retorna o número de apelidos
+func (d *Dictionary) Len() int {
+	return len(d.aliases)
+}
+
+var current atomic.Pointer[Dictionary]
+
+// Default retorna o dicionário em uso: o carregado do banco com SetDefault ou, antes disso, o
+// embutido no binário
+func Default() *Dictionary {
+	if dict := current.Load(); dict != nil {
+		return dict
+	}
+	dict := NewDictionary(builtinAliases)
+	current.CompareAndSwap(nil, dict)
+	return current.Load()
+}
+
+// SetDefault troca o dicionário em uso, por exemplo após alterar os apelidos no banco
+func SetDefault(dict *Dictionary) {
+	current.Store(dict)
+}
+
+// Normalize deixa o nome em maiúsculas, sem acentos e sem espaços repetidos, como a função
+// partexplorer.f_canonical_manufacturer faz no banco
+func Normalize(name string) string {
+	return strings.ToUpper(synonyms.Normalize(name))
+}
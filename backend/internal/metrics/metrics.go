@@ -118,4 +118,78 @@ func RecordResponseTime(endpoint string, duration time.Duration) {
 	ResponseTimeSeconds.WithLabelValues(endpoint).Observe(duration.Seconds())
 }
 
+// Métricas dos provedores de consulta de placa
+var (
+	PlateProviderCircuitState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "partexplorer_plate_provider_circuit_state",
+			Help: "Estado do circuit breaker do provedor de placa (0=fechado, 1=meio-aberto, 2=aberto)",
+		},
+		[]string{"provider"},
+	)
+
+	PlateProviderRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "partexplorer_plate_provider_requests_total",
+			Help: "Total de consultas aos provedores de placa por resultado",
+		},
+		[]string{"provider", "result"},
+	)
+)
+
+// SetPlateProviderCircuitState registra o estado do circuit breaker de um provedor de placa
+func SetPlateProviderCircuitState(provider string, state float64) {
+	PlateProviderCircuitState.WithLabelValues(provider).Set(state)
+}
+
+// RecordPlateProviderRequest registra o resultado de uma consulta a um provedor de placa
+func RecordPlateProviderRequest(provider, result string) {
+	PlateProviderRequestsTotal.WithLabelValues(provider, result).Inc()
+}
+
+// Métricas da fila de consultas de placa que falharam (car_error)
+var (
+	CarErrorsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "partexplorer_car_errors_total",
+			Help: "Total de falhas de consulta de placa registradas na car_error por motivo",
+		},
+		[]string{"reason"},
+	)
+
+	CarErrorRetriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "partexplorer_car_error_retries_total",
+			Help: "Total de novas tentativas da car_error por resultado",
+		},
+		[]string{"result"},
+	)
 
+	CarErrorEntries = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "partexplorer_car_error_entries",
+			Help: "Entradas da car_error por status e motivo",
+		},
+		[]string{"status", "reason"},
+	)
+)
+
+// RecordCarError registra uma falha de consulta de placa
+func RecordCarError(reason string) {
+	CarErrorsTotal.WithLabelValues(reason).Inc()
+}
+
+// RecordCarErrorRetry registra o resultado de uma nova tentativa
+func RecordCarErrorRetry(result string) {
+	CarErrorRetriesTotal.WithLabelValues(result).Inc()
+}
+
+// ResetCarErrorEntries zera as contagens antes de uma nova leitura da car_error
+func ResetCarErrorEntries() {
+	CarErrorEntries.Reset()
+}
+
+// SetCarErrorEntries registra quantas entradas da car_error têm o status e o motivo
+func SetCarErrorEntries(status, reason string, count float64) {
+	CarErrorEntries.WithLabelValues(status, reason).Set(count)
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"partexplorer/backend/internal/fipe"
 )
 
 // Car representa a tabela car no banco de dados
@@ -24,8 +26,12 @@ type Car struct {
 	Imported      string    `json:"imported" gorm:"column:imported;type:varchar(3)"`
 	FipeCode      string    `json:"fipe_code" gorm:"column:fipe_code;type:varchar(80)"`
 	FipeValue     float64   `json:"fipe_value" gorm:"column:fipe_value;type:numeric"`
-	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
-	UpdatedAt     time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamp with time zone;default:current_timestamp"`
+	Provider      string    `json:"provider" gorm:"column:provider;type:varchar(40)"`
+	// LastQueriedAt é a última consulta da placa por um usuário; o refresher só atualiza as
+	// placas consultadas recentemente
+	LastQueriedAt *time.Time `json:"last_queried_at,omitempty" gorm:"column:last_queried_at;type:timestamp with time zone"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"column:updated_at;type:timestamp with time zone;default:current_timestamp"`
 }
 
 // TableName especifica o nome da tabela
@@ -33,12 +39,52 @@ func (Car) TableName() string {
 	return "partexplorer.car"
 }
 
-// CarError representa a tabela car_error no banco de dados
+// Status de uma entrada da car_error
+const (
+	// CarErrorPending aguarda a próxima tentativa automática
+	CarErrorPending = "pending"
+	// CarErrorExhausted esgotou as tentativas automáticas; só volta com uma nova tentativa manual
+	CarErrorExhausted = "exhausted"
+	// CarErrorResolved foi resolvida por uma nova tentativa
+	CarErrorResolved = "resolved"
+	// CarErrorDiscarded foi descartada na revisão
+	CarErrorDiscarded = "discarded"
+)
+
+// Motivos de falha de uma entrada da car_error
+const (
+	// CarErrorReasonParse indica que a resposta do provedor não pôde ser interpretada
+	CarErrorReasonParse = "parse_failure"
+	// CarErrorReasonBlocked indica que o provedor bloqueou a consulta (403/429)
+	CarErrorReasonBlocked = "provider_blocked"
+	// CarErrorReasonProvider são as demais falhas dos provedores (timeout, circuit breaker aberto)
+	CarErrorReasonProvider = "provider_error"
+	// CarErrorReasonConstraint indica que o banco rejeitou os dados (restrição violada)
+	CarErrorReasonConstraint = "db_constraint"
+	// CarErrorReasonDatabase são as demais falhas ao gravar no banco
+	CarErrorReasonDatabase = "db_error"
+)
+
+// CarError representa a tabela car_error no banco de dados: consultas de placa que falharam,
+// com os dados obtidos (quando houver) e o controle das novas tentativas
 type CarError struct {
-	LicensePlate string                 `json:"license_plate" gorm:"column:license_plate;type:varchar(10);primary_key"`
-	Data         map[string]interface{} `json:"data" gorm:"column:data;type:jsonb"`
-	CreatedAt    time.Time              `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
-	UpdatedAt    time.Time              `json:"updated_at" gorm:"column:updated_at;type:timestamp with time zone;default:current_timestamp"`
+	LicensePlate  string                 `json:"license_plate" gorm:"column:license_plate;type:varchar(10);primary_key"`
+	Data          map[string]interface{} `json:"data" gorm:"column:data;type:jsonb;serializer:json"`
+	Status        string                 `json:"status" gorm:"column:status;type:varchar(20);default:pending"`
+	Reason        string                 `json:"reason" gorm:"column:reason;type:varchar(30)"`
+	Message       string                 `json:"message,omitempty" gorm:"column:message;type:text"`
+	Attempts      int                    `json:"attempts" gorm:"column:attempts;type:int;default:0"`
+	NextRetryAt   *time.Time             `json:"next_retry_at,omitempty" gorm:"column:next_retry_at;type:timestamp with time zone"`
+	LastAttemptAt *time.Time             `json:"last_attempt_at,omitempty" gorm:"column:last_attempt_at;type:timestamp with time zone"`
+	CreatedAt     time.Time              `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
+	UpdatedAt     time.Time              `json:"updated_at" gorm:"column:updated_at;type:timestamp with time zone;default:current_timestamp"`
+}
+
+// HasCarData indica se a entrada guarda os dados do veículo (falha ao gravar) e pode ser
+// refeita sem consultar os provedores
+func (e *CarError) HasCarData() bool {
+	marca, _ := e.Data["marca"].(string)
+	return marca != ""
 }
 
 // TableName especifica o nome da tabela
@@ -46,6 +92,22 @@ func (CarError) TableName() string {
 	return "partexplorer.car_error"
 }
 
+// CarErrorListResponse representa a resposta paginada da car_error
+type CarErrorListResponse struct {
+	Errors     []CarError `json:"errors"`
+	Total      int64      `json:"total"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"page_size"`
+	TotalPages int        `json:"total_pages"`
+}
+
+// CarErrorCount é o número de entradas da car_error por status e motivo
+type CarErrorCount struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+	Count  int64  `json:"count"`
+}
+
 // CarInfo representa as informações do veículo retornadas pela API externa
 type CarInfo struct {
 	Placa          string  `json:"placa"`
@@ -63,6 +125,20 @@ type CarInfo struct {
 	ValorFipe      string  `json:"valor_fipe"`
 	DataConsulta   string  `json:"data_consulta"`
 	Confiabilidade float64 `json:"confiabilidade"`
+	Provedor       string  `json:"provedor,omitempty"` // Provedor que respondeu a consulta
+	// Freshness indica a idade dos dados e os grupos de campos vencidos
+	Freshness *CarFreshness `json:"freshness,omitempty"`
+}
+
+// CarFreshness é o indicador de frescor dos dados do veículo
+type CarFreshness struct {
+	UpdatedAt  time.Time `json:"updated_at"`
+	AgeSeconds int64     `json:"age_seconds"`
+	Stale      bool      `json:"stale"`
+	// StaleFields são os grupos de campos vencidos: identity, registration, fipe
+	StaleFields []string `json:"stale_fields,omitempty"`
+	// Refreshing indica que os dados vencidos serão atualizados em background
+	Refreshing bool `json:"refreshing,omitempty"`
 }
 
 // ToCar converte CarInfo para Car
@@ -83,17 +159,17 @@ func (ci *CarInfo) ToCar() *Car {
 
 	fipeValue := 0.0
 	if ci.ValorFipe != "" {
-		// Remover "R$ " e outros caracteres, manter apenas números e vírgula/ponto
-		valorLimpo := strings.ReplaceAll(ci.ValorFipe, "R$", "")
-		valorLimpo = strings.ReplaceAll(valorLimpo, " ", "")
-		valorLimpo = strings.ReplaceAll(valorLimpo, ".", "")
-		valorLimpo = strings.ReplaceAll(valorLimpo, ",", ".")
-
-		if fv, err := strconv.ParseFloat(valorLimpo, 64); err == nil {
+		if fv, err := fipe.ParsePrice(ci.ValorFipe); err == nil {
 			fipeValue = fv
 		}
 	}
 
+	// Código na forma canônica da tabela FIPE ("000000-0"), que liga o carro ao histórico de preços
+	fipeCode := strings.TrimSpace(ci.CodigoFipe)
+	if code, err := fipe.NormalizeCode(fipeCode); err == nil {
+		fipeCode = code
+	}
+
 	return &Car{
 		LicensePlate:  ci.Placa,
 		Brand:         ci.Marca,
@@ -106,7 +182,8 @@ func (ci *CarInfo) ToCar() *Car {
 		City:          ci.Municipio,
 		State:         ci.UF,
 		Imported:      ci.Importado,
-		FipeCode:      ci.CodigoFipe,
+		FipeCode:      fipeCode,
 		FipeValue:     fipeValue,
+		Provider:      ci.Provedor,
 	}
 }
@@ -217,6 +217,7 @@ func ToCleanSearchResponse(searchResponse *SearchResponse) *CleanSearchResponse
 		PageSize:   searchResponse.PageSize,
 		TotalPages: searchResponse.TotalPages,
 		Query:      searchResponse.Query,
+		Facets:     searchResponse.Facets,
 	}
 }
 
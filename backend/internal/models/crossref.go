@@ -0,0 +1,38 @@
+package models
+
+// CrossReferenceResponse - Códigos equivalentes ao código consultado
+type CrossReferenceResponse struct {
+	Code           string                `json:"code"`
+	NormalizedCode string                `json:"normalized_code"`
+	GTIN           *GTINValidation       `json:"gtin,omitempty"`
+	Groups         []CrossReferenceGroup `json:"groups"`
+}
+
+// GTINValidation - Resultado da validação do dígito verificador de um EAN/GTIN
+type GTINValidation struct {
+	Format     string `json:"format,omitempty"`
+	Normalized string `json:"normalized,omitempty"`
+	Valid      bool   `json:"valid"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CrossReferenceGroup - Códigos de um part_group que contém o código consultado
+type CrossReferenceGroup struct {
+	GroupID     string                `json:"group_id"`
+	ProductType string                `json:"product_type,omitempty"`
+	Brands      []CrossReferenceBrand `json:"brands"`
+}
+
+// CrossReferenceBrand - Códigos de uma marca, agrupados por tipo (sku, ean)
+type CrossReferenceBrand struct {
+	Brand string                          `json:"brand"`
+	Codes map[string][]CrossReferenceCode `json:"codes"`
+}
+
+// CrossReferenceCode - Um código equivalente
+type CrossReferenceCode struct {
+	Code           string `json:"code"`
+	NormalizedCode string `json:"normalized_code"`
+	Matched        bool   `json:"matched"`
+	ValidGTIN      *bool  `json:"valid_gtin,omitempty"`
+}
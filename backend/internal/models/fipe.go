@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Origem de um preço do histórico FIPE
+const (
+	// FipePriceSourceTable é o preço importado da tabela oficial do mês
+	FipePriceSourceTable = "table"
+	// FipePriceSourcePlate é o valor informado pelo provedor de placa, guardado até a
+	// importação da tabela oficial do mês
+	FipePriceSourcePlate = "plate"
+)
+
+// FipeVehicle representa um código da tabela FIPE (fipe_vehicle)
+type FipeVehicle struct {
+	Code      string    `json:"code" gorm:"column:code;type:varchar(8);primary_key"`
+	Brand     string    `json:"brand" gorm:"column:brand;type:varchar(80)"`
+	Model     string    `json:"model" gorm:"column:model;type:varchar(255)"`
+	Fuel      string    `json:"fuel" gorm:"column:fuel;type:varchar(40)"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamp with time zone;default:current_timestamp"`
+}
+
+// TableName especifica o nome da tabela
+func (FipeVehicle) TableName() string {
+	return "partexplorer.fipe_vehicle"
+}
+
+// FipePrice é o preço de um código FIPE e ano-modelo em um mês de referência (fipe_price).
+// O ano-modelo 32000 é o dos veículos zero quilômetro.
+type FipePrice struct {
+	Code           string    `json:"code" gorm:"column:code;type:varchar(8);primary_key"`
+	ModelYear      int       `json:"model_year" gorm:"column:model_year;type:int;primary_key"`
+	ReferenceMonth time.Time `json:"reference_month" gorm:"column:reference_month;type:date;primary_key"`
+	Price          float64   `json:"price" gorm:"column:price;type:numeric(12,2)"`
+	Source         string    `json:"source" gorm:"column:source;type:varchar(10)"`
+	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamp with time zone;default:current_timestamp"`
+}
+
+// TableName especifica o nome da tabela
+func (FipePrice) TableName() string {
+	return "partexplorer.fipe_price"
+}
+
+// FipePricePoint é um mês da curva de preço
+type FipePricePoint struct {
+	ReferenceMonth string  `json:"reference_month"`
+	Price          float64 `json:"price"`
+	Source         string  `json:"source"`
+}
+
+// FipePriceCurve é o histórico mês a mês do preço de um código FIPE e ano-modelo
+type FipePriceCurve struct {
+	LicensePlate string           `json:"license_plate,omitempty"`
+	Code         string           `json:"code"`
+	ModelYear    int              `json:"model_year"`
+	Vehicle      *FipeVehicle     `json:"vehicle,omitempty"`
+	Prices       []FipePricePoint `json:"prices"`
+	// ChangePercent é a variação entre o primeiro e o último mês da curva
+	ChangePercent float64 `json:"change_percent"`
+}
+
+// FipeImportResult resume a importação de uma tabela FIPE
+type FipeImportResult struct {
+	Vehicles int      `json:"vehicles"`
+	Prices   int      `json:"prices"`
+	Months   []string `json:"months"`
+}
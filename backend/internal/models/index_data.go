@@ -0,0 +1,18 @@
+package models
+
+// PartGroupIndexData reúne os relacionamentos de um part_group usados na indexação
+type PartGroupIndexData struct {
+	Names        []PartName
+	Images       []PartImage
+	Applications []Application
+	Stock        []StockLocationSummary
+}
+
+// StockLocationSummary agrega o estoque disponível de um grupo por cidade
+type StockLocationSummary struct {
+	State     string   `json:"state"`
+	City      string   `json:"city"`
+	Companies int64    `json:"companies"`
+	Quantity  int64    `json:"quantity"`
+	MinPrice  *float64 `json:"min_price,omitempty"`
+}
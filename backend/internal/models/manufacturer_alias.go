@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ManufacturerAlias associa uma grafia de fabricante ("VW", "GM", "CHEV") ao fabricante
+// canônico usado nas buscas por aplicação
+type ManufacturerAlias struct {
+	Alias        string    `json:"alias" gorm:"column:alias;type:varchar(60);primary_key"`
+	Manufacturer string    `json:"manufacturer" gorm:"column:manufacturer;type:varchar(40)"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamp with time zone;default:current_timestamp"`
+}
+
+// TableName especifica o nome da tabela
+func (ManufacturerAlias) TableName() string {
+	return "partexplorer.manufacturer_alias"
+}
+
+// MissingManufacturerAlias é um fabricante das aplicações sem apelido cadastrado
+type MissingManufacturerAlias struct {
+	Manufacturer string `json:"manufacturer"`
+	Applications int64  `json:"applications"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
@@ -76,6 +77,8 @@ type Company struct {
 	Email        *string   `gorm:"size:255" json:"email,omitempty"`
 	Website      *string   `gorm:"size:255" json:"website,omitempty"`
 	GroupName    *string   `gorm:"size:255" json:"group_name,omitempty"`
+	CNPJ         *string   `gorm:"column:cnpj;size:14" json:"cnpj,omitempty"`
+	Version      int       `gorm:"not null;default:1" json:"version"`
 	CreatedAt    time.Time `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
 	UpdatedAt    time.Time `json:"updated_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
 
@@ -184,6 +187,8 @@ type Application struct {
 	Image          string    `gorm:"size:300" json:"image"`
 	CreatedAt      time.Time `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
 	UpdatedAt      time.Time `json:"updated_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+	// CanonicalManufacturer é mantido pelos triggers da migração 024; somente leitura
+	CanonicalManufacturer string `gorm:"column:canonical_manufacturer;->" json:"-"`
 }
 
 func (Application) TableName() string {
@@ -226,11 +231,101 @@ type SearchRequest struct {
 
 // SearchResponse - Resposta de busca
 type SearchResponse struct {
-	Results    []SearchResult `json:"results"`
-	Total      int64          `json:"total"`
-	Page       int            `json:"page"`
-	PageSize   int            `json:"page_size"`
-	TotalPages int            `json:"total_pages"`
-	Query      string         `json:"query"`
+	Results    []SearchResult          `json:"results"`
+	Total      int64                   `json:"total"`
+	Page       int                     `json:"page"`
+	PageSize   int                     `json:"page_size"`
+	TotalPages int                     `json:"total_pages"`
+	Query      string                  `json:"query"`
+	Facets     map[string][]FacetValue `json:"facets,omitempty"`
+}
+
+// FacetValue - Valor de um facet com a quantidade de grupos
+type FacetValue struct {
+	Value    string `json:"value"`
+	Count    int64  `json:"count"`
+	Selected bool   `json:"selected,omitempty"`
+}
+
+// Nomes dos facets da busca (também usados como query params, ver FacetParam)
+const (
+	FacetBrand       = "brand"
+	FacetFamily      = "family"
+	FacetSubfamily   = "subfamily"
+	FacetProductType = "product_type"
+	FacetState       = "state"
+	FacetCity        = "city"
+)
+
+// SearchFacets - Facets calculados na busca, na ordem de exibição
+var SearchFacets = []string{FacetBrand, FacetFamily, FacetSubfamily, FacetProductType, FacetState, FacetCity}
+
+// FacetParam retorna o query param do facet. state e city já são a localização do modo
+// "Onde encontrar", então os facets de localização usam facet_state e facet_city.
+func FacetParam(name string) string {
+	switch name {
+	case FacetState, FacetCity:
+		return "facet_" + name
+	}
+	return name
+}
+
+// ParseSearchFilters lê os facets selecionados da query (?brand=X&brand=Y&facet_state=SP)
+func ParseSearchFilters(query url.Values) SearchFilters {
+	filters := make(SearchFilters)
+	for _, name := range SearchFacets {
+		if values := query[FacetParam(name)]; len(values) > 0 {
+			filters[name] = values
+		}
+	}
+	return filters
+}
+
+// SearchFilters - Valores de facet selecionados: OR dentro do mesmo facet, AND entre facets
+type SearchFilters map[string][]string
+
+// IsEmpty indica se nenhum facet foi selecionado
+func (f SearchFilters) IsEmpty() bool {
+	for _, values := range f {
+		if len(values) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Except retorna os filtros sem o facet informado (contagem multi-seleção)
+func (f SearchFilters) Except(facet string) SearchFilters {
+	other := make(SearchFilters, len(f))
+	for name, values := range f {
+		if name != facet {
+			other[name] = values
+		}
+	}
+	return other
+}
+
+// MarkSelected marca nos facets os valores selecionados nos filtros
+func (f SearchFilters) MarkSelected(facets map[string][]FacetValue) {
+	for name, values := range facets {
+		for i := range values {
+			for _, selected := range f[name] {
+				if values[i].Value == selected {
+					values[i].Selected = true
+				}
+			}
+		}
+	}
+}
+
+// FitmentQuery - Veículo usado na busca por aplicação
+type FitmentQuery struct {
+	Manufacturer string `json:"manufacturer" form:"manufacturer"`
+	Model        string `json:"model" form:"model"`
+	Year         int    `json:"year" form:"year"`
+	Engine       string `json:"engine" form:"engine"`
+	Fuel         string `json:"fuel" form:"fuel"`
+	// VIN (chassi) decodificado localmente; preenche fabricante e ano quando não informados
+	VIN string `json:"vin,omitempty" form:"vin"`
 }
 
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status de um item de NF-e no estoque de uma empresa
+const (
+	// NFeItemPosted indica o item lançado no livro de movimentações
+	NFeItemPosted = "posted"
+	// NFeItemPending indica o item na fila de conciliação, aguardando revisão
+	NFeItemPending = "pending"
+	// NFeItemDiscarded indica o item descartado na revisão, sem movimentação
+	NFeItemDiscarded = "discarded"
+)
+
+// Motivos para um item ir para a fila de conciliação
+const (
+	NFeReasonNotFound          = "not_found"
+	NFeReasonAmbiguous         = "ambiguous"
+	NFeReasonFractional        = "fractional_quantity"
+	NFeReasonInsufficientStock = "insufficient_stock"
+)
+
+// NFeInvoice é uma NF-e de compra ou venda importada (nfe_invoice). A chave de acesso é única:
+// a mesma nota não movimenta o estoque duas vezes.
+type NFeInvoice struct {
+	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	AccessKey          string     `json:"access_key" gorm:"column:access_key;type:varchar(44);not null"`
+	Number             string     `json:"number" gorm:"column:number;type:varchar(20)"`
+	Series             string     `json:"series" gorm:"column:series;type:varchar(10)"`
+	Type               int        `json:"type" gorm:"column:type;not null"`
+	IssuedAt           time.Time  `json:"issued_at" gorm:"column:issued_at;type:timestamp with time zone"`
+	IssuerDocument     string     `json:"issuer_document" gorm:"column:issuer_document;type:varchar(14)"`
+	IssuerName         string     `json:"issuer_name" gorm:"column:issuer_name;type:varchar(255)"`
+	RecipientDocument  string     `json:"recipient_document,omitempty" gorm:"column:recipient_document;type:varchar(14)"`
+	RecipientName      string     `json:"recipient_name,omitempty" gorm:"column:recipient_name;type:varchar(255)"`
+	IssuerCompanyID    *uuid.UUID `json:"issuer_company_id,omitempty" gorm:"column:issuer_company_id;type:uuid"`
+	RecipientCompanyID *uuid.UUID `json:"recipient_company_id,omitempty" gorm:"column:recipient_company_id;type:uuid"`
+	Actor              string     `json:"actor" gorm:"column:actor;type:varchar(120);not null"`
+	// Posted e Pending contam os itens lançados e os que ficaram na fila de conciliação
+	Posted    int       `json:"posted" gorm:"column:posted;not null;default:0"`
+	Pending   int       `json:"pending" gorm:"column:pending;not null;default:0"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
+
+	Items []NFeItem `json:"items,omitempty" gorm:"foreignKey:InvoiceID"`
+}
+
+// TableName especifica o nome da tabela
+func (NFeInvoice) TableName() string {
+	return "partexplorer.nfe_invoice"
+}
+
+// NFeItem é um item da nota aplicado ao estoque de uma empresa (nfe_item). Uma nota entre duas
+// empresas cadastradas gera um item para cada uma: a venda de uma e a entrada da outra.
+type NFeItem struct {
+	ID           int64      `json:"id" gorm:"primary_key;autoIncrement"`
+	InvoiceID    uuid.UUID  `json:"invoice_id" gorm:"column:invoice_id;type:uuid;not null"`
+	ItemNumber   int        `json:"item_number" gorm:"column:item_number;not null"`
+	CompanyID    uuid.UUID  `json:"company_id" gorm:"column:company_id;type:uuid;not null"`
+	MovementType string     `json:"movement_type" gorm:"column:movement_type;type:varchar(20);not null"`
+	Code         string     `json:"code" gorm:"column:code;type:varchar(60)"`
+	EAN          string     `json:"ean,omitempty" gorm:"column:ean;type:varchar(14)"`
+	Description  string     `json:"description" gorm:"column:description;type:text"`
+	Unit         string     `json:"unit,omitempty" gorm:"column:unit;type:varchar(10)"`
+	Quantity     float64    `json:"quantity" gorm:"column:quantity;type:numeric(15,4);not null"`
+	UnitPrice    float64    `json:"unit_price" gorm:"column:unit_price;type:numeric(21,10)"`
+	Status       string     `json:"status" gorm:"column:status;type:varchar(20);not null"`
+	Reason       string     `json:"reason,omitempty" gorm:"column:reason;type:varchar(40)"`
+	Details      string     `json:"details,omitempty" gorm:"column:details;type:text"`
+	PartNameID   *uuid.UUID `json:"part_name_id,omitempty" gorm:"column:part_name_id;type:uuid"`
+	StockID      *uuid.UUID `json:"stock_id,omitempty" gorm:"column:stock_id;type:uuid"`
+	MovementID   *int64     `json:"movement_id,omitempty" gorm:"column:movement_id"`
+	ResolvedBy   string     `json:"resolved_by,omitempty" gorm:"column:resolved_by;type:varchar(120)"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty" gorm:"column:resolved_at;type:timestamp with time zone"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
+}
+
+// TableName especifica o nome da tabela
+func (NFeItem) TableName() string {
+	return "partexplorer.nfe_item"
+}
+
+// NFeResolveRequest representa a revisão de um item da fila de conciliação. Sem PartNameID o
+// casamento por EAN/SKU é refeito (depois do cadastro do código, por exemplo); Quantity
+// substitui a quantidade da nota, para itens fracionários ou em outra unidade.
+type NFeResolveRequest struct {
+	PartNameID string `json:"part_name_id,omitempty"`
+	Quantity   *int   `json:"quantity,omitempty"`
+	Actor      string `json:"actor"`
+}
+
+// NFeInvoiceListResponse representa a lista de notas importadas
+type NFeInvoiceListResponse struct {
+	Invoices   []NFeInvoice `json:"invoices"`
+	Total      int64        `json:"total"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"page_size"`
+	TotalPages int          `json:"total_pages"`
+}
+
+// NFeItemListResponse representa a fila de conciliação dos itens de NF-e
+type NFeItemListResponse struct {
+	Items      []NFeItem `json:"items"`
+	Total      int64     `json:"total"`
+	Page       int       `json:"page"`
+	PageSize   int       `json:"page_size"`
+	TotalPages int       `json:"total_pages"`
+}
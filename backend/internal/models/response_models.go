@@ -113,11 +113,12 @@ type CleanSearchResult struct {
 
 // CleanSearchResponse - Resposta de busca limpa
 type CleanSearchResponse struct {
-	Results    []CleanSearchResult `json:"results"`
-	Total      int64               `json:"total"`
-	Page       int                 `json:"page"`
-	PageSize   int                 `json:"page_size"`
-	TotalPages int                 `json:"total_pages"`
-	Query      string              `json:"query"`
+	Results    []CleanSearchResult     `json:"results"`
+	Total      int64                   `json:"total"`
+	Page       int                     `json:"page"`
+	PageSize   int                     `json:"page_size"`
+	TotalPages int                     `json:"total_pages"`
+	Query      string                  `json:"query"`
+	Facets     map[string][]FacetValue `json:"facets,omitempty"`
 }
 
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SearchOutbox representa um evento de alteração pendente de indexação
+type SearchOutbox struct {
+	ID            int64      `json:"id" gorm:"primary_key;autoIncrement"`
+	GroupID       uuid.UUID  `json:"group_id" gorm:"column:group_id;type:uuid;not null"`
+	SourceTable   string     `json:"source_table" gorm:"column:source_table;type:varchar(64)"`
+	Operation     string     `json:"operation" gorm:"column:operation;type:varchar(10)"`
+	Attempts      int        `json:"attempts" gorm:"column:attempts;default:0"`
+	LastError     *string    `json:"last_error,omitempty" gorm:"column:last_error;type:text"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"column:next_attempt_at;type:timestamp with time zone;default:current_timestamp"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty" gorm:"column:processed_at;type:timestamp with time zone"`
+	FailedAt      *time.Time `json:"failed_at,omitempty" gorm:"column:failed_at;type:timestamp with time zone"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
+}
+
+// TableName especifica o nome da tabela
+func (SearchOutbox) TableName() string {
+	return "partexplorer.search_outbox"
+}
+
+// SearchOutboxStats resume o atraso da fila de indexação incremental
+type SearchOutboxStats struct {
+	Pending         int64      `json:"pending"`
+	Retrying        int64      `json:"retrying"`
+	Failed          int64      `json:"failed"`
+	OldestPendingAt *time.Time `json:"oldest_pending_at,omitempty"`
+	LagSeconds      float64    `json:"lag_seconds"`
+	LastProcessedAt *time.Time `json:"last_processed_at,omitempty"`
+}
@@ -14,6 +14,7 @@ type Stock struct {
 	Quantity   *int      `json:"quantity" gorm:"type:int"`
 	Price      *float64  `json:"price" gorm:"type:float"`
 	Obsolete   bool      `json:"obsolete" gorm:"default:false"`
+	Version    int       `json:"version" gorm:"not null;default:1"`
 	CreatedAt  time.Time `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
 	UpdatedAt  time.Time `json:"updated_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
 
@@ -34,6 +35,7 @@ type StockResponse struct {
 	CompanyID  string   `json:"company_id"`
 	Quantity   *int     `json:"quantity,omitempty"`
 	Price      *float64 `json:"price,omitempty"`
+	Version    int      `json:"version"`
 	CreatedAt  string   `json:"created_at"`
 	UpdatedAt  string   `json:"updated_at"`
 
@@ -58,13 +60,18 @@ type CreateStockRequest struct {
 	CompanyID  string   `json:"company_id" binding:"required"`
 	Quantity   *int     `json:"quantity,omitempty"`
 	Price      *float64 `json:"price,omitempty"`
+	// Actor é quem cadastra; a quantidade inicial entra no livro como ajuste
+	Actor string `json:"actor,omitempty"`
 }
 
-// UpdateStockRequest representa a requisição para atualizar estoque
+// UpdateStockRequest representa a requisição para atualizar estoque. A quantidade informada
+// é tratada como contagem física: a diferença entra no livro como ajuste, com Actor e Reason.
 type UpdateStockRequest struct {
 	CompanyID *string  `json:"company_id,omitempty"`
 	Quantity  *int     `json:"quantity,omitempty"`
 	Price     *float64 `json:"price,omitempty"`
+	Actor     string   `json:"actor,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
 }
 
 // StockListResponse representa a resposta da API para lista de estoque
@@ -92,6 +99,7 @@ type CompanyResponse struct {
 	Mobile       *string `json:"mobile,omitempty"`
 	Email        *string `json:"email,omitempty"`
 	Website      *string `json:"website,omitempty"`
+	Version      int     `json:"version"`
 	CreatedAt    string  `json:"created_at"`
 	UpdatedAt    string  `json:"updated_at"`
 }
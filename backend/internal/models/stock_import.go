@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Modos de importação do arquivo de estoque de um distribuidor
+const (
+	// StockImportMerge atualiza apenas os itens presentes no arquivo
+	StockImportMerge = "merge"
+	// StockImportReplace trata o arquivo como o estoque completo: os itens da empresa que não
+	// estão no arquivo ficam com quantidade zero
+	StockImportReplace = "replace"
+)
+
+// Status de um job de importação de estoque
+const (
+	StockImportPending   = "pending"
+	StockImportRunning   = "running"
+	StockImportCompleted = "completed"
+	StockImportFailed    = "failed"
+	StockImportCancelled = "cancelled"
+)
+
+// Status de uma linha do arquivo importado
+const (
+	StockImportLinePending   = "pending"
+	StockImportLineCreated   = "created"
+	StockImportLineUpdated   = "updated"
+	StockImportLineUnchanged = "unchanged"
+	StockImportLineError     = "error"
+)
+
+// StockImportJob é a importação de um arquivo de estoque (stock_import_job). As linhas são
+// processadas em lotes; o job pode ser retomado depois de uma falha ou reinício do servidor.
+type StockImportJob struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	CompanyID      uuid.UUID `json:"company_id" gorm:"column:company_id;type:uuid;not null"`
+	Mode           string    `json:"mode" gorm:"column:mode;type:varchar(20);not null"`
+	Format         string    `json:"format" gorm:"column:format;type:varchar(10);not null"`
+	FileName       string    `json:"file_name,omitempty" gorm:"column:file_name;type:varchar(255)"`
+	Actor          string    `json:"actor" gorm:"column:actor;type:varchar(120);not null"`
+	Status         string    `json:"status" gorm:"column:status;type:varchar(20);not null"`
+	TotalLines     int       `json:"total_lines" gorm:"column:total_lines;not null;default:0"`
+	ProcessedLines int       `json:"processed_lines" gorm:"column:processed_lines;not null;default:0"`
+	Created        int       `json:"created" gorm:"column:created;not null;default:0"`
+	Updated        int       `json:"updated" gorm:"column:updated;not null;default:0"`
+	Unchanged      int       `json:"unchanged" gorm:"column:unchanged;not null;default:0"`
+	// Zeroed são os estoques fora do arquivo zerados no modo replace
+	Zeroed     int        `json:"zeroed" gorm:"column:zeroed;not null;default:0"`
+	Errors     int        `json:"errors" gorm:"column:errors;not null;default:0"`
+	Message    string     `json:"message,omitempty" gorm:"column:message;type:text"`
+	StartedAt  *time.Time `json:"started_at,omitempty" gorm:"column:started_at;type:timestamp with time zone"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" gorm:"column:finished_at;type:timestamp with time zone"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"column:updated_at;type:timestamp with time zone;default:current_timestamp"`
+}
+
+// TableName especifica o nome da tabela
+func (StockImportJob) TableName() string {
+	return "partexplorer.stock_import_job"
+}
+
+// Finished indica se o job terminou (concluído, com falha ou cancelado)
+func (j *StockImportJob) Finished() bool {
+	return j.Status == StockImportCompleted || j.Status == StockImportFailed || j.Status == StockImportCancelled
+}
+
+// StockImportLine é uma linha do arquivo importado, com o resultado do processamento
+// (stock_import_line). Os campos nil não foram informados e não alteram o estoque.
+type StockImportLine struct {
+	JobID      uuid.UUID  `json:"job_id" gorm:"column:job_id;type:uuid;primary_key"`
+	Line       int        `json:"line" gorm:"column:line;primary_key"`
+	SKU        string     `json:"sku,omitempty" gorm:"column:sku;type:varchar(255)"`
+	EAN        string     `json:"ean,omitempty" gorm:"column:ean;type:varchar(255)"`
+	Brand      string     `json:"brand,omitempty" gorm:"column:brand;type:varchar(255)"`
+	Quantity   *int       `json:"quantity,omitempty" gorm:"column:quantity;type:int"`
+	Price      *float64   `json:"price,omitempty" gorm:"column:price;type:float"`
+	Obsolete   *bool      `json:"obsolete,omitempty" gorm:"column:obsolete"`
+	Status     string     `json:"status" gorm:"column:status;type:varchar(20);not null"`
+	Error      string     `json:"error,omitempty" gorm:"column:error;type:text"`
+	PartNameID *uuid.UUID `json:"part_name_id,omitempty" gorm:"column:part_name_id;type:uuid"`
+	StockID    *uuid.UUID `json:"stock_id,omitempty" gorm:"column:stock_id;type:uuid"`
+}
+
+// TableName especifica o nome da tabela
+func (StockImportLine) TableName() string {
+	return "partexplorer.stock_import_line"
+}
+
+// StockImportJobListResponse representa a lista de importações de estoque
+type StockImportJobListResponse struct {
+	Jobs       []StockImportJob `json:"jobs"`
+	Total      int64            `json:"total"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalPages int              `json:"total_pages"`
+}
+
+// StockImportLineListResponse representa as linhas de uma importação (o relatório de erros)
+type StockImportLineListResponse struct {
+	Lines      []StockImportLine `json:"lines"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+}
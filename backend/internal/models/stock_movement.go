@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tipos de movimentação de estoque
+const (
+	// StockMovementReceipt é a entrada de mercadoria (quantidade positiva)
+	StockMovementReceipt = "receipt"
+	// StockMovementSale é a saída por venda (quantidade negativa)
+	StockMovementSale = "sale"
+	// StockMovementAdjustment é a correção manual ou por contagem física (positiva ou negativa)
+	StockMovementAdjustment = "adjustment"
+	// StockMovementTransfer é a transferência entre dois estoques do mesmo SKU; gera uma
+	// movimentação negativa na origem e uma positiva no destino, com o mesmo TransferID
+	StockMovementTransfer = "transfer"
+)
+
+// StockMovementTypes são os tipos aceitos na API
+var StockMovementTypes = []string{StockMovementReceipt, StockMovementSale, StockMovementAdjustment, StockMovementTransfer}
+
+// StockMovement é uma linha imutável do livro de movimentações de estoque (stock_movement).
+// A quantidade do estoque é a soma das movimentações da linha.
+type StockMovement struct {
+	ID      int64     `json:"id" gorm:"primary_key;autoIncrement"`
+	StockID uuid.UUID `json:"stock_id" gorm:"column:stock_id;type:uuid;not null"`
+	Type    string    `json:"type" gorm:"column:type;type:varchar(20);not null"`
+	// Quantity é a variação com sinal; QuantityAfter é o saldo depois da movimentação
+	Quantity      int        `json:"quantity" gorm:"column:quantity;type:int;not null"`
+	QuantityAfter int        `json:"quantity_after" gorm:"column:quantity_after;type:int;not null"`
+	Actor         string     `json:"actor" gorm:"column:actor;type:varchar(120);not null"`
+	Reason        string     `json:"reason,omitempty" gorm:"column:reason;type:text"`
+	Reference     string     `json:"reference,omitempty" gorm:"column:reference;type:varchar(120)"`
+	TransferID    *uuid.UUID `json:"transfer_id,omitempty" gorm:"column:transfer_id;type:uuid"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
+}
+
+// TableName especifica o nome da tabela
+func (StockMovement) TableName() string {
+	return "partexplorer.stock_movement"
+}
+
+// StockMovementRequest representa a requisição para registrar uma movimentação. Quantity é
+// sempre positiva para entrada, venda e transferência; no ajuste tem sinal, ou se informa
+// Counted, a quantidade contada, e a diferença para o saldo vira a movimentação.
+type StockMovementRequest struct {
+	Type          string `json:"type" binding:"required"`
+	Quantity      int    `json:"quantity"`
+	Counted       *int   `json:"counted,omitempty"`
+	Actor         string `json:"actor"`
+	Reason        string `json:"reason"`
+	Reference     string `json:"reference"`
+	TargetStockID string `json:"target_stock_id,omitempty"`
+}
+
+// StockQuantityChangeRequest representa um incremento ou decremento atômico da quantidade. O
+// tipo padrão é receipt no incremento e sale no decremento; adjustment exige Reason.
+type StockQuantityChangeRequest struct {
+	Amount    int    `json:"amount" binding:"required,min=1"`
+	Type      string `json:"type,omitempty"`
+	Actor     string `json:"actor"`
+	Reason    string `json:"reason"`
+	Reference string `json:"reference"`
+}
+
+// StockMovementListResponse representa o histórico de movimentações de um estoque
+type StockMovementListResponse struct {
+	Movements  []StockMovement `json:"movements"`
+	Total      int64           `json:"total"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+	TotalPages int             `json:"total_pages"`
+}
+
+// StockReconciliation compara a quantidade gravada no estoque com a soma do livro
+type StockReconciliation struct {
+	StockID        uuid.UUID `json:"stock_id"`
+	Quantity       int       `json:"quantity"`
+	LedgerQuantity int       `json:"ledger_quantity"`
+	Difference     int       `json:"difference"`
+	Movements      int64     `json:"movements"`
+}
+
+// Lado que prevalece na conciliação
+const (
+	// StockReconcileKeepLedger grava no estoque a soma do livro
+	StockReconcileKeepLedger = "ledger"
+	// StockReconcileKeepQuantity registra um ajuste para o livro chegar à quantidade do estoque
+	StockReconcileKeepQuantity = "quantity"
+)
+
+// StockReconcileRequest representa a requisição para conciliar um estoque com o livro
+type StockReconcileRequest struct {
+	Keep   string `json:"keep"`
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+// StockReconciliationListResponse lista os estoques cuja quantidade diverge do livro
+type StockReconciliationListResponse struct {
+	Stocks     []StockReconciliation `json:"stocks"`
+	Total      int64                 `json:"total"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"page_size"`
+	TotalPages int                   `json:"total_pages"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockPriceHistory é uma foto do preço de um estoque (stock_price_history), gravada pelo
+// banco a cada alteração do preço
+type StockPriceHistory struct {
+	ID            int64     `json:"id" gorm:"primary_key;autoIncrement"`
+	StockID       uuid.UUID `json:"stock_id" gorm:"column:stock_id;type:uuid;not null"`
+	PartNameID    uuid.UUID `json:"part_name_id" gorm:"column:part_name_id;type:uuid;not null"`
+	CompanyID     uuid.UUID `json:"company_id" gorm:"column:company_id;type:uuid;not null"`
+	Price         *float64  `json:"price" gorm:"column:price"`
+	PreviousPrice *float64  `json:"previous_price,omitempty" gorm:"column:previous_price"`
+	ChangedAt     time.Time `json:"changed_at" gorm:"column:changed_at;type:timestamp with time zone;default:current_timestamp"`
+}
+
+// TableName especifica o nome da tabela
+func (StockPriceHistory) TableName() string {
+	return "partexplorer.stock_price_history"
+}
+
+// StockPriceHistoryListResponse representa o histórico de preços de um estoque
+type StockPriceHistoryListResponse struct {
+	Prices     []StockPriceHistory `json:"prices"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// PriceFilter restringe as ofertas usadas nas estatísticas e no ranking. Estoques obsoletos
+// ou sem preço nunca entram.
+type PriceFilter struct {
+	State   string
+	City    string
+	InStock bool // só estoques com quantidade positiva
+}
+
+// PriceStats resume os preços de uma região: State e City vazios no total geral, City vazia
+// no resumo do estado
+type PriceStats struct {
+	State  string  `json:"state,omitempty"`
+	City   string  `json:"city,omitempty"`
+	Offers int64   `json:"offers"`
+	Min    float64 `json:"min"`
+	Median float64 `json:"median"`
+	Max    float64 `json:"max"`
+}
+
+// PartGroupPriceStats representa as estatísticas de preço das peças de um grupo
+type PartGroupPriceStats struct {
+	GroupID string       `json:"group_id"`
+	Overall *PriceStats  `json:"overall"`
+	ByState []PriceStats `json:"by_state"`
+	ByCity  []PriceStats `json:"by_city"`
+}
+
+// StockOffer é uma oferta do SKU no ranking por preço, com os dados de contato da empresa
+type StockOffer struct {
+	Rank int `json:"rank"`
+	StockResponse
+	CompanyCity  *string `json:"company_city,omitempty"`
+	CompanyState *string `json:"company_state,omitempty"`
+}
+
+// StockOfferListResponse representa o ranking de ofertas de um SKU, do menor preço ao maior
+type StockOfferListResponse struct {
+	PartNameID string       `json:"part_name_id"`
+	Offers     []StockOffer `json:"offers"`
+	Total      int64        `json:"total"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"page_size"`
+	TotalPages int          `json:"total_pages"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "math"
+
+// Tipos de sugestão do autocomplete
+const (
+	SuggestionSKU          = "sku"
+	SuggestionBrand        = "brand"
+	SuggestionProductType  = "product_type"
+	SuggestionVehicleModel = "vehicle_model"
+)
+
+// SuggestionTypes lista os tipos de sugestão, na ordem de desempate
+var SuggestionTypes = []string{SuggestionSKU, SuggestionBrand, SuggestionProductType, SuggestionVehicleModel}
+
+// SuggestionSearchWeight é o peso de cada busca registrada na popularidade de uma sugestão
+const SuggestionSearchWeight = 10
+
+// Suggestion - Sugestão tipada do autocomplete
+type Suggestion struct {
+	Text  string  `json:"text"`
+	Type  string  `json:"type"`
+	Score float64 `json:"score"`
+}
+
+// SuggestionCandidate - Termo do catálogo que pode ser sugerido, com sua popularidade
+type SuggestionCandidate struct {
+	Type         string
+	Text         string
+	CatalogCount int64    // Quantidade de grupos com o termo
+	Searches     int64    // Buscas registradas pelo termo
+	States       []string // Estados com estoque disponível
+}
+
+// Weight calcula a popularidade do candidato: ocorrências no catálogo mais as buscas
+// registradas, com peso maior para as buscas. Usa a mesma fórmula do fallback SQL.
+func (c SuggestionCandidate) Weight() int {
+	weight := c.CatalogCount + SuggestionSearchWeight*c.Searches
+	if weight > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(weight)
+}
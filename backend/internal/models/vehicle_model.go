@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status do mapeamento entre o modelo do provedor de placa e o catálogo
+const (
+	// VehicleModelMappingAuto foi preenchido pela correspondência aproximada com confiança suficiente
+	VehicleModelMappingAuto = "auto"
+	// VehicleModelMappingPending aguarda revisão: confiança baixa ou nenhum candidato no catálogo
+	VehicleModelMappingPending = "pending"
+	// VehicleModelMappingConfirmed teve o palpite automático confirmado na revisão
+	VehicleModelMappingConfirmed = "confirmed"
+	// VehicleModelMappingCorrected foi corrigido manualmente na revisão
+	VehicleModelMappingCorrected = "corrected"
+)
+
+// VehicleModelMapping associa a marca/modelo informados pelo provedor de placa (ex. "VW" /
+// "GOL 1.0 GIV") ao fabricante/modelo/versão/motor das aplicações do catálogo
+type VehicleModelMapping struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	SourceBrand  string     `json:"source_brand" gorm:"column:source_brand;type:varchar(80)"`
+	SourceModel  string     `json:"source_model" gorm:"column:source_model;type:varchar(255)"`
+	Manufacturer string     `json:"manufacturer" gorm:"column:manufacturer;type:varchar(40)"`
+	Model        string     `json:"model" gorm:"column:model;type:varchar(60)"`
+	Version      string     `json:"version" gorm:"column:version;type:varchar(40)"`
+	Engine       string     `json:"engine" gorm:"column:engine;type:varchar(40)"`
+	Confidence   float64    `json:"confidence" gorm:"column:confidence;type:numeric(4,3)"`
+	Status       string     `json:"status" gorm:"column:status;type:varchar(20)"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty" gorm:"column:reviewed_at;type:timestamp with time zone"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"column:created_at;type:timestamp with time zone;default:current_timestamp"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"column:updated_at;type:timestamp with time zone;default:current_timestamp"`
+}
+
+// TableName especifica o nome da tabela
+func (VehicleModelMapping) TableName() string {
+	return "partexplorer.vehicle_model_mapping"
+}
+
+// Usable indica se o mapeamento pode ser usado na busca de peças: tem modelo e não está
+// aguardando revisão
+func (m *VehicleModelMapping) Usable() bool {
+	return m != nil && m.Model != "" && m.Status != VehicleModelMappingPending
+}
+
+// VehicleModelMappingListResponse representa a fila de revisão paginada
+type VehicleModelMappingListResponse struct {
+	Mappings   []VehicleModelMapping `json:"mappings"`
+	Total      int64                 `json:"total"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"page_size"`
+	TotalPages int                   `json:"total_pages"`
+}
@@ -0,0 +1,286 @@
+package nfe
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"partexplorer/backend/internal/models"
+)
+
+// Tipo da operação da nota (ide/tpNF)
+const (
+	TypeInbound  = 0 // entrada
+	TypeOutbound = 1 // saída
+)
+
+// Lado da empresa cadastrada na nota
+const (
+	SideIssuer    = "issuer"
+	SideRecipient = "recipient"
+)
+
+// withoutGTIN é o valor de cEAN para produtos sem código de barras
+const withoutGTIN = "SEM GTIN"
+
+var (
+	// ErrInvalidNFe indica um XML que não é uma NF-e ou sem os campos obrigatórios
+	ErrInvalidNFe = errors.New("invalid NF-e")
+	// ErrNotAuthorized indica uma NF-e com protocolo de autorização recusado (cStat diferente de 100/150)
+	ErrNotAuthorized = errors.New("NF-e not authorized")
+)
+
+// Invoice é a NF-e lida do XML, com apenas os campos usados no estoque
+type Invoice struct {
+	AccessKey string
+	Number    string
+	Series    string
+	Type      int
+	IssuedAt  time.Time
+	Issuer    Party
+	Recipient Party
+	// Status é o cStat do protocolo de autorização, vazio quando o XML não traz o protocolo
+	Status string
+	Items  []Item
+}
+
+// Party é o emitente ou o destinatário da nota
+type Party struct {
+	Document string // CNPJ ou CPF, apenas dígitos
+	Name     string
+}
+
+// Item é um produto da nota (det/prod)
+type Item struct {
+	Number      int
+	Code        string // cProd, o código do produto no emitente
+	EAN         string // cEAN, ou cEANTrib quando vazio; vazio para "SEM GTIN"
+	Description string
+	Unit        string
+	Quantity    float64
+	UnitPrice   float64
+}
+
+// Estrutura do XML: a raiz pode ser nfeProc (nota com protocolo) ou NFe
+
+type xmlParty struct {
+	CNPJ string `xml:"CNPJ"`
+	CPF  string `xml:"CPF"`
+	Name string `xml:"xNome"`
+}
+
+type xmlInfNFe struct {
+	ID  string `xml:"Id,attr"`
+	Ide struct {
+		Number   string `xml:"nNF"`
+		Series   string `xml:"serie"`
+		Type     string `xml:"tpNF"`
+		IssuedAt string `xml:"dhEmi"`
+		Date     string `xml:"dEmi"`
+	} `xml:"ide"`
+	Emit xmlParty `xml:"emit"`
+	Dest xmlParty `xml:"dest"`
+	Det  []struct {
+		Number string `xml:"nItem,attr"`
+		Prod   struct {
+			Code        string `xml:"cProd"`
+			EAN         string `xml:"cEAN"`
+			EANTrib     string `xml:"cEANTrib"`
+			Description string `xml:"xProd"`
+			Unit        string `xml:"uCom"`
+			Quantity    string `xml:"qCom"`
+			UnitPrice   string `xml:"vUnCom"`
+		} `xml:"prod"`
+	} `xml:"det"`
+}
+
+type xmlDocument struct {
+	InfNFe *xmlInfNFe `xml:"infNFe"`
+	NFe    *struct {
+		InfNFe *xmlInfNFe `xml:"infNFe"`
+	} `xml:"NFe"`
+	Protocol *struct {
+		Status string `xml:"infProt>cStat"`
+	} `xml:"protNFe"`
+}
+
+// Parse lê o XML da NF-e (nfeProc ou NFe, versões 3.10 e 4.00). Notas com protocolo de
+// autorização recusado retornam ErrNotAuthorized.
+func Parse(r io.Reader) (*Invoice, error) {
+	var doc xmlDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNFe, err)
+	}
+
+	inf := doc.InfNFe
+	if inf == nil && doc.NFe != nil {
+		inf = doc.NFe.InfNFe
+	}
+	if inf == nil {
+		return nil, fmt.Errorf("%w: infNFe not found", ErrInvalidNFe)
+	}
+
+	invoice := &Invoice{
+		AccessKey: digits(inf.ID),
+		Number:    strings.TrimSpace(inf.Ide.Number),
+		Series:    strings.TrimSpace(inf.Ide.Series),
+		Issuer:    party(inf.Emit),
+		Recipient: party(inf.Dest),
+	}
+	if len(invoice.AccessKey) != 44 {
+		return nil, fmt.Errorf("%w: access key %q must have 44 digits", ErrInvalidNFe, inf.ID)
+	}
+	if doc.Protocol != nil {
+		invoice.Status = strings.TrimSpace(doc.Protocol.Status)
+		if invoice.Status != "100" && invoice.Status != "150" {
+			return nil, fmt.Errorf("%w: cStat %s", ErrNotAuthorized, invoice.Status)
+		}
+	}
+
+	switch strings.TrimSpace(inf.Ide.Type) {
+	case "0":
+		invoice.Type = TypeInbound
+	case "1":
+		invoice.Type = TypeOutbound
+	default:
+		return nil, fmt.Errorf("%w: tpNF %q", ErrInvalidNFe, inf.Ide.Type)
+	}
+
+	issuedAt, err := parseDate(inf.Ide.IssuedAt, inf.Ide.Date)
+	if err != nil {
+		return nil, err
+	}
+	invoice.IssuedAt = issuedAt
+
+	if invoice.Issuer.Document == "" {
+		return nil, fmt.Errorf("%w: issuer CNPJ not found", ErrInvalidNFe)
+	}
+	if len(inf.Det) == 0 {
+		return nil, fmt.Errorf("%w: no items", ErrInvalidNFe)
+	}
+
+	for i, det := range inf.Det {
+		item := Item{
+			Number:      i + 1,
+			Code:        strings.TrimSpace(det.Prod.Code),
+			EAN:         gtin(det.Prod.EAN),
+			Description: strings.TrimSpace(det.Prod.Description),
+			Unit:        strings.TrimSpace(det.Prod.Unit),
+		}
+		if number, err := strconv.Atoi(det.Number); err == nil && number > 0 {
+			item.Number = number
+		}
+		if item.EAN == "" {
+			item.EAN = gtin(det.Prod.EANTrib)
+		}
+		if item.Quantity, err = strconv.ParseFloat(strings.TrimSpace(det.Prod.Quantity), 64); err != nil || item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: item %d has invalid qCom %q", ErrInvalidNFe, item.Number, det.Prod.Quantity)
+		}
+		if price := strings.TrimSpace(det.Prod.UnitPrice); price != "" {
+			if item.UnitPrice, err = strconv.ParseFloat(price, 64); err != nil {
+				return nil, fmt.Errorf("%w: item %d has invalid vUnCom %q", ErrInvalidNFe, item.Number, det.Prod.UnitPrice)
+			}
+		}
+		invoice.Items = append(invoice.Items, item)
+	}
+	return invoice, nil
+}
+
+// MovementType é o tipo da movimentação de estoque da empresa no lado informado: numa nota
+// de saída o emitente vende e o destinatário recebe; numa nota de entrada, o contrário.
+func MovementType(invoiceType int, side string) string {
+	if (invoiceType == TypeOutbound) == (side == SideIssuer) {
+		return models.StockMovementSale
+	}
+	return models.StockMovementReceipt
+}
+
+// WholeQuantity converte a quantidade do item para unidades inteiras do estoque; ok é false
+// para quantidades fracionárias (metros, litros), que precisam de revisão
+func WholeQuantity(quantity float64) (int, bool) {
+	if quantity <= 0 || quantity != math.Trunc(quantity) || quantity > math.MaxInt32 {
+		return 0, false
+	}
+	return int(quantity), true
+}
+
+// NormalizeDocument deixa apenas os dígitos do CNPJ ou CPF
+func NormalizeDocument(document string) string {
+	return digits(document)
+}
+
+// ValidCNPJ confere o tamanho e os dígitos verificadores do CNPJ (apenas dígitos)
+func ValidCNPJ(cnpj string) bool {
+	if len(cnpj) != 14 || strings.Count(cnpj, cnpj[:1]) == 14 {
+		return false
+	}
+	for _, r := range cnpj {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	check := func(length int) byte {
+		weights := []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}[13-length:]
+		sum := 0
+		for i := 0; i < length; i++ {
+			sum += int(cnpj[i]-'0') * weights[i]
+		}
+		if rest := sum % 11; rest >= 2 {
+			return byte('0' + 11 - rest)
+		}
+		return '0'
+	}
+	return check(12) == cnpj[12] && check(13) == cnpj[13]
+}
+
+func party(p xmlParty) Party {
+	document := digits(p.CNPJ)
+	if document == "" {
+		document = digits(p.CPF)
+	}
+	return Party{Document: document, Name: strings.TrimSpace(p.Name)}
+}
+
+// gtin normaliza o cEAN, descartando "SEM GTIN"
+func gtin(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, withoutGTIN) {
+		return ""
+	}
+	return value
+}
+
+// parseDate lê dhEmi (4.00, com fuso) ou dEmi (3.10, só a data)
+func parseDate(dateTime, date string) (time.Time, error) {
+	if value := strings.TrimSpace(dateTime); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: invalid dhEmi %q", ErrInvalidNFe, value)
+		}
+		return parsed, nil
+	}
+	if value := strings.TrimSpace(date); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: invalid dEmi %q", ErrInvalidNFe, value)
+		}
+		return parsed, nil
+	}
+	return time.Time{}, fmt.Errorf("%w: issue date not found", ErrInvalidNFe)
+}
+
+func digits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package partcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tipos de part_name que representam códigos
+const (
+	TypeSKU = "sku"
+	TypeEAN = "ean"
+)
+
+// gtinLength é o tamanho do GTIN-14, forma canônica usada para comparar EANs
+const gtinLength = 14
+
+// Normalize normaliza um SKU: apenas letras e dígitos ASCII, em maiúsculas.
+// Deve permanecer equivalente a partexplorer.normalize_part_code (migration 010).
+func Normalize(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// NormalizeGTIN normaliza um EAN para GTIN-14: apenas dígitos, completados com zeros à
+// esquerda, de forma que "7891234567895" e "07891234567895" sejam iguais.
+// Deve permanecer equivalente a partexplorer.normalize_part_code (migration 010).
+func NormalizeGTIN(code string) string {
+	digits := onlyDigits(code)
+	if digits == "" {
+		return ""
+	}
+	if len(digits) < gtinLength {
+		digits = strings.Repeat("0", gtinLength-len(digits)) + digits
+	}
+	return digits
+}
+
+// NormalizeByType normaliza o código conforme o tipo do part_name; descrições retornam vazio
+func NormalizeByType(code, nameType string) string {
+	switch strings.ToLower(nameType) {
+	case TypeSKU:
+		return Normalize(code)
+	case TypeEAN:
+		return NormalizeGTIN(code)
+	default:
+		return ""
+	}
+}
+
+// LooksLikeGTIN indica se o código tem o formato de um GTIN (8, 12, 13 ou 14 dígitos,
+// admitindo espaços e hífens como separadores)
+func LooksLikeGTIN(code string) bool {
+	for _, r := range code {
+		if (r < '0' || r > '9') && r != ' ' && r != '-' {
+			return false
+		}
+	}
+	_, ok := gtinFormats[len(onlyDigits(code))]
+	return ok
+}
+
+// Candidates retorna as formas normalizadas sob as quais o código pode estar cadastrado
+func Candidates(code string) []string {
+	var candidates []string
+	if sku := Normalize(code); sku != "" {
+		candidates = append(candidates, sku)
+	}
+	if LooksLikeGTIN(code) {
+		if gtin := NormalizeGTIN(code); gtin != "" && (len(candidates) == 0 || gtin != candidates[0]) {
+			candidates = append(candidates, gtin)
+		}
+	}
+	return candidates
+}
+
+var gtinFormats = map[int]string{
+	8:  "EAN-8",
+	12: "UPC-A",
+	13: "EAN-13",
+	14: "GTIN-14",
+}
+
+// ValidateGTIN valida formato e dígito verificador (módulo 10 do GS1) de um GTIN e
+// retorna o formato detectado (EAN-8, UPC-A, EAN-13 ou GTIN-14)
+func ValidateGTIN(code string) (string, error) {
+	if !LooksLikeGTIN(code) {
+		return "", fmt.Errorf("code must have 8, 12, 13 or 14 digits")
+	}
+
+	digits := onlyDigits(code)
+	format := gtinFormats[len(digits)]
+
+	expected := CheckDigit(digits[:len(digits)-1])
+	if actual := int(digits[len(digits)-1] - '0'); actual != expected {
+		return format, fmt.Errorf("invalid check digit: expected %d, got %d", expected, actual)
+	}
+
+	return format, nil
+}
+
+// CheckDigit calcula o dígito verificador GS1 para o código sem o último dígito
+func CheckDigit(payload string) int {
+	sum := 0
+	// Da direita para a esquerda, os pesos alternam 3 e 1 começando por 3
+	for i := 0; i < len(payload); i++ {
+		digit := int(payload[len(payload)-1-i] - '0')
+		if i%2 == 0 {
+			sum += digit * 3
+		} else {
+			sum += digit
+		}
+	}
+	return (10 - sum%10) % 10
+}
+
+// onlyDigits remove tudo que não for dígito
+func onlyDigits(code string) string {
+	var b strings.Builder
+	for _, r := range code {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package plate
+
+import (
+	"errors"
+	"strings"
+)
+
+// Formatos de placa brasileira
+const (
+	// FormatLegacy é o padrão anterior ao Mercosul: três letras e quatro dígitos (ABC1234)
+	FormatLegacy = "legacy"
+	// FormatMercosul é o padrão Mercosul: três letras, dígito, letra e dois dígitos (ABC1D23)
+	FormatMercosul = "mercosul"
+)
+
+// ErrInvalid indica que o texto não é uma placa no padrão antigo nem no Mercosul
+var ErrInvalid = errors.New("invalid plate: expected ABC1234 or ABC1D23")
+
+// Plate é uma placa validada, na forma canônica (maiúsculas, sem separadores)
+type Plate struct {
+	Canonical string `json:"canonical"`
+	Format    string `json:"format"`
+}
+
+// Normalize mantém apenas letras e dígitos ASCII, em maiúsculas ("abc-1234" -> "ABC1234").
+// Não valida o formato.
+func Normalize(plate string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(plate) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Parse normaliza e valida a placa nos padrões antigo (ABC1234) e Mercosul (ABC1D23)
+func Parse(plate string) (Plate, error) {
+	canonical := Normalize(plate)
+	if len(canonical) != 7 {
+		return Plate{}, ErrInvalid
+	}
+	for i := 0; i < 3; i++ {
+		if !isLetter(canonical[i]) {
+			return Plate{}, ErrInvalid
+		}
+	}
+	if !isDigit(canonical[3]) || !isDigit(canonical[5]) || !isDigit(canonical[6]) {
+		return Plate{}, ErrInvalid
+	}
+
+	switch {
+	case isDigit(canonical[4]):
+		return Plate{Canonical: canonical, Format: FormatLegacy}, nil
+	case isLetter(canonical[4]):
+		return Plate{Canonical: canonical, Format: FormatMercosul}, nil
+	default:
+		return Plate{}, ErrInvalid
+	}
+}
+
+// Valid indica se o texto é uma placa válida em algum dos dois padrões
+func Valid(plate string) bool {
+	_, err := Parse(plate)
+	return err == nil
+}
+
+// Alternate retorna a placa no outro padrão, convertendo o 5º caractere (0-9 <-> A-J), como
+// na troca para a placa Mercosul. Retorna vazio para placas Mercosul com 5ª letra além de J,
+// que não têm equivalente antigo.
+func (p Plate) Alternate() string {
+	if len(p.Canonical) != 7 {
+		return ""
+	}
+
+	fifth := p.Canonical[4]
+	var converted byte
+	switch {
+	case isDigit(fifth):
+		converted = 'A' + (fifth - '0')
+	case fifth >= 'A' && fifth <= 'J':
+		converted = '0' + (fifth - 'A')
+	default:
+		return ""
+	}
+	return p.Canonical[:4] + string(converted) + p.Canonical[5:]
+}
+
+// Forms retorna a forma canônica e, se existir, a do outro padrão: as formas sob as quais o
+// mesmo veículo pode estar cadastrado
+func (p Plate) Forms() []string {
+	if alternate := p.Alternate(); alternate != "" {
+		return []string{p.Canonical, alternate}
+	}
+	return []string{p.Canonical}
+}
+
+// Key retorna uma chave igual para as duas formas da mesma placa (a forma antiga, quando há)
+func (p Plate) Key() string {
+	if p.Format == FormatMercosul {
+		if alternate := p.Alternate(); alternate != "" {
+			return alternate
+		}
+	}
+	return p.Canonical
+}
+
+// String formata a placa para exibição: ABC-1234 no padrão antigo, ABC1D23 no Mercosul
+func (p Plate) String() string {
+	if p.Format == FormatLegacy {
+		return p.Canonical[:3] + "-" + p.Canonical[3:]
+	}
+	return p.Canonical
+}
+
+func isLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
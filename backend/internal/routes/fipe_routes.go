@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/handlers"
+)
+
+// SetupFipeRoutes configura as rotas da tabela FIPE importada
+func SetupFipeRoutes(router *gin.RouterGroup, fipeRepo database.FipeRepository, carRepo database.CarRepository) {
+	fipeHandler := handlers.NewFipeHandler(fipeRepo, carRepo)
+
+	// Grupo de rotas da tabela FIPE
+	fipeGroup := router.Group("/fipe")
+	{
+		fipeGroup.GET("/:code", fipeHandler.GetVehicle)           // GET /api/v1/fipe/:code
+		fipeGroup.GET("/:code/prices", fipeHandler.GetPriceCurve) // GET /api/v1/fipe/:code/prices?model_year=2019&from=2024-01&to=2025-01
+	}
+}
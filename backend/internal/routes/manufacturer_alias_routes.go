@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/handlers"
+)
+
+// SetupManufacturerAliasRoutes configura as rotas do dicionário de apelidos de fabricantes
+func SetupManufacturerAliasRoutes(router *gin.RouterGroup, aliasRepo database.ManufacturerAliasRepository) {
+	aliasHandler := handlers.NewManufacturerAliasHandler(aliasRepo)
+
+	// Grupo de rotas para os apelidos de fabricantes
+	aliasGroup := router.Group("/manufacturer-aliases")
+	{
+		// Relatório de fabricantes das aplicações sem apelido
+		aliasGroup.GET("/missing", aliasHandler.MissingAliases) // GET /api/v1/manufacturer-aliases/missing
+
+		// CRUD
+		aliasGroup.GET("/", aliasHandler.ListAliases)          // GET /api/v1/manufacturer-aliases/?manufacturer=VOLKSWAGEN
+		aliasGroup.GET("/:alias", aliasHandler.GetAlias)       // GET /api/v1/manufacturer-aliases/:alias
+		aliasGroup.POST("/", aliasHandler.CreateAlias)         // POST /api/v1/manufacturer-aliases/
+		aliasGroup.PUT("/:alias", aliasHandler.UpdateAlias)    // PUT /api/v1/manufacturer-aliases/:alias
+		aliasGroup.DELETE("/:alias", aliasHandler.DeleteAlias) // DELETE /api/v1/manufacturer-aliases/:alias
+	}
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/handlers"
+)
+
+// SetupNFeRoutes configura as rotas de importação de NF-e e da fila de conciliação
+func SetupNFeRoutes(router *gin.RouterGroup, nfeRepo database.NFeRepository) {
+	nfeHandler := handlers.NewNFeHandler(nfeRepo)
+
+	nfeGroup := router.Group("/nfe")
+	{
+		nfeGroup.POST("/", nfeHandler.ImportInvoice) // POST /api/v1/nfe/ (XML no corpo ou multipart file)
+		nfeGroup.GET("/", nfeHandler.ListInvoices)   // GET /api/v1/nfe/?company_id=
+		nfeGroup.GET("/:id", nfeHandler.GetInvoice)  // GET /api/v1/nfe/:id
+
+		// Fila de conciliação dos itens não lançados
+		nfeGroup.GET("/queue", nfeHandler.ListQueue)                     // GET /api/v1/nfe/queue?company_id=&reason=not_found
+		nfeGroup.POST("/queue/:item_id/resolve", nfeHandler.ResolveItem) // POST /api/v1/nfe/queue/:item_id/resolve
+		nfeGroup.POST("/queue/:item_id/discard", nfeHandler.DiscardItem) // POST /api/v1/nfe/queue/:item_id/discard
+	}
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/handlers"
+	"partexplorer/backend/internal/stockfeed"
+)
+
+// SetupStockImportRoutes configura as rotas de importação de arquivos de estoque
+func SetupStockImportRoutes(router *gin.RouterGroup, importRepo database.StockImportRepository, runner *stockfeed.Runner) {
+	importHandler := handlers.NewStockImportHandler(importRepo, runner)
+
+	importGroup := router.Group("/stock-imports")
+	{
+		importGroup.POST("/", importHandler.CreateImport) // POST /api/v1/stock-imports/ (multipart: file, company_id, mode)
+		importGroup.GET("/", importHandler.ListImports)   // GET /api/v1/stock-imports/?company_id=
+		importGroup.GET("/:id", importHandler.GetImport)  // GET /api/v1/stock-imports/:id
+
+		// Relatório por linha
+		importGroup.GET("/:id/errors", importHandler.ListErrors) // GET /api/v1/stock-imports/:id/errors
+		importGroup.GET("/:id/lines", importHandler.ListLines)   // GET /api/v1/stock-imports/:id/lines?status=updated
+
+		importGroup.POST("/:id/cancel", importHandler.CancelImport) // POST /api/v1/stock-imports/:id/cancel
+		importGroup.POST("/:id/resume", importHandler.ResumeImport) // POST /api/v1/stock-imports/:id/resume
+	}
+}
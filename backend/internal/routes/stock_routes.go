@@ -29,5 +29,23 @@ func SetupStockRoutes(router *gin.RouterGroup, stockRepo database.StockRepositor
 
 		// Estoque por grupo de peças
 		stockGroup.GET("/group/:group_id", stockHandler.GetStocksByGroupID) // GET /api/v1/stocks/group/:group_id
+
+		// Livro de movimentações
+		stockGroup.POST("/:id/movements", stockHandler.RecordMovement) // POST /api/v1/stocks/:id/movements
+		stockGroup.GET("/:id/movements", stockHandler.ListMovements)   // GET /api/v1/stocks/:id/movements?type=sale
+
+		// Incremento e decremento atômicos da quantidade, sem If-Match
+		stockGroup.POST("/:id/increment", stockHandler.IncrementQuantity) // POST /api/v1/stocks/:id/increment
+		stockGroup.POST("/:id/decrement", stockHandler.DecrementQuantity) // POST /api/v1/stocks/:id/decrement
+
+		// Comparação e histórico de preços
+		stockGroup.GET("/:id/prices", stockHandler.ListPriceHistory)                    // GET /api/v1/stocks/:id/prices
+		stockGroup.GET("/part/:part_name_id/offers", stockHandler.RankOffers)           // GET /api/v1/stocks/part/:part_name_id/offers?state=SP&in_stock=true
+		stockGroup.GET("/group/:group_id/price-stats", stockHandler.GetGroupPriceStats) // GET /api/v1/stocks/group/:group_id/price-stats?state=SP
+
+		// Conciliação da quantidade com o livro
+		stockGroup.GET("/reconciliation", stockHandler.ListReconciliation)    // GET /api/v1/stocks/reconciliation
+		stockGroup.GET("/:id/reconciliation", stockHandler.GetReconciliation) // GET /api/v1/stocks/:id/reconciliation
+		stockGroup.POST("/:id/reconcile", stockHandler.Reconcile)             // POST /api/v1/stocks/:id/reconcile
 	}
 }
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/handlers"
+)
+
+// SetupVehicleModelRoutes configura as rotas de revisão do mapeamento de modelos de veículo
+func SetupVehicleModelRoutes(router *gin.RouterGroup, vehicleModelRepo database.VehicleModelRepository) {
+	vehicleModelHandler := handlers.NewVehicleModelHandler(vehicleModelRepo)
+
+	// Grupo de rotas para o mapeamento de modelos
+	vehicleModelGroup := router.Group("/vehicle-models")
+	{
+		// Fila de revisão
+		vehicleModelGroup.GET("/", vehicleModelHandler.ListMappings)               // GET /api/v1/vehicle-models/?status=pending
+		vehicleModelGroup.GET("/:id", vehicleModelHandler.GetMapping)              // GET /api/v1/vehicle-models/:id
+		vehicleModelGroup.POST("/:id/confirm", vehicleModelHandler.ConfirmMapping) // POST /api/v1/vehicle-models/:id/confirm
+		vehicleModelGroup.PUT("/:id", vehicleModelHandler.CorrectMapping)          // PUT /api/v1/vehicle-models/:id
+	}
+}
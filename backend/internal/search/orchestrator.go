@@ -0,0 +1,220 @@
+package search
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"partexplorer/backend/internal/database"
+	"partexplorer/backend/internal/elasticsearch"
+	"partexplorer/backend/internal/manufacturer"
+	"partexplorer/backend/internal/metrics"
+	"partexplorer/backend/internal/models"
+)
+
+// Engine identifica qual backend atendeu a busca
+type Engine string
+
+const (
+	EngineElasticsearch Engine = "elasticsearch"
+	EnginePostgres      Engine = "postgres"
+
+	// EngineHeader é o header de resposta que informa o backend utilizado
+	EngineHeader = "X-Search-Engine"
+)
+
+// Orchestrator decide entre Elasticsearch e PostgreSQL para cada busca
+type Orchestrator struct {
+	repo        database.PartRepository
+	suggestions database.SuggestionRepository
+	es          *elasticsearch.SearchService
+
+	healthTTL     time.Duration
+	healthTimeout time.Duration
+
+	mu          sync.Mutex
+	lastCheck   time.Time
+	lastHealthy bool
+	checking    bool
+}
+
+// NewOrchestrator cria um novo orquestrador de busca; suggestions pode ser nil sem banco
+func NewOrchestrator(repo database.PartRepository, suggestions database.SuggestionRepository, es *elasticsearch.SearchService) *Orchestrator {
+	return &Orchestrator{
+		repo:          repo,
+		suggestions:   suggestions,
+		es:            es,
+		healthTTL:     10 * time.Second,
+		healthTimeout: 2 * time.Second,
+	}
+}
+
+// SearchParts busca no Elasticsearch quando o cluster está saudável e usa o SQL como fallback.
+// Os dois backends aplicam os facets selecionados e retornam as contagens.
+func (o *Orchestrator) SearchParts(query string, filters models.SearchFilters, page, pageSize int) (*models.SearchResponse, Engine, error) {
+	page, pageSize = normalizePaging(page, pageSize)
+
+	return o.dispatch(
+		func() (*models.SearchResponse, error) {
+			return o.es.SearchParts(query, filters, page, pageSize)
+		},
+		func() (*models.SearchResponse, error) {
+			return o.repo.SearchPartsFaceted(query, filters, page, pageSize)
+		},
+	)
+}
+
+// SearchFitment busca peças compatíveis com o veículo, com fallback para o SQL
+func (o *Orchestrator) SearchFitment(query models.FitmentQuery, page, pageSize int) (*models.SearchResponse, Engine, error) {
+	page, pageSize = normalizePaging(page, pageSize)
+
+	// O índice guarda o fabricante canônico do banco; a consulta usa o mesmo dicionário
+	if query.Manufacturer != "" {
+		if canonical, err := o.repo.CanonicalManufacturer(query.Manufacturer); err != nil {
+			log.Printf("⚠️ [SEARCH] Erro ao converter o fabricante %q: %v", query.Manufacturer, err)
+			query.Manufacturer = manufacturer.Normalize(query.Manufacturer)
+		} else {
+			query.Manufacturer = canonical
+		}
+	}
+
+	return o.dispatch(
+		func() (*models.SearchResponse, error) {
+			return o.es.SearchFitment(query, page, pageSize)
+		},
+		func() (*models.SearchResponse, error) {
+			return o.repo.SearchPartsByFitment(query, page, pageSize)
+		},
+	)
+}
+
+// Suggest busca sugestões de autocomplete no índice de sugestões, com fallback para o SQL.
+// Uma falha aqui não marca o cluster como indisponível: o índice de sugestões pode ainda
+// não ter sido construído sem afetar a busca principal.
+func (o *Orchestrator) Suggest(prefix string, types []string, state string, limit int) ([]models.Suggestion, Engine, error) {
+	if o.esAvailable() {
+		suggestions, err := o.es.Suggest(prefix, types, state, limit)
+		if err == nil {
+			return suggestions, EngineElasticsearch, nil
+		}
+		log.Printf("⚠️ [SEARCH] Autocomplete no Elasticsearch falhou, usando PostgreSQL: %v", err)
+	}
+
+	if o.suggestions == nil {
+		return nil, EnginePostgres, fmt.Errorf("database not available")
+	}
+
+	suggestions, err := o.suggestions.Suggest(prefix, types, state, limit)
+	return suggestions, EnginePostgres, err
+}
+
+// dispatch executa a busca no Elasticsearch (hidratando os resultados) ou no SQL
+func (o *Orchestrator) dispatch(esSearch, sqlSearch func() (*models.SearchResponse, error)) (*models.SearchResponse, Engine, error) {
+	if o.esAvailable() {
+		response, err := esSearch()
+		if err == nil {
+			err = o.hydrate(response)
+		}
+		if err == nil {
+			metrics.RecordSearchQuery(string(EngineElasticsearch), resultBucket(response.Total))
+			return response, EngineElasticsearch, nil
+		}
+
+		log.Printf("⚠️ [SEARCH] Elasticsearch falhou, usando PostgreSQL: %v", err)
+		o.markUnhealthy()
+	}
+
+	response, err := sqlSearch()
+	if err != nil {
+		return nil, EnginePostgres, err
+	}
+
+	metrics.RecordSearchQuery(string(EnginePostgres), resultBucket(response.Total))
+	return response, EnginePostgres, nil
+}
+
+// hydrate substitui os hits do índice pelos dados do banco, preservando ordem e score
+func (o *Orchestrator) hydrate(response *models.SearchResponse) error {
+	ids := make([]string, len(response.Results))
+	scores := make(map[string]float64, len(response.Results))
+	for i, result := range response.Results {
+		ids[i] = result.ID
+		scores[result.ID] = result.Score
+	}
+
+	// O índice guarda apenas os campos pesquisáveis; names, images e stocks vêm do banco
+	hydrated, err := o.repo.GetPartsByIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range hydrated {
+		hydrated[i].Score = scores[hydrated[i].ID]
+	}
+
+	response.Results = hydrated
+	return nil
+}
+
+// esAvailable consulta a saúde do cluster, reaproveitando o último resultado por healthTTL.
+// A verificação roda fora do lock e só uma por vez: enquanto ela não termina, as outras
+// buscas usam o último resultado em vez de esperar o timeout.
+func (o *Orchestrator) esAvailable() bool {
+	if o.es == nil {
+		return false
+	}
+
+	o.mu.Lock()
+	if o.checking || (!o.lastCheck.IsZero() && time.Since(o.lastCheck) < o.healthTTL) {
+		healthy := o.lastHealthy
+		o.mu.Unlock()
+		return healthy
+	}
+	o.checking = true
+	o.mu.Unlock()
+
+	healthy := o.es.IsHealthy(o.healthTimeout)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.checking = false
+	o.lastHealthy = healthy
+	o.lastCheck = time.Now()
+	return healthy
+}
+
+// markUnhealthy força o fallback até a próxima verificação de saúde
+func (o *Orchestrator) markUnhealthy() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.lastHealthy = false
+	o.lastCheck = time.Now()
+}
+
+// normalizePaging aplica os valores padrão de paginação
+func normalizePaging(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	return page, pageSize
+}
+
+// resultBucket agrupa a contagem de resultados para manter baixa a cardinalidade das métricas
+func resultBucket(total int64) string {
+	switch {
+	case total == 0:
+		return "0"
+	case total <= 10:
+		return "1-10"
+	case total <= 100:
+		return "11-100"
+	default:
+		return "100+"
+	}
+}
@@ -0,0 +1,302 @@
+package stockfeed
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Formatos aceitos para o arquivo de estoque
+const (
+	FormatCSV       = "csv"
+	FormatXLSX      = "xlsx"
+	FormatJSONLines = "jsonl"
+)
+
+// Campos de uma linha do arquivo
+const (
+	fieldSKU      = "sku"
+	fieldEAN      = "ean"
+	fieldBrand    = "brand"
+	fieldQuantity = "quantity"
+	fieldPrice    = "price"
+	fieldObsolete = "obsolete"
+)
+
+// columns associa os nomes de coluna (sem acentos e separadores) aos campos
+var columns = map[string]string{
+	"sku": fieldSKU, "codigo": fieldSKU, "code": fieldSKU, "referencia": fieldSKU, "ref": fieldSKU, "partnumber": fieldSKU,
+	"ean": fieldEAN, "gtin": fieldEAN, "codigobarras": fieldEAN, "codigodebarras": fieldEAN, "barcode": fieldEAN,
+	"marca": fieldBrand, "brand": fieldBrand, "fabricante": fieldBrand,
+	"quantidade": fieldQuantity, "quantity": fieldQuantity, "qtd": fieldQuantity, "qty": fieldQuantity,
+	"estoque": fieldQuantity, "stock": fieldQuantity, "saldo": fieldQuantity,
+	"preco": fieldPrice, "price": fieldPrice, "valor": fieldPrice,
+	"obsoleto": fieldObsolete, "obsolete": fieldObsolete, "inativo": fieldObsolete, "descontinuado": fieldObsolete,
+}
+
+// accents tira os acentos dos nomes de coluna
+var accents = strings.NewReplacer("á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ç", "c")
+
+var (
+	// ErrUnknownFormat indica um formato diferente de CSV, XLSX e JSON lines
+	ErrUnknownFormat = errors.New("unknown stock file format: expected csv, xlsx or jsonl")
+	// ErrNoCodeColumn indica um arquivo sem coluna de SKU nem de EAN
+	ErrNoCodeColumn = errors.New("stock file has no sku or ean column")
+)
+
+// Line é uma linha do arquivo de estoque. Os campos não informados ficam nil e não são
+// alterados no estoque; Error é o motivo da rejeição da linha, quando houver.
+type Line struct {
+	Line     int
+	SKU      string
+	EAN      string
+	Brand    string
+	Quantity *int
+	Price    *float64
+	Obsolete *bool
+	Error    string
+}
+
+// FormatFromPath deduz o formato pela extensão do arquivo
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".txt":
+		return FormatCSV
+	case ".xlsx":
+		return FormatXLSX
+	case ".jsonl", ".ndjson", ".json":
+		return FormatJSONLines
+	default:
+		return ""
+	}
+}
+
+// Read lê o arquivo de estoque inteiro. CSV e XLSX precisam de cabeçalho; no JSON lines cada
+// linha é um objeto. Linhas inválidas voltam com Error preenchido, para o relatório.
+func Read(r io.Reader, format string) ([]Line, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatXLSX:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stock xlsx: %w", err)
+		}
+		rows, err := readXLSX(data)
+		if err != nil {
+			return nil, err
+		}
+		return readTable(rows)
+	case FormatJSONLines:
+		return readJSONLines(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+func readCSV(r io.Reader) ([]Line, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(buffered.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("failed to read stock csv: %w", err)
+	}
+	if index := bytes.IndexByte(header, '\n'); index >= 0 {
+		header = header[:index]
+	}
+
+	reader := csv.NewReader(buffered)
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	rows := []tableRow{}
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stock csv: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, tableRow{line: line, values: values})
+	}
+	return readTable(rows)
+}
+
+// tableRow é uma linha de CSV ou planilha com o número da linha no arquivo
+type tableRow struct {
+	line   int
+	values []string
+}
+
+// readTable converte as linhas de uma tabela com cabeçalho
+func readTable(rows []tableRow) ([]Line, error) {
+	if len(rows) == 0 {
+		return []Line{}, nil
+	}
+
+	fields := make([]string, len(rows[0].values))
+	hasCode := false
+	for i, name := range rows[0].values {
+		fields[i] = columns[columnKey(name)]
+		hasCode = hasCode || fields[i] == fieldSKU || fields[i] == fieldEAN
+	}
+	if !hasCode {
+		return nil, ErrNoCodeColumn
+	}
+
+	lines := make([]Line, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		values := make(map[string]string, len(fields))
+		empty := true
+		for i, value := range row.values {
+			if i < len(fields) && fields[i] != "" {
+				values[fields[i]] = value
+				empty = empty && strings.TrimSpace(value) == ""
+			}
+		}
+		if empty {
+			continue
+		}
+		lines = append(lines, parseLine(row.line, values))
+	}
+	return lines, nil
+}
+
+func readJSONLines(r io.Reader) ([]Line, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lines := []Line{}
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			lines = append(lines, Line{Line: number, Error: "invalid json: " + err.Error()})
+			continue
+		}
+
+		values := make(map[string]string, len(object))
+		for name, value := range object {
+			if field := columns[columnKey(name)]; field != "" && value != nil {
+				values[field] = fmt.Sprint(value)
+			}
+		}
+		lines = append(lines, parseLine(number, values))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stock json lines: %w", err)
+	}
+	return lines, nil
+}
+
+// parseLine valida e converte os valores de uma linha
+func parseLine(number int, values map[string]string) Line {
+	line := Line{
+		Line:  number,
+		SKU:   strings.TrimSpace(values[fieldSKU]),
+		EAN:   strings.TrimSpace(values[fieldEAN]),
+		Brand: strings.TrimSpace(values[fieldBrand]),
+	}
+	if line.SKU == "" && line.EAN == "" {
+		line.Error = "sku or ean is required"
+		return line
+	}
+
+	if value := strings.TrimSpace(values[fieldQuantity]); value != "" {
+		quantity, err := parseQuantity(value)
+		if err != nil {
+			line.Error = err.Error()
+			return line
+		}
+		line.Quantity = &quantity
+	}
+	if value := strings.TrimSpace(values[fieldPrice]); value != "" {
+		price, err := parsePrice(value)
+		if err != nil {
+			line.Error = err.Error()
+			return line
+		}
+		line.Price = &price
+	}
+	if value := strings.TrimSpace(values[fieldObsolete]); value != "" {
+		obsolete, err := parseBool(value)
+		if err != nil {
+			line.Error = err.Error()
+			return line
+		}
+		line.Obsolete = &obsolete
+	}
+
+	if line.Quantity == nil && line.Price == nil && line.Obsolete == nil {
+		line.Error = "quantity, price or obsolete is required"
+	}
+	return line
+}
+
+// parseQuantity aceita inteiros, inclusive como as planilhas gravam ("10.0", "10,0")
+func parseQuantity(value string) (int, error) {
+	number, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil || number < 0 || number != math.Trunc(number) || number > math.MaxInt32 {
+		return 0, fmt.Errorf("invalid quantity %q: expected a non-negative integer", value)
+	}
+	return int(number), nil
+}
+
+// parsePrice aceita o formato brasileiro ("R$ 1.234,56") e o decimal ("1234.56")
+func parsePrice(value string) (float64, error) {
+	cleaned := strings.ReplaceAll(strings.TrimPrefix(value, "R$"), " ", "")
+	if strings.Contains(cleaned, ",") {
+		cleaned = strings.ReplaceAll(cleaned, ".", "")
+		cleaned = strings.Replace(cleaned, ",", ".", 1)
+	}
+	price, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil || price < 0 {
+		return 0, fmt.Errorf("invalid price %q", value)
+	}
+	return price, nil
+}
+
+// parseBool aceita sim/não, s/n, true/false, 1/0 e x (marcado)
+func parseBool(value string) (bool, error) {
+	switch accents.Replace(strings.ToLower(value)) {
+	case "1", "true", "sim", "s", "yes", "y", "x":
+		return true, nil
+	case "0", "false", "nao", "n", "no":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid obsolete flag %q", value)
+	}
+}
+
+// columnKey tira acentos, separadores e maiúsculas do nome da coluna ("Código de Barras" -> "codigodebarras")
+func columnKey(name string) string {
+	name = accents.Replace(strings.ToLower(strings.TrimPrefix(name, "\ufeff")))
+
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
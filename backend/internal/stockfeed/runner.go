@@ -0,0 +1,206 @@
+package stockfeed
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"partexplorer/backend/internal/models"
+)
+
+// ErrInvalidJob indica uma importação sem empresa, responsável ou com modo desconhecido
+var ErrInvalidJob = errors.New("invalid stock import")
+
+// JobStore processa os jobs de importação gravados no banco.
+// database.StockImportRepository implementa esta interface.
+type JobStore interface {
+	// ProcessBatch processa até size linhas pendentes e, sem linhas pendentes, conclui o job
+	ProcessBatch(id string, size int) (*models.StockImportJob, error)
+	// Resumable lista os jobs pendentes ou em andamento, os mais antigos primeiro
+	Resumable() ([]string, error)
+	Fail(id, message string) error
+}
+
+// NewJob monta o job e as linhas a gravar a partir do arquivo lido. As linhas rejeitadas na
+// leitura já entram com erro no relatório.
+func NewJob(companyID, mode, format, fileName, actor string, lines []Line) (*models.StockImportJob, []models.StockImportLine, error) {
+	company, err := uuid.Parse(strings.TrimSpace(companyID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid company ID", ErrInvalidJob)
+	}
+	if mode == "" {
+		mode = models.StockImportMerge
+	}
+	if mode != models.StockImportMerge && mode != models.StockImportReplace {
+		return nil, nil, fmt.Errorf("%w: mode must be merge or replace", ErrInvalidJob)
+	}
+	if strings.TrimSpace(actor) == "" {
+		return nil, nil, fmt.Errorf("%w: actor is required", ErrInvalidJob)
+	}
+	if len(lines) == 0 {
+		return nil, nil, fmt.Errorf("%w: the file has no lines", ErrInvalidJob)
+	}
+
+	job := &models.StockImportJob{
+		ID:         uuid.New(),
+		CompanyID:  company,
+		Mode:       mode,
+		Format:     format,
+		FileName:   fileName,
+		Actor:      strings.TrimSpace(actor),
+		Status:     models.StockImportPending,
+		TotalLines: len(lines),
+	}
+
+	rows := make([]models.StockImportLine, 0, len(lines))
+	for _, line := range lines {
+		row := models.StockImportLine{
+			JobID:    job.ID,
+			Line:     line.Line,
+			SKU:      line.SKU,
+			EAN:      line.EAN,
+			Brand:    line.Brand,
+			Quantity: line.Quantity,
+			Price:    line.Price,
+			Obsolete: line.Obsolete,
+			Status:   models.StockImportLinePending,
+		}
+		if line.Error != "" {
+			row.Status = models.StockImportLineError
+			row.Error = line.Error
+			job.Errors++
+			job.ProcessedLines++
+		}
+		rows = append(rows, row)
+	}
+	return job, rows, nil
+}
+
+// Runner executa as importações de estoque em background, um job de cada vez, em lotes de
+// linhas. Os jobs ficam no banco: os pendentes ou interrompidos são retomados a cada
+// intervalo e quando o servidor reinicia.
+type Runner struct {
+	store JobStore
+
+	interval  time.Duration
+	batchSize int
+
+	wake     chan struct{}
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewRunner cria o executor. STOCK_IMPORT_BATCH (padrão 500) é o número de linhas por
+// transação e STOCK_IMPORT_INTERVAL (padrão 1m) o intervalo da busca por jobs a retomar.
+func NewRunner(store JobStore) *Runner {
+	batchSize := 500
+	if value, err := strconv.Atoi(os.Getenv("STOCK_IMPORT_BATCH")); err == nil && value > 0 {
+		batchSize = value
+	}
+
+	interval := time.Minute
+	if value, err := time.ParseDuration(os.Getenv("STOCK_IMPORT_INTERVAL")); err == nil && value > 0 {
+		interval = value
+	}
+
+	return &Runner{
+		store:     store,
+		interval:  interval,
+		batchSize: batchSize,
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start inicia o loop em background, retomando de imediato os jobs interrompidos
+func (r *Runner) Start() {
+	go r.run()
+	r.Notify()
+	log.Printf("✅ [STOCK-IMPORT] Importações de estoque iniciadas (lote %d, intervalo %s)", r.batchSize, r.interval)
+}
+
+// Stop encerra o loop ao fim do lote em andamento; o job interrompido é retomado no próximo Start
+func (r *Runner) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+}
+
+// Notify avisa o loop que há um job novo ou retomado, sem esperar o próximo intervalo
+func (r *Runner) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Runner) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		}
+
+		ids, err := r.store.Resumable()
+		if err != nil {
+			log.Printf("⚠️ [STOCK-IMPORT] Erro ao buscar importações pendentes: %v", err)
+			continue
+		}
+		for _, id := range ids {
+			if r.stopped() {
+				return
+			}
+			if _, err := r.Run(id); err != nil {
+				log.Printf("⚠️ [STOCK-IMPORT] Importação %s falhou: %v", id, err)
+			}
+		}
+	}
+}
+
+// Run processa o job até o fim, lote a lote, e retorna o estado final. Um erro marca o job
+// como failed, com a mensagem; as linhas já processadas são mantidas e o job pode ser retomado.
+func (r *Runner) Run(id string) (*models.StockImportJob, error) {
+	for {
+		job, err := r.store.ProcessBatch(id, r.batchSize)
+		if err != nil {
+			if failErr := r.store.Fail(id, err.Error()); failErr != nil {
+				log.Printf("⚠️ [STOCK-IMPORT] Erro ao marcar a importação %s como falha: %v", id, failErr)
+			}
+			return job, err
+		}
+		if job.Finished() {
+			log.Printf("📦 [STOCK-IMPORT] Importação %s %s: %d criado(s), %d atualizado(s), %d sem alteração, %d zerado(s), %d erro(s)",
+				id, job.Status, job.Created, job.Updated, job.Unchanged, job.Zeroed, job.Errors)
+			return job, nil
+		}
+		if r.stopped() {
+			return job, nil
+		}
+	}
+}
+
+func (r *Runner) stopped() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,193 @@
+package stockfeed
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxXLSXColumns é o limite de colunas do formato (A até XFD)
+	maxXLSXColumns = 16384
+	// maxXLSXPartSize limita o tamanho descompactado de cada arquivo lido de dentro do XLSX
+	maxXLSXPartSize = 200 << 20
+)
+
+// Estruturas mínimas do SpreadsheetML: apenas o necessário para ler os valores da primeira
+// planilha do arquivo
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX lê as linhas da primeira planilha do arquivo, com os números de linha da planilha
+func readXLSX(data []byte) ([]tableRow, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stock xlsx: %w", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var shared xlsxSharedStrings
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(file, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	file, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, fmt.Errorf("failed to open stock xlsx: no worksheet found")
+	}
+	var sheet xlsxSheet
+	if err := decodeXLSXPart(file, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([]tableRow, 0, len(sheet.Rows))
+	for i, row := range sheet.Rows {
+		number := row.Number
+		if number == 0 {
+			number = i + 1
+		}
+
+		values := []string{}
+		for position, cell := range row.Cells {
+			column := position
+			if index := columnIndex(cell.Ref); index >= 0 {
+				column = index
+			}
+			if column >= maxXLSXColumns {
+				return nil, fmt.Errorf("failed to read stock xlsx: invalid cell reference %q", cell.Ref)
+			}
+			for len(values) <= column {
+				values = append(values, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("failed to read stock xlsx: invalid shared string in %s", cell.Ref)
+				}
+				values[column] = shared.Items[index].String()
+			case "inlineStr":
+				values[column] = cell.Inline.String()
+			default:
+				values[column] = cell.Value
+			}
+		}
+		rows = append(rows, tableRow{line: number, values: values})
+	}
+	return rows, nil
+}
+
+// firstSheetPath encontra o arquivo da primeira planilha pelo workbook, com sheet1.xml como padrão
+func firstSheetPath(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var workbook xlsxWorkbook
+	var relationships xlsxRelationships
+	workbookFile, ok := files["xl/workbook.xml"]
+	relsFile, relsOK := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !relsOK || decodeXLSXPart(workbookFile, &workbook) != nil ||
+		decodeXLSXPart(relsFile, &relationships) != nil || len(workbook.Sheets) == 0 {
+		return fallback
+	}
+
+	for _, relationship := range relationships.Relationships {
+		if relationship.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(relationship.Target, "/") {
+			return strings.TrimPrefix(relationship.Target, "/")
+		}
+		return path.Join("xl", relationship.Target)
+	}
+	return fallback
+}
+
+func decodeXLSXPart(file *zip.File, target interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read stock xlsx %s: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	// Um byte além do limite indica que o arquivo foi cortado
+	limited := io.LimitReader(reader, maxXLSXPartSize+1).(*io.LimitedReader)
+	if err := xml.NewDecoder(limited).Decode(target); err != nil {
+		if limited.N <= 0 {
+			return fmt.Errorf("failed to read stock xlsx %s: larger than %d MB uncompressed", file.Name, maxXLSXPartSize>>20)
+		}
+		return fmt.Errorf("failed to read stock xlsx %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// columnIndex converte a referência da célula na coluna, a partir de zero ("C7" -> 2, "AA1" -> 26).
+// Referências além da última coluna do formato param em maxXLSXColumns.
+func columnIndex(ref string) int {
+	column := 0
+	for _, r := range strings.ToUpper(ref) {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		if column > maxXLSXColumns {
+			return maxXLSXColumns
+		}
+	}
+	return column - 1
+}
@@ -0,0 +1,191 @@
+package synonyms
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// maxVariants limita quantas variações de uma consulta a expansão pode gerar
+const maxVariants = 8
+
+//go:embed synonyms_pt.txt
+var defaultDictionary string
+
+// accentReplacer remove os acentos usados em português (o texto já deve estar em minúsculas)
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// rule é uma linha do dicionário já normalizada
+type rule struct {
+	// terms são os termos do lado esquerdo (ou todos, quando equivalentes)
+	terms []string
+	// targets são os termos que substituem terms; vazio indica equivalência
+	targets []string
+}
+
+// Dictionary é um dicionário de sinônimos no formato Solr, compartilhado pelo
+// analyzer do Elasticsearch e pela expansão de consultas do fallback SQL
+type Dictionary struct {
+	lines []string
+	rules []rule
+}
+
+var (
+	defaultOnce sync.Once
+	defaultDict *Dictionary
+)
+
+// Default retorna o dicionário do arquivo em SEARCH_SYNONYMS_FILE ou, se não
+// definido ou inválido, o dicionário embutido no binário
+func Default() *Dictionary {
+	defaultOnce.Do(func() {
+		if path := os.Getenv("SEARCH_SYNONYMS_FILE"); path != "" {
+			dict, err := LoadFile(path)
+			if err == nil {
+				log.Printf("✅ [SYNONYMS] %d regras carregadas de %s", len(dict.rules), path)
+				defaultDict = dict
+				return
+			}
+			log.Printf("⚠️ [SYNONYMS] Falha ao carregar %s, usando dicionário embutido: %v", path, err)
+		}
+
+		dict, err := Parse(strings.NewReader(defaultDictionary))
+		if err != nil {
+			log.Printf("⚠️ [SYNONYMS] Falha ao carregar dicionário embutido: %v", err)
+			dict = &Dictionary{}
+		}
+		defaultDict = dict
+	})
+
+	return defaultDict
+}
+
+// LoadFile carrega um dicionário de sinônimos a partir de um arquivo
+func LoadFile(path string) (*Dictionary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open synonyms file: %w", err)
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// Parse lê um dicionário no formato Solr: "a, b, c" (equivalentes) ou "a, b => c"
+// (substituição). Linhas vazias e iniciadas por # são ignoradas.
+func Parse(r io.Reader) (*Dictionary, error) {
+	dict := &Dictionary{}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var parsed rule
+		if left, right, found := strings.Cut(line, "=>"); found {
+			parsed.terms = splitTerms(left)
+			parsed.targets = splitTerms(right)
+			if len(parsed.targets) == 0 {
+				return nil, fmt.Errorf("invalid synonym rule at line %d: missing replacement", lineNumber)
+			}
+		} else {
+			parsed.terms = splitTerms(line)
+		}
+		if len(parsed.terms) == 0 {
+			return nil, fmt.Errorf("invalid synonym rule at line %d: no terms", lineNumber)
+		}
+
+		dict.lines = append(dict.lines, line)
+		dict.rules = append(dict.rules, parsed)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read synonyms: %w", err)
+	}
+
+	return dict, nil
+}
+
+// Rules retorna as regras no formato aceito pelo filtro synonym_graph do Elasticsearch
+func (d *Dictionary) Rules() []string {
+	return append([]string{}, d.lines...)
+}
+
+// Expand retorna a consulta normalizada seguida das variações obtidas trocando
+// cada termo do dicionário presente na consulta por seus sinônimos
+func (d *Dictionary) Expand(query string) []string {
+	normalized := Normalize(query)
+	if normalized == "" {
+		return nil
+	}
+
+	variants := []string{normalized}
+	seen := map[string]bool{normalized: true}
+
+	for _, r := range d.rules {
+		for _, current := range variants {
+			for _, term := range r.terms {
+				if !containsPhrase(current, term) {
+					continue
+				}
+
+				replacements := r.targets
+				if len(replacements) == 0 {
+					replacements = r.terms
+				}
+				for _, replacement := range replacements {
+					variant := replacePhrase(current, term, replacement)
+					if seen[variant] || len(variants) >= maxVariants {
+						continue
+					}
+					seen[variant] = true
+					variants = append(variants, variant)
+				}
+			}
+		}
+	}
+
+	return variants
+}
+
+// Normalize converte o texto para minúsculas, remove acentos e espaços repetidos
+func Normalize(text string) string {
+	return strings.Join(strings.Fields(accentReplacer.Replace(strings.ToLower(text))), " ")
+}
+
+// splitTerms separa e normaliza os termos de uma lista separada por vírgulas
+func splitTerms(list string) []string {
+	var terms []string
+	for _, term := range strings.Split(list, ",") {
+		if normalized := Normalize(term); normalized != "" {
+			terms = append(terms, normalized)
+		}
+	}
+	return terms
+}
+
+// containsPhrase verifica se phrase aparece em text respeitando limites de palavra
+func containsPhrase(text, phrase string) bool {
+	return strings.Contains(" "+text+" ", " "+phrase+" ")
+}
+
+// replacePhrase troca a primeira ocorrência de phrase (como palavras inteiras) por replacement
+func replacePhrase(text, phrase, replacement string) string {
+	replaced := strings.Replace(" "+text+" ", " "+phrase+" ", " "+replacement+" ", 1)
+	return strings.TrimSpace(replaced)
+}
@@ -0,0 +1,105 @@
+package vehicledata
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/chromedp/chromedp"
+)
+
+// defaultChromePoolSize é o número de navegadores simultâneos quando KEPLACA_CHROME_POOL não
+// está definido
+const defaultChromePoolSize = 2
+
+// chromeBrowser é um Chrome headless mantido aberto entre consultas; cada consulta abre uma aba
+type chromeBrowser struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	allocCancel context.CancelFunc
+}
+
+// chromePool limita quantos Chrome headless rodam ao mesmo tempo e reaproveita os processos
+type chromePool struct {
+	opts  []chromedp.ExecAllocatorOption
+	slots chan *chromeBrowser
+}
+
+// newChromePool cria o pool com KEPLACA_CHROME_POOL navegadores (padrão 2). Os navegadores só
+// são iniciados na primeira consulta que os usa.
+func newChromePool(opts []chromedp.ExecAllocatorOption) *chromePool {
+	size := defaultChromePoolSize
+	if value, err := strconv.Atoi(os.Getenv("KEPLACA_CHROME_POOL")); err == nil && value > 0 {
+		size = value
+	}
+
+	pool := &chromePool{
+		opts:  opts,
+		slots: make(chan *chromeBrowser, size),
+	}
+	for i := 0; i < size; i++ {
+		pool.slots <- &chromeBrowser{}
+	}
+	return pool
+}
+
+// Run espera um navegador livre e executa as ações numa aba nova. Erros que não vêm do ctx
+// (navegador travado ou encerrado) fazem o navegador ser recriado na próxima consulta.
+func (p *chromePool) Run(ctx context.Context, actions ...chromedp.Action) error {
+	var browser *chromeBrowser
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case browser = <-p.slots:
+	}
+	defer func() { p.slots <- browser }()
+
+	if browser.ctx == nil {
+		if err := browser.start(p.opts); err != nil {
+			return err
+		}
+	}
+
+	tabCtx, cancelTab := chromedp.NewContext(browser.ctx)
+	defer cancelTab()
+	stop := context.AfterFunc(ctx, cancelTab)
+	defer stop()
+
+	err := chromedp.Run(tabCtx, actions...)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("⚠️ [CHROME] Falha no navegador, será reiniciado: %v", err)
+		browser.close()
+	}
+	return err
+}
+
+// start abre o processo do Chrome
+func (b *chromeBrowser) start(opts []chromedp.ExecAllocatorOption) error {
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	browserCtx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+
+	// O primeiro Run inicia o navegador; as abas criadas depois compartilham o processo
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancel()
+		allocCancel()
+		return fmt.Errorf("failed to start chrome: %w", err)
+	}
+
+	b.ctx = browserCtx
+	b.cancel = cancel
+	b.allocCancel = allocCancel
+	log.Printf("✅ [CHROME] Navegador iniciado")
+	return nil
+}
+
+// close encerra o processo do Chrome, se estiver aberto
+func (b *chromeBrowser) close() {
+	if b.ctx == nil {
+		return
+	}
+	b.cancel()
+	b.allocCancel()
+	b.ctx = nil
+}
@@ -0,0 +1,145 @@
+package vehicledata
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"partexplorer/backend/internal/metrics"
+	"partexplorer/backend/internal/models"
+)
+
+// ErrorQueue lista as placas da car_error com nova tentativa vencida e conta as entradas.
+// database.CarErrorRepository implementa esta interface.
+type ErrorQueue interface {
+	Due(limit int) ([]string, error)
+	Counts() ([]models.CarErrorCount, error)
+}
+
+// ErrorRetrier refaz a consulta de uma placa da car_error e reagenda em caso de nova falha.
+// database.CarRepository implementa esta interface.
+type ErrorRetrier interface {
+	RetryCarError(plate string) (*models.CarError, error)
+}
+
+// ErrorRetryWorker refaz periodicamente as consultas de placa que falharam e exporta as
+// contagens da car_error como métricas
+type ErrorRetryWorker struct {
+	queue   ErrorQueue
+	retrier ErrorRetrier
+
+	interval  time.Duration
+	batchSize int
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewErrorRetryWorker cria o worker. CAR_ERROR_RETRY_INTERVAL (padrão 1m) é o intervalo entre
+// lotes e CAR_ERROR_RETRY_BATCH (padrão 10) quantas placas tentar por lote. O intervalo entre
+// as tentativas de uma mesma placa é definido pelo repositório (CAR_ERROR_RETRY_BACKOFF).
+func NewErrorRetryWorker(queue ErrorQueue, retrier ErrorRetrier) *ErrorRetryWorker {
+	interval := time.Minute
+	if value, err := time.ParseDuration(os.Getenv("CAR_ERROR_RETRY_INTERVAL")); err == nil && value > 0 {
+		interval = value
+	}
+
+	batchSize := 10
+	if value, err := strconv.Atoi(os.Getenv("CAR_ERROR_RETRY_BATCH")); err == nil && value > 0 {
+		batchSize = value
+	}
+
+	return &ErrorRetryWorker{
+		queue:     queue,
+		retrier:   retrier,
+		interval:  interval,
+		batchSize: batchSize,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start inicia o loop em background
+func (w *ErrorRetryWorker) Start() {
+	go w.run()
+	log.Printf("✅ [CAR-ERROR] Novas tentativas da car_error iniciadas (intervalo %s, lote %d)", w.interval, w.batchSize)
+}
+
+// Stop encerra o loop e aguarda o lote em andamento
+func (w *ErrorRetryWorker) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+func (w *ErrorRetryWorker) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := w.RetryBatch(); err != nil {
+			log.Printf("⚠️ [CAR-ERROR] Erro ao buscar entradas vencidas: %v", err)
+		}
+		if err := w.ExportCounts(); err != nil {
+			log.Printf("⚠️ [CAR-ERROR] Erro ao contar entradas: %v", err)
+		}
+	}
+}
+
+// RetryBatch refaz um lote de placas com nova tentativa vencida, uma de cada vez. Retorna
+// quantas foram resolvidas.
+func (w *ErrorRetryWorker) RetryBatch() (int, error) {
+	plates, err := w.queue.Due(w.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for _, plateNumber := range plates {
+		select {
+		case <-w.stop:
+			return resolved, nil
+		default:
+		}
+
+		entry, err := w.retrier.RetryCarError(plateNumber)
+		if err != nil {
+			log.Printf("⚠️ [CAR-ERROR] Erro ao refazer a placa %s: %v", plateNumber, err)
+			continue
+		}
+		if entry.Status == models.CarErrorResolved {
+			resolved++
+		}
+	}
+
+	if len(plates) > 0 {
+		log.Printf("🔁 [CAR-ERROR] %d de %d placa(s) resolvida(s)", resolved, len(plates))
+	}
+	return resolved, nil
+}
+
+// ExportCounts atualiza a métrica partexplorer_car_error_entries com as contagens atuais
+func (w *ErrorRetryWorker) ExportCounts() error {
+	counts, err := w.queue.Counts()
+	if err != nil {
+		return err
+	}
+
+	metrics.ResetCarErrorEntries()
+	for _, count := range counts {
+		metrics.SetCarErrorEntries(count.Status, count.Reason, float64(count.Count))
+	}
+	return nil
+}
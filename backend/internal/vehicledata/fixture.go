@@ -0,0 +1,69 @@
+package vehicledata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"partexplorer/backend/internal/models"
+)
+
+func init() {
+	Register("fixture", func() (PlateProvider, error) {
+		path := os.Getenv("PLATE_FIXTURE_FILE")
+		if path == "" {
+			return nil, fmt.Errorf("PLATE_FIXTURE_FILE is required")
+		}
+		return LoadFixtureProvider(path)
+	})
+}
+
+// fixtureProvider responde com veículos de um arquivo JSON, para testes sem rede
+type fixtureProvider struct {
+	vehicles map[string]models.CarInfo
+}
+
+// LoadFixtureProvider carrega um arquivo JSON no formato {"ABC1234": {"marca": ..., ...}}
+func LoadFixtureProvider(path string) (PlateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plate fixtures: %w", err)
+	}
+
+	var raw map[string]models.CarInfo
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse plate fixtures: %w", err)
+	}
+
+	return NewFixtureProvider(raw), nil
+}
+
+// NewFixtureProvider cria o provedor com os veículos informados, indexados pela placa
+func NewFixtureProvider(vehicles map[string]models.CarInfo) PlateProvider {
+	normalized := make(map[string]models.CarInfo, len(vehicles))
+	for plate, info := range vehicles {
+		normalized[normalizePlate(plate)] = info
+	}
+	return &fixtureProvider{vehicles: normalized}
+}
+
+// Name identifica o provedor
+func (p *fixtureProvider) Name() string {
+	return "fixture"
+}
+
+// Lookup retorna o veículo do arquivo ou ErrNotFound
+func (p *fixtureProvider) Lookup(ctx context.Context, plate string) (*models.CarInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	plate = normalizePlate(plate)
+	info, ok := p.vehicles[plate]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	info.Placa = plate
+	return &info, nil
+}
@@ -0,0 +1,90 @@
+package vehicledata
+
+import (
+	"os"
+	"time"
+
+	"partexplorer/backend/internal/models"
+)
+
+// Grupos de campos dos dados do veículo, cada um com seu prazo de validade
+const (
+	// FieldGroupIdentity são marca, modelo, anos, chassi, combustível e importado: não mudam
+	FieldGroupIdentity = "identity"
+	// FieldGroupRegistration são cor, município e UF, que mudam com transferências
+	FieldGroupRegistration = "registration"
+	// FieldGroupFipe são código e valor FIPE, atualizados todo mês
+	FieldGroupFipe = "fipe"
+)
+
+// FieldGroups lista os grupos de campos na ordem usada nas respostas
+var FieldGroups = []string{FieldGroupIdentity, FieldGroupRegistration, FieldGroupFipe}
+
+// FreshnessPolicy define por quanto tempo cada grupo de campos do cache continua válido.
+// Um TTL zero (ou ausente) significa que o grupo nunca vence.
+type FreshnessPolicy struct {
+	TTL map[string]time.Duration
+}
+
+// DefaultFreshnessPolicy lê os prazos de CAR_TTL_IDENTITY (padrão: nunca vence),
+// CAR_TTL_REGISTRATION (padrão 720h) e CAR_TTL_FIPE (padrão 24h). "0" desativa o vencimento.
+func DefaultFreshnessPolicy() FreshnessPolicy {
+	policy := FreshnessPolicy{TTL: map[string]time.Duration{
+		FieldGroupIdentity:     0,
+		FieldGroupRegistration: 30 * 24 * time.Hour,
+		FieldGroupFipe:         24 * time.Hour,
+	}}
+	for group, env := range map[string]string{
+		FieldGroupIdentity:     "CAR_TTL_IDENTITY",
+		FieldGroupRegistration: "CAR_TTL_REGISTRATION",
+		FieldGroupFipe:         "CAR_TTL_FIPE",
+	} {
+		if value, err := time.ParseDuration(os.Getenv(env)); err == nil && value >= 0 {
+			policy.TTL[group] = value
+		}
+	}
+	return policy
+}
+
+// MinTTL retorna o menor prazo definido: dados mais velhos que isso têm algum grupo vencido.
+// Zero quando nenhum grupo vence.
+func (p FreshnessPolicy) MinTTL() time.Duration {
+	var min time.Duration
+	for _, ttl := range p.TTL {
+		if ttl > 0 && (min == 0 || ttl < min) {
+			min = ttl
+		}
+	}
+	return min
+}
+
+// Evaluate calcula o indicador de frescor de dados atualizados em updatedAt
+func (p FreshnessPolicy) Evaluate(updatedAt, now time.Time) *models.CarFreshness {
+	age := now.Sub(updatedAt)
+	if age < 0 {
+		age = 0
+	}
+
+	freshness := &models.CarFreshness{
+		UpdatedAt:  updatedAt,
+		AgeSeconds: int64(age / time.Second),
+	}
+	for _, group := range FieldGroups {
+		if ttl := p.TTL[group]; ttl > 0 && age >= ttl {
+			freshness.StaleFields = append(freshness.StaleFields, group)
+		}
+	}
+	freshness.Stale = len(freshness.StaleFields) > 0
+	return freshness
+}
+
+// IdentityStale indica se o grupo de identificação venceu. Só nesse caso a consulta espera
+// os provedores; os outros grupos vencidos são servidos do cache e atualizados em background.
+func IdentityStale(freshness *models.CarFreshness) bool {
+	for _, group := range freshness.StaleFields {
+		if group == FieldGroupIdentity {
+			return true
+		}
+	}
+	return false
+}
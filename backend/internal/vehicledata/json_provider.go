@@ -0,0 +1,177 @@
+package vehicledata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"partexplorer/backend/internal/models"
+)
+
+func init() {
+	Register("json", newJSONProviderFromEnv)
+}
+
+// maxJSONResponseSize limita o tamanho da resposta lida da API
+const maxJSONResponseSize = 1 << 20
+
+// jsonProvider consulta uma API HTTP genérica que responde JSON. Cada campo do CarInfo é
+// lido de um caminho no JSON ("marca" ou "data.veiculo.marca").
+type jsonProvider struct {
+	name        string
+	urlTemplate string
+	token       string
+	fields      map[string]string
+	client      *http.Client
+}
+
+// newJSONProviderFromEnv cria o provedor a partir de:
+//   - PLATE_JSON_URL: URL com {plate} no lugar da placa (obrigatória)
+//   - PLATE_JSON_TOKEN: enviado como "Authorization: Bearer <token>"
+//   - PLATE_JSON_FIELDS: caminhos dos campos, ex. "marca=data.brand,modelo=data.model";
+//     campos não mapeados usam o próprio nome do CarInfo (marca, modelo, ano, ano_modelo...)
+func newJSONProviderFromEnv() (PlateProvider, error) {
+	urlTemplate := os.Getenv("PLATE_JSON_URL")
+	if urlTemplate == "" {
+		return nil, fmt.Errorf("PLATE_JSON_URL is required")
+	}
+
+	fields, err := parseFieldMapping(os.Getenv("PLATE_JSON_FIELDS"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJSONProvider("json", urlTemplate, os.Getenv("PLATE_JSON_TOKEN"), fields), nil
+}
+
+// NewJSONProvider cria um provedor JSON. fields mapeia o nome JSON do campo do CarInfo para
+// o caminho na resposta; campos ausentes usam o próprio nome.
+func NewJSONProvider(name, urlTemplate, token string, fields map[string]string) PlateProvider {
+	mapping := make(map[string]string)
+	for key := range carInfoFields(&models.CarInfo{}) {
+		mapping[key] = key
+	}
+	for key, path := range fields {
+		mapping[key] = path
+	}
+
+	return &jsonProvider{
+		name:        name,
+		urlTemplate: urlTemplate,
+		token:       token,
+		fields:      mapping,
+		client:      &http.Client{},
+	}
+}
+
+// parseFieldMapping interpreta "campo=caminho,campo=caminho"
+func parseFieldMapping(spec string) (map[string]string, error) {
+	known := carInfoFields(&models.CarInfo{})
+	fields := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, path, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || strings.TrimSpace(path) == "" {
+			return nil, fmt.Errorf("invalid PLATE_JSON_FIELDS entry %q", pair)
+		}
+		if _, exists := known[key]; !exists {
+			return nil, fmt.Errorf("unknown vehicle field %q in PLATE_JSON_FIELDS", key)
+		}
+		fields[key] = strings.TrimSpace(path)
+	}
+	return fields, nil
+}
+
+// Name identifica o provedor
+func (p *jsonProvider) Name() string {
+	return p.name
+}
+
+// Lookup consulta a API e converte a resposta em CarInfo
+func (p *jsonProvider) Lookup(ctx context.Context, plate string) (*models.CarInfo, error) {
+	plate = normalizePlate(plate)
+	target := strings.ReplaceAll(p.urlTemplate, "{plate}", url.PathEscape(plate))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("%w: status %d", ErrBlocked, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	decoder := json.NewDecoder(io.LimitReader(resp.Body, maxJSONResponseSize))
+	decoder.UseNumber()
+	var payload interface{}
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParse, err)
+	}
+
+	info := &models.CarInfo{Confiabilidade: 0.9}
+	for key, field := range carInfoFields(info) {
+		*field = lookupJSONPath(payload, p.fields[key])
+	}
+	if info.Marca == "" || info.Modelo == "" {
+		return nil, ErrNotFound
+	}
+
+	info.Placa = plate
+	if info.DataConsulta == "" {
+		info.DataConsulta = time.Now().Format(time.RFC3339)
+	}
+	return info, nil
+}
+
+// lookupJSONPath percorre um caminho separado por pontos e retorna o valor como texto
+func lookupJSONPath(value interface{}, path string) string {
+	for _, part := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = object[part]
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "SIM"
+		}
+		return "NÃO"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,263 @@
+package vehicledata
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+
+	"partexplorer/backend/internal/models"
+)
+
+func init() {
+	Register("keplaca", func() (PlateProvider, error) {
+		return newKeplacaProvider(), nil
+	})
+}
+
+// keplacaUserAgents são alternados quando o keplaca bloqueia a requisição HTTP
+var keplacaUserAgents = []string{
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+}
+
+// Padrões baseados no HTML real do keplaca.com
+var (
+	keplacaMarcaPattern       = keplacaFieldPattern("Marca")
+	keplacaModeloPattern      = keplacaFieldPattern("Modelo")
+	keplacaAnoPattern         = keplacaFieldPattern("Ano")
+	keplacaAnoModeloPattern   = keplacaFieldPattern("Ano Modelo")
+	keplacaCorPattern         = keplacaFieldPattern("Cor")
+	keplacaCombustivelPattern = keplacaFieldPattern("Combustível")
+	keplacaChassiPattern      = keplacaFieldPattern("Chassi")
+	keplacaUFPattern          = keplacaFieldPattern("UF")
+	keplacaMunicipioPattern   = keplacaFieldPattern("Município")
+	keplacaImportadoPattern   = keplacaFieldPattern("Importado")
+	keplacaFipePattern        = regexp.MustCompile(`(?i)(?:fipe|código fipe)[:\s]*([0-9]{6}-[0-9])`)
+	keplacaValorFipePattern   = regexp.MustCompile(`(?i)(?:valor|preço)[:\s]*R\$([0-9,\.]+)`)
+)
+
+// keplacaFieldPattern casa uma linha "<b>Rótulo:</b>" da tabela de detalhes do keplaca.com
+func keplacaFieldPattern(label string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)<td[^>]*><b>` + label + `:</b></td><td>([^<]+)</td>`)
+}
+
+// keplacaProvider consulta o keplaca.com: primeiro com Chrome headless (mais eficaz contra
+// Cloudflare) e, se falhar, por HTTP simples
+type keplacaProvider struct {
+	baseURL  string
+	chrome   bool
+	browsers *chromePool
+	client   *http.Client
+}
+
+// newKeplacaProvider cria o provedor. KEPLACA_URL permite apontar para outro endereço,
+// KEPLACA_CHROME=false desliga o Chrome headless e KEPLACA_CHROME_POOL limita quantos
+// navegadores rodam ao mesmo tempo.
+func newKeplacaProvider() *keplacaProvider {
+	baseURL := os.Getenv("KEPLACA_URL")
+	if baseURL == "" {
+		baseURL = "https://www.keplaca.com/placa"
+	}
+
+	// Opções do Chrome otimizadas para performance
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", true),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.Flag("disable-web-security", true),
+		chromedp.Flag("disable-features", "VizDisplayCompositor"),
+		chromedp.Flag("disable-extensions", true),
+		chromedp.Flag("disable-plugins", true),
+		chromedp.Flag("disable-images", true),
+		chromedp.Flag("disable-javascript", false), // Manter JS para Cloudflare
+		chromedp.Flag("disable-background-timer-throttling", true),
+		chromedp.Flag("disable-backgrounding-occluded-windows", true),
+		chromedp.Flag("disable-renderer-backgrounding", true),
+		chromedp.Flag("disable-background-networking", true),
+		chromedp.UserAgent(keplacaUserAgents[0]),
+	)
+
+	return &keplacaProvider{
+		baseURL:  baseURL,
+		chrome:   os.Getenv("KEPLACA_CHROME") != "false",
+		browsers: newChromePool(opts),
+		client: &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return nil // Permitir redirects
+			},
+		},
+	}
+}
+
+// Name identifica o provedor
+func (p *keplacaProvider) Name() string {
+	return "keplaca"
+}
+
+// Lookup busca a placa no keplaca.com
+func (p *keplacaProvider) Lookup(ctx context.Context, plate string) (*models.CarInfo, error) {
+	plate = normalizePlate(plate)
+	url := fmt.Sprintf("%s?placa-fipe=%s", p.baseURL, plate)
+
+	if p.chrome {
+		html, err := p.fetchWithChrome(ctx, url)
+		if err == nil {
+			if info := extractKeplacaHTML(plate, html); info != nil {
+				return info, nil
+			}
+			log.Printf("⚠️ [KEPLACA] Não foi possível extrair dados via ChromeDP para %s", plate)
+		} else {
+			log.Printf("⚠️ [KEPLACA] ChromeDP falhou para %s, tentando HTTP: %v", plate, err)
+		}
+	}
+
+	html, err := p.fetchWithHTTP(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if info := extractKeplacaHTML(plate, html); info != nil {
+		return info, nil
+	}
+	return nil, ErrNotFound
+}
+
+// fetchWithChrome obtém o HTML da página com um dos Chrome headless do pool
+func (p *keplacaProvider) fetchWithChrome(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	var html string
+	err := p.browsers.Run(ctx,
+		chromedp.Navigate(url),
+		chromedp.Sleep(3*time.Second),
+		chromedp.WaitReady("body", chromedp.ByQuery),
+		chromedp.OuterHTML("html", &html),
+	)
+	if err != nil {
+		return "", fmt.Errorf("chromedp failed: %w", err)
+	}
+
+	log.Printf("📄 [KEPLACA] HTML obtido via ChromeDP (%d bytes)", len(html))
+	return html, nil
+}
+
+// fetchWithHTTP obtém o HTML por HTTP, trocando o User-Agent quando bloqueado
+func (p *keplacaProvider) fetchWithHTTP(ctx context.Context, url string) (string, error) {
+	var lastErr error
+	for i, userAgent := range keplacaUserAgents {
+		if i > 0 {
+			// Delay entre tentativas
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(3 * time.Second):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create request: %w", err)
+		}
+
+		// Headers de navegador para driblar Cloudflare
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
+		req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en;q=0.8")
+		req.Header.Set("DNT", "1")
+		req.Header.Set("Upgrade-Insecure-Requests", "1")
+		req.Header.Set("Sec-Fetch-Dest", "document")
+		req.Header.Set("Sec-Fetch-Mode", "navigate")
+		req.Header.Set("Sec-Fetch-Site", "none")
+		req.Header.Set("Sec-Fetch-User", "?1")
+		req.Header.Set("Cache-Control", "no-cache")
+		req.Header.Set("Pragma", "no-cache")
+		req.Header.Set("Referer", "https://www.google.com/")
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("request failed: %w", err)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("%w: status %d", ErrBlocked, resp.StatusCode)
+			log.Printf("⚠️ [KEPLACA] Bloqueado (status %d), tentando próximo User-Agent...", resp.StatusCode)
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read response: %w", err)
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return "", ErrNotFound
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+
+		log.Printf("📄 [KEPLACA] HTML obtido via HTTP (%d bytes)", len(body))
+		return string(body), nil
+	}
+
+	return "", lastErr
+}
+
+// extractKeplacaHTML extrai os dados do veículo do HTML do keplaca.com. Retorna nil sem
+// marca e modelo; campos não encontrados ficam vazios para outros provedores completarem.
+func extractKeplacaHTML(plate, html string) *models.CarInfo {
+	find := func(pattern *regexp.Regexp) string {
+		if match := pattern.FindStringSubmatch(html); len(match) > 1 {
+			return strings.TrimSpace(match[1])
+		}
+		return ""
+	}
+
+	info := &models.CarInfo{
+		Placa:          plate,
+		Marca:          find(keplacaMarcaPattern),
+		Modelo:         find(keplacaModeloPattern),
+		Ano:            find(keplacaAnoPattern),
+		AnoModelo:      find(keplacaAnoModeloPattern),
+		Cor:            find(keplacaCorPattern),
+		Combustivel:    find(keplacaCombustivelPattern),
+		Chassi:         find(keplacaChassiPattern),
+		Municipio:      find(keplacaMunicipioPattern),
+		UF:             find(keplacaUFPattern),
+		Importado:      find(keplacaImportadoPattern),
+		CodigoFipe:     find(keplacaFipePattern),
+		DataConsulta:   time.Now().Format(time.RFC3339),
+		Confiabilidade: 0.95, // Alta confiabilidade para dados reais
+	}
+	if valor := find(keplacaValorFipePattern); valor != "" {
+		info.ValorFipe = "R$ " + valor
+	}
+
+	if info.Marca == "" || info.Modelo == "" {
+		log.Printf("❌ [KEPLACA] Dados insuficientes: marca='%s', modelo='%s'", info.Marca, info.Modelo)
+		return nil
+	}
+
+	// Sem ano modelo, usar ano + 1
+	if info.AnoModelo == "" && info.Ano != "" {
+		if ano, err := strconv.Atoi(info.Ano); err == nil {
+			info.AnoModelo = strconv.Itoa(ano + 1)
+		}
+	}
+
+	log.Printf("✅ [KEPLACA] Dados extraídos: %s %s %s", info.Marca, info.Modelo, info.Ano)
+	return info
+}
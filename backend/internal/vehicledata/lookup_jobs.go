@@ -0,0 +1,249 @@
+package vehicledata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/plate"
+)
+
+// Status possíveis de uma consulta de placa em background
+const (
+	LookupStatusQueued    = "queued"
+	LookupStatusRunning   = "running"
+	LookupStatusCompleted = "completed"
+	LookupStatusNotFound  = "not_found"
+	LookupStatusFailed    = "failed"
+)
+
+// ErrLookupJobNotFound indica que o job informado não existe (ou já expirou)
+var ErrLookupJobNotFound = errors.New("plate lookup job not found")
+
+// Resolver obtém os dados do veículo, consultando o cache e os provedores.
+// database.CarRepository implementa esta interface.
+type Resolver interface {
+	SearchCarByPlate(plate string) (*models.CarInfo, error)
+}
+
+// LookupJob representa uma consulta de placa em background. Pedidos simultâneos para a mesma
+// placa compartilham o mesmo job.
+type LookupJob struct {
+	ID         string          `json:"id"`
+	Plate      string          `json:"plate"`
+	Status     string          `json:"status"`
+	CarInfo    *models.CarInfo `json:"car_info,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	Requests   int             `json:"requests"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+
+	done chan struct{}
+}
+
+// Finished indica se o job já terminou (com ou sem sucesso)
+func (j *LookupJob) Finished() bool {
+	return j.Status == LookupStatusCompleted || j.Status == LookupStatusNotFound || j.Status == LookupStatusFailed
+}
+
+// LookupManager executa as consultas de placa em background com um número limitado de workers
+// e agrupa pedidos simultâneos da mesma placa num único job
+type LookupManager struct {
+	resolver  Resolver
+	slots     chan struct{}
+	retention time.Duration
+
+	mu       sync.Mutex
+	jobs     map[string]*LookupJob
+	inflight map[string]*LookupJob // por plate.Key: as duas formas da placa dividem o job
+}
+
+// NewLookupManager cria o gerenciador. PLATE_LOOKUP_WORKERS limita as consultas simultâneas
+// (padrão 4) e PLATE_LOOKUP_RETENTION define por quanto tempo jobs terminados ficam
+// disponíveis para consulta (padrão 10m).
+func NewLookupManager(resolver Resolver) *LookupManager {
+	workers := 4
+	if value, err := strconv.Atoi(os.Getenv("PLATE_LOOKUP_WORKERS")); err == nil && value > 0 {
+		workers = value
+	}
+
+	retention := 10 * time.Minute
+	if value, err := time.ParseDuration(os.Getenv("PLATE_LOOKUP_RETENTION")); err == nil && value > 0 {
+		retention = value
+	}
+
+	return &LookupManager{
+		resolver:  resolver,
+		slots:     make(chan struct{}, workers),
+		retention: retention,
+		jobs:      make(map[string]*LookupJob),
+		inflight:  make(map[string]*LookupJob),
+	}
+}
+
+// Submit agenda a consulta da placa. Se já existe um job em andamento para a placa (em
+// qualquer um dos dois padrões), ele é reaproveitado e coalesced é true.
+func (m *LookupManager) Submit(value string) (job *LookupJob, coalesced bool) {
+	value = normalizePlate(value)
+	key := value
+	if parsed, err := plate.Parse(value); err == nil {
+		key = parsed.Key()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.purge()
+
+	if existing, ok := m.inflight[key]; ok {
+		existing.Requests++
+		snapshot := *existing
+		return &snapshot, true
+	}
+
+	created := &LookupJob{
+		ID:        uuid.New().String(),
+		Plate:     value,
+		Status:    LookupStatusQueued,
+		Requests:  1,
+		CreatedAt: time.Now(),
+		done:      make(chan struct{}),
+	}
+	m.jobs[created.ID] = created
+	m.inflight[key] = created
+
+	go m.run(created, key)
+
+	snapshot := *created
+	return &snapshot, false
+}
+
+// Get retorna o estado atual de um job
+func (m *LookupManager) Get(id string) (*LookupJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrLookupJobNotFound
+	}
+
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// Wait aguarda o fim do job ou o cancelamento do ctx. Se o ctx terminar antes, retorna o
+// estado atual do job junto com o erro do ctx.
+func (m *LookupManager) Wait(ctx context.Context, id string) (*LookupJob, error) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrLookupJobNotFound
+	}
+
+	var err error
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := *job
+	return &snapshot, err
+}
+
+// Lookup agenda a consulta (ou entra num job existente) e aguarda o resultado até o fim do ctx
+func (m *LookupManager) Lookup(ctx context.Context, plate string) (*LookupJob, error) {
+	job, _ := m.Submit(plate)
+	return m.Wait(ctx, job.ID)
+}
+
+// List retorna os jobs conhecidos, do mais recente para o mais antigo
+func (m *LookupManager) List() []LookupJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]LookupJob, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// run espera um worker livre e executa a consulta
+func (m *LookupManager) run(job *LookupJob, key string) {
+	m.slots <- struct{}{}
+	defer func() { <-m.slots }()
+
+	m.update(job, func() {
+		now := time.Now()
+		job.Status = LookupStatusRunning
+		job.StartedAt = &now
+	})
+
+	info, err := m.resolve(job.Plate)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	job.FinishedAt = &now
+	switch {
+	case err == nil && info != nil:
+		job.Status = LookupStatusCompleted
+		job.CarInfo = info
+	case err == nil || errors.Is(err, ErrNotFound):
+		job.Status = LookupStatusNotFound
+		job.Message = "vehicle not found"
+	default:
+		job.Status = LookupStatusFailed
+		job.Message = err.Error()
+	}
+	log.Printf("🚗 [PLATE-JOB] Job %s (%s): %s em %v, %d pedido(s)", job.ID, job.Plate, job.Status, now.Sub(job.CreatedAt), job.Requests)
+
+	delete(m.inflight, key)
+	close(job.done)
+}
+
+// resolve chama o Resolver, convertendo panics em erro para não derrubar o servidor
+func (m *LookupManager) resolve(plate string) (info *models.CarInfo, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("💥 [PLATE-JOB] PANIC na consulta da placa %s: %v", plate, r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return m.resolver.SearchCarByPlate(plate)
+}
+
+// purge remove os jobs terminados há mais tempo que a retenção. Deve ser chamado com o lock.
+func (m *LookupManager) purge() {
+	for id, job := range m.jobs {
+		if job.FinishedAt != nil && time.Since(*job.FinishedAt) > m.retention {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// update aplica uma alteração no job sob o lock
+func (m *LookupManager) update(job *LookupJob, fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn()
+}
@@ -0,0 +1,297 @@
+package vehicledata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"partexplorer/backend/internal/models"
+	"partexplorer/backend/internal/plate"
+)
+
+// defaultOrder é a ordem de consulta quando PLATE_PROVIDERS não está definido
+const defaultOrder = "keplaca"
+
+// defaultTimeout é o tempo máximo de cada provedor quando não configurado
+const defaultTimeout = 30 * time.Second
+
+var (
+	// ErrNotFound indica que o provedor respondeu, mas não conhece a placa
+	ErrNotFound = errors.New("vehicle not found")
+	// ErrBlocked indica que o provedor recusou a consulta (403/429, anti-bot)
+	ErrBlocked = errors.New("blocked by provider")
+	// ErrParse indica que a resposta do provedor não pôde ser interpretada
+	ErrParse = errors.New("failed to parse provider response")
+)
+
+// PlateProvider consulta os dados de um veículo pela placa
+type PlateProvider interface {
+	// Name identifica o provedor em PLATE_PROVIDERS e no CarInfo retornado
+	Name() string
+	// Lookup retorna os dados do veículo ou ErrNotFound. Deve respeitar o cancelamento do ctx.
+	Lookup(ctx context.Context, plate string) (*models.CarInfo, error)
+}
+
+// Factory cria um provedor a partir das variáveis de ambiente
+type Factory func() (PlateProvider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register registra um provedor pelo nome usado em PLATE_PROVIDERS
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(name)] = factory
+}
+
+// Registered lista os nomes dos provedores registrados
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Chain consulta os provedores em ordem, cada um com seu timeout, e mescla as respostas
+type Chain struct {
+	providers []PlateProvider
+	timeouts  map[string]time.Duration
+}
+
+// NewChain cria uma cadeia com os provedores na ordem informada. Provedores sem timeout
+// em timeouts usam o padrão de 30s.
+func NewChain(providers []PlateProvider, timeouts map[string]time.Duration) *Chain {
+	if timeouts == nil {
+		timeouts = make(map[string]time.Duration)
+	}
+	return &Chain{providers: providers, timeouts: timeouts}
+}
+
+// NewChainFromEnv monta a cadeia a partir de PLATE_PROVIDERS (nomes separados por vírgula,
+// na ordem de consulta). O timeout padrão vem de PLATE_PROVIDER_TIMEOUT e pode ser
+// sobrescrito por provedor em PLATE_PROVIDER_TIMEOUT_<NOME>. Cada provedor é protegido por
+// circuit breaker, limite de taxa e retentativas (ver guardConfigFromEnv).
+func NewChainFromEnv() (*Chain, error) {
+	order := os.Getenv("PLATE_PROVIDERS")
+	if strings.TrimSpace(order) == "" {
+		order = defaultOrder
+	}
+
+	fallback := defaultTimeout
+	if value, err := time.ParseDuration(os.Getenv("PLATE_PROVIDER_TIMEOUT")); err == nil && value > 0 {
+		fallback = value
+	}
+
+	var providers []PlateProvider
+	timeouts := make(map[string]time.Duration)
+	for _, name := range strings.Split(order, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		registryMu.RLock()
+		factory, ok := registry[name]
+		registryMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown plate provider %q (registered: %s)", name, strings.Join(Registered(), ", "))
+		}
+
+		provider, err := factory()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create plate provider %s: %w", name, err)
+		}
+		providers = append(providers, NewGuardedProvider(provider, guardConfigFromEnv(name)))
+
+		timeouts[name] = fallback
+		if value, err := time.ParseDuration(os.Getenv("PLATE_PROVIDER_TIMEOUT_" + strings.ToUpper(name))); err == nil && value > 0 {
+			timeouts[name] = value
+		}
+	}
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no plate providers configured")
+	}
+	return NewChain(providers, timeouts), nil
+}
+
+var (
+	defaultOnce  sync.Once
+	defaultChain *Chain
+)
+
+// Default retorna a cadeia configurada pelo ambiente ou, se a configuração for inválida,
+// apenas o keplaca
+func Default() *Chain {
+	defaultOnce.Do(func() {
+		chain, err := NewChainFromEnv()
+		if err != nil {
+			log.Printf("⚠️ [PLATE] Configuração de provedores inválida, usando %s: %v", defaultOrder, err)
+			keplaca := newKeplacaProvider()
+			chain = NewChain([]PlateProvider{NewGuardedProvider(keplaca, guardConfigFromEnv(keplaca.Name()))}, nil)
+		}
+		log.Printf("✅ [PLATE] Provedores de placa: %s", strings.Join(chain.Providers(), ", "))
+		defaultChain = chain
+	})
+
+	return defaultChain
+}
+
+// Providers retorna os nomes dos provedores na ordem de consulta
+func (c *Chain) Providers() []string {
+	names := make([]string, len(c.providers))
+	for i, provider := range c.providers {
+		names[i] = provider.Name()
+	}
+	return names
+}
+
+// Status retorna a saúde de cada provedor, na ordem de consulta
+func (c *Chain) Status() []ProviderStatus {
+	statuses := make([]ProviderStatus, len(c.providers))
+	for i, provider := range c.providers {
+		status := ProviderStatus{Name: provider.Name(), State: CircuitClosed}
+		if guarded, ok := provider.(*GuardedProvider); ok {
+			status = guarded.Status()
+		}
+		status.Timeout = c.timeout(provider.Name()).String()
+		statuses[i] = status
+	}
+	return statuses
+}
+
+// timeout retorna o tempo máximo de consulta do provedor
+func (c *Chain) timeout(name string) time.Duration {
+	if timeout, ok := c.timeouts[name]; ok {
+		return timeout
+	}
+	return defaultTimeout
+}
+
+// Lookup consulta os provedores em ordem até obter marca, modelo e ano. A primeira resposta
+// é a base (e define Provedor); as seguintes só preenchem os campos que faltam.
+func (c *Chain) Lookup(ctx context.Context, plate string) (*models.CarInfo, error) {
+	var merged *models.CarInfo
+	var failures []string
+	var errs []error
+	notFound := true
+
+	for _, provider := range c.providers {
+		if ctx.Err() != nil {
+			break
+		}
+
+		providerCtx, cancel := context.WithTimeout(ctx, c.timeout(provider.Name()))
+		start := time.Now()
+		info, err := provider.Lookup(providerCtx, plate)
+		cancel()
+
+		if err == nil && info == nil {
+			err = ErrNotFound
+		}
+		if err != nil {
+			log.Printf("⚠️ [PLATE] %s não respondeu para %s em %v: %v", provider.Name(), plate, time.Since(start), err)
+			failures = append(failures, fmt.Sprintf("%s: %v", provider.Name(), err))
+			errs = append(errs, err)
+			notFound = notFound && errors.Is(err, ErrNotFound)
+			continue
+		}
+
+		log.Printf("✅ [PLATE] %s respondeu para %s em %v", provider.Name(), plate, time.Since(start))
+		if merged == nil {
+			answer := *info
+			answer.Provedor = provider.Name()
+			merged = &answer
+		} else {
+			fillMissing(merged, info)
+		}
+		if hasMinimalInfo(merged) {
+			break
+		}
+	}
+
+	if merged == nil {
+		if notFound && ctx.Err() == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, strings.Join(failures, "; "))
+		}
+		return nil, &chainError{message: "no plate provider answered: " + strings.Join(failures, "; "), errs: errs}
+	}
+
+	merged.Placa = plate
+	if merged.DataConsulta == "" {
+		merged.DataConsulta = time.Now().Format(time.RFC3339)
+	}
+	return merged, nil
+}
+
+// hasMinimalInfo indica se o veículo tem o mínimo para buscar peças
+func hasMinimalInfo(info *models.CarInfo) bool {
+	return info.Marca != "" && info.Modelo != "" && info.Ano != ""
+}
+
+// fillMissing preenche os campos vazios de dst com os valores de src
+func fillMissing(dst, src *models.CarInfo) {
+	srcFields := carInfoFields(src)
+	for key, field := range carInfoFields(dst) {
+		if *field == "" {
+			*field = *srcFields[key]
+		}
+	}
+	if dst.Confiabilidade == 0 {
+		dst.Confiabilidade = src.Confiabilidade
+	}
+}
+
+// carInfoFields mapeia o nome JSON de cada campo texto do CarInfo para o campo
+func carInfoFields(info *models.CarInfo) map[string]*string {
+	return map[string]*string{
+		"placa":         &info.Placa,
+		"marca":         &info.Marca,
+		"modelo":        &info.Modelo,
+		"ano":           &info.Ano,
+		"ano_modelo":    &info.AnoModelo,
+		"cor":           &info.Cor,
+		"combustivel":   &info.Combustivel,
+		"chassi":        &info.Chassi,
+		"municipio":     &info.Municipio,
+		"uf":            &info.UF,
+		"importado":     &info.Importado,
+		"codigo_fipe":   &info.CodigoFipe,
+		"valor_fipe":    &info.ValorFipe,
+		"data_consulta": &info.DataConsulta,
+	}
+}
+
+// normalizePlate remove separadores e coloca a placa em maiúsculas
+func normalizePlate(value string) string {
+	return plate.Normalize(value)
+}
+
+// chainError reúne as falhas dos provedores consultados, mantendo os erros originais para
+// errors.Is (ex. ErrBlocked)
+type chainError struct {
+	message string
+	errs    []error
+}
+
+func (e *chainError) Error() string {
+	return e.message
+}
+
+func (e *chainError) Unwrap() []error {
+	return e.errs
+}
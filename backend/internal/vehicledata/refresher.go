@@ -0,0 +1,157 @@
+package vehicledata
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"partexplorer/backend/internal/models"
+)
+
+// RefreshSource lista as placas com dados vencidos e as atualiza nos provedores.
+// database.CarRepository implementa esta interface.
+type RefreshSource interface {
+	StalePlates(queriedSince time.Time, limit int) ([]string, error)
+	RefreshCarByPlate(plate string) (*models.CarInfo, error)
+}
+
+// Refresher atualiza em background os dados vencidos das placas consultadas recentemente, para
+// que a próxima consulta já encontre o cache em dia
+type Refresher struct {
+	source RefreshSource
+
+	interval   time.Duration
+	recent     time.Duration
+	batchSize  int
+	retryAfter time.Duration
+
+	mu     sync.Mutex
+	failed map[string]time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewRefresher cria o refresher. CAR_REFRESH_INTERVAL (padrão 10m) é o intervalo entre lotes,
+// CAR_REFRESH_RECENT (padrão 72h) até quando uma consulta conta como recente e
+// CAR_REFRESH_BATCH (padrão 20) quantas placas atualizar por lote.
+func NewRefresher(source RefreshSource) *Refresher {
+	interval := 10 * time.Minute
+	if value, err := time.ParseDuration(os.Getenv("CAR_REFRESH_INTERVAL")); err == nil && value > 0 {
+		interval = value
+	}
+
+	recent := 72 * time.Hour
+	if value, err := time.ParseDuration(os.Getenv("CAR_REFRESH_RECENT")); err == nil && value > 0 {
+		recent = value
+	}
+
+	batchSize := 20
+	if value, err := strconv.Atoi(os.Getenv("CAR_REFRESH_BATCH")); err == nil && value > 0 {
+		batchSize = value
+	}
+
+	return &Refresher{
+		source:     source,
+		interval:   interval,
+		recent:     recent,
+		batchSize:  batchSize,
+		retryAfter: 6 * interval,
+		failed:     make(map[string]time.Time),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start inicia o loop em background
+func (r *Refresher) Start() {
+	go r.run()
+	log.Printf("✅ [CAR-REFRESH] Atualização de placas vencidas iniciada (intervalo %s, lote %d, consultas das últimas %s)", r.interval, r.batchSize, r.recent)
+}
+
+// Stop encerra o loop e aguarda o lote em andamento
+func (r *Refresher) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+}
+
+func (r *Refresher) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := r.RefreshBatch(); err != nil {
+			log.Printf("⚠️ [CAR-REFRESH] Erro ao buscar placas vencidas: %v", err)
+		}
+	}
+}
+
+// RefreshBatch atualiza um lote de placas vencidas, uma de cada vez para não disputar os
+// provedores com as consultas dos usuários. Placas que falharam esperam retryAfter antes de
+// uma nova tentativa. Retorna quantas foram atualizadas.
+func (r *Refresher) RefreshBatch() (int, error) {
+	// Busca folga para as placas que ainda estão aguardando nova tentativa
+	plates, err := r.source.StalePlates(time.Now().Add(-r.recent), 2*r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	refreshed, attempted := 0, 0
+	for _, plateNumber := range plates {
+		if attempted == r.batchSize {
+			break
+		}
+		if r.waiting(plateNumber) {
+			continue
+		}
+		select {
+		case <-r.stop:
+			return refreshed, nil
+		default:
+		}
+
+		attempted++
+		if _, err := r.source.RefreshCarByPlate(plateNumber); err != nil {
+			log.Printf("⚠️ [CAR-REFRESH] Falha ao atualizar placa %s: %v", plateNumber, err)
+			r.mu.Lock()
+			r.failed[plateNumber] = time.Now()
+			r.mu.Unlock()
+			continue
+		}
+		refreshed++
+	}
+
+	if attempted > 0 {
+		log.Printf("🔄 [CAR-REFRESH] %d de %d placa(s) vencida(s) atualizada(s)", refreshed, attempted)
+	}
+	return refreshed, nil
+}
+
+// waiting indica se a placa falhou há pouco e ainda não deve ser tentada de novo
+func (r *Refresher) waiting(plateNumber string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failedAt, ok := r.failed[plateNumber]
+	if !ok {
+		return false
+	}
+	if time.Since(failedAt) < r.retryAfter {
+		return true
+	}
+	delete(r.failed, plateNumber)
+	return false
+}
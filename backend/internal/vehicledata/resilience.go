@@ -0,0 +1,380 @@
+package vehicledata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"partexplorer/backend/internal/metrics"
+	"partexplorer/backend/internal/models"
+)
+
+// Estados do circuit breaker de um provedor
+const (
+	CircuitClosed   = "closed"
+	CircuitHalfOpen = "half_open"
+	CircuitOpen     = "open"
+)
+
+// ErrCircuitOpen indica que o provedor foi desligado temporariamente após falhas seguidas
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// circuitStateValues são os valores do gauge partexplorer_plate_provider_circuit_state
+var circuitStateValues = map[string]float64{
+	CircuitClosed:   0,
+	CircuitHalfOpen: 1,
+	CircuitOpen:     2,
+}
+
+// GuardConfig configura a proteção de um provedor: circuit breaker, limite de taxa e retentativas
+type GuardConfig struct {
+	// FailureThreshold é quantas falhas seguidas abrem o circuito
+	FailureThreshold int
+	// OpenTimeout é quanto tempo o circuito fica aberto antes de liberar uma tentativa
+	OpenTimeout time.Duration
+	// RateLimit é o máximo de consultas por segundo (0 = sem limite) e RateBurst a rajada
+	RateLimit float64
+	RateBurst int
+	// Retries é quantas vezes repetir uma falha transitória; o intervalo parte de RetryBackoff,
+	// dobra a cada tentativa e é sorteado entre zero e esse valor
+	Retries      int
+	RetryBackoff time.Duration
+}
+
+// DefaultGuardConfig retorna a proteção padrão dos provedores
+func DefaultGuardConfig() GuardConfig {
+	return GuardConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      time.Minute,
+		RateLimit:        1,
+		RateBurst:        3,
+		Retries:          1,
+		RetryBackoff:     500 * time.Millisecond,
+	}
+}
+
+// guardConfigFromEnv lê a proteção de um provedor. Cada variável (PLATE_BREAKER_FAILURES,
+// PLATE_BREAKER_OPEN_TIMEOUT, PLATE_RATE_LIMIT, PLATE_RATE_BURST, PLATE_RETRIES,
+// PLATE_RETRY_BACKOFF) pode ser sobrescrita por provedor com o sufixo _<NOME>.
+func guardConfigFromEnv(name string) GuardConfig {
+	lookup := func(key string) string {
+		if value := os.Getenv(key + "_" + strings.ToUpper(name)); value != "" {
+			return value
+		}
+		return os.Getenv(key)
+	}
+
+	cfg := DefaultGuardConfig()
+	if value, err := strconv.Atoi(lookup("PLATE_BREAKER_FAILURES")); err == nil && value > 0 {
+		cfg.FailureThreshold = value
+	}
+	if value, err := time.ParseDuration(lookup("PLATE_BREAKER_OPEN_TIMEOUT")); err == nil && value > 0 {
+		cfg.OpenTimeout = value
+	}
+	if value, err := strconv.ParseFloat(lookup("PLATE_RATE_LIMIT"), 64); err == nil && value >= 0 {
+		cfg.RateLimit = value
+	}
+	if value, err := strconv.Atoi(lookup("PLATE_RATE_BURST")); err == nil && value > 0 {
+		cfg.RateBurst = value
+	}
+	if value, err := strconv.Atoi(lookup("PLATE_RETRIES")); err == nil && value >= 0 {
+		cfg.Retries = value
+	}
+	if value, err := time.ParseDuration(lookup("PLATE_RETRY_BACKOFF")); err == nil && value > 0 {
+		cfg.RetryBackoff = value
+	}
+	return cfg
+}
+
+// CircuitBreaker desliga um provedor após falhas seguidas. Aberto, rejeita as consultas até
+// OpenTimeout passar; então libera uma única tentativa (meio-aberto), que fecha o circuito se
+// der certo ou o reabre se falhar.
+type CircuitBreaker struct {
+	name        string
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker cria um circuit breaker fechado
+func NewCircuitBreaker(name string, threshold int, openTimeout time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	metrics.SetPlateProviderCircuitState(name, circuitStateValues[CircuitClosed])
+	return &CircuitBreaker{
+		name:        name,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		state:       CircuitClosed,
+	}
+}
+
+// Allow indica se uma consulta pode seguir. No estado meio-aberto só a primeira passa; as
+// demais recebem ErrCircuitOpen até ela terminar.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+		b.setState(CircuitHalfOpen)
+		b.probing = true
+		return nil
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success registra uma consulta respondida e fecha o circuito
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != CircuitClosed {
+		b.setState(CircuitClosed)
+	}
+}
+
+// Failure registra uma falha; abre o circuito no limite de falhas ou se a tentativa do estado
+// meio-aberto falhar
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(CircuitOpen)
+	}
+}
+
+// Release libera a tentativa do estado meio-aberto sem contar sucesso nem falha (consulta
+// cancelada por quem pediu)
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State retorna o estado atual, as falhas seguidas e até quando o circuito fica aberto
+func (b *CircuitBreaker) State() (state string, failures int, openUntil *time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		until := b.openedAt.Add(b.openTimeout)
+		openUntil = &until
+	}
+	return b.state, b.failures, openUntil
+}
+
+// setState troca o estado e atualiza o gauge. Deve ser chamado com o lock.
+func (b *CircuitBreaker) setState(state string) {
+	log.Printf("🔌 [PLATE] Circuit breaker de %s: %s -> %s (%d falhas seguidas)", b.name, b.state, state, b.failures)
+	b.state = state
+	metrics.SetPlateProviderCircuitState(b.name, circuitStateValues[state])
+}
+
+// TokenBucket limita a taxa de consultas: cada consulta consome um token e os tokens são
+// repostos a rate por segundo, até burst. Um TokenBucket nil não limita.
+type TokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket cria o limitador cheio. Com rate <= 0 retorna nil (sem limite).
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait consome um token, aguardando a reposição se necessário, ou retorna o erro do ctx
+func (t *TokenBucket) Wait(ctx context.Context) error {
+	if t == nil {
+		return ctx.Err()
+	}
+
+	for {
+		t.mu.Lock()
+		t.refill()
+		if t.tokens >= 1 {
+			t.tokens--
+			t.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - t.tokens) / t.rate * float64(time.Second))
+		t.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Tokens retorna os tokens disponíveis agora
+func (t *TokenBucket) Tokens() float64 {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refill()
+	return t.tokens
+}
+
+// refill repõe os tokens pelo tempo decorrido. Deve ser chamado com o lock.
+func (t *TokenBucket) refill() {
+	now := time.Now()
+	t.tokens = math.Min(t.burst, t.tokens+now.Sub(t.last).Seconds()*t.rate)
+	t.last = now
+}
+
+// ProviderStatus descreve a saúde de um provedor de placa
+type ProviderStatus struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+	RateLimit           float64    `json:"rate_limit"`
+	AvailableTokens     float64    `json:"available_tokens"`
+	Timeout             string     `json:"timeout"`
+}
+
+// GuardedProvider envolve um provedor com circuit breaker, limite de taxa e retentativas
+type GuardedProvider struct {
+	provider PlateProvider
+	breaker  *CircuitBreaker
+	limiter  *TokenBucket
+	cfg      GuardConfig
+}
+
+// NewGuardedProvider protege o provedor com a configuração informada
+func NewGuardedProvider(provider PlateProvider, cfg GuardConfig) *GuardedProvider {
+	return &GuardedProvider{
+		provider: provider,
+		breaker:  NewCircuitBreaker(provider.Name(), cfg.FailureThreshold, cfg.OpenTimeout),
+		limiter:  NewTokenBucket(cfg.RateLimit, cfg.RateBurst),
+		cfg:      cfg,
+	}
+}
+
+// Name identifica o provedor protegido
+func (g *GuardedProvider) Name() string {
+	return g.provider.Name()
+}
+
+// Lookup consulta o provedor respeitando o circuit breaker e o limite de taxa. Falhas
+// transitórias são repetidas com intervalo aleatório crescente; ErrNotFound conta como
+// resposta e não é repetido.
+func (g *GuardedProvider) Lookup(ctx context.Context, plate string) (*models.CarInfo, error) {
+	name := g.provider.Name()
+	for attempt := 0; ; attempt++ {
+		if err := g.breaker.Allow(); err != nil {
+			metrics.RecordPlateProviderRequest(name, "rejected")
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := g.limiter.Wait(ctx); err != nil {
+			g.breaker.Release()
+			metrics.RecordPlateProviderRequest(name, "rate_limited")
+			return nil, fmt.Errorf("%s rate limit: %w", name, err)
+		}
+
+		info, err := g.provider.Lookup(ctx, plate)
+		switch {
+		case err == nil:
+			g.breaker.Success()
+			metrics.RecordPlateProviderRequest(name, "success")
+			return info, nil
+		case errors.Is(err, ErrNotFound):
+			g.breaker.Success()
+			metrics.RecordPlateProviderRequest(name, "not_found")
+			return nil, err
+		case errors.Is(err, context.Canceled):
+			g.breaker.Release()
+			metrics.RecordPlateProviderRequest(name, "cancelled")
+			return nil, err
+		}
+
+		g.breaker.Failure()
+		metrics.RecordPlateProviderRequest(name, "error")
+		if attempt >= g.cfg.Retries || ctx.Err() != nil {
+			return nil, err
+		}
+
+		backoff := g.backoff(attempt)
+		log.Printf("🔁 [PLATE] %s falhou para %s (%v), nova tentativa em %v", name, plate, err, backoff)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff sorteia o intervalo da próxima tentativa entre zero e RetryBackoff * 2^attempt
+func (g *GuardedProvider) backoff(attempt int) time.Duration {
+	ceiling := g.cfg.RetryBackoff << attempt
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// Status retorna o estado do circuit breaker e do limitador
+func (g *GuardedProvider) Status() ProviderStatus {
+	state, failures, openUntil := g.breaker.State()
+	return ProviderStatus{
+		Name:                g.provider.Name(),
+		State:               state,
+		ConsecutiveFailures: failures,
+		OpenUntil:           openUntil,
+		RateLimit:           g.cfg.RateLimit,
+		AvailableTokens:     math.Floor(g.limiter.Tokens()*100) / 100,
+	}
+}
@@ -0,0 +1,255 @@
+package vehiclemodel
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"partexplorer/backend/internal/manufacturer"
+	"partexplorer/backend/internal/synonyms"
+)
+
+// importedPrefix marca veículos importados no padrão do DENATRAN ("I/VW GOLF")
+const importedPrefix = "I/"
+
+var (
+	displacementPattern = regexp.MustCompile(`\b(\d)[.,](\d)\b`)
+	valvesPattern       = regexp.MustCompile(`\b(\d{1,2})V\b`)
+)
+
+// Source é a marca/modelo informados pelo provedor de placa, já normalizados. As marcas são
+// reconhecidas pelo dicionário de fabricantes (manufacturer.Default).
+type Source struct {
+	// Brand e Model são os textos do provedor normalizados, usados como chave do mapeamento
+	Brand string
+	Model string
+	// Manufacturer é o fabricante canônico (vazio se o provedor não informou a marca)
+	Manufacturer string
+	// Tokens são as palavras do modelo, sem a marca repetida no início
+	Tokens []string
+}
+
+// ParseSource normaliza marca e modelo do provedor. Aceita o formato do DENATRAN com a marca
+// no modelo ("VW/GOL 1.0 GIV", "I/VW GOLF") e a marca repetida no início ("CHEV ONIX 1.0").
+func ParseSource(brand, model string) Source {
+	brand = normalize(brand)
+	model = normalize(model)
+	model = strings.TrimPrefix(model, importedPrefix)
+
+	aliases := manufacturer.Default()
+	if index := strings.Index(model, "/"); index > 0 {
+		prefix := model[:index]
+		if _, ok := aliases.Lookup(prefix); ok {
+			if brand == "" {
+				brand = prefix
+			}
+			model = strings.TrimSpace(model[index+1:])
+		}
+	}
+	brand = strings.TrimPrefix(brand, importedPrefix)
+	if index := strings.Index(brand, "/"); index > 0 {
+		brand = brand[:index]
+	}
+
+	source := Source{Brand: brand, Model: model}
+	if brand != "" {
+		source.Manufacturer = aliases.Canonical(brand)
+	}
+	tokens := strings.Fields(model)
+	for len(tokens) > 1 {
+		canonical, ok := aliases.Lookup(tokens[0])
+		if !ok || (source.Manufacturer != "" && canonical != source.Manufacturer) {
+			break
+		}
+		if source.Manufacturer == "" {
+			source.Manufacturer = canonical
+		}
+		tokens = tokens[1:]
+	}
+	source.Tokens = tokens
+	return source
+}
+
+// ModelGuess retorna o palpite de modelo sem o catálogo: a primeira palavra do modelo
+func (s Source) ModelGuess() string {
+	if len(s.Tokens) == 0 {
+		return ""
+	}
+	return s.Tokens[0]
+}
+
+// Candidate é uma combinação fabricante/modelo/versão/motor existente no catálogo
+type Candidate struct {
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+	Version      string `json:"version,omitempty"`
+	Engine       string `json:"engine,omitempty"`
+}
+
+// Match é um candidato com a confiança (0 a 1) de que corresponde ao modelo do provedor
+type Match struct {
+	Candidate
+	Confidence float64 `json:"confidence"`
+}
+
+// Rank compara o modelo do provedor com os candidatos do mesmo fabricante e retorna os
+// melhores, do mais provável para o menos provável (no máximo limit)
+func Rank(source Source, candidates []Candidate, limit int) []Match {
+	text := strings.Join(source.Tokens, " ")
+	displacement := displacementPattern.FindString(text)
+	valves := valvesPattern.FindString(text)
+
+	aliases := manufacturer.Default()
+	var matches []Match
+	for _, candidate := range candidates {
+		if source.Manufacturer != "" && aliases.Canonical(candidate.Manufacturer) != source.Manufacturer {
+			continue
+		}
+		modelScore, covered := scoreModel(source.Tokens, strings.Fields(normalize(candidate.Model)))
+		if modelScore == 0 {
+			continue
+		}
+
+		confidence := 0.70*modelScore +
+			0.20*scoreEngine(displacement, valves, normalize(candidate.Engine)) +
+			0.10*scoreVersion(source.Tokens[covered:], strings.Fields(normalize(candidate.Version)))
+		matches = append(matches, Match{Candidate: candidate, Confidence: round(confidence)})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Confidence != matches[j].Confidence {
+			return matches[i].Confidence > matches[j].Confidence
+		}
+		// Em empate, o modelo mais específico ("ONIX PLUS" antes de "ONIX")
+		return len(matches[i].Model) > len(matches[j].Model)
+	})
+
+	// Dois modelos diferentes quase empatados: o melhor palpite precisa de revisão. Um modelo
+	// que apenas estende o outro ("ONIX PLUS" e "ONIX") não é ambíguo.
+	if len(matches) > 1 && matches[0].Confidence-matches[1].Confidence < 0.05 {
+		best, runnerUp := normalize(matches[0].Model), normalize(matches[1].Model)
+		if best != runnerUp && !strings.HasPrefix(best+" ", runnerUp+" ") {
+			matches[0].Confidence = round(matches[0].Confidence * 0.9)
+		}
+	}
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Best retorna o candidato mais provável, se houver algum
+func Best(source Source, candidates []Candidate) (Match, bool) {
+	matches := Rank(source, candidates, 1)
+	if len(matches) == 0 {
+		return Match{}, false
+	}
+	return matches[0], true
+}
+
+// scoreModel compara o início do modelo do provedor com o modelo do catálogo. Retorna a
+// similaridade e quantas palavras do provedor o modelo cobre.
+func scoreModel(source, model []string) (float64, int) {
+	if len(source) == 0 || len(model) == 0 {
+		return 0, 0
+	}
+
+	n := len(model)
+	if n > len(source) {
+		n = len(source)
+	}
+	if equalTokens(source[:n], model) {
+		return 1, n
+	}
+
+	// Erros de digitação e variações ("CELTA" / "CELTA5", "SANDERO" / "SANDER")
+	similarity := trigramSimilarity(strings.Join(source[:n], " "), strings.Join(model, " "))
+	if similarity < 0.4 {
+		return 0, 0
+	}
+	return similarity, n
+}
+
+// scoreEngine compara cilindrada e válvulas do modelo do provedor com o motor do catálogo:
+// 1 quando confere, 0 quando diverge e 0.5 quando não há como comparar
+func scoreEngine(displacement, valves, engine string) float64 {
+	if displacement == "" || engine == "" {
+		return 0.5
+	}
+	engineDisplacement := displacementPattern.FindString(engine)
+	if engineDisplacement == "" {
+		return 0.5
+	}
+	if strings.ReplaceAll(engineDisplacement, ",", ".") != strings.ReplaceAll(displacement, ",", ".") {
+		return 0
+	}
+	if engineValves := valvesPattern.FindString(engine); valves != "" && engineValves != "" && engineValves != valves {
+		return 0.5
+	}
+	return 1
+}
+
+// scoreVersion verifica se as palavras da versão do catálogo aparecem no restante do modelo
+func scoreVersion(rest, version []string) float64 {
+	if len(version) == 0 {
+		return 0.5
+	}
+	present := make(map[string]bool, len(rest))
+	for _, token := range rest {
+		present[token] = true
+	}
+	for _, token := range version {
+		if !present[token] {
+			return 0
+		}
+	}
+	return 1
+}
+
+// trigramSimilarity é a similaridade de Jaccard entre os trigramas dos textos, como no pg_trgm
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for gram := range ta {
+		if tb[gram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(text string) map[string]bool {
+	grams := make(map[string]bool)
+	for _, word := range strings.Fields(text) {
+		padded := "  " + word + " "
+		for i := 0; i+3 <= len(padded); i++ {
+			grams[padded[i:i+3]] = true
+		}
+	}
+	return grams
+}
+
+func equalTokens(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize deixa o texto em maiúsculas, sem acentos e sem espaços repetidos
+func normalize(text string) string {
+	return strings.ToUpper(synonyms.Normalize(text))
+}
+
+func round(value float64) float64 {
+	return float64(int(value*1000+0.5)) / 1000
+}
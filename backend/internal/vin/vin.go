@@ -0,0 +1,146 @@
+package vin
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalid indica que o texto não é um VIN (chassi) de 17 caracteres válidos
+var ErrInvalid = errors.New("invalid VIN: expected 17 characters without I, O or Q")
+
+// Info é o resultado da decodificação local de um VIN
+type Info struct {
+	VIN string `json:"vin"`
+	// WMI são os três primeiros caracteres (fabricante mundial)
+	WMI string `json:"wmi"`
+	// VDS são os caracteres 4 a 9 (descrição do veículo, específica de cada fabricante)
+	VDS string `json:"vds"`
+	// VIS são os caracteres 10 a 17 (ano-modelo, fábrica e número de série)
+	VIS          string `json:"vis"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Country      string `json:"country,omitempty"`
+	// ModelYear é o ano-modelo mais recente compatível com a 10ª posição que não passa do
+	// próximo ano; YearCandidates traz todos os anos compatíveis até esse limite
+	ModelYear      int    `json:"model_year,omitempty"`
+	YearCandidates []int  `json:"year_candidates,omitempty"`
+	PlantCode      string `json:"plant_code"`
+	Plant          string `json:"plant,omitempty"`
+	SerialNumber   string `json:"serial_number"`
+	CheckDigit     string `json:"check_digit"`
+	// CheckDigitValid informa se a 9ª posição confere com o dígito verificador. Fabricantes
+	// no Brasil nem sempre o calculam, por isso o VIN não é rejeitado quando não confere.
+	CheckDigitValid bool `json:"check_digit_valid"`
+}
+
+// Normalize mantém apenas letras e dígitos ASCII, em maiúsculas. Não valida o VIN.
+func Normalize(vin string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(vin) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Valid indica se o texto é um VIN com formato válido (sem conferir o dígito verificador)
+func Valid(vin string) bool {
+	_, err := Decode(vin)
+	return err == nil
+}
+
+// Decode decodifica o VIN sem consultas externas, usando o ano corrente como referência
+func Decode(vin string) (Info, error) {
+	return DecodeAt(vin, time.Now())
+}
+
+// DecodeAt decodifica o VIN escolhendo o ano-modelo em relação à data informada
+func DecodeAt(vin string, now time.Time) (Info, error) {
+	canonical := Normalize(vin)
+	if len(canonical) != 17 {
+		return Info{}, ErrInvalid
+	}
+	for i := 0; i < len(canonical); i++ {
+		if c := canonical[i]; c == 'I' || c == 'O' || c == 'Q' {
+			return Info{}, ErrInvalid
+		}
+	}
+
+	info := Info{
+		VIN:          canonical,
+		WMI:          canonical[:3],
+		VDS:          canonical[3:9],
+		VIS:          canonical[9:],
+		PlantCode:    canonical[10:11],
+		SerialNumber: canonical[11:],
+		CheckDigit:   canonical[8:9],
+	}
+	info.CheckDigitValid = CheckDigit(canonical) == canonical[8]
+
+	if maker, ok := lookupManufacturer(info.WMI); ok {
+		info.Manufacturer = maker.name
+		info.Plant = plantName(maker.name, canonical[10])
+	}
+	info.Country = country(canonical[:2])
+
+	info.YearCandidates = yearCandidates(canonical[9], now.Year()+1)
+	if len(info.YearCandidates) > 0 {
+		info.ModelYear = info.YearCandidates[len(info.YearCandidates)-1]
+	}
+
+	return info, nil
+}
+
+// transliteration converte as letras do VIN nos valores usados no dígito verificador
+var transliteration = map[byte]int{
+	'A': 1, 'B': 2, 'C': 3, 'D': 4, 'E': 5, 'F': 6, 'G': 7, 'H': 8,
+	'J': 1, 'K': 2, 'L': 3, 'M': 4, 'N': 5, 'P': 7, 'R': 9,
+	'S': 2, 'T': 3, 'U': 4, 'V': 5, 'W': 6, 'X': 7, 'Y': 8, 'Z': 9,
+}
+
+// weights são os pesos de cada posição no cálculo do dígito verificador (a 9ª é o próprio dígito)
+var weights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// CheckDigit calcula o dígito verificador (ISO 3779 / FMVSS 565) de um VIN normalizado de 17
+// caracteres: '0'-'9' ou 'X' quando o resto é 10. Retorna 0 se o VIN não tiver 17 caracteres.
+func CheckDigit(vin string) byte {
+	if len(vin) != 17 {
+		return 0
+	}
+
+	sum := 0
+	for i := 0; i < 17; i++ {
+		c := vin[i]
+		value := 0
+		if c >= '0' && c <= '9' {
+			value = int(c - '0')
+		} else {
+			value = transliteration[c]
+		}
+		sum += value * weights[i]
+	}
+
+	if remainder := sum % 11; remainder != 10 {
+		return byte('0' + remainder)
+	}
+	return 'X'
+}
+
+// yearCodes são os códigos da 10ª posição na ordem do ciclo, a partir de 1980 (A)
+const yearCodes = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+// yearCandidates retorna os anos-modelo possíveis para o código, um por ciclo de 30 anos, sem
+// passar do limite (em ordem crescente)
+func yearCandidates(code byte, limit int) []int {
+	index := strings.IndexByte(yearCodes, code)
+	if index < 0 {
+		return nil
+	}
+
+	var years []int
+	for year := 1980 + index; year <= limit; year += 30 {
+		years = append(years, year)
+	}
+	return years
+}
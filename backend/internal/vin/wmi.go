@@ -0,0 +1,205 @@
+package vin
+
+import "strings"
+
+// manufacturer identifica o fabricante de um WMI. O nome segue o usado em
+// partexplorer.application.manufacturer.
+type manufacturer struct {
+	name string
+}
+
+// wmis mapeia os WMIs (três primeiros caracteres) das marcas vendidas no Brasil, tanto das
+// fábricas nacionais quanto das importações mais comuns
+var wmis = map[string]manufacturer{
+	// Fabricados no Brasil
+	"9BW": {"VOLKSWAGEN"},
+	"9BD": {"FIAT"},
+	"9BG": {"CHEVROLET"},
+	"9BF": {"FORD"},
+	"93H": {"HONDA"},
+	"9BR": {"TOYOTA"},
+	"93Y": {"RENAULT"},
+	"936": {"PEUGEOT"},
+	"935": {"CITROEN"},
+	"94D": {"NISSAN"},
+	"9BH": {"HYUNDAI"},
+	"95P": {"HYUNDAI"},
+	"93X": {"MITSUBISHI"},
+	"9BM": {"MERCEDES-BENZ"},
+	"93Z": {"IVECO"},
+	"988": {"JEEP"},
+	"93U": {"AUDI"},
+	"9BS": {"SCANIA"},
+	"9BV": {"VOLVO"},
+	"9C2": {"HONDA"},
+	"9C6": {"YAMAHA"},
+	"9CD": {"SUZUKI"},
+	"98M": {"BMW"},
+
+	// Mercosul
+	"8AP": {"FIAT"},
+	"8AF": {"FORD"},
+	"8AG": {"CHEVROLET"},
+	"8AW": {"VOLKSWAGEN"},
+	"8AJ": {"TOYOTA"},
+	"8AD": {"PEUGEOT"},
+	"8BC": {"CITROEN"},
+	"8A1": {"RENAULT"},
+	"8AC": {"MERCEDES-BENZ"},
+
+	// México e América do Norte
+	"3VW": {"VOLKSWAGEN"},
+	"3G1": {"CHEVROLET"},
+	"3GN": {"CHEVROLET"},
+	"3FA": {"FORD"},
+	"3HG": {"HONDA"},
+	"3N1": {"NISSAN"},
+	"3C4": {"CHRYSLER"},
+	"1G1": {"CHEVROLET"},
+	"1GC": {"CHEVROLET"},
+	"1FA": {"FORD"},
+	"1FT": {"FORD"},
+	"1C4": {"JEEP"},
+	"1J4": {"JEEP"},
+	"1HG": {"HONDA"},
+	"5YJ": {"TESLA"},
+
+	// Europa
+	"WVW": {"VOLKSWAGEN"},
+	"WV1": {"VOLKSWAGEN"},
+	"WV2": {"VOLKSWAGEN"},
+	"WAU": {"AUDI"},
+	"WBA": {"BMW"},
+	"WBS": {"BMW"},
+	"WMW": {"MINI"},
+	"WDB": {"MERCEDES-BENZ"},
+	"WDD": {"MERCEDES-BENZ"},
+	"W1K": {"MERCEDES-BENZ"},
+	"WP0": {"PORSCHE"},
+	"WP1": {"PORSCHE"},
+	"VF1": {"RENAULT"},
+	"VF3": {"PEUGEOT"},
+	"VF7": {"CITROEN"},
+	"VSS": {"SEAT"},
+	"ZFA": {"FIAT"},
+	"ZAR": {"ALFA ROMEO"},
+	"ZFF": {"FERRARI"},
+	"YV1": {"VOLVO"},
+	"SAL": {"LAND ROVER"},
+	"SAJ": {"JAGUAR"},
+	"TMB": {"SKODA"},
+
+	// Ásia
+	"KMH": {"HYUNDAI"},
+	"KNA": {"KIA"},
+	"KND": {"KIA"},
+	"KL1": {"CHEVROLET"},
+	"JHM": {"HONDA"},
+	"JN1": {"NISSAN"},
+	"JN8": {"NISSAN"},
+	"JMB": {"MITSUBISHI"},
+	"JA3": {"MITSUBISHI"},
+	"JS3": {"SUZUKI"},
+	"JF1": {"SUBARU"},
+	"JM1": {"MAZDA"},
+	"LVV": {"CHERY"},
+	"LGW": {"GWM"},
+	"LGX": {"BYD"},
+	"LC0": {"BYD"},
+	"LJ1": {"JAC"},
+}
+
+// wmiPrefixes cobre fabricantes que usam vários WMIs com o mesmo prefixo de dois caracteres
+var wmiPrefixes = map[string]manufacturer{
+	"JT": {"TOYOTA"},
+}
+
+// lookupManufacturer identifica o fabricante pelo WMI
+func lookupManufacturer(wmi string) (manufacturer, bool) {
+	if maker, ok := wmis[wmi]; ok {
+		return maker, true
+	}
+	maker, ok := wmiPrefixes[wmi[:2]]
+	return maker, ok
+}
+
+// countryRange associa um intervalo da segunda posição do VIN a um país
+type countryRange struct {
+	first    byte
+	from, to byte
+	name     string
+}
+
+// countries segue a tabela de códigos de região da ISO 3780 para os países mais comuns na frota
+// brasileira. A ordem dos caracteres da segunda posição é A-Z e depois 1-9, 0.
+var countries = []countryRange{
+	{'9', 'A', 'E', "Brasil"},
+	{'9', '3', '9', "Brasil"},
+	{'8', 'A', 'E', "Argentina"},
+	{'8', 'X', 'Z', "Venezuela"},
+	{'9', 'F', 'J', "Colômbia"},
+	{'3', 'A', 'W', "México"},
+	{'1', 'A', '0', "Estados Unidos"},
+	{'4', 'A', '0', "Estados Unidos"},
+	{'5', 'A', '0', "Estados Unidos"},
+	{'2', 'A', '0', "Canadá"},
+	{'J', 'A', '0', "Japão"},
+	{'K', 'L', 'R', "Coreia do Sul"},
+	{'L', 'A', '0', "China"},
+	{'W', 'A', '0', "Alemanha"},
+	{'V', 'F', 'R', "França"},
+	{'V', 'S', 'W', "Espanha"},
+	{'Z', 'A', 'R', "Itália"},
+	{'S', 'A', 'M', "Reino Unido"},
+	{'Y', 'S', 'W', "Suécia"},
+	{'T', 'J', 'P', "República Tcheca"},
+}
+
+// regionOrder é a ordem da segunda posição nos intervalos de país
+const regionOrder = "ABCDEFGHJKLMNPRSTUVWXYZ1234567890"
+
+// country retorna o país de fabricação pelos dois primeiros caracteres
+func country(prefix string) string {
+	position := strings.IndexByte(regionOrder, prefix[1])
+	if position < 0 {
+		return ""
+	}
+	for _, r := range countries {
+		if prefix[0] == r.first && position >= strings.IndexByte(regionOrder, r.from) && position <= strings.IndexByte(regionOrder, r.to) {
+			return r.name
+		}
+	}
+	return ""
+}
+
+// plants traz as fábricas brasileiras conhecidas pela 11ª posição do VIN, por fabricante
+var plants = map[string]map[byte]string{
+	"CHEVROLET": {
+		'B': "São Caetano do Sul (SP)",
+		'C': "São José dos Campos (SP)",
+		'G': "Gravataí (RS)",
+		'J': "Joinville (SC)",
+	},
+	"VOLKSWAGEN": {
+		'B': "São Bernardo do Campo (SP)",
+		'T': "Taubaté (SP)",
+		'P': "São José dos Pinhais (PR)",
+		'4': "São Carlos (SP)",
+	},
+	"FIAT": {
+		'B': "Betim (MG)",
+		'2': "Goiana (PE)",
+	},
+	"FORD": {
+		'B': "São Bernardo do Campo (SP)",
+		'C': "Camaçari (BA)",
+	},
+	"RENAULT": {
+		'J': "São José dos Pinhais (PR)",
+	},
+}
+
+// plantName retorna a fábrica pelo código da 11ª posição, quando conhecida
+func plantName(manufacturer string, code byte) string {
+	return plants[manufacturer][code]
+}
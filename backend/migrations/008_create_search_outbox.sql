@@ -0,0 +1,87 @@
+-- Migration: Create search_outbox table and triggers for incremental indexing
+-- Date: 2025-01-XX
+
+-- Fila de grupos que precisam ser reindexados no Elasticsearch
+CREATE TABLE IF NOT EXISTS partexplorer.search_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    group_id UUID NOT NULL,
+    source_table VARCHAR(64) NOT NULL,
+    operation VARCHAR(10) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_outbox_pending ON partexplorer.search_outbox(next_attempt_at) WHERE processed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_search_outbox_processed_at ON partexplorer.search_outbox(processed_at);
+
+-- Trigger function: registra o grupo afetado por qualquer alteração
+CREATE OR REPLACE FUNCTION partexplorer.enqueue_search_outbox()
+RETURNS TRIGGER AS $$
+DECLARE
+    new_group UUID;
+    old_group UUID;
+BEGIN
+    IF TG_TABLE_NAME = 'part_group' THEN
+        IF TG_OP <> 'DELETE' THEN new_group := NEW.id; END IF;
+        IF TG_OP <> 'INSERT' THEN old_group := OLD.id; END IF;
+    ELSIF TG_TABLE_NAME = 'stock' THEN
+        -- stock não tem group_id, resolver via part_name
+        IF TG_OP <> 'DELETE' THEN
+            SELECT group_id INTO new_group FROM partexplorer.part_name WHERE id = NEW.part_name_id;
+        END IF;
+        IF TG_OP <> 'INSERT' THEN
+            SELECT group_id INTO old_group FROM partexplorer.part_name WHERE id = OLD.part_name_id;
+        END IF;
+    ELSE
+        IF TG_OP <> 'DELETE' THEN new_group := NEW.group_id; END IF;
+        IF TG_OP <> 'INSERT' THEN old_group := OLD.group_id; END IF;
+    END IF;
+
+    IF new_group IS NOT NULL THEN
+        INSERT INTO partexplorer.search_outbox (group_id, source_table, operation)
+        VALUES (new_group, TG_TABLE_NAME, TG_OP);
+    END IF;
+
+    -- Registro trocou de grupo (ou foi removido): o grupo antigo também muda
+    IF old_group IS NOT NULL AND old_group IS DISTINCT FROM new_group THEN
+        INSERT INTO partexplorer.search_outbox (group_id, source_table, operation)
+        VALUES (old_group, TG_TABLE_NAME, TG_OP);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create triggers
+DROP TRIGGER IF EXISTS part_group_search_outbox_trigger ON partexplorer.part_group;
+CREATE TRIGGER part_group_search_outbox_trigger
+    AFTER INSERT OR UPDATE OR DELETE ON partexplorer.part_group
+    FOR EACH ROW
+    EXECUTE FUNCTION partexplorer.enqueue_search_outbox();
+
+DROP TRIGGER IF EXISTS part_name_search_outbox_trigger ON partexplorer.part_name;
+CREATE TRIGGER part_name_search_outbox_trigger
+    AFTER INSERT OR UPDATE OR DELETE ON partexplorer.part_name
+    FOR EACH ROW
+    EXECUTE FUNCTION partexplorer.enqueue_search_outbox();
+
+DROP TRIGGER IF EXISTS part_image_search_outbox_trigger ON partexplorer.part_image;
+CREATE TRIGGER part_image_search_outbox_trigger
+    AFTER INSERT OR UPDATE OR DELETE ON partexplorer.part_image
+    FOR EACH ROW
+    EXECUTE FUNCTION partexplorer.enqueue_search_outbox();
+
+DROP TRIGGER IF EXISTS part_group_application_search_outbox_trigger ON partexplorer.part_group_application;
+CREATE TRIGGER part_group_application_search_outbox_trigger
+    AFTER INSERT OR UPDATE OR DELETE ON partexplorer.part_group_application
+    FOR EACH ROW
+    EXECUTE FUNCTION partexplorer.enqueue_search_outbox();
+
+DROP TRIGGER IF EXISTS stock_search_outbox_trigger ON partexplorer.stock;
+CREATE TRIGGER stock_search_outbox_trigger
+    AFTER INSERT OR UPDATE OR DELETE ON partexplorer.stock
+    FOR EACH ROW
+    EXECUTE FUNCTION partexplorer.enqueue_search_outbox();
@@ -0,0 +1,18 @@
+-- Migration: Enable accent-insensitive and typo-tolerant search (pg_trgm + unaccent)
+-- Date: 2025-01-XX
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent() é STABLE; o wrapper IMMUTABLE com dicionário explícito permite usá-lo em índices
+CREATE OR REPLACE FUNCTION partexplorer.f_unaccent(text)
+RETURNS text AS $$
+    SELECT public.unaccent('public.unaccent'::regdictionary, $1)
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+-- Índices trigram sobre o texto normalizado (minúsculo, sem acento), usados por LIKE e <%
+CREATE INDEX IF NOT EXISTS idx_part_name_name_trgm
+    ON partexplorer.part_name USING gin (partexplorer.f_unaccent(lower(name)) gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_brand_name_trgm
+    ON partexplorer.brand USING gin (partexplorer.f_unaccent(lower(name)) gin_trgm_ops);
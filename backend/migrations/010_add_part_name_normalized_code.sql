@@ -0,0 +1,25 @@
+-- Migration: Add normalized code to part_name for SKU/EAN lookups
+-- Date: 2025-01-XX
+
+-- SKUs: apenas letras e dígitos em maiúsculas ("KL-1234", "kl 1234" -> "KL1234")
+-- EANs: apenas dígitos completados para GTIN-14 ("7891234567895" -> "07891234567895")
+-- Deve permanecer equivalente a partcode.NormalizeByType no backend
+CREATE OR REPLACE FUNCTION partexplorer.normalize_part_code(code text, code_type text)
+RETURNS text AS $$
+    SELECT CASE lower(code_type)
+        WHEN 'sku' THEN NULLIF(upper(regexp_replace(code, '[^A-Za-z0-9]', '', 'g')), '')
+        WHEN 'ean' THEN (
+            SELECT CASE WHEN length(d) < 14 THEN lpad(d, 14, '0') ELSE d END
+            FROM (SELECT NULLIF(regexp_replace(code, '[^0-9]', '', 'g'), '') AS d) digits
+        )
+        ELSE NULL
+    END
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE;
+
+ALTER TABLE partexplorer.part_name
+    ADD COLUMN IF NOT EXISTS normalized_code VARCHAR(255)
+    GENERATED ALWAYS AS (partexplorer.normalize_part_code(name, type)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_part_name_normalized_code
+    ON partexplorer.part_name(normalized_code)
+    WHERE normalized_code IS NOT NULL;
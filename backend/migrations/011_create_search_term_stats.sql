@@ -0,0 +1,13 @@
+-- Migration: Create search_term_stats table for popularity-weighted autocomplete
+-- Date: 2025-01-XX
+
+-- Buscas com resultado, por termo normalizado (minúsculo, sem acento) e estado
+CREATE TABLE IF NOT EXISTS partexplorer.search_term_stats (
+    term VARCHAR(255) NOT NULL,
+    state VARCHAR(2) NOT NULL DEFAULT '',
+    searches BIGINT NOT NULL DEFAULT 0,
+    last_searched_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (term, state)
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_term_stats_searches ON partexplorer.search_term_stats(searches DESC);
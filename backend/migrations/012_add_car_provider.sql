@@ -0,0 +1,4 @@
+-- Migration: Record which vehicle-data provider answered each plate lookup
+-- Date: 2025-01-XX
+
+ALTER TABLE partexplorer.car ADD COLUMN IF NOT EXISTS provider VARCHAR(40);
@@ -0,0 +1,22 @@
+-- Migration: Map plate-lookup brand/model strings to catalog applications
+-- Date: 2025-01-XX
+
+-- Marca/modelo do provedor de placa (normalizados) -> fabricante/modelo/versão/motor do catálogo.
+-- status: auto (correspondência confiável), pending (fila de revisão), confirmed, corrected
+CREATE TABLE IF NOT EXISTS partexplorer.vehicle_model_mapping (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source_brand VARCHAR(80) NOT NULL DEFAULT '',
+    source_model VARCHAR(255) NOT NULL,
+    manufacturer VARCHAR(40),
+    model VARCHAR(60),
+    version VARCHAR(40),
+    engine VARCHAR(40),
+    confidence NUMERIC(4,3) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (source_brand, source_model)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vehicle_model_mapping_status ON partexplorer.vehicle_model_mapping(status, created_at);
@@ -0,0 +1,61 @@
+-- Migration: Manufacturer alias dictionary used by fitment, indexing and plate search
+-- Date: 2025-01-XX
+
+-- Grafias de fabricante (normalizadas: maiúsculas, sem acento) -> fabricante canônico.
+-- Todo fabricante canônico tem uma linha apontando para si mesmo.
+CREATE TABLE IF NOT EXISTS partexplorer.manufacturer_alias (
+    alias VARCHAR(60) PRIMARY KEY,
+    manufacturer VARCHAR(40) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_manufacturer_alias_manufacturer ON partexplorer.manufacturer_alias(manufacturer);
+
+INSERT INTO partexplorer.manufacturer_alias (alias, manufacturer) VALUES
+    ('VOLKSWAGEN', 'VOLKSWAGEN'),
+    ('VW', 'VOLKSWAGEN'),
+    ('VOLKS', 'VOLKSWAGEN'),
+    ('CHEVROLET', 'CHEVROLET'),
+    ('GM', 'CHEVROLET'),
+    ('CHEV', 'CHEVROLET'),
+    ('FIAT', 'FIAT'),
+    ('FORD', 'FORD'),
+    ('RENAULT', 'RENAULT'),
+    ('PEUGEOT', 'PEUGEOT'),
+    ('CITROEN', 'CITROEN'),
+    ('HONDA', 'HONDA'),
+    ('TOYOTA', 'TOYOTA'),
+    ('HYUNDAI', 'HYUNDAI'),
+    ('KIA', 'KIA'),
+    ('NISSAN', 'NISSAN'),
+    ('MITSUBISHI', 'MITSUBISHI'),
+    ('JEEP', 'JEEP'),
+    ('AUDI', 'AUDI'),
+    ('BMW', 'BMW'),
+    ('MERCEDES-BENZ', 'MERCEDES-BENZ'),
+    ('MERCEDES', 'MERCEDES-BENZ'),
+    ('MERCEDESBENZ', 'MERCEDES-BENZ'),
+    ('M.BENZ', 'MERCEDES-BENZ'),
+    ('MBENZ', 'MERCEDES-BENZ'),
+    ('MB', 'MERCEDES-BENZ'),
+    ('SUZUKI', 'SUZUKI'),
+    ('CHERY', 'CHERY'),
+    ('CAOA CHERY', 'CHERY'),
+    ('CAOA', 'CHERY'),
+    ('JAC', 'JAC'),
+    ('LAND ROVER', 'LAND ROVER'),
+    ('LAND', 'LAND ROVER'),
+    ('LR', 'LAND ROVER')
+ON CONFLICT (alias) DO NOTHING;
+
+-- Normaliza o nome como manufacturer.Normalize (maiúsculas, sem acento, espaços simples) e
+-- troca o apelido pelo fabricante canônico; nomes sem apelido ficam apenas normalizados
+CREATE OR REPLACE FUNCTION partexplorer.f_canonical_manufacturer(text)
+RETURNS text AS $$
+    SELECT COALESCE(
+        (SELECT ma.manufacturer FROM partexplorer.manufacturer_alias ma
+         WHERE ma.alias = upper(partexplorer.f_unaccent(regexp_replace(btrim($1), '\s+', ' ', 'g')))),
+        upper(partexplorer.f_unaccent(regexp_replace(btrim($1), '\s+', ' ', 'g')))
+    )
+$$ LANGUAGE sql STABLE PARALLEL SAFE STRICT;
@@ -0,0 +1,7 @@
+-- Migration: Track when each cached plate was last queried, for the background refresher
+-- Date: 2025-01-XX
+
+ALTER TABLE partexplorer.car ADD COLUMN IF NOT EXISTS last_queried_at TIMESTAMP WITH TIME ZONE;
+
+-- O refresher busca as placas consultadas recentemente com dados vencidos
+CREATE INDEX IF NOT EXISTS idx_car_last_queried_at ON partexplorer.car(last_queried_at) WHERE last_queried_at IS NOT NULL;
@@ -0,0 +1,42 @@
+-- Migration: Retry queue and failure classification for car_error
+-- Date: 2025-01-XX
+
+ALTER TABLE partexplorer.car_error ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'pending';
+ALTER TABLE partexplorer.car_error ADD COLUMN IF NOT EXISTS reason VARCHAR(30);
+ALTER TABLE partexplorer.car_error ADD COLUMN IF NOT EXISTS message TEXT;
+ALTER TABLE partexplorer.car_error ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE partexplorer.car_error ADD COLUMN IF NOT EXISTS next_retry_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE partexplorer.car_error ADD COLUMN IF NOT EXISTS last_attempt_at TIMESTAMP WITH TIME ZONE;
+
+-- Até aqui a car_error só recebia falhas ao gravar o carro
+UPDATE partexplorer.car_error
+SET reason = 'db_error', next_retry_at = CURRENT_TIMESTAMP
+WHERE reason IS NULL;
+
+-- O worker busca as entradas pendentes com nova tentativa vencida
+CREATE INDEX IF NOT EXISTS idx_car_error_retry ON partexplorer.car_error(status, next_retry_at);
+CREATE INDEX IF NOT EXISTS idx_car_error_reason ON partexplorer.car_error(reason);
+
+-- O trigger de upsert da migração 007 só conhecia a coluna data
+CREATE OR REPLACE FUNCTION partexplorer.handle_car_error_upsert()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE partexplorer.car_error
+    SET
+        data = NEW.data,
+        status = NEW.status,
+        reason = NEW.reason,
+        message = NEW.message,
+        attempts = NEW.attempts,
+        next_retry_at = NEW.next_retry_at,
+        last_attempt_at = NEW.last_attempt_at,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE license_plate = NEW.license_plate;
+
+    IF NOT FOUND THEN
+        RETURN NEW;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
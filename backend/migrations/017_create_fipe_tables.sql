@@ -0,0 +1,50 @@
+-- Migration: FIPE reference table imported from the official monthly dumps, with price history per month
+-- Date: 2025-01-XX
+
+-- Códigos da tabela FIPE ("000000-0")
+CREATE TABLE IF NOT EXISTS partexplorer.fipe_vehicle (
+    code VARCHAR(8) PRIMARY KEY,
+    brand VARCHAR(80),
+    model VARCHAR(255),
+    fuel VARCHAR(40),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Preço de cada código e ano-modelo por mês de referência (ano-modelo 32000 = zero km).
+-- source = 'table' para a tabela oficial, 'plate' para o valor visto nas consultas de placa
+-- antes da importação do mês; a importação sobrescreve os valores das consultas.
+CREATE TABLE IF NOT EXISTS partexplorer.fipe_price (
+    code VARCHAR(8) NOT NULL,
+    model_year INT NOT NULL,
+    reference_month DATE NOT NULL,
+    price NUMERIC(12,2) NOT NULL,
+    source VARCHAR(10) NOT NULL DEFAULT 'table',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (code, model_year, reference_month),
+    CONSTRAINT chk_fipe_price_month CHECK (reference_month = date_trunc('month', reference_month)::date),
+    CONSTRAINT chk_fipe_price_source CHECK (source IN ('table', 'plate'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_fipe_price_reference_month ON partexplorer.fipe_price(reference_month);
+
+-- Códigos dos carros na forma canônica, para a ligação com a tabela FIPE. Não há chave
+-- estrangeira: o provedor de placa pode informar um código antes da importação da tabela.
+UPDATE partexplorer.car
+SET fipe_code = substr(lpad(regexp_replace(fipe_code, '[^0-9]', '', 'g'), 7, '0'), 1, 6) || '-' ||
+                substr(lpad(regexp_replace(fipe_code, '[^0-9]', '', 'g'), 7, '0'), 7, 1)
+WHERE fipe_code ~ '^[0-9 .-]+$'
+  AND length(regexp_replace(fipe_code, '[^0-9]', '', 'g')) BETWEEN 1 AND 7
+  AND fipe_code !~ '^[0-9]{6}-[0-9]$';
+
+CREATE INDEX IF NOT EXISTS idx_car_fipe_code ON partexplorer.car(fipe_code) WHERE fipe_code IS NOT NULL AND fipe_code <> '';
+
+-- Valores já vistos nas consultas de placa entram no histórico do mês da última atualização
+INSERT INTO partexplorer.fipe_price (code, model_year, reference_month, price, source)
+SELECT DISTINCT ON (fipe_code, model_year, date_trunc('month', updated_at)::date)
+       fipe_code, model_year, date_trunc('month', updated_at)::date, fipe_value, 'plate'
+FROM partexplorer.car
+WHERE fipe_code ~ '^[0-9]{6}-[0-9]$' AND model_year > 0 AND fipe_value > 0
+ORDER BY fipe_code, model_year, date_trunc('month', updated_at)::date, updated_at DESC
+ON CONFLICT (code, model_year, reference_month) DO NOTHING;
@@ -0,0 +1,46 @@
+-- Migration: Immutable stock movement ledger (receipt, sale, adjustment, transfer)
+-- Date: 2025-01-XX
+
+-- Livro de movimentações: a quantidade do estoque é a soma das movimentações da linha.
+-- Sem chave estrangeira para o estoque: o histórico continua disponível depois da remoção.
+CREATE TABLE IF NOT EXISTS partexplorer.stock_movement (
+    id BIGSERIAL PRIMARY KEY,
+    stock_id UUID NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    quantity INT NOT NULL,
+    quantity_after INT NOT NULL,
+    actor VARCHAR(120) NOT NULL,
+    reason TEXT,
+    reference VARCHAR(120),
+    transfer_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_stock_movement_type CHECK (type IN ('receipt', 'sale', 'adjustment', 'transfer')),
+    -- Ajuste por contagem que confirma o saldo fica registrado com quantidade zero
+    CONSTRAINT chk_stock_movement_quantity CHECK (quantity <> 0 OR type = 'adjustment'),
+    CONSTRAINT chk_stock_movement_quantity_after CHECK (quantity_after >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movement_stock_id ON partexplorer.stock_movement(stock_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stock_movement_transfer_id ON partexplorer.stock_movement(transfer_id) WHERE transfer_id IS NOT NULL;
+
+-- Movimentações são imutáveis: correções entram como novos ajustes
+CREATE OR REPLACE FUNCTION partexplorer.prevent_stock_movement_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movement is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_movement_immutable_trigger ON partexplorer.stock_movement;
+CREATE TRIGGER stock_movement_immutable_trigger
+    BEFORE UPDATE OR DELETE ON partexplorer.stock_movement
+    FOR EACH ROW
+    EXECUTE FUNCTION partexplorer.prevent_stock_movement_change();
+
+-- Saldo inicial dos estoques existentes, para que a soma do livro bata com a quantidade.
+-- Quantidades negativas ficam de fora e aparecem na conciliação.
+INSERT INTO partexplorer.stock_movement (stock_id, type, quantity, quantity_after, actor, reason, created_at)
+SELECT s.id, 'adjustment', s.quantity, s.quantity, 'migration', 'Saldo inicial', COALESCE(s.updated_at, CURRENT_TIMESTAMP)
+FROM partexplorer.stock s
+WHERE s.quantity > 0
+  AND NOT EXISTS (SELECT 1 FROM partexplorer.stock_movement m WHERE m.stock_id = s.id);
@@ -0,0 +1,55 @@
+-- Migration: Bulk stock feed imports (distributor inventory files) with per-line report
+-- Date: 2025-01-XX
+
+-- Importações de arquivos de estoque: processadas em lotes pelo servidor (ou por
+-- cmd/stock_import) e retomadas a partir das linhas pendentes
+CREATE TABLE IF NOT EXISTS partexplorer.stock_import_job (
+    id UUID PRIMARY KEY,
+    company_id UUID NOT NULL REFERENCES partexplorer.company(id) ON DELETE CASCADE,
+    mode VARCHAR(20) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    file_name VARCHAR(255),
+    actor VARCHAR(120) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    total_lines INT NOT NULL DEFAULT 0,
+    processed_lines INT NOT NULL DEFAULT 0,
+    created INT NOT NULL DEFAULT 0,
+    updated INT NOT NULL DEFAULT 0,
+    unchanged INT NOT NULL DEFAULT 0,
+    zeroed INT NOT NULL DEFAULT 0,
+    errors INT NOT NULL DEFAULT 0,
+    message TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_stock_import_job_mode CHECK (mode IN ('merge', 'replace')),
+    CONSTRAINT chk_stock_import_job_status CHECK (status IN ('pending', 'running', 'completed', 'failed', 'cancelled'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_import_job_company_id ON partexplorer.stock_import_job(company_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stock_import_job_status ON partexplorer.stock_import_job(status)
+    WHERE status IN ('pending', 'running');
+
+-- Linhas do arquivo com o resultado de cada uma (o relatório de erros por linha)
+CREATE TABLE IF NOT EXISTS partexplorer.stock_import_line (
+    job_id UUID NOT NULL REFERENCES partexplorer.stock_import_job(id) ON DELETE CASCADE,
+    line INT NOT NULL,
+    sku VARCHAR(255),
+    ean VARCHAR(255),
+    brand VARCHAR(255),
+    quantity INT,
+    price FLOAT,
+    obsolete BOOLEAN,
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    part_name_id UUID,
+    stock_id UUID,
+    PRIMARY KEY (job_id, line),
+    CONSTRAINT chk_stock_import_line_status CHECK (status IN ('pending', 'created', 'updated', 'unchanged', 'error'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_import_line_pending ON partexplorer.stock_import_line(job_id, line)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_stock_import_line_error ON partexplorer.stock_import_line(job_id, line)
+    WHERE status = 'error';
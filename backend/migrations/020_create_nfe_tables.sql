@@ -0,0 +1,61 @@
+-- Migration: NF-e ingestion (purchase and sale invoices) with a reconciliation queue
+-- Date: 2025-01-XX
+
+-- CNPJ da empresa (apenas dígitos), usado para achar o emitente e o destinatário das notas
+ALTER TABLE partexplorer.company ADD COLUMN IF NOT EXISTS cnpj VARCHAR(14);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_company_cnpj ON partexplorer.company(cnpj) WHERE cnpj IS NOT NULL;
+
+-- Notas importadas; a chave de acesso impede lançar a mesma nota duas vezes
+CREATE TABLE IF NOT EXISTS partexplorer.nfe_invoice (
+    id UUID PRIMARY KEY,
+    access_key VARCHAR(44) NOT NULL,
+    number VARCHAR(20),
+    series VARCHAR(10),
+    type SMALLINT NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE,
+    issuer_document VARCHAR(14),
+    issuer_name VARCHAR(255),
+    recipient_document VARCHAR(14),
+    recipient_name VARCHAR(255),
+    issuer_company_id UUID REFERENCES partexplorer.company(id) ON DELETE SET NULL,
+    recipient_company_id UUID REFERENCES partexplorer.company(id) ON DELETE SET NULL,
+    actor VARCHAR(120) NOT NULL,
+    posted INT NOT NULL DEFAULT 0,
+    pending INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_nfe_invoice_type CHECK (type IN (0, 1))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_nfe_invoice_access_key ON partexplorer.nfe_invoice(access_key);
+CREATE INDEX IF NOT EXISTS idx_nfe_invoice_issuer_company ON partexplorer.nfe_invoice(issuer_company_id, issued_at DESC);
+CREATE INDEX IF NOT EXISTS idx_nfe_invoice_recipient_company ON partexplorer.nfe_invoice(recipient_company_id, issued_at DESC);
+
+-- Itens aplicados ao estoque de cada empresa da nota; os pendentes formam a fila de conciliação
+CREATE TABLE IF NOT EXISTS partexplorer.nfe_item (
+    id BIGSERIAL PRIMARY KEY,
+    invoice_id UUID NOT NULL REFERENCES partexplorer.nfe_invoice(id) ON DELETE CASCADE,
+    item_number INT NOT NULL,
+    company_id UUID NOT NULL REFERENCES partexplorer.company(id) ON DELETE CASCADE,
+    movement_type VARCHAR(20) NOT NULL,
+    code VARCHAR(60),
+    ean VARCHAR(14),
+    description TEXT,
+    unit VARCHAR(10),
+    quantity NUMERIC(15,4) NOT NULL,
+    unit_price NUMERIC(21,10),
+    status VARCHAR(20) NOT NULL,
+    reason VARCHAR(40),
+    details TEXT,
+    part_name_id UUID,
+    stock_id UUID,
+    movement_id BIGINT,
+    resolved_by VARCHAR(120),
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_nfe_item UNIQUE (invoice_id, item_number, company_id),
+    CONSTRAINT chk_nfe_item_movement_type CHECK (movement_type IN ('receipt', 'sale')),
+    CONSTRAINT chk_nfe_item_status CHECK (status IN ('posted', 'pending', 'discarded'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_nfe_item_pending ON partexplorer.nfe_item(company_id, created_at)
+    WHERE status = 'pending';
@@ -0,0 +1,7 @@
+-- Migration: Version column for optimistic concurrency on stock and company updates
+-- Date: 2025-01-XX
+
+-- A versão sobe a cada escrita na linha; o ETag das respostas é a versão, e o If-Match das
+-- atualizações precisa conferir com ela
+ALTER TABLE partexplorer.stock ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE partexplorer.company ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
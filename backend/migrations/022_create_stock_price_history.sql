@@ -0,0 +1,57 @@
+-- Migration: Stock price history snapshots for price comparison across companies
+-- Date: 2025-01-XX
+
+-- Histórico de preços: uma linha a cada preço gravado no estoque. Sem chave estrangeira para o
+-- estoque, com a peça e a empresa copiadas: o histórico continua disponível depois da remoção.
+CREATE TABLE IF NOT EXISTS partexplorer.stock_price_history (
+    id BIGSERIAL PRIMARY KEY,
+    stock_id UUID NOT NULL,
+    part_name_id UUID NOT NULL,
+    company_id UUID NOT NULL,
+    price DOUBLE PRECISION,
+    previous_price DOUBLE PRECISION,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_price_history_stock_id ON partexplorer.stock_price_history(stock_id, changed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stock_price_history_part_name_id ON partexplorer.stock_price_history(part_name_id, changed_at DESC);
+
+-- A foto é gravada pelo banco, para cobrir todos os caminhos que alteram o preço (API,
+-- importação de estoque, cargas em lote)
+CREATE OR REPLACE FUNCTION partexplorer.record_stock_price()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO partexplorer.stock_price_history (stock_id, part_name_id, company_id, price, changed_at)
+        VALUES (NEW.id, NEW.part_name_id, NEW.company_id, NEW.price, CURRENT_TIMESTAMP);
+    ELSE
+        INSERT INTO partexplorer.stock_price_history (stock_id, part_name_id, company_id, price, previous_price, changed_at)
+        VALUES (NEW.id, NEW.part_name_id, NEW.company_id, NEW.price, OLD.price, CURRENT_TIMESTAMP);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_price_insert_trigger ON partexplorer.stock;
+CREATE TRIGGER stock_price_insert_trigger
+    AFTER INSERT ON partexplorer.stock
+    FOR EACH ROW
+    WHEN (NEW.price IS NOT NULL)
+    EXECUTE FUNCTION partexplorer.record_stock_price();
+
+DROP TRIGGER IF EXISTS stock_price_update_trigger ON partexplorer.stock;
+CREATE TRIGGER stock_price_update_trigger
+    AFTER UPDATE OF price ON partexplorer.stock
+    FOR EACH ROW
+    WHEN (OLD.price IS DISTINCT FROM NEW.price)
+    EXECUTE FUNCTION partexplorer.record_stock_price();
+
+-- Preço atual dos estoques existentes como primeira foto do histórico
+INSERT INTO partexplorer.stock_price_history (stock_id, part_name_id, company_id, price, changed_at)
+SELECT s.id, s.part_name_id, s.company_id, s.price, COALESCE(s.updated_at, CURRENT_TIMESTAMP)
+FROM partexplorer.stock s
+WHERE s.price IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM partexplorer.stock_price_history h WHERE h.stock_id = s.id);
+
+-- Estatísticas e ranking de ofertas filtram por peça e preço
+CREATE INDEX IF NOT EXISTS idx_stock_part_name_price ON partexplorer.stock(part_name_id, price) WHERE price IS NOT NULL;
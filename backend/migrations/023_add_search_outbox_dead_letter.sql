@@ -0,0 +1,9 @@
+-- Migration: Dead letter status for search_outbox entries that exhausted their retries
+-- Date: 2025-01-XX
+
+-- Eventos que esgotaram as tentativas saem da fila e ficam para inspeção
+ALTER TABLE partexplorer.search_outbox ADD COLUMN IF NOT EXISTS failed_at TIMESTAMP WITH TIME ZONE;
+
+DROP INDEX IF EXISTS partexplorer.idx_search_outbox_pending;
+CREATE INDEX IF NOT EXISTS idx_search_outbox_pending ON partexplorer.search_outbox(next_attempt_at) WHERE processed_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_search_outbox_failed_at ON partexplorer.search_outbox(failed_at) WHERE failed_at IS NOT NULL;
@@ -0,0 +1,64 @@
+-- Migration: Store the canonical manufacturer on application and reindex groups when an alias changes
+-- Date: 2025-01-XX
+
+-- Fabricante canônico da aplicação, mantido pelos triggers abaixo. As buscas por aplicação
+-- filtram por esta coluna em vez de chamar f_canonical_manufacturer em cada linha.
+ALTER TABLE partexplorer.application ADD COLUMN IF NOT EXISTS canonical_manufacturer VARCHAR(60);
+
+UPDATE partexplorer.application
+SET canonical_manufacturer = partexplorer.f_canonical_manufacturer(manufacturer)
+WHERE canonical_manufacturer IS DISTINCT FROM partexplorer.f_canonical_manufacturer(manufacturer);
+
+CREATE INDEX IF NOT EXISTS idx_application_canonical_manufacturer ON partexplorer.application(canonical_manufacturer);
+
+-- Trigger function: calcula o fabricante canônico ao gravar a aplicação
+CREATE OR REPLACE FUNCTION partexplorer.set_application_canonical_manufacturer()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.canonical_manufacturer := partexplorer.f_canonical_manufacturer(NEW.manufacturer);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS application_canonical_manufacturer_trigger ON partexplorer.application;
+CREATE TRIGGER application_canonical_manufacturer_trigger
+    BEFORE INSERT OR UPDATE OF manufacturer ON partexplorer.application
+    FOR EACH ROW
+    EXECUTE FUNCTION partexplorer.set_application_canonical_manufacturer();
+
+-- Trigger function: recalcula as aplicações afetadas pelo apelido e coloca os grupos delas no
+-- search_outbox. Uma aplicação afetada tinha como canônico o próprio apelido (quando ele ainda
+-- não existia) ou o fabricante para o qual ele apontava.
+CREATE OR REPLACE FUNCTION partexplorer.refresh_application_canonical_manufacturer()
+RETURNS TRIGGER AS $$
+DECLARE
+    affected TEXT[];
+BEGIN
+    IF TG_OP <> 'DELETE' THEN
+        affected := affected || ARRAY[NEW.alias::TEXT, NEW.manufacturer::TEXT];
+    END IF;
+    IF TG_OP <> 'INSERT' THEN
+        affected := affected || ARRAY[OLD.alias::TEXT, OLD.manufacturer::TEXT];
+    END IF;
+
+    WITH changed AS (
+        UPDATE partexplorer.application app
+        SET canonical_manufacturer = partexplorer.f_canonical_manufacturer(app.manufacturer)
+        WHERE app.canonical_manufacturer = ANY (affected)
+          AND app.canonical_manufacturer IS DISTINCT FROM partexplorer.f_canonical_manufacturer(app.manufacturer)
+        RETURNING app.id
+    )
+    INSERT INTO partexplorer.search_outbox (group_id, source_table, operation)
+    SELECT DISTINCT pga.group_id, TG_TABLE_NAME, TG_OP
+    FROM partexplorer.part_group_application pga
+    JOIN changed ON changed.id = pga.application_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS manufacturer_alias_application_trigger ON partexplorer.manufacturer_alias;
+CREATE TRIGGER manufacturer_alias_application_trigger
+    AFTER INSERT OR UPDATE OR DELETE ON partexplorer.manufacturer_alias
+    FOR EACH ROW
+    EXECUTE FUNCTION partexplorer.refresh_application_canonical_manufacturer();
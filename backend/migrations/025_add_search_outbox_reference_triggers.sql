@@ -0,0 +1,52 @@
+-- Migration: Enqueue search_outbox entries when brand, company or application rows change
+-- Date: 2025-01-XX
+
+-- Os documentos do índice trazem o nome da marca, a cidade/estado das empresas com estoque e os
+-- dados das aplicações; alterar essas linhas muda os documentos de todos os grupos ligados a elas
+CREATE OR REPLACE FUNCTION partexplorer.enqueue_search_outbox_reference()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'brand' THEN
+        INSERT INTO partexplorer.search_outbox (group_id, source_table, operation)
+        SELECT DISTINCT pn.group_id, TG_TABLE_NAME, TG_OP
+        FROM partexplorer.part_name pn
+        WHERE pn.brand_id = OLD.id AND pn.group_id IS NOT NULL;
+    ELSIF TG_TABLE_NAME = 'company' THEN
+        INSERT INTO partexplorer.search_outbox (group_id, source_table, operation)
+        SELECT DISTINCT pn.group_id, TG_TABLE_NAME, TG_OP
+        FROM partexplorer.stock s
+        JOIN partexplorer.part_name pn ON pn.id = s.part_name_id
+        WHERE s.company_id = OLD.id AND pn.group_id IS NOT NULL;
+    ELSIF TG_TABLE_NAME = 'application' THEN
+        INSERT INTO partexplorer.search_outbox (group_id, source_table, operation)
+        SELECT DISTINCT pga.group_id, TG_TABLE_NAME, TG_OP
+        FROM partexplorer.part_group_application pga
+        WHERE pga.application_id = OLD.id;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Inclusões não afetam documentos (ainda não há linhas ligadas) e as exclusões chegam pelos
+-- triggers de part_name, stock e part_group_application; só as alterações relevantes enfileiram
+DROP TRIGGER IF EXISTS brand_search_outbox_trigger ON partexplorer.brand;
+CREATE TRIGGER brand_search_outbox_trigger
+    AFTER UPDATE ON partexplorer.brand
+    FOR EACH ROW
+    WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION partexplorer.enqueue_search_outbox_reference();
+
+DROP TRIGGER IF EXISTS company_search_outbox_trigger ON partexplorer.company;
+CREATE TRIGGER company_search_outbox_trigger
+    AFTER UPDATE ON partexplorer.company
+    FOR EACH ROW
+    WHEN (OLD.state IS DISTINCT FROM NEW.state OR OLD.city IS DISTINCT FROM NEW.city)
+    EXECUTE FUNCTION partexplorer.enqueue_search_outbox_reference();
+
+DROP TRIGGER IF EXISTS application_search_outbox_trigger ON partexplorer.application;
+CREATE TRIGGER application_search_outbox_trigger
+    AFTER UPDATE ON partexplorer.application
+    FOR EACH ROW
+    WHEN (OLD.* IS DISTINCT FROM NEW.*)
+    EXECUTE FUNCTION partexplorer.enqueue_search_outbox_reference();